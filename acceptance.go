@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"linkedin-automation/internal/export"
+	"linkedin-automation/internal/message"
+	"linkedin-automation/internal/report"
+	"linkedin-automation/internal/runctx"
+)
+
+// checkAccepted records which pending connection requests were accepted,
+// without queueing or sending follow-ups, and lists every request checked in
+// a CSV next to the run report
+func (s *session) checkAccepted(rc *runctx.Context, rep *report.Report) {
+	messenger := message.New(s.page, rc)
+	messenger.SetLimiter(s.limiter)
+	s.pageUsers = append(s.pageUsers, messenger)
+
+	results, err := messenger.CheckAccepted(rc)
+	if err != nil {
+		s.lgr.Error("Failed to check accepted connections: %v", err)
+		rep.AddError("acceptance check: %v", err)
+	}
+
+	check := &report.AcceptanceCheck{}
+	t := export.Table{Name: "acceptance", Columns: []string{"profile_url", "name", "campaign", "source", "sent_at", "status"}}
+	for _, r := range results {
+		status := "unchecked"
+		switch {
+		case r.Accepted:
+			status = "accepted"
+			check.Accepted++
+			check.Checked++
+		case r.Checked:
+			status = "pending"
+			check.Checked++
+		default:
+			check.Unchecked++
+		}
+		t.Rows = append(t.Rows, []string{r.ProfileURL, r.Name, r.Campaign, r.Source, r.SentAt.Format(time.RFC3339), status})
+	}
+	rep.AcceptanceCheck = check
+	s.lgr.Info("✓ Acceptance check completed (%d accepted of %d checked, %d not reached)", check.Accepted, check.Checked, check.Unchecked)
+
+	if len(t.Rows) == 0 {
+		return
+	}
+	if err := os.MkdirAll(s.cfg.Storage.ReportDir, 0755); err != nil {
+		s.lgr.Warn("Failed to create report directory: %v", err)
+		return
+	}
+	path := filepath.Join(s.cfg.Storage.ReportDir, "acceptance-"+rep.StartedAt.Format("20060102-150405")+".csv")
+	f, err := os.Create(path)
+	if err != nil {
+		s.lgr.Warn("Failed to write acceptance list: %v", err)
+		return
+	}
+	defer f.Close()
+	if err := export.WriteCSV(f, t); err != nil {
+		s.lgr.Warn("Failed to write acceptance list: %v", err)
+		return
+	}
+	check.File = path
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"linkedin-automation/internal/addressbook"
+	"linkedin-automation/internal/logger"
+	"linkedin-automation/internal/report"
+	"linkedin-automation/internal/runctx"
+	"linkedin-automation/internal/search"
+	"linkedin-automation/internal/storage"
+)
+
+// candidatesPerEmail is how many search results are scored for an address
+const candidatesPerEmail = 5
+
+// matchEmails looks up at most max addresses from path that have not been
+// looked up before, by the name and company read off each, and keeps the
+// scored candidates for review with the contacts command. The addresses
+// themselves never leave the machine
+func (s *session) matchEmails(rc *runctx.Context, rep *report.Report, path string, max int) {
+	lgr, store := rc.Logger, s.store
+
+	contacts, err := addressbook.Load(path)
+	if err != nil {
+		lgr.Error("%v", err)
+		rep.AddError("email matching: %v", err)
+		return
+	}
+
+	searcher := search.New(s.page, rc)
+	searcher.SetLimiter(s.limiter)
+	searched, found := 0, 0
+	for _, c := range contacts {
+		if searched >= max || s.worker.Expired() || rc.Stopped() {
+			break
+		}
+		if done, err := store.EmailSearched(c.Email); err != nil || done {
+			continue
+		}
+		if c.Name == "" {
+			lgr.Debug("No name in %s; add a name column to look it up", c.Email)
+			continue
+		}
+
+		searched++
+		profiles, err := searcher.SearchPeople(rc, c.Name, "", c.Company, candidatesPerEmail)
+		if err == nil && len(profiles) == 0 && c.Company != "" {
+			// The company read off a domain is often not how LinkedIn spells it
+			searched++
+			profiles, err = searcher.SearchPeople(rc, c.Name, "", "", candidatesPerEmail)
+		}
+		if search.IsSearchLimit(err) {
+			lgr.Warn("LinkedIn search limit reached; the remaining addresses are looked up next time")
+			break
+		}
+		if err != nil {
+			lgr.Warn("Failed to look up %s: %v", c.Email, err)
+			continue
+		}
+
+		cands := addressbook.Rank(c, profiles)
+		if len(cands) == 0 {
+			store.SaveEmailMatch(storage.EmailMatch{Email: c.Email, Name: c.Name, Company: c.Company, Status: storage.EmailMatchNone})
+			continue
+		}
+		found++
+		for _, cand := range cands {
+			if err := store.SaveEmailMatch(storage.EmailMatch{
+				Email:      c.Email,
+				Name:       c.Name,
+				Company:    c.Company,
+				ProfileURL: cand.Profile.URL,
+				Candidate:  cand.Profile.Name,
+				Headline:   cand.Profile.Title,
+				Confidence: cand.Confidence,
+				Status:     storage.EmailMatchReview,
+			}); err != nil {
+				lgr.Warn("%v", err)
+			}
+		}
+	}
+	lgr.Info("✓ Found candidates for %d addresses in %d searches; review them with \"contacts review\"", found, searched)
+}
+
+// loadMatched returns up to max confirmed email matches that have not been
+// sent a request yet, as -connect prospects
+func loadMatched(store *storage.Store, lgr *logger.Logger, max int) ([]search.Profile, error) {
+	matches, err := store.GetEmailMatches(storage.EmailMatchConfirmed)
+	if err != nil {
+		return nil, err
+	}
+
+	var profiles []search.Profile
+	for _, m := range matches {
+		if len(profiles) >= max {
+			break
+		}
+		done, err := store.HasConnectionRequest(m.ProfileURL)
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			continue
+		}
+		profiles = append(profiles, search.Profile{
+			URL:    m.ProfileURL,
+			Name:   m.Candidate,
+			Title:  m.Headline,
+			Source: "email-match",
+		})
+	}
+	lgr.Info("Read %d confirmed email matches to connect with", len(profiles))
+	return profiles, nil
+}
+
+// formatConfidence renders a match confidence as a percentage
+func formatConfidence(c float64) string {
+	return fmt.Sprintf("%d%%", int(math.Round(c*100)))
+}
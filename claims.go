@@ -0,0 +1,43 @@
+package main
+
+import (
+	"time"
+
+	"linkedin-automation/internal/queue"
+	"linkedin-automation/internal/storage"
+)
+
+// openTeam opens the team's claims and has the worker claim each prospect
+// there before acting on it. Dry runs only look, so they claim nothing
+func (s *session) openTeam() error {
+	if !s.cfg.Team.Enabled() || s.cfg.DryRun {
+		return nil
+	}
+	team, err := storage.OpenClaims(s.cfg.Team.DBURL, s.cfg.Team.DBPath)
+	if err != nil {
+		return err
+	}
+	s.team = team
+	s.worker.Admit(s.claim)
+	return nil
+}
+
+// claim claims item's prospect for this account and operator for
+// team.claim_days. While another account or operator holds it, the item is
+// deferred until their claim lapses
+func (s *session) claim(item storage.QueueItem) error {
+	ttl := time.Duration(s.cfg.Team.ClaimDays) * 24 * time.Hour
+	c, err := s.team.ClaimProspect(item.ProfileURL, s.cfg.Account, s.cfg.Operator, ttl)
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		return nil
+	}
+
+	if err := s.store.RescheduleQueueItem(item.ID, c.ExpiresAt); err != nil {
+		return err
+	}
+	s.lgr.Info("%s is claimed by %s; %s deferred until %s", item.Name, c.Holder(), item.Action, c.ExpiresAt.Local().Format(time.RFC1123))
+	return queue.ErrDeferred
+}
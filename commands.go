@@ -0,0 +1,1784 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"linkedin-automation/config"
+	"linkedin-automation/internal/analytics"
+	"linkedin-automation/internal/auth"
+	"linkedin-automation/internal/bench"
+	"linkedin-automation/internal/browser"
+	"linkedin-automation/internal/campaign"
+	"linkedin-automation/internal/cooldown"
+	"linkedin-automation/internal/cron"
+	"linkedin-automation/internal/export"
+	"linkedin-automation/internal/fixtures"
+	"linkedin-automation/internal/links"
+	"linkedin-automation/internal/logger"
+	"linkedin-automation/internal/message"
+	"linkedin-automation/internal/notify"
+	"linkedin-automation/internal/report"
+	"linkedin-automation/internal/runctx"
+	"linkedin-automation/internal/simulate"
+	"linkedin-automation/internal/stealth"
+	"linkedin-automation/internal/storage"
+	"linkedin-automation/internal/tracking"
+	"linkedin-automation/internal/warmup"
+)
+
+// cmdEnv is what subcommands operate on: local state, no browser
+type cmdEnv struct {
+	cfg   *config.Config
+	lgr   *logger.Logger
+	store *storage.Store
+	args  []string
+	// configPath is the -config file, for commands that inspect it
+	configPath string
+}
+
+// audit records a manual decision under the current operator
+func (env *cmdEnv) audit(action, detail string) {
+	if err := env.store.RecordAudit(env.cfg.Operator, action, detail); err != nil {
+		env.lgr.Warn("Failed to record audit entry: %v", err)
+	}
+}
+
+type command struct {
+	summary string
+	// setup registers the command's flags and returns the function that runs it
+	setup func(fs *flag.FlagSet) func(env *cmdEnv) error
+	// standalone commands run without loading the config, logger or database
+	standalone bool
+}
+
+var commands = map[string]command{
+	"archive": {
+		summary: "Archive a campaign or prospect (campaign <name> | prospect <url> | list)",
+		setup:   setupArchive(true),
+	},
+	"tag": {
+		summary: "Tag contacts for broadcast segments (add <url> <tag> [name] | remove <url> <tag> | list <tag>)",
+		setup:   setupTag,
+	},
+	"track": {
+		summary: "Track contacts for job changes (add <url> [name] | remove <url> | list | changes [-days 30])",
+		setup:   setupTrack,
+	},
+	"unarchive": {
+		summary: "Restore an archived campaign or prospect (campaign <name> | prospect <url>)",
+		setup:   setupArchive(false),
+	},
+	"audit": {
+		summary: "Show who launched runs and approved or rejected actions (-n entries, -by operator)",
+		setup:   setupAudit,
+	},
+	"bench": {
+		summary: "Run search and connect against a local simulated site and show where the time goes",
+		setup:   setupBench,
+	},
+	"broadcast": {
+		summary: "Review staged broadcasts (review <campaign> | approve <campaign> | reject <campaign> [id])",
+		setup:   setupBroadcast,
+	},
+	"config": {
+		summary:    "Print the supported config keys (schema) or compare a config file against them (check)",
+		setup:      setupConfig,
+		standalone: true,
+	},
+	"init": {
+		summary:    "Interactively create a config and .env (account, pacing, storage paths, templates) and check the browser",
+		setup:      setupInit,
+		standalone: true,
+	},
+	"secrets": {
+		summary:    "Manage the encrypted config overlay (keygen | encrypt <plain.yaml> | decrypt <secrets.enc.yaml>)",
+		setup:      setupSecrets,
+		standalone: true,
+	},
+	"dead-letter": {
+		summary: "List prospects that failed too often, with their error history (retry <id> requeues one)",
+		setup:   setupDeadLetter,
+	},
+	"queue": {
+		summary: "Inspect and adjust the action queue (list [-campaign c] [-action a] | remove <url> | bump <url> | clear -campaign c)",
+		setup:   setupQueue,
+	},
+	"digest": {
+		summary: "Show what waits on you: replies to answer, approvals, stalled campaign prospects (-send posts it)",
+		setup:   setupDigest,
+	},
+	"summary": {
+		summary: "Show the last 24 hours: connections sent and accepted, messages, errors (-send emails it)",
+		setup:   setupSummary,
+	},
+	"pending": {
+		summary: "List what dry runs would have done (-since 24h; clear deletes the record)",
+		setup:   setupPending,
+	},
+	"captures": {
+		summary: "List the screenshots taken before and after each send (-since 168h, -failed, -profile url)",
+		setup:   setupCaptures,
+	},
+	"claims": {
+		summary: "List the prospects claimed in the team database and by whom (release <url> frees one)",
+		setup:   setupClaims,
+	},
+	"sequences": {
+		summary: "List the connections on the messaging.sequence follow-ups, their step and when the next is due (-status)",
+		setup:   setupSequences,
+	},
+	"fixtures": {
+		summary: "Replay saved LinkedIn pages through the extractors and compare with golden files (-update rewrites them)",
+		setup:   setupFixtures,
+	},
+	"dnc": {
+		summary: "Manage the do-not-contact list (add <url> [reason] | remove <url> | list)",
+		setup:   setupDNC,
+	},
+	"pacing": {
+		summary: "Check logged actions against the limits and for machine-like timing (audit [-days 7])",
+		setup:   setupPacing,
+	},
+	"simulate": {
+		summary: "Plan a virtual week of the schedule on a fake clock and show when actions would happen (-days 7, -seed n, -timeline)",
+		setup:   setupSimulate,
+	},
+	"export": {
+		summary: "Dump connections, messages and profiles to CSV, JSON or XLSX (-format, -from, -to, -campaign, -out)",
+		setup:   setupExport,
+	},
+	"trends": {
+		summary: "Show pipeline snapshots over time (-period week|month, -n periods)",
+		setup:   setupTrends,
+	},
+	"maintain": {
+		summary: "Prune old action log rows, then VACUUM and ANALYZE the database",
+		setup:   setupMaintain,
+	},
+	"campaign": {
+		summary: "Show defined campaigns and their progress (list | status <name>)",
+		setup:   setupCampaign,
+	},
+	"session": {
+		summary: "Report the saved session's age and cookie expiry without launching a browser (status)",
+		setup:   setupSession,
+	},
+	"creds": {
+		summary: "Log in with changed credentials, keeping the database and Chrome profile, and verify the session (rotate)",
+		setup:   setupCreds,
+	},
+	"contacts": {
+		summary: "Review LinkedIn matches found for -match-emails addresses (review [-min 0.5] | confirm <id>... | confirm -min 0.9 | reject <id>...)",
+		setup:   setupContacts,
+	},
+	"cooldown": {
+		summary: "Show the cooldown level repeated security challenges have reached and when it decays (status | reset)",
+		setup:   setupCooldown,
+	},
+	"warmup": {
+		summary: "Show the account's warm-up stage or ramp, active days, volumes reached and setbacks (status | restart)",
+		setup:   setupWarmUp,
+	},
+	"validate": {
+		summary: "Message the configured test account with accented, emoji, RTL and CJK samples and check the rendering",
+		setup:   setupValidate,
+	},
+	"serve-links": {
+		summary: "Run the click-tracking redirect server for wrapped message links",
+		setup:   setupServeLinks,
+	},
+}
+
+// runCommand executes a subcommand and returns the process exit code
+func runCommand(name string, cmd command, args []string) int {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	configPath, operator, account := commandFlags(fs)
+	run := cmd.setup(fs)
+	fs.Parse(args)
+
+	if cmd.standalone {
+		if err := run(&cmdEnv{args: fs.Args(), configPath: *configPath}); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+			return 1
+		}
+		return 0
+	}
+
+	cfg, err := config.LoadAccount(*configPath, *account)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		return 1
+	}
+	if *operator != "" {
+		cfg.Operator = *operator
+	}
+
+	lgr, err := logger.New(cfg.Logging.Level, cfg.Logging.File, cfg.Logging.Console)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
+		return 1
+	}
+	defer lgr.Close()
+
+	store, err := storage.New(cfg.Storage.DBPath)
+	if err != nil {
+		lgr.Error("Failed to initialize storage: %v", err)
+		return 1
+	}
+	defer store.Close()
+
+	if err := run(&cmdEnv{cfg: cfg, lgr: lgr, store: store, args: fs.Args(), configPath: *configPath}); err != nil {
+		lgr.Error("%s: %v", name, err)
+		return 1
+	}
+	return 0
+}
+
+// commandFlags registers the flags every subcommand accepts
+func commandFlags(fs *flag.FlagSet) (configPath, operator, account *string) {
+	configPath = fs.String("config", "./config/config.yaml", "Path to config file")
+	operator = fs.String("operator", "", "Who is running this (overrides operator / LINKEDIN_OPERATOR)")
+	account = fs.String("account", "", "Account from accounts whose state to use (overrides account / LINKEDIN_ACCOUNT)")
+	return configPath, operator, account
+}
+
+// printCommands lists the available subcommands
+func printCommands() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Println("Commands:")
+	for _, name := range names {
+		fmt.Printf("  %-16s %s\n", name, commands[name].summary)
+	}
+}
+
+func setupServeLinks(fs *flag.FlagSet) func(env *cmdEnv) error {
+	return func(env *cmdEnv) error {
+		addr := env.cfg.Links.Listen
+		if addr == "" {
+			addr = ":8088"
+		}
+
+		env.lgr.Info("Serving tracked links on %s (public base %s)", addr, env.cfg.Links.BaseURL)
+		return http.ListenAndServe(addr, links.Handler(env.store, env.lgr))
+	}
+}
+
+func setupDNC(fs *flag.FlagSet) func(env *cmdEnv) error {
+	return func(env *cmdEnv) error {
+		if len(env.args) == 0 {
+			return fmt.Errorf("usage: dnc add <url> [reason] | dnc remove <url> | dnc list")
+		}
+
+		switch env.args[0] {
+		case "add":
+			if len(env.args) < 2 {
+				return fmt.Errorf("usage: dnc add <url> [reason]")
+			}
+			reason := strings.Join(env.args[2:], " ")
+			if err := env.store.AddDoNotContact(env.args[1], reason); err != nil {
+				return err
+			}
+			fmt.Printf("Added %s to the do-not-contact list\n", env.args[1])
+
+		case "remove":
+			if len(env.args) < 2 {
+				return fmt.Errorf("usage: dnc remove <url>")
+			}
+			if err := env.store.RemoveDoNotContact(env.args[1]); err != nil {
+				return err
+			}
+			fmt.Printf("Removed %s from the do-not-contact list\n", env.args[1])
+
+		case "list":
+			entries, err := env.store.ListDoNotContact()
+			if err != nil {
+				return err
+			}
+			for _, e := range entries {
+				fmt.Printf("%s  %s  %s\n", e.AddedAt.Format("2006-01-02"), e.ProfileURL, e.Reason)
+			}
+			fmt.Printf("%d entries\n", len(entries))
+
+		default:
+			return fmt.Errorf("unknown dnc action %q", env.args[0])
+		}
+		return nil
+	}
+}
+
+// setupArchive builds both archive and unarchive; archived campaigns and
+// prospects drop out of queues and stats but keep their history
+func setupArchive(archive bool) func(fs *flag.FlagSet) func(env *cmdEnv) error {
+	verb := "unarchive"
+	if archive {
+		verb = "archive"
+	}
+
+	return func(fs *flag.FlagSet) func(env *cmdEnv) error {
+		return func(env *cmdEnv) error {
+			if archive && len(env.args) == 1 && env.args[0] == "list" {
+				entries, err := env.store.ListArchived()
+				if err != nil {
+					return err
+				}
+				for _, e := range entries {
+					fmt.Printf("%s  %-8s  %s\n", e.ArchivedAt.Format("2006-01-02"), e.Kind, e.Key)
+				}
+				fmt.Printf("%d entries\n", len(entries))
+				return nil
+			}
+
+			if len(env.args) != 2 {
+				return fmt.Errorf("usage: %s campaign <name> | %s prospect <url>", verb, verb)
+			}
+
+			kind, key := env.args[0], env.args[1]
+			if kind != storage.ArchiveCampaign && kind != storage.ArchiveProspect {
+				return fmt.Errorf("unknown %s kind %q", verb, kind)
+			}
+
+			if archive {
+				if err := env.store.Archive(kind, key); err != nil {
+					return err
+				}
+				fmt.Printf("Archived %s %s\n", kind, key)
+				return nil
+			}
+
+			if err := env.store.Unarchive(kind, key); err != nil {
+				return err
+			}
+			fmt.Printf("Restored %s %s\n", kind, key)
+			return nil
+		}
+	}
+}
+
+func setupDeadLetter(fs *flag.FlagSet) func(env *cmdEnv) error {
+	return func(env *cmdEnv) error {
+		if len(env.args) == 2 && env.args[0] == "retry" {
+			id, err := strconv.ParseInt(env.args[1], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid item id %q", env.args[1])
+			}
+			if err := env.store.RequeueDeadLetter(id); err != nil {
+				return err
+			}
+			env.audit(storage.AuditDeadLetterRetried, fmt.Sprintf("item %d", id))
+			fmt.Printf("Requeued item %d\n", id)
+			return nil
+		}
+		if len(env.args) > 0 {
+			return fmt.Errorf("usage: dead-letter [retry <id>]")
+		}
+
+		items, err := env.store.GetDeadLetters()
+		if err != nil {
+			return err
+		}
+
+		for _, it := range items {
+			fmt.Printf("#%d  %-7s  %s  %s  (%d attempts, campaign %s)\n",
+				it.ID, it.Action, it.Name, it.ProfileURL, it.Attempts, it.Campaign)
+
+			history, err := env.store.GetActionFailures(it.ProfileURL)
+			if err != nil {
+				return err
+			}
+			for _, h := range history {
+				fmt.Printf("      %s  %s\n", h.CreatedAt.Local().Format("2006-01-02 15:04"), h.Detail)
+			}
+			if len(history) == 0 && it.LastError != "" {
+				fmt.Printf("      %s\n", it.LastError)
+			}
+		}
+		fmt.Printf("%d dead-letter items\n", len(items))
+		return nil
+	}
+}
+
+func setupDigest(fs *flag.FlagSet) func(env *cmdEnv) error {
+	send := fs.Bool("send", false, "Post the digest to digest.webhook instead of printing it")
+
+	return func(env *cmdEnv) error {
+		if *send {
+			return sendDigest(env.cfg, env.lgr, env.store)
+		}
+		d, err := report.BuildDigest(env.cfg, env.store, time.Now())
+		if err != nil {
+			return err
+		}
+		fmt.Print(d)
+		return nil
+	}
+}
+
+// sendDigest posts the weekly digest to the configured webhook
+func sendDigest(cfg *config.Config, lgr *logger.Logger, store *storage.Store) error {
+	url := cfg.DigestWebhook()
+	if url == "" {
+		return fmt.Errorf("no webhook to send the digest to; set digest.webhook or challenge.webhook")
+	}
+	d, err := report.BuildDigest(cfg, store, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := notify.Webhook(url, d.String()); err != nil {
+		return fmt.Errorf("failed to send digest: %w", err)
+	}
+	lgr.Info("Digest sent (%d awaiting reply, %d approval groups, %d stalled groups)",
+		len(d.AwaitingReply), len(d.Approvals), len(d.Stalled))
+	return nil
+}
+
+func setupSummary(fs *flag.FlagSet) func(env *cmdEnv) error {
+	send := fs.Bool("send", false, "Email the summary to email.to instead of printing it")
+
+	return func(env *cmdEnv) error {
+		if *send {
+			return sendSummary(env.cfg, env.lgr, env.store)
+		}
+		sum, err := report.BuildSummary(env.cfg, env.store, time.Now())
+		if err != nil {
+			return err
+		}
+		fmt.Println(sum.Subject())
+		fmt.Println()
+		fmt.Print(sum)
+		return nil
+	}
+}
+
+// sendSummary emails the daily summary to email.to
+func sendSummary(cfg *config.Config, lgr *logger.Logger, store *storage.Store) error {
+	if !cfg.Email.Enabled() {
+		return fmt.Errorf("no one to email the summary to; set email.host and email.to")
+	}
+	sum, err := report.BuildSummary(cfg, store, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := notify.Email(cfg.Email, sum.Subject(), sum.String()); err != nil {
+		return fmt.Errorf("failed to email summary: %w", err)
+	}
+	lgr.Info("Summary emailed to %s", strings.Join(cfg.Email.To, ", "))
+	return nil
+}
+
+func setupPending(fs *flag.FlagSet) func(env *cmdEnv) error {
+	since := fs.Duration("since", 24*time.Hour, "List actions planned within this long")
+
+	return func(env *cmdEnv) error {
+		if len(env.args) > 0 && env.args[0] == "clear" {
+			cleared, err := env.store.ClearPendingActions()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Cleared %d planned actions\n", cleared)
+			return nil
+		}
+
+		actions, err := env.store.GetPendingActions(time.Now().Add(-*since))
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "PLANNED\tACTION\tCAMPAIGN\tNAME\tPROFILE\tPLAN")
+		for _, a := range actions {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", a.CreatedAt.Local().Format("2006-01-02 15:04"),
+				a.Action, a.Campaign, a.Name, a.ProfileURL, a.Detail)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		fmt.Printf("%d planned actions\n", len(actions))
+		return nil
+	}
+}
+
+func setupCaptures(fs *flag.FlagSet) func(env *cmdEnv) error {
+	since := fs.Duration("since", 7*24*time.Hour, "List sends captured within this long")
+	failed := fs.Bool("failed", false, "Only sends that failed")
+	profile := fs.String("profile", "", "Only sends to this profile URL")
+
+	return func(env *cmdEnv) error {
+		outcome := ""
+		if *failed {
+			outcome = storage.CaptureFailed
+		}
+		captures, err := env.store.GetSendCaptures(time.Now().Add(-*since), *profile, outcome)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "SENT\tACTION\tCAMPAIGN\tPROFILE\tOUTCOME\tAFTER URL\tSCREENSHOTS")
+		for _, c := range captures {
+			outcome := c.Outcome
+			if c.Detail != "" {
+				outcome += ": " + c.Detail
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", c.CreatedAt.Local().Format("2006-01-02 15:04"),
+				c.Action, c.Campaign, c.ProfileURL, outcome, c.AfterURL, c.Dir)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		fmt.Printf("%d sends captured\n", len(captures))
+		return nil
+	}
+}
+
+func setupClaims(fs *flag.FlagSet) func(env *cmdEnv) error {
+	return func(env *cmdEnv) error {
+		if !env.cfg.Team.Enabled() {
+			return fmt.Errorf("neither team.db_url nor team.db_path is set; prospects are not claimed")
+		}
+		team, err := storage.OpenClaims(env.cfg.Team.DBURL, env.cfg.Team.DBPath)
+		if err != nil {
+			return err
+		}
+		defer team.Close()
+
+		if len(env.args) > 0 {
+			if env.args[0] != "release" || len(env.args) != 2 {
+				return fmt.Errorf("usage: claims [release <url>]")
+			}
+			released, err := team.ReleaseClaim(env.args[1])
+			if err != nil {
+				return err
+			}
+			if !released {
+				return fmt.Errorf("%s is not claimed", env.args[1])
+			}
+			env.audit("claim_released", env.args[1])
+			fmt.Printf("Released %s\n", env.args[1])
+			return nil
+		}
+
+		claims, err := team.GetClaims()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "PROFILE\tHELD BY\tSINCE\tUNTIL")
+		for _, c := range claims {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.ProfileURL, c.Holder(),
+				c.ClaimedAt.Local().Format("2006-01-02 15:04"), c.ExpiresAt.Local().Format("2006-01-02 15:04"))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		fmt.Printf("%d prospects claimed\n", len(claims))
+		return nil
+	}
+}
+
+func setupSequences(fs *flag.FlagSet) func(env *cmdEnv) error {
+	status := fs.String("status", "", "Only this status (accepted, messaging, replied, done)")
+
+	return func(env *cmdEnv) error {
+		prospects, err := env.store.GetCampaignProspects(storage.SequenceCampaign, *status)
+		if err != nil {
+			return err
+		}
+		steps := len(env.cfg.Messaging.Sequence)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tSTATUS\tSENT\tNEXT DUE\tPROFILE")
+		for _, p := range prospects {
+			next := "-"
+			if !p.NextAt.IsZero() {
+				next = p.NextAt.Local().Format("2006-01-02 15:04")
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d/%d\t%s\t%s\n", p.Name, p.Status, p.Step, steps, next, p.ProfileURL)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		counts, err := env.store.CountCampaignProspects(storage.SequenceCampaign)
+		if err != nil {
+			return err
+		}
+		var parts []string
+		for _, s := range []string{storage.CampaignAccepted, storage.CampaignMessaging, storage.CampaignReplied, storage.CampaignDone} {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[s], s))
+		}
+		fmt.Printf("%d connections listed (%s); %d steps configured\n", len(prospects), strings.Join(parts, ", "), steps)
+		return nil
+	}
+}
+
+func setupQueue(fs *flag.FlagSet) func(env *cmdEnv) error {
+	campaign := fs.String("campaign", "", "Only this campaign")
+	action := fs.String("action", "", "Only this action (connect, message, broadcast, ...)")
+	n := fs.Int("n", 50, "Items to list (0 = all)")
+
+	return func(env *cmdEnv) error {
+		if len(env.args) == 0 {
+			return fmt.Errorf("usage: queue list | remove <url> | bump <url> | clear -campaign <name>")
+		}
+		// Flags may also follow the action, as in "queue clear -campaign x"
+		verb := env.args[0]
+		fs.Parse(env.args[1:])
+		args := fs.Args()
+
+		switch verb {
+		case "list":
+			items, err := env.store.GetOpenQueueItems(*campaign, *action, *n)
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tSTATUS\tACTION\tCAMPAIGN\tPRIORITY\tDUE\tATTEMPTS\tNAME\tPROFILE")
+			for _, it := range items {
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\t%s\t%d\t%s\t%s\n", it.ID, it.Status, it.Action, it.Campaign,
+					it.Priority, it.NotBefore.Local().Format("2006-01-02 15:04"), it.Attempts, it.Name, it.ProfileURL)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			fmt.Printf("%d items\n", len(items))
+
+		case "remove":
+			if len(args) != 1 {
+				return fmt.Errorf("usage: queue remove [-campaign <name>] <url>")
+			}
+			removed, err := env.store.RemoveQueuedProfile(args[0], *campaign)
+			if err != nil {
+				return err
+			}
+			if removed == 0 {
+				return fmt.Errorf("no unfinished queue items for %s", args[0])
+			}
+			env.audit(storage.AuditQueueRemoved, fmt.Sprintf("%s (%d items)", args[0], removed))
+			fmt.Printf("Removed %d items for %s\n", removed, args[0])
+
+		case "bump":
+			if len(args) != 1 {
+				return fmt.Errorf("usage: queue bump <url>")
+			}
+			bumped, err := env.store.BumpQueuedProfile(args[0])
+			if err != nil {
+				return err
+			}
+			if bumped == 0 {
+				return fmt.Errorf("no pending queue items for %s", args[0])
+			}
+			env.audit(storage.AuditQueueBumped, args[0])
+			fmt.Printf("Moved %d items for %s to the front of the queue\n", bumped, args[0])
+
+		case "clear":
+			if *campaign == "" {
+				return fmt.Errorf("usage: queue clear -campaign <name> [-action <action>]")
+			}
+			cleared, err := env.store.ClearQueue(*campaign, *action)
+			if err != nil {
+				return err
+			}
+			detail := "campaign " + *campaign
+			if *action != "" {
+				detail += ", action " + *action
+			}
+			env.audit(storage.AuditQueueCleared, fmt.Sprintf("%s (%d items)", detail, cleared))
+			fmt.Printf("Cleared %d pending items from %q\n", cleared, *campaign)
+
+		default:
+			return fmt.Errorf("unknown queue action %q", verb)
+		}
+		return nil
+	}
+}
+
+func setupBroadcast(fs *flag.FlagSet) func(env *cmdEnv) error {
+	return func(env *cmdEnv) error {
+		if len(env.args) < 2 {
+			return fmt.Errorf("usage: broadcast review|approve|reject <campaign> [id]")
+		}
+		action, campaign := env.args[0], env.args[1]
+
+		switch action {
+		case "review":
+			items, err := env.store.ListQueueItems(campaign, storage.QueueActionBroadcast, storage.QueueStatusReview)
+			if err != nil {
+				return err
+			}
+			for _, it := range items {
+				fmt.Printf("#%d  %s  %s\n      %s\n", it.ID, it.Name, it.ProfileURL, strings.ReplaceAll(it.Payload, "\n", "\n      "))
+			}
+			fmt.Printf("%d messages awaiting approval in %q\n", len(items), campaign)
+
+		case "approve":
+			n, err := env.store.SetQueueStatus(campaign, storage.QueueActionBroadcast, storage.QueueStatusReview, storage.QueueStatusPending)
+			if err != nil {
+				return err
+			}
+			env.audit(storage.AuditBroadcastApproved, fmt.Sprintf("campaign %s, %d messages", campaign, n))
+			fmt.Printf("Approved %d messages in %q; they go out on the next -broadcast run\n", n, campaign)
+
+		case "reject":
+			var id int64
+			if len(env.args) > 2 {
+				var err error
+				if id, err = strconv.ParseInt(env.args[2], 10, 64); err != nil {
+					return fmt.Errorf("invalid item id %q", env.args[2])
+				}
+			}
+			n, err := env.store.DeleteQueueItems(campaign, storage.QueueActionBroadcast, storage.QueueStatusReview, id)
+			if err != nil {
+				return err
+			}
+			detail := fmt.Sprintf("campaign %s, %d messages", campaign, n)
+			if id != 0 {
+				detail = fmt.Sprintf("campaign %s, item %d", campaign, id)
+			}
+			env.audit(storage.AuditBroadcastRejected, detail)
+			fmt.Printf("Rejected %d messages in %q\n", n, campaign)
+
+		default:
+			return fmt.Errorf("unknown broadcast action %q", action)
+		}
+		return nil
+	}
+}
+
+func setupTag(fs *flag.FlagSet) func(env *cmdEnv) error {
+	return func(env *cmdEnv) error {
+		if len(env.args) < 2 {
+			return fmt.Errorf("usage: tag add <url> <tag> [name] | tag remove <url> <tag> | tag list <tag>")
+		}
+
+		switch env.args[0] {
+		case "add":
+			if len(env.args) < 3 {
+				return fmt.Errorf("usage: tag add <url> <tag> [name]")
+			}
+			name := strings.Join(env.args[3:], " ")
+			if err := env.store.TagContact(env.args[1], name, env.args[2]); err != nil {
+				return err
+			}
+			fmt.Printf("Tagged %s as %s\n", env.args[1], env.args[2])
+
+		case "remove":
+			if len(env.args) < 3 {
+				return fmt.Errorf("usage: tag remove <url> <tag>")
+			}
+			if err := env.store.UntagContact(env.args[1], env.args[2]); err != nil {
+				return err
+			}
+			fmt.Printf("Removed tag %s from %s\n", env.args[2], env.args[1])
+
+		case "list":
+			contacts, err := env.store.GetTaggedContacts(env.args[1])
+			if err != nil {
+				return err
+			}
+			for _, c := range contacts {
+				fmt.Printf("%s  %s\n", c.ProfileURL, c.Name)
+			}
+			fmt.Printf("%d contacts tagged %s\n", len(contacts), env.args[1])
+
+		default:
+			return fmt.Errorf("unknown tag action %q", env.args[0])
+		}
+		return nil
+	}
+}
+
+func setupTrack(fs *flag.FlagSet) func(env *cmdEnv) error {
+	days := fs.Int("days", 30, "Show job changes detected within this many days")
+
+	return func(env *cmdEnv) error {
+		if len(env.args) == 0 {
+			return fmt.Errorf("usage: track add <url> [name] | track remove <url> | track list | track changes [-days n]")
+		}
+
+		switch env.args[0] {
+		case "add":
+			if len(env.args) < 2 {
+				return fmt.Errorf("usage: track add <url> [name]")
+			}
+			name := strings.Join(env.args[2:], " ")
+			if err := env.store.TrackContact(env.args[1], name); err != nil {
+				return err
+			}
+			fmt.Printf("Tracking %s; -track runs recheck it every %d days\n", env.args[1], env.cfg.Tracking.IntervalDays)
+
+		case "remove":
+			if len(env.args) < 2 {
+				return fmt.Errorf("usage: track remove <url>")
+			}
+			if err := env.store.UntrackContact(env.args[1]); err != nil {
+				return err
+			}
+			fmt.Printf("Stopped tracking %s\n", env.args[1])
+
+		case "list":
+			contacts, err := env.store.GetTrackedContacts()
+			if err != nil {
+				return err
+			}
+			for _, c := range contacts {
+				checked := "never"
+				if !c.CheckedAt.IsZero() {
+					checked = c.CheckedAt.Local().Format("2006-01-02")
+				}
+				fmt.Printf("%-10s  %s  %s\n", checked, c.ProfileURL, c.Name)
+			}
+			fmt.Printf("%d tracked contacts\n", len(contacts))
+
+		case "changes":
+			// As in "track changes -days 90"
+			fs.Parse(env.args[1:])
+			changes, err := env.store.GetJobChangesSince("", time.Now().AddDate(0, 0, -*days))
+			if err != nil {
+				return err
+			}
+			for _, c := range changes {
+				fmt.Printf("%s  %s: %s\n", c.DetectedAt.Local().Format("2006-01-02"), c.Name, tracking.Describe(c))
+			}
+			fmt.Printf("%d job changes in the last %d days\n", len(changes), *days)
+
+		default:
+			return fmt.Errorf("unknown track action %q", env.args[0])
+		}
+		return nil
+	}
+}
+
+func setupSecrets(fs *flag.FlagSet) func(env *cmdEnv) error {
+	out := fs.String("o", "", "Write to this file instead of stdout")
+
+	return func(env *cmdEnv) error {
+		if len(env.args) == 0 {
+			return fmt.Errorf("usage: secrets keygen | secrets encrypt <plain.yaml> | secrets decrypt <secrets.enc.yaml>")
+		}
+
+		var result []byte
+		switch env.args[0] {
+		case "keygen":
+			key, err := config.GenerateKey()
+			if err != nil {
+				return err
+			}
+			result = []byte(key + "\n")
+
+		case "encrypt", "decrypt":
+			if len(env.args) < 2 {
+				return fmt.Errorf("usage: secrets %s <file>", env.args[0])
+			}
+			data, err := os.ReadFile(env.args[1])
+			if err != nil {
+				return err
+			}
+			key, err := config.LoadKey()
+			if err != nil {
+				return err
+			}
+			if env.args[0] == "encrypt" {
+				result, err = config.EncryptOverlay(data, key)
+			} else {
+				result, err = config.DecryptOverlay(data, key)
+			}
+			if err != nil {
+				return err
+			}
+
+		default:
+			return fmt.Errorf("unknown secrets action %q", env.args[0])
+		}
+
+		if *out == "" {
+			_, err := os.Stdout.Write(result)
+			return err
+		}
+		// Keys and decrypted overlays are secrets themselves
+		return os.WriteFile(*out, result, 0600)
+	}
+}
+
+func setupConfig(fs *flag.FlagSet) func(env *cmdEnv) error {
+	return func(env *cmdEnv) error {
+		if len(env.args) == 0 {
+			return fmt.Errorf("usage: config schema | config check")
+		}
+
+		switch env.args[0] {
+		case "schema":
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "KEY\tTYPE\tENV\tDEFAULT")
+			for _, f := range config.Schema() {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", f.Key, f.Type, f.Env, f.Default)
+			}
+			return w.Flush()
+
+		case "check":
+			issues, err := config.Check(env.configPath)
+			if err != nil {
+				return err
+			}
+			errs := 0
+			for _, is := range issues {
+				if is.Level == "error" {
+					errs++
+				}
+				fmt.Printf("%-5s  %s: %s\n", is.Level, is.Key, is.Message)
+			}
+			if errs > 0 {
+				return fmt.Errorf("%d problems in %s", errs, env.configPath)
+			}
+			fmt.Printf("%s matches the schema\n", env.configPath)
+
+		default:
+			return fmt.Errorf("unknown config action %q", env.args[0])
+		}
+		return nil
+	}
+}
+
+func setupFixtures(fs *flag.FlagSet) func(env *cmdEnv) error {
+	dir := fs.String("dir", fixtures.DefaultDir, "Fixture directory")
+	update := fs.Bool("update", false, "Rewrite golden files from the current output")
+
+	return func(env *cmdEnv) error {
+		// Fixtures never need a visible window
+		env.cfg.Browser.Headless = true
+		br, err := browser.New(env.cfg, env.lgr)
+		if err != nil {
+			return err
+		}
+		defer br.Close()
+
+		results, err := fixtures.Verify(br.Page(), *dir, *update)
+		if err != nil {
+			return err
+		}
+
+		failed := 0
+		for _, r := range results {
+			switch {
+			case r.Err != nil:
+				failed++
+				fmt.Printf("ERROR   %s/%s: %v\n", r.Extractor, r.Case, r.Err)
+			case r.Updated:
+				fmt.Printf("UPDATED %s/%s\n", r.Extractor, r.Case)
+			case r.Passed:
+				fmt.Printf("ok      %s/%s\n", r.Extractor, r.Case)
+			default:
+				failed++
+				fmt.Printf("FAIL    %s/%s\n%s\n", r.Extractor, r.Case, r.Diff)
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d fixtures failed", failed, len(results))
+		}
+		fmt.Printf("%d fixtures passed\n", len(results))
+		return nil
+	}
+}
+
+func setupBench(fs *flag.FlagSet) func(env *cmdEnv) error {
+	var opts bench.Options
+	fs.IntVar(&opts.Profiles, "profiles", 5, "Simulated search results to connect with")
+	fs.DurationVar(&opts.Latency, "latency", 800*time.Millisecond, "Simulated page load time")
+	fs.DurationVar(&opts.MaxRuntime, "max-runtime", 30*time.Minute, "Stop the queue after this long")
+	fs.StringVar(&opts.Note, "note", "Hi {name}, I'd like to connect.", "Connection note")
+
+	return func(env *cmdEnv) error {
+		br, err := browser.New(env.cfg, env.lgr)
+		if err != nil {
+			return err
+		}
+		defer br.Close()
+
+		res, err := bench.Run(br.Page(), env.cfg, env.lgr, opts)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Found %d, sent %d in %v\n\n", res.Found, res.Sent, res.Wall.Round(time.Second))
+		report.PrintTimings(res.Stages, res.Wall)
+		return nil
+	}
+}
+
+func setupExport(fs *flag.FlagSet) func(env *cmdEnv) error {
+	format := fs.String("format", "csv", "Output format: csv, json or xlsx")
+	from := fs.String("from", "", "First day to export (YYYY-MM-DD, in stealth.timezone)")
+	to := fs.String("to", "", "Last day to export (YYYY-MM-DD)")
+	campaignName := fs.String("campaign", "", "Only this campaign's prospects")
+	out := fs.String("out", ".", "Directory to write the files to")
+
+	return func(env *cmdEnv) error {
+		usage := fmt.Errorf("usage: export [-format csv|json|xlsx] [-from day] [-to day] [-campaign c] [-out dir] [%s ...]",
+			strings.Join(storage.ExportTables(), "|"))
+		known := false
+		for _, f := range export.Formats {
+			known = known || f == *format
+		}
+		if !known {
+			return usage
+		}
+
+		loc := analytics.Location(env.cfg.Stealth.Timezone)
+		var since, until time.Time
+		var err error
+		if *from != "" {
+			if since, err = time.ParseInLocation("2006-01-02", *from, loc); err != nil {
+				return fmt.Errorf("invalid -from: %w", err)
+			}
+		}
+		if *to != "" {
+			if until, err = time.ParseInLocation("2006-01-02", *to, loc); err != nil {
+				return fmt.Errorf("invalid -to: %w", err)
+			}
+			until = until.AddDate(0, 0, 1)
+		}
+
+		names := env.args
+		if len(names) == 0 {
+			names = storage.ExportTables()
+		}
+		var tables []export.Table
+		for _, name := range names {
+			columns, rows, err := env.store.ExportRows(name, since, until, *campaignName)
+			if err != nil {
+				return err
+			}
+			tables = append(tables, export.Table{Name: name, Columns: columns, Rows: rows})
+		}
+
+		if err := os.MkdirAll(*out, 0755); err != nil {
+			return fmt.Errorf("failed to create export directory: %w", err)
+		}
+		write := func(name string, fn func(f *os.File) error) error {
+			path := filepath.Join(*out, name)
+			f, err := os.Create(path)
+			if err != nil {
+				return err
+			}
+			if err := fn(f); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+			fmt.Printf("Wrote %s\n", path)
+			return nil
+		}
+
+		if *format == "xlsx" {
+			if err := write("export.xlsx", func(f *os.File) error { return export.WriteXLSX(f, tables) }); err != nil {
+				return err
+			}
+		} else {
+			for _, t := range tables {
+				t := t
+				err := write(t.Name+"."+*format, func(f *os.File) error {
+					if *format == "json" {
+						return export.WriteJSON(f, t)
+					}
+					return export.WriteCSV(f, t)
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+		for _, t := range tables {
+			fmt.Printf("  %-12s %d rows\n", t.Name, len(t.Rows))
+		}
+		env.audit("export", fmt.Sprintf("%s %s", *format, strings.Join(names, ",")))
+		return nil
+	}
+}
+
+func setupTrends(fs *flag.FlagSet) func(env *cmdEnv) error {
+	period := fs.String("period", storage.PeriodWeek, "Snapshot period: week or month")
+	n := fs.Int("n", 12, "Number of periods to show")
+
+	return func(env *cmdEnv) error {
+		if *period != storage.PeriodWeek && *period != storage.PeriodMonth {
+			return fmt.Errorf("unknown period %q (want week or month)", *period)
+		}
+		snaps, err := env.store.GetPipelineSnapshots(*period, *n)
+		if err != nil {
+			return err
+		}
+		if len(snaps) == 0 {
+			fmt.Println("No snapshots yet; one is taken at the end of every run")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(w, "Period\tInvites\tAwaiting\tAccepted\tMessaged\tActive\tQueued\tDead\t")
+		for _, p := range snaps {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t\n", p.PeriodStart.Format("2006-01-02"),
+				p.InvitesSent, p.AwaitingAcceptance, p.Accepted, p.Messaged, p.ActiveConversations, p.Queued, p.DeadLettered)
+		}
+		return w.Flush()
+	}
+}
+
+func setupPacing(fs *flag.FlagSet) func(env *cmdEnv) error {
+	days := fs.Int("days", 7, "Number of days to replay")
+
+	return func(env *cmdEnv) error {
+		if len(env.args) == 0 || env.args[0] != "audit" {
+			return fmt.Errorf("usage: pacing audit [-days n]")
+		}
+		// As in "pacing audit -days 30"
+		fs.Parse(env.args[1:])
+
+		loc := analytics.Location(env.cfg.Stealth.Timezone)
+		now := time.Now().In(loc)
+		since := time.Date(now.Year(), now.Month(), now.Day()-*days+1, 0, 0, 0, 0, loc)
+		entries, err := env.store.GetActionLogSince(since)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			fmt.Printf("No actions logged since %s\n", since.Format("2006-01-02"))
+			return nil
+		}
+
+		activity, violations := analytics.AuditPacing(entries, env.cfg, loc)
+		for _, line := range env.cfg.PacingSummary() {
+			fmt.Println(line)
+		}
+		fmt.Println()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(w, "Day\tConnect\tMessage\tInvite\tFollow\tTotal\t")
+		for _, d := range activity {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t\n", d.Day.Format("2006-01-02"),
+				d.Actions[string(stealth.ActionConnectionReq)], d.Actions[string(stealth.ActionMessage)],
+				d.Actions[string(stealth.ActionEventInvite)], d.Actions[string(stealth.ActionFollow)], d.Total)
+		}
+		w.Flush()
+
+		if len(violations) == 0 {
+			fmt.Printf("\nNo pacing violations in the last %d days\n", *days)
+			return nil
+		}
+		fmt.Println()
+		for _, v := range violations {
+			fmt.Println(v)
+		}
+		return fmt.Errorf("%d pacing violations in the last %d days", len(violations), *days)
+	}
+}
+
+func setupSimulate(fs *flag.FlagSet) func(env *cmdEnv) error {
+	days := fs.Int("days", 7, "Number of virtual days to plan, from midnight tonight")
+	seed := fs.Int64("seed", 0, "Seed of the random choices, to repeat a plan (0 = a new one)")
+	timeline := fs.Bool("timeline", false, "List every session, action, break and wait, not only the daily totals")
+
+	return func(env *cmdEnv) error {
+		sessions, err := simSessions(env.cfg, env.lgr)
+		if err != nil {
+			return err
+		}
+		if *seed == 0 {
+			*seed = time.Now().UnixNano()
+		}
+		loc := analytics.Location(env.cfg.Stealth.Timezone)
+		now := time.Now().In(loc)
+		start := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, loc)
+		plan, err := simulate.Run(env.cfg, newLimiter(env.cfg, env.lgr), sessions, start, *days, *seed)
+		if err != nil {
+			return err
+		}
+
+		for _, line := range env.cfg.PacingSummary() {
+			fmt.Println(line)
+		}
+		fmt.Println()
+
+		if *timeline {
+			for _, e := range plan.Events {
+				line := fmt.Sprintf("%s  %-6s  %s", e.At.Format("Mon 01-02 15:04:05"), e.Kind, e.Session)
+				if e.Action != "" {
+					line += " " + e.Action
+				}
+				if e.Duration > 0 {
+					line += fmt.Sprintf(" (%v)", e.Duration.Round(time.Second))
+				}
+				if e.Detail != "" {
+					line += ": " + e.Detail
+				}
+				fmt.Println(line)
+			}
+			fmt.Println()
+		}
+
+		var names []string
+		for _, sess := range sessions {
+			for _, a := range sess.Actions {
+				if !slices.Contains(names, a.Name) {
+					names = append(names, a.Name)
+				}
+			}
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintf(w, "Day\t%s\tFirst\tLast\tBreaks\tBreak time\t\n", strings.Join(names, "\t"))
+		for _, d := range plan.Days {
+			fmt.Fprint(w, d.Date.Format("Mon 2006-01-02"), "\t")
+			for _, name := range names {
+				fmt.Fprintf(w, "%d\t", d.Actions[name])
+			}
+			first, last := "-", "-"
+			if !d.First.IsZero() {
+				first, last = d.First.Format("15:04"), d.Last.Format("15:04")
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%v\t\n", first, last, d.Breaks, d.BreakTime.Round(time.Minute))
+		}
+		w.Flush()
+
+		fmt.Printf("\nPlanned with seed %d; nothing was sent\n", *seed)
+		return nil
+	}
+}
+
+// simSessions are the runs the configured schedule makes: the daemon jobs,
+// or else one run a day at stealth.work_start_hour that connects and messages
+func simSessions(cfg *config.Config, lgr *logger.Logger) ([]simulate.Session, error) {
+	if len(cfg.Daemon.Jobs) == 0 {
+		schedule, err := cron.Parse(fmt.Sprintf("0 %d * * *", cfg.Stealth.WorkStartHour))
+		if err != nil {
+			return nil, err
+		}
+		return []simulate.Session{{
+			Name:     "daily",
+			Schedule: schedule,
+			Actions:  simActions(cfg, runOptions{connect: true, message: true}),
+		}}, nil
+	}
+
+	jobs, err := loadJobs(cfg, lgr, loadTemplates())
+	if err != nil {
+		return nil, err
+	}
+	var sessions []simulate.Session
+	for _, j := range jobs {
+		actions := simActions(cfg, j.opts)
+		if j.maintenance || j.digest || j.summary || len(actions) == 0 {
+			continue
+		}
+		sessions = append(sessions, simulate.Session{Name: j.name, Schedule: j.schedule, Actions: actions, MaxRuntime: j.maxRuntime})
+	}
+	if len(sessions) == 0 {
+		return nil, fmt.Errorf("no daemon job sends anything to simulate")
+	}
+	return sessions, nil
+}
+
+// simActions are the queues a run with o drains, in the order run takes them
+func simActions(cfg *config.Config, o runOptions) []simulate.Action {
+	l := cfg.Limits
+	var actions []simulate.Action
+	if o.connect || o.runCampaign != "" {
+		actions = append(actions, simulate.Action{Name: storage.QueueActionConnect, Type: stealth.ActionConnectionReq,
+			PerDay: l.MaxConnectionsPerDay, PerWeek: l.MaxConnectionsPerWeek})
+	}
+	if o.message || o.runCampaign != "" {
+		actions = append(actions, simulate.Action{Name: storage.QueueActionMessage, Type: stealth.ActionMessage, PerDay: l.MaxMessagesPerDay})
+	}
+	if o.broadcast {
+		actions = append(actions, simulate.Action{Name: storage.QueueActionBroadcast, Type: stealth.ActionMessage, PerDay: l.MaxBroadcastsPerDay})
+	}
+	if o.eventURL != "" {
+		actions = append(actions, simulate.Action{Name: storage.QueueActionEventInvite, Type: stealth.ActionEventInvite, PerDay: l.MaxEventInvitesPerDay})
+	}
+	if o.follow != "" {
+		actions = append(actions, simulate.Action{Name: storage.QueueActionFollow, Type: stealth.ActionFollow, PerDay: l.MaxFollowsPerDay})
+	}
+	if o.cleanupFollows {
+		actions = append(actions, simulate.Action{Name: storage.QueueActionUnfollow, Type: stealth.ActionFollow, PerDay: l.MaxUnfollowsPerDay})
+	}
+	return actions
+}
+
+func setupCampaign(fs *flag.FlagSet) func(env *cmdEnv) error {
+	return func(env *cmdEnv) error {
+		if len(env.args) == 0 {
+			return fmt.Errorf("usage: campaign list | campaign status <name>")
+		}
+
+		switch env.args[0] {
+		case "list":
+			names, err := campaign.List(env.cfg.Campaigns.Dir)
+			if err != nil {
+				return err
+			}
+			runs, err := env.store.GetCampaignRuns()
+			if err != nil {
+				return err
+			}
+			lastRun := make(map[string]time.Time)
+			for _, r := range runs {
+				lastRun[r.Name] = r.LastRunAt
+			}
+
+			for _, name := range names {
+				last := "never run"
+				if t, ok := lastRun[name]; ok {
+					last = "last run " + t.Local().Format("2006-01-02 15:04")
+				}
+				fmt.Printf("%-24s  %s\n", name, last)
+			}
+			fmt.Printf("%d campaigns in %s\n", len(names), env.cfg.Campaigns.Dir)
+
+		case "status":
+			if len(env.args) < 2 {
+				return fmt.Errorf("usage: campaign status <name>")
+			}
+			def, err := campaign.Load(env.cfg.Campaigns.Dir, env.args[1])
+			if err != nil {
+				return err
+			}
+			counts, err := env.store.CountCampaignProspects(def.Name)
+			if err != nil {
+				return err
+			}
+
+			total := 0
+			for _, status := range storage.CampaignStatuses {
+				fmt.Printf("  %-10s %d\n", status, counts[status])
+				total += counts[status]
+			}
+			fmt.Printf("%d of %d prospects, %d follow-ups per prospect\n", total, def.Search.Max, len(def.FollowUps))
+
+		default:
+			return fmt.Errorf("unknown campaign action %q", env.args[0])
+		}
+		return nil
+	}
+}
+
+func setupMaintain(fs *flag.FlagSet) func(env *cmdEnv) error {
+	days := fs.Int("retention-days", -1, "Keep this many days of action log (default storage.action_log_retention_days, 0 = keep all)")
+
+	return func(env *cmdEnv) error {
+		if *days >= 0 {
+			env.cfg.Storage.ActionLogRetentionDays = *days
+		}
+		_, err := maintain(env.cfg, env.lgr, env.store)
+		return err
+	}
+}
+
+// maintain prunes the action log per the retention policy and compacts the
+// database; the daemon runs it on daemon.maintenance
+func maintain(cfg *config.Config, lgr *logger.Logger, store *storage.Store) (storage.MaintenanceResult, error) {
+	var before time.Time
+	if days := cfg.Storage.ActionLogRetentionDays; days > 0 {
+		before = time.Now().AddDate(0, 0, -days)
+	}
+
+	lgr.Info("Running database maintenance...")
+	res, err := store.Maintain(before)
+	if err != nil {
+		return res, err
+	}
+	lgr.Info("✓ Maintenance completed: %d action log rows pruned, %s -> %s",
+		res.Pruned, humanBytes(res.SizeBefore), humanBytes(res.SizeAfter))
+	return res, nil
+}
+
+func humanBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+func setupAudit(fs *flag.FlagSet) func(env *cmdEnv) error {
+	n := fs.Int("n", 50, "Number of entries to show")
+	by := fs.String("by", "", "Only entries by this operator")
+
+	return func(env *cmdEnv) error {
+		entries, err := env.store.GetAuditLog(*by, *n)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			fmt.Printf("%s  %-12s  %-20s  %s\n", e.CreatedAt.Local().Format("2006-01-02 15:04"), e.Operator, e.Action, e.Detail)
+		}
+		fmt.Printf("%d entries\n", len(entries))
+		return nil
+	}
+}
+
+func setupSession(fs *flag.FlagSet) func(env *cmdEnv) error {
+	return func(env *cmdEnv) error {
+		if len(env.args) != 1 || env.args[0] != "status" {
+			return fmt.Errorf("usage: session status")
+		}
+
+		st, err := auth.ReadSession(env.cfg.Storage.SessionCookiePath)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		fmt.Printf("Session:  %s\n", st.Path)
+		fmt.Printf("Saved:    %s (%s ago)\n", st.SavedAt.Local().Format("2006-01-02 15:04"), age(now.Sub(st.SavedAt)))
+		for _, c := range st.Cookies {
+			switch {
+			case c.Expires.IsZero():
+				fmt.Printf("  %-11s browser session only\n", c.Name)
+			case c.Expires.Before(now):
+				fmt.Printf("  %-11s expired %s\n", c.Name, c.Expires.Local().Format("2006-01-02 15:04"))
+			default:
+				fmt.Printf("  %-11s expires %s (in %s)\n", c.Name, c.Expires.Local().Format("2006-01-02 15:04"), age(c.Expires.Sub(now)))
+			}
+		}
+
+		refresh := time.Duration(env.cfg.Storage.SessionRefreshDays) * 24 * time.Hour
+		switch {
+		case !st.HasAuth(now):
+			fmt.Println("Status:   invalid (no unexpired li_at); the next run logs in with credentials")
+		case st.ExpiresWithin(now, refresh):
+			fmt.Println("Status:   valid, but the next run logs in again to refresh li_at")
+		default:
+			fmt.Println("Status:   valid")
+		}
+		return nil
+	}
+}
+
+// setupWarmUp reports warm-up progress, and restarts the warm-up for an
+// account coming out of a restriction
+func setupContacts(fs *flag.FlagSet) func(env *cmdEnv) error {
+	minConf := fs.Float64("min", 0, "Only matches at or above this confidence (0-1); confirm takes each address's best")
+
+	return func(env *cmdEnv) error {
+		if len(env.args) == 0 {
+			return fmt.Errorf("usage: contacts review | confirm <id>... | confirm -min <confidence> | reject <id>...")
+		}
+		// Flags may also follow the action, as in "contacts confirm -min 0.9"
+		verb := env.args[0]
+		fs.Parse(env.args[1:])
+		args := fs.Args()
+
+		matches, err := env.store.GetEmailMatches(storage.EmailMatchReview)
+		if err != nil {
+			return err
+		}
+
+		switch verb {
+		case "review":
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tEMAIL\tLOOKED UP AS\tCONFIDENCE\tCANDIDATE\tHEADLINE\tPROFILE")
+			n := 0
+			for _, m := range matches {
+				if m.Confidence < *minConf {
+					continue
+				}
+				lookup := m.Name
+				if m.Company != "" {
+					lookup += " @ " + m.Company
+				}
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", m.ID, m.Email, lookup, formatConfidence(m.Confidence),
+					m.Candidate, m.Headline, m.ProfileURL)
+				n++
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			fmt.Printf("%d candidates awaiting review; confirmed ones are connected with by -connect -matched\n", n)
+
+		case "confirm":
+			var ids []int64
+			if len(args) == 0 && *minConf > 0 {
+				// Matches come best first for each address
+				best := make(map[string]bool)
+				for _, m := range matches {
+					if !best[m.Email] && m.Confidence >= *minConf {
+						ids = append(ids, m.ID)
+					}
+					best[m.Email] = true
+				}
+			}
+			for _, a := range args {
+				id, err := strconv.ParseInt(a, 10, 64)
+				if err != nil {
+					return fmt.Errorf("invalid match id %q", a)
+				}
+				ids = append(ids, id)
+			}
+			if len(ids) == 0 {
+				return fmt.Errorf("usage: contacts confirm <id>... | confirm -min <confidence>")
+			}
+			for _, id := range ids {
+				if err := env.store.ConfirmEmailMatch(id); err != nil {
+					return err
+				}
+			}
+			env.audit("email_matches_confirmed", fmt.Sprintf("%d matches", len(ids)))
+			fmt.Printf("Confirmed %d matches; other candidates for the same addresses were rejected\n", len(ids))
+
+		case "reject":
+			if len(args) == 0 {
+				return fmt.Errorf("usage: contacts reject <id>...")
+			}
+			for _, a := range args {
+				id, err := strconv.ParseInt(a, 10, 64)
+				if err != nil {
+					return fmt.Errorf("invalid match id %q", a)
+				}
+				if err := env.store.RejectEmailMatch(id); err != nil {
+					return err
+				}
+			}
+			fmt.Printf("Rejected %d candidates\n", len(args))
+
+		default:
+			return fmt.Errorf("usage: contacts review | confirm <id>... | confirm -min <confidence> | reject <id>...")
+		}
+		return nil
+	}
+}
+
+func setupCooldown(fs *flag.FlagSet) func(env *cmdEnv) error {
+	return func(env *cmdEnv) error {
+		if len(env.args) != 1 {
+			return fmt.Errorf("usage: cooldown status | reset")
+		}
+
+		now := time.Now()
+		switch env.args[0] {
+		case "status":
+		case "reset":
+			if err := cooldown.Reset(env.store, now); err != nil {
+				return err
+			}
+			env.audit("cooldown_reset", "")
+			fmt.Println("Cooldown escalation cleared; the next repeated challenge starts from the first level")
+			return nil
+		default:
+			return fmt.Errorf("usage: cooldown status | reset")
+		}
+
+		st, err := cooldown.Load(env.cfg, env.store, now)
+		if err != nil {
+			return err
+		}
+		levels := make([]string, len(st.Levels))
+		for i, d := range st.Levels {
+			levels[i] = cooldown.Describe(d)
+		}
+		fmt.Printf("Levels:        %s (a challenge within %d hours of another goes up one)\n",
+			strings.Join(levels, ", "), env.cfg.Cooldown.WindowHours)
+		fmt.Printf("Level:         %s\n", st)
+		if !st.LastIncident.IsZero() {
+			fmt.Printf("Last incident: %s (%s ago)\n", st.LastIncident.Local().Format(time.RFC1123), age(now.Sub(st.LastIncident)))
+		}
+		if st.Active(now) {
+			fmt.Printf("Cooldown:      until %s (%s left)\n", st.Until.Local().Format(time.RFC1123), age(st.Until.Sub(now)))
+		} else {
+			fmt.Println("Cooldown:      none in force")
+		}
+		if next := st.NextDecay(); !next.IsZero() {
+			fmt.Printf("Decay:         one level down on %s after %d days without incidents\n", next.Local().Format("2006-01-02"), st.DecayDays)
+		}
+		if !env.cfg.Cooldown.Enabled {
+			fmt.Println("cooldown.enabled is off, so challenges are recorded but nothing is held back")
+		}
+		return nil
+	}
+}
+
+func setupWarmUp(fs *flag.FlagSet) func(env *cmdEnv) error {
+	return func(env *cmdEnv) error {
+		if len(env.args) != 1 {
+			return fmt.Errorf("usage: warmup status | restart")
+		}
+
+		now := time.Now()
+		switch env.args[0] {
+		case "status":
+		case "restart":
+			if err := warmup.Restart(env.store, now); err != nil {
+				return err
+			}
+			env.audit("warmup_restart", "")
+			fmt.Println("Warm-up restarted; the next run starts from the lowest volumes")
+			if !env.cfg.WarmUp.Enabled {
+				fmt.Println("warmup.enabled is off, so nothing is capped until it is turned on")
+			}
+			return nil
+		default:
+			return fmt.Errorf("usage: warmup status | restart")
+		}
+
+		if seen, err := env.store.GetStateTime(storage.StateAccountFirstSeen); err == nil && !seen.IsZero() {
+			fmt.Printf("Account:     first run %s (%s ago)\n", seen.Local().Format("2006-01-02"), age(now.Sub(seen)))
+		}
+		p, err := warmup.Load(env.cfg, env.store, now)
+		if err != nil {
+			return err
+		}
+		if p == nil {
+			if env.cfg.WarmUp.Enabled {
+				fmt.Println("Warm-up not started; it begins with the next run")
+			} else {
+				fmt.Println("Warm-up not started (warmup.enabled is off)")
+			}
+			return nil
+		}
+
+		fmt.Printf("Started:     %s (%s ago)\n", p.Started.Local().Format("2006-01-02"), age(now.Sub(p.Started)))
+		fmt.Printf("Active days: %d\n", p.DaysCompleted)
+		fmt.Printf("Peak volume: %d connections, %d messages in a day\n", p.PeakConnections, p.PeakMessages)
+		if p.RampDays > 0 {
+			fmt.Printf("Setbacks:    %d (each day with a challenge or invitation limit sets the ramp back a week)\n", p.Setbacks)
+			fmt.Printf("Ramp:        %d to %d connections and %d messages a day over %d days\n", env.cfg.WarmUp.StartPerDay,
+				env.cfg.Limits.MaxConnectionsPerDay, env.cfg.Limits.MaxMessagesPerDay, p.RampDays)
+		} else {
+			fmt.Printf("Setbacks:    %d (challenges and invitation limits start a stage over)\n", p.Setbacks)
+			for i, st := range p.Stages {
+				mark := " "
+				switch {
+				case i < p.Stage:
+					mark = "✓"
+				case i == p.Stage:
+					mark = ">"
+				}
+				fmt.Printf("  %s %d. %-14s %2d days  %3d connections/day  %3d messages/day\n",
+					mark, i+1, st.Name, st.Days, st.ConnectionsPerDay, st.MessagesPerDay)
+			}
+		}
+
+		var status string
+		switch {
+		case p.Graduated():
+			fmt.Println("Status:      complete; the configured limits apply")
+			return nil
+		case p.RampDays > 0:
+			status = fmt.Sprintf("ramp day %d of %d, %d connections and %d messages today", p.RampDay+1, p.RampDays, p.Connections, p.Messages)
+		default:
+			status = fmt.Sprintf("stage %d, day %d of %d", p.Stage+1, p.DaysInStage+1, p.Current().Days)
+		}
+		if !env.cfg.WarmUp.Enabled {
+			status += ", but warmup.enabled is off so nothing is capped"
+		}
+		fmt.Printf("Status:      %s\n", status)
+		return nil
+	}
+}
+
+// setupCreds rotates credentials: after the password is changed on LinkedIn
+// and in the environment or secrets overlay, it logs in with the new one
+// and saves a verified session for the next scheduled run
+func setupCreds(fs *flag.FlagSet) func(env *cmdEnv) error {
+	return func(env *cmdEnv) error {
+		if len(env.args) != 1 || env.args[0] != "rotate" {
+			return fmt.Errorf("usage: creds rotate")
+		}
+
+		// The typing rhythm, remote browser and throttle lease are keyed by
+		// the email, so a new one starts them afresh
+		prev, err := env.store.GetState(storage.StateLoginEmail)
+		if err != nil {
+			return err
+		}
+		if prev != "" && !strings.EqualFold(prev, env.cfg.Creds.Email) {
+			env.lgr.Warn("Email changed from %s to %s; typing rhythm and browser assignment will change with it", prev, env.cfg.Creds.Email)
+		}
+
+		br, err := browser.New(env.cfg, env.lgr)
+		if err != nil {
+			return err
+		}
+		defer br.Close()
+
+		rc := runctx.New(env.cfg, env.lgr, env.store)
+		if err := auth.New(br.Page(), rc).Rotate(rc); err != nil {
+			return fmt.Errorf("rotation failed, previous session kept: %w", err)
+		}
+		if err := env.store.SetState(storage.StateLoginEmail, env.cfg.Creds.Email); err != nil {
+			env.lgr.Warn("%v", err)
+		}
+		env.audit("creds_rotate", env.cfg.Creds.Email)
+
+		fmt.Printf("Logged in as %s; session verified and saved to %s\n", env.cfg.Creds.Email, env.cfg.Storage.SessionCookiePath)
+		fmt.Printf("Database:       %s\n", env.cfg.Storage.DBPath)
+		if env.cfg.Browser.ProfileDir != "" {
+			fmt.Printf("Chrome profile: %s\n", env.cfg.Browser.ProfileDir)
+		}
+		return nil
+	}
+}
+
+// age renders a duration in days and hours
+func age(d time.Duration) string {
+	days := int(d.Hours()) / 24
+	if days > 0 {
+		return fmt.Sprintf("%dd %dh", days, int(d.Hours())%24)
+	}
+	return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+func setupValidate(fs *flag.FlagSet) func(env *cmdEnv) error {
+	return func(env *cmdEnv) error {
+		target := env.cfg.Validation
+		if target.TestProfileURL == "" {
+			return fmt.Errorf("set validation.test_profile_url to a secondary account you own")
+		}
+
+		br, err := browser.New(env.cfg, env.lgr)
+		if err != nil {
+			return err
+		}
+		defer br.Close()
+
+		rc := runctx.New(env.cfg, env.lgr, env.store)
+		if err := auth.New(br.Page(), rc).Login(rc); err != nil {
+			return err
+		}
+
+		results := message.New(br.Page(), rc).Validate(rc, target.TestProfileURL, target.TestProfileName)
+
+		failed := 0
+		for _, r := range results {
+			switch {
+			case r.Err != nil:
+				failed++
+				fmt.Printf("FAIL  %-11s %v\n", r.Sample, r.Err)
+			case !r.Passed():
+				failed++
+				fmt.Printf("FAIL  %-11s %s\n", r.Sample, r.Diff())
+			default:
+				fmt.Printf("ok    %s\n", r.Sample)
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d samples did not render as sent", failed, len(results))
+		}
+		fmt.Printf("All %d samples rendered as sent\n", len(results))
+		return nil
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// completion is registered here rather than in the commands literal because
+// it lists the commands itself
+func init() {
+	commands["completion"] = command{
+		summary:    "Print a shell completion script (bash | zsh | fish), e.g. source <(linkedin-automation completion bash)",
+		setup:      setupCompletion,
+		standalone: true,
+	}
+}
+
+// completionWords holds what a shell can complete: subcommands, the flags of
+// a normal run, and each subcommand's flags
+type completionWords struct {
+	commands []string
+	runFlags []string
+	cmdFlags map[string][]string
+	summary  map[string]string
+}
+
+func collectCompletions() completionWords {
+	w := completionWords{cmdFlags: make(map[string][]string), summary: make(map[string]string)}
+
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	var pf processFlags
+	pf.register(fs)
+	var opts runOptions
+	opts.register(fs)
+	w.runFlags = flagNames(fs)
+
+	for name, cmd := range commands {
+		fs := flag.NewFlagSet(name, flag.ContinueOnError)
+		commandFlags(fs)
+		cmd.setup(fs)
+		w.commands = append(w.commands, name)
+		w.cmdFlags[name] = flagNames(fs)
+		w.summary[name] = cmd.summary
+	}
+	sort.Strings(w.commands)
+	return w
+}
+
+// flagNames returns fs's flags with their dash, sorted
+func flagNames(fs *flag.FlagSet) []string {
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) {
+		names = append(names, "-"+f.Name)
+	})
+	return names
+}
+
+func setupCompletion(fs *flag.FlagSet) func(env *cmdEnv) error {
+	prog := fs.String("name", filepath.Base(os.Args[0]), "Program name the script completes")
+
+	return func(env *cmdEnv) error {
+		if len(env.args) == 0 {
+			return fmt.Errorf("usage: completion bash | zsh | fish")
+		}
+
+		w := collectCompletions()
+		switch env.args[0] {
+		case "bash":
+			writeBashCompletion(os.Stdout, *prog, w)
+		case "zsh":
+			// zsh runs the bash function through its compatibility layer
+			fmt.Fprintln(os.Stdout, "autoload -U +X bashcompinit && bashcompinit")
+			writeBashCompletion(os.Stdout, *prog, w)
+		case "fish":
+			writeFishCompletion(os.Stdout, *prog, w)
+		default:
+			return fmt.Errorf("unsupported shell %q (want bash, zsh or fish)", env.args[0])
+		}
+		return nil
+	}
+}
+
+// writeBashCompletion completes a subcommand or run flag in first position,
+// then the flags of the subcommand, and file names for other arguments
+func writeBashCompletion(out io.Writer, prog string, w completionWords) {
+	fn := "_" + strings.NewReplacer("-", "_", ".", "_").Replace(prog)
+
+	fmt.Fprintf(out, "%s() {\n", fn)
+	fmt.Fprintf(out, "\tlocal cur=\"${COMP_WORDS[COMP_CWORD]}\" words\n")
+	fmt.Fprintf(out, "\tif [[ $COMP_CWORD -eq 1 ]]; then\n")
+	fmt.Fprintf(out, "\t\twords=%q\n", strings.Join(append(append([]string{}, w.commands...), w.runFlags...), " "))
+	fmt.Fprintf(out, "\telse\n")
+	fmt.Fprintf(out, "\t\tcase \"${COMP_WORDS[1]}\" in\n")
+	for _, name := range w.commands {
+		fmt.Fprintf(out, "\t\t%s) words=%q ;;\n", name, strings.Join(w.cmdFlags[name], " "))
+	}
+	fmt.Fprintf(out, "\t\t*) words=%q ;;\n", strings.Join(w.runFlags, " "))
+	fmt.Fprintf(out, "\t\tesac\n")
+	fmt.Fprintf(out, "\tfi\n")
+	fmt.Fprintf(out, "\tif [[ $COMP_CWORD -eq 1 || $cur == -* ]]; then\n")
+	fmt.Fprintf(out, "\t\tCOMPREPLY=($(compgen -W \"$words\" -- \"$cur\"))\n")
+	fmt.Fprintf(out, "\telse\n")
+	fmt.Fprintf(out, "\t\tCOMPREPLY=($(compgen -f -- \"$cur\"))\n")
+	fmt.Fprintf(out, "\tfi\n")
+	fmt.Fprintf(out, "}\n")
+	fmt.Fprintf(out, "complete -o filenames -F %s %s\n", fn, prog)
+}
+
+func writeFishCompletion(out io.Writer, prog string, w completionWords) {
+	for _, name := range w.commands {
+		fmt.Fprintf(out, "complete -c %s -n __fish_use_subcommand -f -a %s -d %s\n", prog, name, fishQuote(w.summary[name]))
+	}
+	for _, f := range w.runFlags {
+		fmt.Fprintf(out, "complete -c %s -n __fish_use_subcommand -o %s\n", prog, strings.TrimPrefix(f, "-"))
+	}
+	for _, name := range w.commands {
+		for _, f := range w.cmdFlags[name] {
+			fmt.Fprintf(out, "complete -c %s -n '__fish_seen_subcommand_from %s' -o %s\n", prog, name, strings.TrimPrefix(f, "-"))
+		}
+	}
+}
+
+// fishQuote single-quotes s for fish, which only escapes \ and ' inside
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// An account gets its own state directory next to the default paths, e.g.
+// ./data/accounts/<name>/automation.db. The database, saved cookies, Chrome
+// profile and reports all live there, so rate-limiter state, the queue and
+// contact history never mix between accounts. Without an account the
+// top-level paths and LINKEDIN_EMAIL/LINKEDIN_PASSWORD are used as before.
+
+var accountName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// AccountsConfig maps account names to their settings
+type AccountsConfig map[string]AccountConfig
+
+type AccountConfig struct {
+	// EmailEnv, PasswordEnv and TOTPEnv name the variables holding the
+	// credentials, by default LINKEDIN_EMAIL_<NAME>, LINKEDIN_PASSWORD_<NAME>
+	// and LINKEDIN_TOTP_SECRET_<NAME>
+	EmailEnv    string `yaml:"email_env"`
+	PasswordEnv string `yaml:"password_env"`
+	TOTPEnv     string `yaml:"totp_env"`
+	// Pacing overrides the top-level preset, e.g. paranoid for a new account
+	Pacing string `yaml:"pacing"`
+	// Languages, Locale and Spellcheck override the browser's, so each
+	// account reads and types like its audience
+	Languages  []string `yaml:"languages"`
+	Locale     string   `yaml:"locale"`
+	Spellcheck []string `yaml:"spellcheck"`
+}
+
+// Names returns the configured account names in alphabetical order
+func (a AccountsConfig) Names() []string {
+	names := make([]string, 0, len(a))
+	for name := range a {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyAccount switches credentials and state paths to the selected account
+func (c *Config) applyAccount() error {
+	if c.Account == "" {
+		return nil
+	}
+	acct, ok := c.Accounts[c.Account]
+	if !ok || !accountName.MatchString(c.Account) {
+		if len(c.Accounts) == 0 {
+			return fmt.Errorf("unknown account %q: no accounts are configured", c.Account)
+		}
+		return fmt.Errorf("unknown account %q (want one of %s)", c.Account, strings.Join(c.Accounts.Names(), ", "))
+	}
+
+	suffix := strings.ToUpper(strings.ReplaceAll(c.Account, "-", "_"))
+	if acct.EmailEnv == "" {
+		acct.EmailEnv = "LINKEDIN_EMAIL_" + suffix
+	}
+	if acct.PasswordEnv == "" {
+		acct.PasswordEnv = "LINKEDIN_PASSWORD_" + suffix
+	}
+	if acct.TOTPEnv == "" {
+		acct.TOTPEnv = "LINKEDIN_TOTP_SECRET_" + suffix
+	}
+	c.Creds.Email = os.Getenv(acct.EmailEnv)
+	c.Creds.Password = os.Getenv(acct.PasswordEnv)
+	c.Creds.TOTPSecret = os.Getenv(acct.TOTPEnv)
+	if c.Creds.Email == "" || c.Creds.Password == "" {
+		return fmt.Errorf("account %q: %s and %s must be set", c.Account, acct.EmailEnv, acct.PasswordEnv)
+	}
+
+	if acct.Pacing != "" {
+		c.Pacing = acct.Pacing
+	}
+	if len(acct.Languages) > 0 {
+		c.Browser.Languages = acct.Languages
+	}
+	if acct.Locale != "" {
+		c.Browser.Locale = acct.Locale
+	}
+	if len(acct.Spellcheck) > 0 {
+		c.Browser.Spellcheck = acct.Spellcheck
+	}
+
+	c.Storage.DBPath = c.accountPath(c.Storage.DBPath)
+	c.Storage.SessionCookiePath = c.accountPath(c.Storage.SessionCookiePath)
+	c.Storage.ReportDir = c.accountPath(c.Storage.ReportDir)
+	if c.Browser.ProfileDir != "" {
+		c.Browser.ProfileDir = c.accountPath(c.Browser.ProfileDir)
+	} else {
+		// A persistent profile keeps each account's browser fingerprint
+		// (local storage, cache) apart and stable between runs
+		c.Browser.ProfileDir = filepath.Join(filepath.Dir(c.Storage.DBPath), "chrome-profile")
+	}
+	return nil
+}
+
+// accountPath moves a path into the account's directory beside it
+func (c *Config) accountPath(path string) string {
+	if path == "" {
+		return path
+	}
+	return filepath.Join(filepath.Dir(path), "accounts", c.Account, filepath.Base(path))
+}
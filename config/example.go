@@ -0,0 +1,9 @@
+package config
+
+import _ "embed"
+
+// Example is the documented config.yaml the binary ships with; the init
+// wizard fills in its answers on a copy
+//
+//go:embed config.yaml
+var Example []byte
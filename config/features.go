@@ -0,0 +1,40 @@
+package config
+
+// FeaturesConfig switches whole subsystems off without a new build, so a
+// capability can be adopted on its own and one that misbehaves disabled at
+// once. A feature that is off stays off whatever its own section says
+type FeaturesConfig struct {
+	// InboxPolling reads the unread count between daemon jobs and queued
+	// actions (daemon.inbox_poll_minutes)
+	InboxPolling bool `yaml:"inbox_polling" default:"true" env:"FEATURE_INBOX_POLLING"`
+	// WarmUp holds a new account to rising daily volumes (warmup)
+	WarmUp bool `yaml:"warmup" default:"true" env:"FEATURE_WARMUP"`
+	// Analytics records pipeline snapshots and adds read receipts, source
+	// outcomes and the acceptance model to run reports
+	Analytics bool `yaml:"analytics" default:"true" env:"FEATURE_ANALYTICS"`
+}
+
+// Off names the features that are switched off
+func (f FeaturesConfig) Off() []string {
+	var off []string
+	if !f.InboxPolling {
+		off = append(off, "inbox_polling")
+	}
+	if !f.WarmUp {
+		off = append(off, "warmup")
+	}
+	if !f.Analytics {
+		off = append(off, "analytics")
+	}
+	return off
+}
+
+// applyFeatures turns off the settings of the features that are off
+func (c *Config) applyFeatures() {
+	if !c.Features.InboxPolling {
+		c.Daemon.InboxPollMinutes = 0
+	}
+	if !c.Features.WarmUp {
+		c.WarmUp.Enabled = false
+	}
+}
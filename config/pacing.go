@@ -0,0 +1,165 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// pacingPreset bundles every setting that decides how fast the account
+// moves. Tuning one knob alone tends to leave the others inconsistent (a low
+// daily cap with machine-gun delays still looks automated), so a preset sets
+// them all together
+type pacingPreset struct {
+	connectionsPerDay  int
+	connectionsPerWeek int
+	messagesPerDay     int
+	broadcastsPerDay   int
+	eventInvitesPerDay int
+	// followsPerDay also caps unfollows
+	followsPerDay int
+	// min and max, in milliseconds
+	actionDelay [2]int
+	typingDelay [2]int
+	scrollDelay [2]int
+	// workHours are the start and end hour when business_hours_only is on
+	workHours [2]int
+	// minGapSeconds spaces actions of cooperating processes (throttle)
+	minGapSeconds int
+	// thinkTimeFactor stretches the worker's pause between queued items
+	thinkTimeFactor float64
+}
+
+var pacingPresets = map[string]pacingPreset{
+	// paranoid is for new accounts, accounts coming out of a restriction, and
+	// the first weeks after a warm-up: single-digit daily invites, long
+	// pauses and a short working day, so activity stays well under what
+	// LinkedIn flags even for low-reputation accounts
+	"paranoid": {
+		connectionsPerDay:  8,
+		connectionsPerWeek: 40,
+		messagesPerDay:     12,
+		broadcastsPerDay:   3,
+		eventInvitesPerDay: 15,
+		followsPerDay:      10,
+		actionDelay:        [2]int{5000, 12000},
+		typingDelay:        [2]int{90, 280},
+		scrollDelay:        [2]int{1000, 3500},
+		workHours:          [2]int{10, 17},
+		minGapSeconds:      180,
+		thinkTimeFactor:    2.5,
+	},
+	// conservative suits established accounts that have been restricted
+	// before or run unattended: about three quarters of standard volume and
+	// half again as much idle time between items
+	"conservative": {
+		connectionsPerDay:  15,
+		connectionsPerWeek: 80,
+		messagesPerDay:     25,
+		broadcastsPerDay:   6,
+		eventInvitesPerDay: 30,
+		followsPerDay:      20,
+		actionDelay:        [2]int{3000, 8000},
+		typingDelay:        [2]int{60, 220},
+		scrollDelay:        [2]int{700, 2500},
+		workHours:          [2]int{9, 18},
+		minGapSeconds:      90,
+		thinkTimeFactor:    1.5,
+	},
+	// standard matches the built-in defaults: an aged account with a good
+	// acceptance rate, staying inside the weekly invitation limit
+	"standard": {
+		connectionsPerDay:  20,
+		connectionsPerWeek: 100,
+		messagesPerDay:     30,
+		broadcastsPerDay:   10,
+		eventInvitesPerDay: 50,
+		followsPerDay:      30,
+		actionDelay:        [2]int{2000, 5000},
+		typingDelay:        [2]int{50, 200},
+		scrollDelay:        [2]int{500, 2000},
+		workHours:          [2]int{9, 18},
+		minGapSeconds:      60,
+		thinkTimeFactor:    1,
+	},
+}
+
+// PacingPresets returns the preset names in alphabetical order
+func PacingPresets() []string {
+	names := make([]string, 0, len(pacingPresets))
+	for name := range pacingPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyPacing overwrites the settings a preset covers; with no preset the
+// individual keys are used as configured
+func (c *Config) applyPacing() error {
+	if c.Pacing == "" {
+		return nil
+	}
+	p, ok := pacingPresets[strings.ToLower(c.Pacing)]
+	if !ok {
+		return fmt.Errorf("unknown pacing preset %q (want one of %s)", c.Pacing, strings.Join(PacingPresets(), ", "))
+	}
+
+	c.Limits.MaxConnectionsPerDay = p.connectionsPerDay
+	c.Limits.MaxConnectionsPerWeek = p.connectionsPerWeek
+	c.Limits.MaxMessagesPerDay = p.messagesPerDay
+	c.Limits.MaxBroadcastsPerDay = p.broadcastsPerDay
+	c.Limits.MaxEventInvitesPerDay = p.eventInvitesPerDay
+	c.Limits.MaxFollowsPerDay = p.followsPerDay
+	c.Limits.MaxUnfollowsPerDay = p.followsPerDay
+	c.Delays.MinActionDelayMs, c.Delays.MaxActionDelayMs = p.actionDelay[0], p.actionDelay[1]
+	c.Delays.MinTypingDelayMs, c.Delays.MaxTypingDelayMs = p.typingDelay[0], p.typingDelay[1]
+	c.Delays.MinScrollDelayMs, c.Delays.MaxScrollDelayMs = p.scrollDelay[0], p.scrollDelay[1]
+	c.Stealth.WorkStartHour, c.Stealth.WorkEndHour = p.workHours[0], p.workHours[1]
+	c.Stealth.ThinkTimeFactor = p.thinkTimeFactor
+	c.Throttle.MinGapSeconds = p.minGapSeconds
+	return nil
+}
+
+// Scale cuts every daily and weekly limit to percent of its value, keeping at
+// least one of each action that is allowed at all
+func (l *LimitsConfig) Scale(percent int) {
+	for _, v := range []*int{
+		&l.MaxConnectionsPerDay, &l.MaxConnectionsPerWeek, &l.MaxMessagesPerDay, &l.MaxBroadcastsPerDay,
+		&l.MaxEventInvitesPerDay, &l.MaxFollowsPerDay, &l.MaxUnfollowsPerDay, &l.MaxWithdrawalsPerDay,
+	} {
+		if *v > 0 {
+			*v = max(1, *v*percent/100)
+		}
+	}
+}
+
+// Cap lowers every daily limit to at most perDay, and the weekly connection
+// limit to five times it
+func (l *LimitsConfig) Cap(perDay int) {
+	for _, v := range []*int{
+		&l.MaxConnectionsPerDay, &l.MaxMessagesPerDay, &l.MaxBroadcastsPerDay, &l.MaxEventInvitesPerDay,
+		&l.MaxFollowsPerDay, &l.MaxUnfollowsPerDay, &l.MaxWithdrawalsPerDay,
+	} {
+		*v = min(*v, perDay)
+	}
+	l.MaxConnectionsPerWeek = min(l.MaxConnectionsPerWeek, 5*perDay)
+}
+
+// PacingSummary describes the effective pacing, after the preset and any
+// later adjustments such as a compliance profile
+func (c *Config) PacingSummary() []string {
+	name := c.Pacing
+	if name == "" {
+		name = "custom"
+	}
+	l, d, s := c.Limits, c.Delays, c.Stealth
+	return []string{
+		fmt.Sprintf("Pacing %s: %d connections/day (%d/week), %d messages/day (%d broadcasts), %d event invites/day, %d follows and %d unfollows/day",
+			name, l.MaxConnectionsPerDay, l.MaxConnectionsPerWeek, l.MaxMessagesPerDay, l.MaxBroadcastsPerDay,
+			l.MaxEventInvitesPerDay, l.MaxFollowsPerDay, l.MaxUnfollowsPerDay),
+		fmt.Sprintf("Pacing %s: action delay %d-%dms, typing %d-%dms, scroll %d-%dms, think time x%.1f, throttle gap %ds, working hours %02d-%02d (enforced: %t)",
+			name, d.MinActionDelayMs, d.MaxActionDelayMs, d.MinTypingDelayMs, d.MaxTypingDelayMs, d.MinScrollDelayMs, d.MaxScrollDelayMs,
+			s.ThinkTimeFactor, c.Throttle.MinGapSeconds, s.WorkStartHour, s.WorkEndHour, s.BusinessHoursOnly),
+	}
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RateLimitsConfig maps rate limiter action types, e.g. "connection_request",
+// to overrides of their built-in limits
+type RateLimitsConfig map[string]RateLimit
+
+// RateLimit overrides the rate limiter's limits for one action; zero keeps
+// the built-in value (see stealth.NewRateLimiter)
+type RateLimit struct {
+	HourlyMax          int `yaml:"hourly_max"`
+	DailyMax           int `yaml:"daily_max"`
+	MinIntervalSeconds int `yaml:"min_interval_seconds"`
+	// CooldownAfter consecutive actions pause all actions for CooldownSeconds
+	CooldownAfter   int `yaml:"cooldown_after"`
+	CooldownSeconds int `yaml:"cooldown_seconds"`
+}
+
+// rateLimitCeilings bound what an override may set per action: the most an
+// hour or a day may allow and the shortest interval. Past them LinkedIn
+// flags an account whatever else the pacing does
+var rateLimitCeilings = map[string]RateLimit{
+	"profile_view":       {HourlyMax: 80, DailyMax: 400, MinIntervalSeconds: 5},
+	"connection_request": {HourlyMax: 20, DailyMax: 100, MinIntervalSeconds: 30},
+	"message":            {HourlyMax: 30, DailyMax: 150, MinIntervalSeconds: 20},
+	"search":             {HourlyMax: 60, DailyMax: 300, MinIntervalSeconds: 5},
+	"scroll":             {HourlyMax: 400, DailyMax: 2000, MinIntervalSeconds: 1},
+	"like":               {HourlyMax: 50, DailyMax: 240, MinIntervalSeconds: 10},
+	"comment":            {HourlyMax: 15, DailyMax: 60, MinIntervalSeconds: 60},
+	"page_view":          {HourlyMax: 200, DailyMax: 1000, MinIntervalSeconds: 2},
+	"event_invite":       {HourlyMax: 40, DailyMax: 120, MinIntervalSeconds: 10},
+	"follow":             {HourlyMax: 30, DailyMax: 150, MinIntervalSeconds: 15},
+}
+
+// validateRateLimits rejects unknown actions, negative values, and limits
+// past the ceilings
+func (c *Config) validateRateLimits() error {
+	names := make([]string, 0, len(c.RateLimits))
+	for name := range c.RateLimits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		l := c.RateLimits[name]
+		ceil, ok := rateLimitCeilings[name]
+		if !ok {
+			known := make([]string, 0, len(rateLimitCeilings))
+			for k := range rateLimitCeilings {
+				known = append(known, k)
+			}
+			sort.Strings(known)
+			return fmt.Errorf("rate_limits.%s: unknown action (want one of %s)", name, strings.Join(known, ", "))
+		}
+
+		key := "rate_limits." + name
+		switch {
+		case l.HourlyMax < 0 || l.DailyMax < 0 || l.MinIntervalSeconds < 0 || l.CooldownAfter < 0 || l.CooldownSeconds < 0:
+			return fmt.Errorf("%s: values must not be negative", key)
+		case l.HourlyMax > ceil.HourlyMax:
+			return fmt.Errorf("%s.hourly_max: %d is above the safe maximum of %d", key, l.HourlyMax, ceil.HourlyMax)
+		case l.DailyMax > ceil.DailyMax:
+			return fmt.Errorf("%s.daily_max: %d is above the safe maximum of %d", key, l.DailyMax, ceil.DailyMax)
+		case l.MinIntervalSeconds > 0 && l.MinIntervalSeconds < ceil.MinIntervalSeconds:
+			return fmt.Errorf("%s.min_interval_seconds: %d is below the safe minimum of %d", key, l.MinIntervalSeconds, ceil.MinIntervalSeconds)
+		case l.HourlyMax > 0 && l.DailyMax > 0 && l.HourlyMax > l.DailyMax:
+			return fmt.Errorf("%s: hourly_max %d is above daily_max %d", key, l.HourlyMax, l.DailyMax)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,328 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Field describes one supported setting, derived from the Config struct tags
+type Field struct {
+	Key     string // dotted YAML path; "*" stands for any map key
+	Type    string
+	Default string
+	Env     string // environment variable that overrides the file, if any
+}
+
+// Issue is one finding from Check
+type Issue struct {
+	Key     string
+	Level   string // "error" or "info"
+	Message string
+}
+
+// Schema lists every supported setting in declaration order
+func Schema() []Field {
+	var fields []Field
+	walkFields(reflect.TypeOf(Config{}), "", func(key string, sf reflect.StructField) {
+		fields = append(fields, Field{
+			Key:     key,
+			Type:    typeName(sf.Type),
+			Default: sf.Tag.Get("default"),
+			Env:     sf.Tag.Get("env"),
+		})
+	})
+	return fields
+}
+
+// walkFields calls fn for every leaf setting under t; maps of structs are
+// expanded with a "*" path element
+func walkFields(t reflect.Type, prefix string, fn func(key string, sf reflect.StructField)) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := strings.Split(sf.Tag.Get("yaml"), ",")[0]
+		if name == "-" {
+			// Env-only settings (credentials) are listed by their variable
+			if sf.Type.Kind() == reflect.Struct {
+				walkFields(sf.Type, prefix, fn)
+			} else if sf.Tag.Get("env") != "" {
+				fn("("+sf.Tag.Get("env")+")", sf)
+			}
+			continue
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+
+		switch {
+		case sf.Type.Kind() == reflect.Struct:
+			walkFields(sf.Type, key, fn)
+		case sf.Type.Kind() == reflect.Map && sf.Type.Elem().Kind() == reflect.Struct:
+			walkFields(sf.Type.Elem(), key+".*", fn)
+		default:
+			fn(key, sf)
+		}
+	}
+}
+
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Slice:
+		return "list of " + typeName(t.Elem())
+	case reflect.Map:
+		return "map of " + typeName(t.Elem())
+	case reflect.Float64:
+		return "float"
+	default:
+		return t.Kind().String()
+	}
+}
+
+// applyDefaults sets every field with a default tag; YAML values decoded
+// afterwards take precedence, so keys left out of the file keep the default
+func applyDefaults(v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		fv := v.Field(i)
+		if sf.Type.Kind() == reflect.Struct {
+			applyDefaults(fv)
+			continue
+		}
+		if def, ok := sf.Tag.Lookup("default"); ok {
+			setString(fv, def)
+		}
+	}
+}
+
+// applyEnv overrides fields that declare an env tag when the variable is set
+func applyEnv(v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		fv := v.Field(i)
+		if sf.Type.Kind() == reflect.Struct {
+			applyEnv(fv)
+			continue
+		}
+		if name := sf.Tag.Get("env"); name != "" {
+			if val := os.Getenv(name); val != "" {
+				setString(fv, val)
+			}
+		}
+	}
+}
+
+// setString assigns a textual value to a scalar or list field; unparsable
+// values are ignored, matching how environment overrides always behaved
+func setString(fv reflect.Value, s string) {
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(s); err == nil {
+			fv.SetBool(b)
+		}
+	case reflect.Int:
+		if n, err := strconv.Atoi(s); err == nil {
+			fv.SetInt(int64(n))
+		}
+	case reflect.Float64:
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			fv.SetFloat(f)
+		}
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() != reflect.String {
+			return
+		}
+		parts := strings.Split(s, ",")
+		list := reflect.MakeSlice(fv.Type(), 0, len(parts))
+		for _, p := range parts {
+			if p = strings.TrimSpace(p); p != "" {
+				list = reflect.Append(list, reflect.ValueOf(p))
+			}
+		}
+		fv.Set(list)
+	}
+}
+
+// Check compares a config file against the schema: unknown keys and values
+// of the wrong type are errors, supported keys missing from the file are
+// reported with the default that will be used
+func Check(path string) ([]Issue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	schema := make(map[string]Field)
+	var keys []string
+	for _, f := range Schema() {
+		if strings.HasPrefix(f.Key, "(") {
+			continue
+		}
+		schema[f.Key] = f
+		keys = append(keys, f.Key)
+	}
+
+	var issues []Issue
+	seen := make(map[string]bool)
+	if len(doc.Content) > 0 {
+		checkNode(doc.Content[0], "", schema, keys, seen, &issues)
+	}
+
+	for _, f := range Schema() {
+		if strings.HasPrefix(f.Key, "(") || strings.Contains(f.Key, "*") || seen[f.Key] {
+			continue
+		}
+		msg := "not set, default " + strconv.Quote(f.Default)
+		if f.Default == "" {
+			msg = "not set, defaults to the zero value"
+		}
+		issues = append(issues, Issue{Key: f.Key, Level: "info", Message: msg})
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Level == "error" && issues[j].Level != "error"
+	})
+	return issues, nil
+}
+
+func checkNode(n *yaml.Node, prefix string, schema map[string]Field, keys []string, seen map[string]bool, issues *[]Issue) {
+	if n.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key := n.Content[i].Value
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		val := n.Content[i+1]
+
+		if f, ok := lookupField(schema, key); ok {
+			seen[f.Key] = true
+			if msg := typeMismatch(f, val); msg != "" {
+				*issues = append(*issues, Issue{Key: key, Level: "error", Message: msg})
+			}
+			continue
+		}
+		if hasPrefix(keys, key) {
+			checkNode(val, key, schema, keys, seen, issues)
+			continue
+		}
+
+		msg := "unknown key"
+		if s := closest(key, keys); s != "" {
+			msg += fmt.Sprintf(" (did you mean %q?)", s)
+		}
+		*issues = append(*issues, Issue{Key: key, Level: "error", Message: msg})
+	}
+}
+
+// lookupField resolves a dotted key, letting "*" in the schema match any map key
+func lookupField(schema map[string]Field, key string) (Field, bool) {
+	if f, ok := schema[key]; ok {
+		return f, true
+	}
+	parts := strings.Split(key, ".")
+	for i := range parts {
+		wild := append(append([]string{}, parts[:i]...), "*")
+		wild = append(wild, parts[i+1:]...)
+		if f, ok := schema[strings.Join(wild, ".")]; ok {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// hasPrefix reports whether key is a section containing schema keys
+func hasPrefix(keys []string, key string) bool {
+	for _, k := range keys {
+		if strings.HasPrefix(k, key+".") {
+			return true
+		}
+		// Map sections: "compliance.profiles.eu" under "compliance.profiles.*"
+		if i := strings.Index(k, ".*"); i >= 0 && strings.HasPrefix(key, k[:i]+".") &&
+			strings.Count(key, ".") == strings.Count(k[:i], ".")+1 {
+			return true
+		}
+	}
+	return false
+}
+
+func typeMismatch(f Field, n *yaml.Node) string {
+	switch {
+	case strings.HasPrefix(f.Type, "list"):
+		if n.Kind != yaml.SequenceNode {
+			return "expected a list"
+		}
+	case strings.HasPrefix(f.Type, "map"):
+		if n.Kind != yaml.MappingNode {
+			return "expected a mapping"
+		}
+	default:
+		if n.Kind != yaml.ScalarNode {
+			return "expected " + article(f.Type) + " " + f.Type
+		}
+		var err error
+		switch f.Type {
+		case "bool":
+			_, err = strconv.ParseBool(n.Value)
+		case "int":
+			_, err = strconv.Atoi(n.Value)
+		case "float":
+			_, err = strconv.ParseFloat(n.Value, 64)
+		}
+		if err != nil {
+			return fmt.Sprintf("expected %s %s, got %q", article(f.Type), f.Type, n.Value)
+		}
+	}
+	return ""
+}
+
+func article(word string) string {
+	if strings.ContainsAny(word[:1], "aeiou") {
+		return "an"
+	}
+	return "a"
+}
+
+// closest suggests the schema key nearest to an unknown one
+func closest(key string, keys []string) string {
+	best, bestDist := "", len(key)/2+1
+	for _, k := range keys {
+		if d := distance(key, k); d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	return best
+}
+
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,206 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The secrets overlay is a YAML file laid out like config.yaml, plus an
+// "env" section for settings that only come from the environment (e.g.
+// LINKEDIN_EMAIL). Keys stay readable and every value is encrypted on its
+// own, sops-style, so the file can be committed and still diffs per key.
+// Values are AES-256-GCM sealed with the key path as additional data, so a
+// value moved to another key no longer decrypts.
+
+const (
+	// KeyEnv holds the base64 overlay key itself; KeyFileEnv names a file holding it
+	KeyEnv     = "LINKEDIN_CONFIG_KEY"
+	KeyFileEnv = "LINKEDIN_CONFIG_KEY_FILE"
+)
+
+var encValue = regexp.MustCompile(`^ENC\[AES256_GCM,data:([A-Za-z0-9+/=]*),iv:([A-Za-z0-9+/=]+),tag:([A-Za-z0-9+/=]+),type:(str|int|float|bool)\]$`)
+
+// GenerateKey returns a new random overlay key, base64 encoded
+func GenerateKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// LoadKey reads the overlay key from LINKEDIN_CONFIG_KEY or the file named
+// by LINKEDIN_CONFIG_KEY_FILE
+func LoadKey() ([]byte, error) {
+	encoded := os.Getenv(KeyEnv)
+	if encoded == "" {
+		path := os.Getenv(KeyFileEnv)
+		if path == "" {
+			return nil, fmt.Errorf("no decryption key: set %s or %s", KeyEnv, KeyFileEnv)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file: %w", err)
+		}
+		encoded = string(data)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != 32 {
+		return nil, errors.New("invalid key: expected 32 bytes, base64 encoded (see secrets keygen)")
+	}
+	return key, nil
+}
+
+// EncryptOverlay encrypts every scalar value of a plaintext overlay;
+// values that are already encrypted are kept as they are
+func EncryptOverlay(plain, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return transformOverlay(plain, func(path string, n *yaml.Node) error {
+		if encValue.MatchString(n.Value) {
+			return nil
+		}
+		iv := make([]byte, gcm.NonceSize())
+		if _, err := rand.Read(iv); err != nil {
+			return err
+		}
+		sealed := gcm.Seal(nil, iv, []byte(n.Value), []byte(path))
+		data, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+
+		// The type is kept so "yes" stays a string and 30 an int
+		typ := strings.TrimPrefix(n.ShortTag(), "!!")
+		if typ != "int" && typ != "float" && typ != "bool" {
+			typ = "str"
+		}
+		n.Value = fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:%s]", b64(data), b64(iv), b64(tag), typ)
+		n.Tag, n.Style = "!!str", 0
+		return nil
+	})
+}
+
+// DecryptOverlay turns an encrypted overlay back into plain YAML
+func DecryptOverlay(data, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return transformOverlay(data, func(path string, n *yaml.Node) error {
+		m := encValue.FindStringSubmatch(n.Value)
+		if m == nil {
+			return fmt.Errorf("%s is not encrypted", path)
+		}
+		var parts [3][]byte
+		for i := range parts {
+			if parts[i], err = base64.StdEncoding.DecodeString(m[i+1]); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		}
+		plain, err := gcm.Open(nil, parts[1], append(parts[0], parts[2]...), []byte(path))
+		if err != nil {
+			return fmt.Errorf("failed to decrypt %s: wrong key or tampered value", path)
+		}
+
+		n.Value, n.Tag, n.Style = string(plain), "!!"+m[4], 0
+		return nil
+	})
+}
+
+// applyOverlay decrypts the secrets file onto cfg; its env section fills
+// environment variables that are not already set
+func applyOverlay(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read secrets file: %w", err)
+	}
+	key, err := LoadKey()
+	if err != nil {
+		return fmt.Errorf("secrets file %s: %w", path, err)
+	}
+	plain, err := DecryptOverlay(data, key)
+	if err != nil {
+		return fmt.Errorf("secrets file %s: %w", path, err)
+	}
+
+	if err := yaml.Unmarshal(plain, cfg); err != nil {
+		return fmt.Errorf("failed to parse secrets file: %w", err)
+	}
+
+	var overlay struct {
+		Env map[string]string `yaml:"env"`
+	}
+	if err := yaml.Unmarshal(plain, &overlay); err != nil {
+		return fmt.Errorf("failed to parse secrets file: %w", err)
+	}
+	for name, val := range overlay.Env {
+		if os.Getenv(name) == "" {
+			os.Setenv(name, val)
+		}
+	}
+	return nil
+}
+
+// transformOverlay calls fn for every scalar value with its dotted key path
+func transformOverlay(data []byte, fn func(path string, n *yaml.Node) error) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse overlay: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return data, nil
+	}
+	if err := walkValues(doc.Content[0], "", fn); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(&doc)
+}
+
+func walkValues(n *yaml.Node, path string, fn func(string, *yaml.Node) error) error {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := n.Content[i].Value
+			if path != "" {
+				key = path + "." + key
+			}
+			if err := walkValues(n.Content[i+1], key, fn); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for i, c := range n.Content {
+			if err := walkValues(c, fmt.Sprintf("%s[%d]", path, i), fn); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		if n.Tag == "!!null" {
+			return nil
+		}
+		return fn(path, n)
+	}
+	return nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func b64(b []byte) string {
+	return base64.StdEncoding.EncodeToString(b)
+}
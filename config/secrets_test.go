@@ -0,0 +1,122 @@
+package config
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const plainOverlay = `linkedin:
+  password: hunter2
+stealth:
+  min_delay_ms: 1500
+  business_hours_only: true
+env:
+  LINKEDIN_EMAIL: me@example.com
+`
+
+func testKey(t *testing.T) []byte {
+	t.Helper()
+	encoded, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestOverlayRoundTrip(t *testing.T) {
+	key := testKey(t)
+	enc, err := EncryptOverlay([]byte(plainOverlay), key)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	for _, secret := range []string{"hunter2", "1500", "me@example.com"} {
+		if strings.Contains(string(enc), secret) {
+			t.Errorf("encrypted overlay still holds %q:\n%s", secret, enc)
+		}
+	}
+
+	// Encrypting again leaves encrypted values alone
+	again, err := EncryptOverlay(enc, key)
+	if err != nil {
+		t.Fatalf("re-encrypt: %v", err)
+	}
+	if string(again) != string(enc) {
+		t.Errorf("re-encrypting changed the overlay:\n%s\nbecame\n%s", enc, again)
+	}
+
+	dec, err := DecryptOverlay(enc, key)
+	if err != nil {
+		t.Fatalf("decrypt: %v", err)
+	}
+	var want, got map[string]interface{}
+	if err := yaml.Unmarshal([]byte(plainOverlay), &want); err != nil {
+		t.Fatal(err)
+	}
+	if err := yaml.Unmarshal(dec, &got); err != nil {
+		t.Fatalf("decrypted overlay is not YAML: %v", err)
+	}
+	// Types survive: the int stays an int and the bool a bool
+	if !yamlEqual(t, want, got) {
+		t.Errorf("round trip changed the overlay:\n%s\nbecame\n%s", plainOverlay, dec)
+	}
+}
+
+func TestOverlayTamper(t *testing.T) {
+	key := testKey(t)
+	enc, err := EncryptOverlay([]byte(plainOverlay), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := DecryptOverlay(enc, testKey(t)); err == nil {
+		t.Error("decrypted with the wrong key")
+	}
+
+	// Flip a bit of the password's ciphertext
+	var doc yaml.Node
+	if err := yaml.Unmarshal(enc, &doc); err != nil {
+		t.Fatal(err)
+	}
+	password := doc.Content[0].Content[1].Content[1]
+	m := encValue.FindStringSubmatch(password.Value)
+	data, _ := base64.StdEncoding.DecodeString(m[1])
+	data[0] ^= 1
+	password.Value = strings.Replace(password.Value, m[1], base64.StdEncoding.EncodeToString(data), 1)
+	tampered, err := yaml.Marshal(&doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecryptOverlay(tampered, key); err == nil {
+		t.Error("decrypted a tampered value")
+	}
+
+	// A sealed value moved to another key no longer opens
+	moved := strings.Replace(string(enc), "password:", "username:", 1)
+	if _, err := DecryptOverlay([]byte(moved), key); err == nil {
+		t.Error("decrypted a value moved to another key")
+	}
+
+	if _, err := DecryptOverlay([]byte("linkedin:\n  password: plain\n"), key); err == nil {
+		t.Error("accepted an unencrypted value")
+	}
+}
+
+func yamlEqual(t *testing.T, a, b interface{}) bool {
+	t.Helper()
+	x, err := yaml.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	y, err := yaml.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(x) == string(y)
+}
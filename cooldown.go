@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"linkedin-automation/config"
+	"linkedin-automation/internal/cooldown"
+	"linkedin-automation/internal/logger"
+	"linkedin-automation/internal/storage"
+)
+
+// checkCooldown returns an error while a cooldown is in force, escalated by
+// repeated security challenges or held after a LinkedIn warning, so no run
+// starts during it
+func checkCooldown(cfg *config.Config, store *storage.Store, lgr *logger.Logger) error {
+	now := time.Now()
+	st, err := cooldown.Load(cfg, store, now)
+	if err != nil {
+		// Fail closed: a cooldown that cannot be read may be in force
+		return fmt.Errorf("failed to read cooldown state, not starting: %w", err)
+	}
+	if st.Active(now) {
+		return fmt.Errorf("mandatory cooldown until %s after security challenges or LinkedIn warnings (%s)",
+			st.Until.Local().Format(time.RFC1123), st)
+	}
+	if cfg.Cooldown.Enabled && st.Level > 0 {
+		lgr.Info("Account at %s; it drops a level %s if nothing goes wrong", st, st.NextDecay().Local().Format("Mon Jan 2"))
+	}
+	return nil
+}
+
+// challenged records a security challenge and escalates the cooldown when it
+// repeats within cooldown.window_hours. It returns when the cooldown ends,
+// or the zero time when it did not escalate
+func challenged(cfg *config.Config, store *storage.Store, lgr *logger.Logger, detail string) time.Time {
+	if !cfg.Cooldown.Enabled {
+		store.RecordIncident(storage.IncidentChallenge, detail)
+		return time.Time{}
+	}
+	st, escalated, err := cooldown.Challenge(cfg, store, time.Now(), detail)
+	if err != nil {
+		lgr.Warn("Failed to record security challenge: %v", err)
+		return time.Time{}
+	}
+	if !escalated {
+		lgr.Warn("Security challenge recorded; another within %d hours starts a mandatory cooldown", cfg.Cooldown.WindowHours)
+		return time.Time{}
+	}
+	lgr.Error("Repeated security challenge: %s; nothing is sent until %s", st, st.Until.Local().Format(time.RFC1123))
+	return st.Until
+}
+
+// onChallenge escalates the cooldown for a challenge met by a queued action,
+// and stops the rest of the run's actions when it did
+func (s *session) onChallenge(err error) {
+	if until := challenged(s.cfg, s.store, s.lgr, err.Error()); until.After(s.cooldownUntil) {
+		s.cooldownUntil = until
+	}
+}
@@ -0,0 +1,415 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"linkedin-automation/config"
+	"linkedin-automation/internal/analytics"
+	"linkedin-automation/internal/auth"
+	"linkedin-automation/internal/cron"
+	"linkedin-automation/internal/logger"
+	"linkedin-automation/internal/message"
+	"linkedin-automation/internal/notify"
+	"linkedin-automation/internal/report"
+	"linkedin-automation/internal/stealth"
+	"linkedin-automation/internal/storage"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// daemonJob is an entry of daemon.jobs with its schedule and flags parsed
+type daemonJob struct {
+	name       string
+	args       []string
+	schedule   *cron.Schedule
+	opts       runOptions
+	maxRuntime time.Duration
+	// accounts run the job in turn; "" is the default account
+	accounts []string
+	// maintenance compacts the database instead of running actions
+	maintenance bool
+	// digest sends the weekly digest instead of running actions
+	digest bool
+	// summary emails the daily summary instead of running actions
+	summary bool
+}
+
+// loadJobs parses daemon.jobs, so a bad schedule or flag fails at startup
+// rather than when the job first comes due
+func loadJobs(cfg *config.Config, lgr *logger.Logger, t templates) ([]daemonJob, error) {
+	if len(cfg.Daemon.Jobs) == 0 {
+		return nil, errors.New("daemon.jobs is empty; nothing to schedule")
+	}
+
+	names := make([]string, 0, len(cfg.Daemon.Jobs))
+	for name := range cfg.Daemon.Jobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var jobs []daemonJob
+	var used []string
+	for _, name := range names {
+		j := cfg.Daemon.Jobs[name]
+		schedule, err := cron.Parse(j.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("daemon job %q: %w", name, err)
+		}
+
+		job := daemonJob{
+			name:       name,
+			args:       j.Args,
+			schedule:   schedule,
+			maxRuntime: time.Duration(j.MaxRuntimeMinutes) * time.Minute,
+			accounts:   j.Accounts,
+		}
+		if len(job.accounts) == 0 {
+			job.accounts = []string{cfg.Account}
+		}
+		for _, account := range job.accounts {
+			if _, ok := cfg.Accounts[account]; !ok && account != "" {
+				return nil, fmt.Errorf("daemon job %q: unknown account %q", name, account)
+			}
+			if !slices.Contains(used, account) {
+				used = append(used, account)
+			}
+		}
+		fs := flag.NewFlagSet(name, flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		job.opts.register(fs)
+		if err := fs.Parse(j.Args); err != nil {
+			return nil, fmt.Errorf("daemon job %q: %w", name, err)
+		}
+		if fs.NArg() > 0 {
+			return nil, fmt.Errorf("daemon job %q: unexpected argument %q", name, fs.Arg(0))
+		}
+		if job.opts.none() {
+			return nil, fmt.Errorf("daemon job %q has no action flags", name)
+		}
+		if err := checkOptions(cfg, lgr, job.opts, t); err != nil {
+			return nil, fmt.Errorf("daemon job %q: %w", name, err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	if cfg.Daemon.Maintenance != "" {
+		schedule, err := cron.Parse(cfg.Daemon.Maintenance)
+		if err != nil {
+			return nil, fmt.Errorf("daemon.maintenance: %w", err)
+		}
+		// Every account the jobs touch has a database of its own
+		jobs = append(jobs, daemonJob{name: "maintenance", schedule: schedule, maintenance: true, accounts: used})
+	}
+
+	if cfg.Digest.Cron != "" {
+		schedule, err := cron.Parse(cfg.Digest.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("digest.cron: %w", err)
+		}
+		if cfg.DigestWebhook() == "" {
+			lgr.Info("No digest.webhook or challenge.webhook set; the weekly digest is not sent")
+		} else {
+			jobs = append(jobs, daemonJob{name: "digest", schedule: schedule, digest: true, accounts: used})
+		}
+	}
+
+	if cfg.Email.SummaryCron != "" && cfg.Email.Enabled() {
+		schedule, err := cron.Parse(cfg.Email.SummaryCron)
+		if err != nil {
+			return nil, fmt.Errorf("email.summary_cron: %w", err)
+		}
+		jobs = append(jobs, daemonJob{name: "summary", schedule: schedule, summary: true, accounts: used})
+	}
+	return jobs, nil
+}
+
+// nextJob returns the job due first after now; occurrences that pass while
+// another job is running are skipped, not caught up
+func nextJob(jobs []daemonJob, now time.Time) (daemonJob, time.Time) {
+	var next daemonJob
+	var at time.Time
+	for _, j := range jobs {
+		t := j.schedule.Next(now)
+		if !t.IsZero() && (at.IsZero() || t.Before(at)) {
+			next, at = j, t
+		}
+	}
+	return next, at
+}
+
+// loadConfig loads the config of an account, with the command-line
+// overrides applied
+type loadConfig func(account string) (*config.Config, error)
+
+// runDaemon runs the scheduled jobs until ctx is cancelled, switching the
+// browser session between accounts as jobs require. It returns the session
+// open at the end
+func runDaemon(ctx context.Context, s *session, jobs []daemonJob, load loadConfig) *session {
+	loc := analytics.Location(s.cfg.Stealth.Timezone)
+	s.watchInbox = true
+	for {
+		job, at := nextJob(jobs, time.Now().In(loc))
+		if at.IsZero() {
+			s.lgr.Error("No daemon job is ever due again; stopping")
+			return s
+		}
+
+		s.lgr.Info("Next job %q at %s", job.name, at.Format("Mon 2006-01-02 15:04 MST"))
+		if !s.idleUntil(ctx, at) {
+			return s
+		}
+
+		if job.maintenance {
+			for _, account := range job.accounts {
+				err := s.withStore(account, load, func(cfg *config.Config, store *storage.Store) error {
+					_, err := maintain(cfg, s.lgr, store)
+					return err
+				})
+				if err != nil {
+					s.lgr.Error("Database maintenance failed: %v", err)
+				}
+			}
+			continue
+		}
+		if job.digest {
+			for _, account := range job.accounts {
+				err := s.withStore(account, load, func(cfg *config.Config, store *storage.Store) error {
+					return sendDigest(cfg, s.lgr, store)
+				})
+				if err != nil {
+					s.lgr.Error("Digest failed: %v", err)
+				}
+			}
+			continue
+		}
+		if job.summary {
+			for _, account := range job.accounts {
+				err := s.withStore(account, load, func(cfg *config.Config, store *storage.Store) error {
+					return sendSummary(cfg, s.lgr, store)
+				})
+				if err != nil {
+					s.lgr.Error("Summary failed: %v", err)
+				}
+			}
+			continue
+		}
+
+		if s.cfg.Stealth.BusinessHoursOnly && !stealth.IsBusinessHours(s.cfg.Stealth.WorkStartHour, s.cfg.Stealth.WorkEndHour) {
+			s.lgr.Info("Job %q is due outside business hours. Waiting...", job.name)
+			for !stealth.IsBusinessHours(s.cfg.Stealth.WorkStartHour, s.cfg.Stealth.WorkEndHour) {
+				if !sleepUntil(ctx, time.Now().Add(time.Minute)) {
+					return s
+				}
+			}
+		}
+
+		for _, account := range job.accounts {
+			if account != s.cfg.Account {
+				next, err := s.switchAccount(account, load)
+				if err != nil {
+					s.lgr.Error("Failed to switch to account %q; skipping job %q for it: %v", account, job.name, err)
+					continue
+				}
+				s = next
+				s.watchInbox = true
+			}
+			s.runJob(ctx, job)
+			if ctx.Err() != nil {
+				return s
+			}
+		}
+	}
+}
+
+// switchAccount opens a session for account and closes s; s stays open if
+// the new session cannot be opened
+func (s *session) switchAccount(account string, load loadConfig) (*session, error) {
+	s.lgr.Info("Switching to account %q", account)
+	cfg, err := load(account)
+	if err != nil {
+		return nil, err
+	}
+	next, err := openSession(s.rc.Context, cfg, s.lgr, s.templates)
+	if err != nil {
+		return nil, err
+	}
+	s.close()
+	return next, nil
+}
+
+// withStore runs fn on an account's config and database, opening the
+// database only for the duration when it is not the current session's
+func (s *session) withStore(account string, load loadConfig, fn func(*config.Config, *storage.Store) error) error {
+	if account == s.cfg.Account {
+		return fn(s.cfg, s.store)
+	}
+	cfg, err := load(account)
+	if err != nil {
+		return err
+	}
+	store, err := storage.New(cfg.Storage.DBPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	return fn(cfg, store)
+}
+
+// runJob runs one job with a report of its own
+func (s *session) runJob(ctx context.Context, job daemonJob) {
+	// Each job is a run of its own, logged under a fresh run ID
+	s.rc = s.rc.NextRun()
+	s.lgr = s.rc.Logger
+	s.lgr.Info("Running job %q", job.name)
+	// A shutdown stops this session's worker after the current action
+	defer context.AfterFunc(ctx, s.worker.Stop)()
+	rep := report.New()
+	rep.Operator = s.cfg.Operator
+
+	detail := "daemon job " + job.name + ": " + strings.Join(job.args, " ")
+	if err := s.store.RecordAudit(s.cfg.Operator, storage.AuditRunStarted, detail); err != nil {
+		s.lgr.Warn("Failed to record run in audit log: %v", err)
+	}
+
+	if err := s.refresh(); err != nil {
+		s.lgr.Error("Session refresh failed; skipping job %q: %v", job.name, err)
+		rep.AddError("session: %v", err)
+		s.finish(rep)
+		return
+	}
+	if err := s.checkSafety(); err != nil {
+		s.lgr.Warn("Skipping job %q: %v", job.name, err)
+		rep.AddError("safety: %v", err)
+		s.finish(rep)
+		return
+	}
+	if err := checkCooldown(s.cfg, s.store, s.lgr); err != nil {
+		s.lgr.Warn("Skipping job %q: %v", job.name, err)
+		rep.AddError("cooldown: %v", err)
+		s.finish(rep)
+		return
+	}
+
+	var deadline time.Time
+	if job.maxRuntime > 0 {
+		deadline = rep.StartedAt.Add(job.maxRuntime)
+	}
+	// -max-runtime bounds the whole daemon
+	if d, ok := ctx.Deadline(); ok && (deadline.IsZero() || d.Before(deadline)) {
+		deadline = d
+	}
+	s.worker.SetDeadline(deadline)
+
+	s.run(job.opts, rep)
+
+	if ctx.Err() != nil {
+		rep.Stopped = "shutdown requested; unfinished actions stay queued"
+	} else if s.worker.Expired() {
+		rep.Stopped = fmt.Sprintf("job maximum runtime %v reached; unfinished actions stay queued", job.maxRuntime)
+	}
+	s.finish(rep)
+}
+
+// refresh makes sure the long-lived session still works before a job: a
+// dead browser is relaunched, and otherwise the live cookies are saved and
+// the login checked, which also renews a cookie close to expiry
+func (s *session) refresh() error {
+	if !s.br.Alive() {
+		s.lgr.Warn("Browser is gone; relaunching with the saved session")
+		s.store.RecordIncident(storage.IncidentBrowserCrash, "")
+		if err := relaunchBrowser(s.br, s.rc); err != nil {
+			return err
+		}
+		s.followPage()
+		return nil
+	}
+
+	authenticator := auth.New(s.page, s.rc)
+	if err := authenticator.SaveSession(); err != nil {
+		s.lgr.Warn("Failed to save session: %v", err)
+	}
+	return authenticator.Login(s.rc)
+}
+
+// idleUntil waits for t like sleepUntil, looking at the inbox meanwhile
+func (s *session) idleUntil(ctx context.Context, t time.Time) bool {
+	every := time.Duration(s.cfg.Daemon.InboxPollMinutes) * time.Minute
+	for every > 0 && time.Until(t) > every {
+		if !sleepUntil(ctx, time.Now().Add(every)) {
+			return false
+		}
+		s.checkInbox()
+	}
+	return sleepUntil(ctx, t)
+}
+
+// checkInbox reads the unread count off the open page at most every
+// daemon.inbox_poll_minutes. When it went up, the unread conversations with
+// prospects are read at once instead of at the next receipt check, their
+// replies recorded (which holds back follow-ups and bumps), and the operator
+// notified
+func (s *session) checkInbox() {
+	every := time.Duration(s.cfg.Daemon.InboxPollMinutes) * time.Minute
+	if !s.watchInbox || every <= 0 || time.Since(s.inboxPolled) < every || !s.br.Alive() {
+		return
+	}
+	s.inboxPolled = time.Now()
+
+	n, ok := message.UnreadCount(s.page)
+	if !ok {
+		return
+	}
+	prev := s.unread
+	s.unread = n
+	if n <= prev {
+		return
+	}
+
+	s.lgr.Info("%d unread conversations (was %d); checking for replies", n, prev)
+	messenger := message.New(s.page, s.rc)
+	messenger.SetLimiter(s.limiter)
+	names, err := messenger.UnreadSenders(s.rc)
+	if err != nil {
+		s.lgr.Warn("Failed to read inbox: %v", err)
+		return
+	}
+	replies, err := messenger.CheckReplies(s.rc, names)
+	if err != nil {
+		s.lgr.Warn("Failed to record replies: %v", err)
+	}
+	// Threads read here are no longer unread
+	if n, ok := message.UnreadCount(s.page); ok {
+		s.unread = n
+	}
+	if len(replies) == 0 {
+		return
+	}
+
+	if url := s.cfg.DigestWebhook(); url != "" {
+		var who []string
+		for _, r := range replies {
+			who = append(who, r.Name)
+		}
+		text := fmt.Sprintf("New replies on LinkedIn (%s): %s", s.cfg.Creds.Email, strings.Join(who, ", "))
+		if err := notify.Webhook(url, text); err != nil {
+			s.lgr.Warn("Failed to send reply notification: %v", err)
+		}
+	}
+}
+
+// sleepUntil waits for t and reports false if ctx was cancelled first
+func sleepUntil(ctx context.Context, t time.Time) bool {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
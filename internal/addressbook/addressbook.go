@@ -0,0 +1,251 @@
+// Package addressbook turns a list of email addresses into LinkedIn
+// prospects without uploading them anywhere: the name and company are read
+// off each address, LinkedIn's people search is asked for them, and the
+// results are scored so the operator can confirm the right person before
+// anyone is contacted
+package addressbook
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"linkedin-automation/internal/search"
+)
+
+// Contact is one address-book entry
+type Contact struct {
+	Email   string
+	Name    string
+	Company string
+}
+
+// Candidate is a search result scored against a contact
+type Candidate struct {
+	Profile search.Profile
+	// Confidence is 0-1: mostly how much of the name matches, the rest
+	// whether the headline names the company
+	Confidence float64
+}
+
+// MinConfidence drops candidates too unlike the contact to be worth reviewing
+const MinConfidence = 0.3
+
+// secondLevel are the labels under a country code that precede the public
+// suffix, as in acme.co.uk
+var secondLevel = map[string]bool{"co": true, "com": true, "org": true, "net": true, "ac": true, "gov": true, "edu": true}
+
+// freeMail domains say nothing about where someone works
+var freeMail = map[string]bool{
+	"gmail": true, "googlemail": true, "outlook": true, "hotmail": true, "live": true, "msn": true,
+	"yahoo": true, "icloud": true, "me": true, "mac": true, "aol": true, "proton": true,
+	"protonmail": true, "gmx": true, "web": true, "mail": true, "yandex": true, "zoho": true,
+}
+
+// Load reads contacts from a CSV with a header row naming an email column,
+// and optionally name and company, or from a plain list of addresses one per
+// line. Blank lines, # comments and lines without an address are skipped;
+// names and companies missing from the file are derived from the address
+func Load(path string) ([]Contact, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open email list: %w", err)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	var lines []string
+	for sc.Scan() {
+		if line := strings.TrimSpace(sc.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read email list: %w", err)
+	}
+
+	r := csv.NewReader(strings.NewReader(strings.Join(lines, "\n")))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read email list: %w", err)
+	}
+
+	col := map[string]int{"email": -1, "name": -1, "company": -1}
+	if len(records) > 0 && !strings.Contains(strings.Join(records[0], ","), "@") {
+		for i, name := range records[0] {
+			if _, ok := col[strings.ToLower(strings.TrimSpace(name))]; ok {
+				col[strings.ToLower(strings.TrimSpace(name))] = i
+			}
+		}
+		if col["email"] < 0 {
+			return nil, fmt.Errorf("email list %s has no email column", path)
+		}
+		records = records[1:]
+	}
+	field := func(rec []string, name string) string {
+		if i := col[name]; i >= 0 && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+
+	seen := make(map[string]bool)
+	var contacts []Contact
+	for _, rec := range records {
+		email := field(rec, "email")
+		if col["email"] < 0 {
+			// Without a header the address may be any column
+			for _, f := range rec {
+				if strings.Contains(f, "@") {
+					email = strings.TrimSpace(f)
+					break
+				}
+			}
+		}
+		email = strings.ToLower(strings.Trim(email, "<>\" "))
+		if !strings.Contains(email, "@") || seen[email] {
+			continue
+		}
+		seen[email] = true
+
+		c := Derive(email)
+		if name := field(rec, "name"); name != "" {
+			c.Name = name
+		}
+		if company := field(rec, "company"); company != "" {
+			c.Company = company
+		}
+		contacts = append(contacts, c)
+	}
+	return contacts, nil
+}
+
+// Derive reads a name and company off an address like jane.doe@acme-corp.com.
+// A local part that is not split into first and last name gives no name, and
+// a free mail domain no company
+func Derive(email string) Contact {
+	c := Contact{Email: email}
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok {
+		return c
+	}
+
+	// Tags after + and digits are not part of the name
+	local, _, _ = strings.Cut(local, "+")
+	parts := strings.FieldsFunc(local, func(r rune) bool {
+		return r == '.' || r == '_' || r == '-' || unicode.IsDigit(r)
+	})
+	if len(parts) >= 2 && len(parts[0]) > 1 && len(parts[len(parts)-1]) > 1 {
+		for i, p := range parts {
+			parts[i] = title(p)
+		}
+		c.Name = strings.Join(parts, " ")
+	}
+
+	labels := strings.Split(domain, ".")
+	if len(labels) >= 2 {
+		// The label before the public suffix, e.g. acme in mail.acme.co.uk
+		org := labels[len(labels)-2]
+		if len(labels) >= 3 && secondLevel[org] {
+			org = labels[len(labels)-3]
+		}
+		if !freeMail[org] {
+			words := strings.FieldsFunc(org, func(r rune) bool { return r == '-' || r == '_' })
+			for i, w := range words {
+				words[i] = title(w)
+			}
+			c.Company = strings.Join(words, " ")
+		}
+	}
+	return c
+}
+
+// Score rates how likely a search result is the contact
+func Score(c Contact, p search.Profile) float64 {
+	want := strings.Fields(normalize(c.Name))
+	if len(want) == 0 {
+		return 0
+	}
+	got := make(map[string]bool)
+	for _, w := range strings.Fields(normalize(p.Name)) {
+		got[w] = true
+	}
+	found := 0
+	for _, w := range want {
+		if got[w] {
+			found++
+		}
+	}
+	name := float64(found) / float64(len(want))
+
+	if c.Company == "" {
+		// Nothing to tell namesakes apart by
+		return 0.8 * name
+	}
+	company := 0.0
+	headline := normalize(p.Title)
+	if org := normalize(search.Company(p.Title)); org != "" && strings.Contains(org, normalize(c.Company)) {
+		company = 1
+	} else if strings.Contains(headline, normalize(c.Company)) {
+		company = 0.7
+	}
+	return 0.7*name + 0.3*company
+}
+
+// Rank scores the results for a contact, best first, dropping those below
+// MinConfidence
+func Rank(c Contact, profiles []search.Profile) []Candidate {
+	var cands []Candidate
+	for _, p := range profiles {
+		if conf := Score(c, p); conf >= MinConfidence {
+			cands = append(cands, Candidate{Profile: p, Confidence: conf})
+		}
+	}
+	sort.SliceStable(cands, func(i, j int) bool { return cands[i].Confidence > cands[j].Confidence })
+	return cands
+}
+
+// normalize lowercases s and drops accents and punctuation, so "José-Luis"
+// matches "jose luis"
+func normalize(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r >= 'à' && r <= 'å':
+			r = 'a'
+		case r == 'ç':
+			r = 'c'
+		case r >= 'è' && r <= 'ë':
+			r = 'e'
+		case r >= 'ì' && r <= 'ï':
+			r = 'i'
+		case r == 'ñ':
+			r = 'n'
+		case r >= 'ò' && r <= 'ö' || r == 'ø':
+			r = 'o'
+		case r >= 'ù' && r <= 'ü':
+			r = 'u'
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+func title(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(strings.ToLower(s))
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
@@ -0,0 +1,182 @@
+package analytics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"linkedin-automation/config"
+	"linkedin-automation/internal/stealth"
+	"linkedin-automation/internal/storage"
+)
+
+// Human-likeness thresholds for the pacing audit
+const (
+	// burstWindow and burstMax: more actions than this in any window is a burst
+	burstWindow = 10 * time.Minute
+	burstMax    = 10
+	// Gaps longer than breakGap are breaks, not pacing, and are left out of
+	// the regularity check, which needs minGaps gaps in a day
+	breakGap = 2 * time.Hour
+	minGaps  = 10
+	// minVariation is the lowest coefficient of variation of the gaps a
+	// person plausibly produces; a steady metronome scores near 0
+	minVariation = 0.25
+)
+
+// Pacing audit checks
+const (
+	CheckDailyLimit    = "daily_limit"
+	CheckWeeklyLimit   = "weekly_limit"
+	CheckBusinessHours = "business_hours"
+	CheckMinGap        = "min_gap"
+	CheckRegularity    = "regularity"
+	CheckBurst         = "burst"
+)
+
+// PacingDay is one local day of recorded actions
+type PacingDay struct {
+	Day     time.Time
+	Actions map[string]int
+	Total   int
+}
+
+// Violation is a day whose actions broke a limit or looked machine-made
+type Violation struct {
+	Day    time.Time
+	Check  string
+	Detail string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s  %-15s %s", v.Day.Format("2006-01-02"), v.Check, v.Detail)
+}
+
+// AuditPacing replays logged actions against the configured limits and
+// working hours, and checks each day's timing for bursts, gaps shorter than
+// the minimum action delay, and gaps too regular to be typed by hand
+func AuditPacing(entries []storage.ActionLogEntry, cfg *config.Config, loc *time.Location) ([]PacingDay, []Violation) {
+	var days []PacingDay
+	byDay := make(map[time.Time][]time.Time)
+	index := make(map[time.Time]int)
+
+	for _, e := range entries {
+		t := e.CreatedAt.In(loc)
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		i, ok := index[day]
+		if !ok {
+			i = len(days)
+			index[day] = i
+			days = append(days, PacingDay{Day: day, Actions: make(map[string]int)})
+		}
+		// Failed attempts were still seen by LinkedIn, so they count for timing
+		byDay[day] = append(byDay[day], t)
+		if e.Outcome == "success" {
+			days[i].Actions[e.Action]++
+			days[i].Total++
+		}
+	}
+	sort.Slice(days, func(a, b int) bool { return days[a].Day.Before(days[b].Day) })
+
+	limits := map[string]int{
+		string(stealth.ActionConnectionReq): cfg.Limits.MaxConnectionsPerDay,
+		string(stealth.ActionMessage):       cfg.Limits.MaxMessagesPerDay,
+		string(stealth.ActionEventInvite):   cfg.Limits.MaxEventInvitesPerDay,
+		string(stealth.ActionFollow):        cfg.Limits.MaxFollowsPerDay,
+	}
+
+	var violations []Violation
+	add := func(day time.Time, check, format string, v ...interface{}) {
+		violations = append(violations, Violation{Day: day, Check: check, Detail: fmt.Sprintf(format, v...)})
+	}
+
+	for i, d := range days {
+		for action, limit := range limits {
+			if n := d.Actions[action]; limit > 0 && n > limit {
+				add(d.Day, CheckDailyLimit, "%d %s (limit %d)", n, action, limit)
+			}
+		}
+
+		// The weekly window is the day and the six before it
+		if limit := cfg.Limits.MaxConnectionsPerWeek; limit > 0 {
+			week := 0
+			for _, prev := range days[:i+1] {
+				if d.Day.Sub(prev.Day) < 7*24*time.Hour {
+					week += prev.Actions[string(stealth.ActionConnectionReq)]
+				}
+			}
+			if week > limit {
+				add(d.Day, CheckWeeklyLimit, "%d connection requests in the 7 days to this one (limit %d)", week, limit)
+			}
+		}
+
+		times := byDay[d.Day]
+		sort.Slice(times, func(a, b int) bool { return times[a].Before(times[b]) })
+		checkTiming(d.Day, times, cfg, add)
+	}
+
+	sort.SliceStable(violations, func(a, b int) bool { return violations[a].Day.Before(violations[b].Day) })
+	return days, violations
+}
+
+func checkTiming(day time.Time, times []time.Time, cfg *config.Config, add func(time.Time, string, string, ...interface{})) {
+	if cfg.Stealth.BusinessHoursOnly {
+		outside := 0
+		for _, t := range times {
+			if t.Hour() < cfg.Stealth.WorkStartHour || t.Hour() >= cfg.Stealth.WorkEndHour {
+				outside++
+			}
+		}
+		if outside > 0 {
+			add(day, CheckBusinessHours, "%d actions outside %02d:00-%02d:00", outside, cfg.Stealth.WorkStartHour, cfg.Stealth.WorkEndHour)
+		}
+	}
+
+	minGap := time.Duration(cfg.Delays.MinActionDelayMs) * time.Millisecond
+	short := 0
+	var gaps []float64
+	for i := 1; i < len(times); i++ {
+		gap := times[i].Sub(times[i-1])
+		if gap < minGap {
+			short++
+		}
+		if gap < breakGap {
+			gaps = append(gaps, gap.Seconds())
+		}
+	}
+	if short > 0 {
+		add(day, CheckMinGap, "%d gaps shorter than the %v minimum action delay", short, minGap)
+	}
+
+	if len(gaps) >= minGaps {
+		mean, sd := meanStdDev(gaps)
+		if mean > 0 && sd/mean < minVariation {
+			add(day, CheckRegularity, "gaps of %.0fs ± %.0fs are too even (variation %.2f, want at least %.2f)",
+				mean, sd, sd/mean, minVariation)
+		}
+	}
+
+	// Largest number of actions in any burst window
+	most, start := 0, 0
+	for end := range times {
+		for times[end].Sub(times[start]) > burstWindow {
+			start++
+		}
+		most = max(most, end-start+1)
+	}
+	if most > burstMax {
+		add(day, CheckBurst, "%d actions within %v (at most %d expected)", most, burstWindow, burstMax)
+	}
+}
+
+func meanStdDev(values []float64) (mean, sd float64) {
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	for _, v := range values {
+		sd += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sd / float64(len(values)))
+}
@@ -0,0 +1,130 @@
+package analytics
+
+import (
+	"sort"
+	"time"
+
+	"linkedin-automation/internal/storage"
+)
+
+// MinSample is the number of sends a bucket needs before its rate is trusted
+const MinSample = 10
+
+type Bucket struct {
+	Sent      int
+	Converted int
+	Clicked   int
+}
+
+func (b Bucket) Rate() float64 {
+	if b.Sent == 0 {
+		return 0
+	}
+	return float64(b.Converted) / float64(b.Sent)
+}
+
+// ClickRate is the share of sends whose tracked link was clicked
+func (b Bucket) ClickRate() float64 {
+	if b.Sent == 0 {
+		return 0
+	}
+	return float64(b.Clicked) / float64(b.Sent)
+}
+
+// SendTimes buckets outreach outcomes by local send hour and weekday
+type SendTimes struct {
+	ByHour    [24]Bucket
+	ByWeekday [7]Bucket
+	Total     Bucket
+
+	loc *time.Location
+}
+
+// Location resolves the timezone send times are bucketed in, defaulting to local time
+func Location(timezone string) *time.Location {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// LoadSendTimes buckets outcomes for action since the given time in loc
+func LoadSendTimes(store *storage.Store, action string, since time.Time, loc *time.Location) (*SendTimes, error) {
+	outcomes, err := store.GetSendOutcomes(action, since)
+	if err != nil {
+		return nil, err
+	}
+
+	st := &SendTimes{loc: loc}
+	for _, o := range outcomes {
+		local := o.SentAt.In(loc)
+		st.add(&st.ByHour[local.Hour()], o)
+		st.add(&st.ByWeekday[local.Weekday()], o)
+		st.add(&st.Total, o)
+	}
+	return st, nil
+}
+
+func (st *SendTimes) add(b *Bucket, o storage.SendOutcome) {
+	b.Sent++
+	if o.Converted {
+		b.Converted++
+	}
+	if o.Clicked {
+		b.Clicked++
+	}
+}
+
+// BestHours returns up to n hours with enough samples, best rate first
+func (st *SendTimes) BestHours(n int) []int {
+	return best(st.ByHour[:], n)
+}
+
+// BestWeekdays returns up to n weekdays with enough samples, best rate first
+func (st *SendTimes) BestWeekdays(n int) []time.Weekday {
+	var days []time.Weekday
+	for _, i := range best(st.ByWeekday[:], n) {
+		days = append(days, time.Weekday(i))
+	}
+	return days
+}
+
+// Weight scores t against the best-performing hour, from 0.25 to 1. Hours
+// without enough history score 1 so they are never penalized for lack of data.
+func (st *SendTimes) Weight(t time.Time) float64 {
+	top := st.BestHours(1)
+	if len(top) == 0 {
+		return 1
+	}
+
+	b := st.ByHour[t.In(st.loc).Hour()]
+	bestRate := st.ByHour[top[0]].Rate()
+	if b.Sent < MinSample || bestRate == 0 {
+		return 1
+	}
+
+	w := b.Rate() / bestRate
+	if w < 0.25 {
+		w = 0.25
+	}
+	return w
+}
+
+func best(buckets []Bucket, n int) []int {
+	var idx []int
+	for i, b := range buckets {
+		if b.Sent >= MinSample {
+			idx = append(idx, i)
+		}
+	}
+
+	sort.SliceStable(idx, func(a, b int) bool {
+		return buckets[idx[a]].Rate() > buckets[idx[b]].Rate()
+	})
+
+	if len(idx) > n {
+		idx = idx[:n]
+	}
+	return idx
+}
@@ -0,0 +1,41 @@
+package apperr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// Error classes shared by auth, search, connect and message, so callers can
+// branch with errors.Is instead of matching strings
+var (
+	// ErrChallenge means LinkedIn showed a CAPTCHA, 2FA, or checkpoint page
+	ErrChallenge = errors.New("security challenge detected")
+	// ErrRateLimited means a local or LinkedIn-enforced budget is exhausted
+	ErrRateLimited = errors.New("rate limited")
+	// ErrNotConnected means the action requires a 1st-degree connection
+	ErrNotConnected = errors.New("not connected")
+	// ErrSelectorMissing means a required page control could not be found
+	ErrSelectorMissing = errors.New("selector missing")
+	// ErrNavigationTimeout means a page did not load in time
+	ErrNavigationTimeout = errors.New("navigation timeout")
+)
+
+// Selector reports a missing control, e.g. Selector("connect button")
+func Selector(what string) error {
+	return fmt.Errorf("%w: %s not found", ErrSelectorMissing, what)
+}
+
+// Navigation wraps a navigation/load failure, classifying timeouts
+func Navigation(url string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return fmt.Errorf("%w: %s: %v", ErrNavigationTimeout, url, err)
+	}
+	return fmt.Errorf("failed to navigate to %s: %w", url, err)
+}
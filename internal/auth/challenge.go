@@ -0,0 +1,215 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+
+	"linkedin-automation/internal/dom"
+	"linkedin-automation/internal/notify"
+	"linkedin-automation/internal/relay"
+	"linkedin-automation/internal/stealth"
+)
+
+// pinSelectors match the verification code input of LinkedIn's 2FA and
+// email/SMS checkpoint pages
+var pinSelectors = []string{
+	"#input__phone_verification_pin",
+	"#input__email_verification_pin",
+	"input[name='pin']",
+}
+
+// codeAttempts is how many codes are tried before giving up; LinkedIn locks
+// the checkpoint after a few wrong ones
+const codeAttempts = 3
+
+// resolveChallenge pauses the login on a security challenge: the operator is
+// notified by webhook and email, then a verification code is asked for on
+// the terminal, the page is relayed, or the visible window is left for
+// solving by hand, whichever challenge settings allow. It gives up when ctx
+// is done
+func (a *Authenticator) resolveChallenge(ctx context.Context) error {
+	c := a.cfg.Challenge
+	if c.Webhook != "" {
+		if err := notify.Webhook(c.Webhook, "LinkedIn security challenge on "+a.cfg.Creds.Email+": "+a.challengeHint()); err != nil {
+			a.logger.Warn("Failed to send challenge notification: %v", err)
+		}
+	}
+	if a.cfg.Email.Alerting() {
+		if err := notify.Email(a.cfg.Email, "LinkedIn security challenge on "+a.cfg.Creds.Email, a.challengeHint()); err != nil {
+			a.logger.Warn("Failed to email challenge alert: %v", err)
+		}
+	}
+
+	// App-based 2FA is answered without anyone present when the secret is known
+	if a.cfg.Creds.TOTPSecret != "" && a.pinField() != nil {
+		err := a.enterTOTP(ctx)
+		if err == nil {
+			return nil
+		}
+		if !c.Prompt && !c.Relay && !c.Manual {
+			return err
+		}
+		a.logger.Warn("Authenticator code failed: %v", err)
+	}
+
+	if c.Prompt && a.pinField() != nil {
+		if isTerminal(os.Stdin) {
+			return a.promptCode(ctx)
+		}
+		a.logger.Warn("A verification code is needed but stdin is not a terminal")
+	}
+	if c.Relay {
+		return relay.Solve(a.page, a.cfg, a.logger, a.isLoggedIn)
+	}
+	if c.Manual {
+		return a.waitManual(ctx)
+	}
+	return errors.New("manual intervention required")
+}
+
+// promptCode reads verification codes from the terminal and enters them
+func (a *Authenticator) promptCode(ctx context.Context) error {
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			lines <- strings.TrimSpace(scanner.Text())
+		}
+		close(lines)
+	}()
+
+	timeout := time.After(a.timeout())
+	for attempt := 1; attempt <= codeAttempts; attempt++ {
+		fmt.Fprint(os.Stderr, "Enter the verification code LinkedIn sent: ")
+		var code string
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return errors.New("no verification code entered")
+			}
+			code = line
+		case <-timeout:
+			fmt.Fprintln(os.Stderr)
+			return errors.New("timed out waiting for the verification code")
+		case <-ctx.Done():
+			fmt.Fprintln(os.Stderr)
+			return ctx.Err()
+		}
+		if code == "" {
+			attempt--
+			continue
+		}
+
+		accepted, err := a.enterCode(ctx, code)
+		if err != nil || accepted {
+			return err
+		}
+		a.logger.Warn("Verification code was not accepted (attempt %d of %d)", attempt, codeAttempts)
+	}
+	return errors.New("verification code rejected")
+}
+
+// enterCode types a verification code into the challenge form and submits
+// it; it reports false if the form is shown again, i.e. the code was wrong
+func (a *Authenticator) enterCode(ctx context.Context, code string) (bool, error) {
+	page := a.page.Context(ctx)
+	field := a.pinField()
+	if field == nil {
+		return false, errors.New("the verification code field is gone")
+	}
+	// Clear a rejected code before typing the next
+	if err := field.SelectAllText(); err == nil {
+		_ = field.Input("")
+	}
+	if err := stealth.HumanClick(page, field); err != nil {
+		return false, err
+	}
+	if err := a.typist.Type(page, field, code); err != nil {
+		return false, err
+	}
+	if err := stealth.RandomDelayContext(ctx, 500, 1200); err != nil {
+		return false, err
+	}
+
+	submit, err := dom.WaitVisibleEnabled(a.page, "#two-step-submit-button, form button[type='submit']", dom.ControlWait)
+	if err != nil {
+		return false, err
+	}
+	if err := stealth.HumanClick(page, submit); err != nil {
+		return false, err
+	}
+
+	if err := stealth.Wait(ctx, 5*time.Second); err != nil {
+		return false, err
+	}
+	if a.isLoggedIn() {
+		a.logger.Info("Verification code accepted")
+		return true, nil
+	}
+	if a.pinField() == nil {
+		return false, fmt.Errorf("unexpected page after the verification code: %s", a.page.MustInfo().URL)
+	}
+	return false, nil
+}
+
+// waitManual leaves the visible window to the operator until the login
+// completes or the timeout passes
+func (a *Authenticator) waitManual(ctx context.Context) error {
+	if a.cfg.Browser.Headless {
+		return errors.New("challenge.manual needs a visible window; run once with HEADLESS=false (or browser.display) to solve it")
+	}
+
+	a.logger.Warn("Solve the challenge in the browser window; waiting up to %v", a.timeout())
+	deadline := time.Now().Add(a.timeout())
+	for time.Now().Before(deadline) {
+		if err := stealth.Wait(ctx, 2*time.Second); err != nil {
+			return err
+		}
+		if a.isLoggedIn() {
+			a.logger.Info("Challenge solved in the browser window")
+			return nil
+		}
+	}
+	return errors.New("timed out waiting for the challenge to be solved")
+}
+
+// challengeHint says what the operator is expected to do
+func (a *Authenticator) challengeHint() string {
+	c := a.cfg.Challenge
+	switch {
+	case a.cfg.Creds.TOTPSecret != "" && a.pinField() != nil:
+		return "an authenticator code is being entered automatically; no action needed unless it fails"
+	case c.Prompt && a.pinField() != nil:
+		return "enter the verification code on the terminal"
+	case c.Relay:
+		return "solve it through the challenge relay (see the log for the URL)"
+	case c.Manual:
+		return "solve it in the browser window"
+	}
+	return "the run stopped; log in by hand once"
+}
+
+func (a *Authenticator) pinField() *rod.Element {
+	for _, s := range pinSelectors {
+		if has, el, _ := a.page.Has(s); has {
+			return el
+		}
+	}
+	return nil
+}
+
+func (a *Authenticator) timeout() time.Duration {
+	return time.Duration(a.cfg.Challenge.TimeoutMinutes) * time.Minute
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -8,7 +9,10 @@ import (
 	"time"
 
 	"linkedin-automation/config"
+	"linkedin-automation/internal/apperr"
+	"linkedin-automation/internal/dom"
 	"linkedin-automation/internal/logger"
+	"linkedin-automation/internal/runctx"
 	"linkedin-automation/internal/stealth"
 
 	"github.com/go-rod/rod"
@@ -17,38 +21,54 @@ import (
 
 type Authenticator struct {
 	page   *rod.Page
+	run    *runctx.Context
 	cfg    *config.Config
 	logger *logger.Logger
+	typist stealth.TypingProfile
 }
 
-func New(page *rod.Page, cfg *config.Config, log *logger.Logger) *Authenticator {
+func New(page *rod.Page, rc *runctx.Context) *Authenticator {
+	cfg := rc.Cfg
 	return &Authenticator{
 		page:   page,
+		run:    rc,
 		cfg:    cfg,
-		logger: log,
+		logger: rc.Logger,
+		typist: stealth.TypingProfileFor(cfg.Stealth.TypingProfile, cfg.Creds.Email,
+			cfg.Delays.MinTypingDelayMs, cfg.Delays.MaxTypingDelayMs, cfg.Stealth.TypoProbability).WithoutTypos(),
 	}
 }
 
-func (a *Authenticator) Login() error {
+// Login restores the saved session, or logs in with the configured
+// credentials when it has expired. Waits end early when ctx is done
+func (a *Authenticator) Login(ctx context.Context) error {
 	a.logger.Info("Starting login process")
+	page := a.page.Context(ctx)
 
 	// Attempt session restore
 	if err := a.loadSession(); err == nil {
 		a.logger.Info("Loaded saved session")
 		if a.isLoggedIn() {
 			a.logger.Info("Session is still valid")
-			return nil
+			if !a.checkExpiry() {
+				a.warmUp(ctx)
+				return nil
+			}
+			// A fresh login issues a new li_at; drop the old one first
+			if err := a.page.Browser().SetCookies(nil); err != nil {
+				a.logger.Warn("Failed to clear cookies: %v", err)
+			}
 		}
 	}
 
 	// Navigate to LinkedIn login page
 	a.logger.Info("Navigating to login page")
-	if err := a.page.Navigate(a.cfg.LinkedIn.LoginURL); err != nil {
-		return fmt.Errorf("failed to navigate to login: %w", err)
+	if err := page.Navigate(a.cfg.LinkedIn.LoginURL); err != nil {
+		return apperr.Navigation(a.cfg.LinkedIn.LoginURL, err)
 	}
 
-	if err := a.page.WaitLoad(); err != nil {
-		return err
+	if err := page.WaitLoad(); err != nil {
+		return apperr.Navigation(a.cfg.LinkedIn.LoginURL, err)
 	}
 
 	// 🔥 FORCE DESKTOP VIEWPORT (Rod New API)
@@ -64,80 +84,83 @@ func (a *Authenticator) Login() error {
 	// 🔥 Reset zoom (Windows Chromium fix)
 	_, _ = a.page.Eval(`document.body.style.zoom = "100%"`)
 
-	stealth.RandomDelay(1000, 2000)
+	if err := stealth.RandomDelayContext(ctx, 1000, 2000); err != nil {
+		return err
+	}
 
 	// Locate email field
-	emailField, err := a.page.Element("#username")
+	emailField, err := dom.WaitVisibleEnabled(a.page, "#username", dom.ControlWait)
 	if err != nil {
-		return fmt.Errorf("failed to find email field: %w", err)
+		return apperr.Selector("email field")
 	}
 
 	a.logger.Debug("Clicking email field")
-	if err := stealth.HumanClick(a.page, emailField); err != nil {
+	if err := stealth.HumanClick(page, emailField); err != nil {
 		return err
 	}
 
-	stealth.SimulateThinking()
+	if err := stealth.SimulateThinking(ctx); err != nil {
+		return err
+	}
 
 	a.logger.Debug("Typing email")
-	if err := stealth.HumanType(
-		a.page,
-		emailField,
-		a.cfg.Creds.Email,
-		a.cfg.Delays.MinTypingDelayMs,
-		a.cfg.Delays.MaxTypingDelayMs,
-		0,
-	); err != nil {
+	if err := a.typist.Type(page, emailField, a.cfg.Creds.Email); err != nil {
 		return err
 	}
 
-	stealth.RandomDelay(500, 1000)
+	if err := stealth.RandomDelayContext(ctx, 500, 1000); err != nil {
+		return err
+	}
 
 	// Locate password field
-	passwordField, err := a.page.Element("#password")
+	passwordField, err := dom.WaitVisibleEnabled(a.page, "#password", dom.ControlWait)
 	if err != nil {
-		return fmt.Errorf("failed to find password field: %w", err)
+		return apperr.Selector("password field")
 	}
 
 	a.logger.Debug("Clicking password field")
-	if err := stealth.HumanClick(a.page, passwordField); err != nil {
+	if err := stealth.HumanClick(page, passwordField); err != nil {
 		return err
 	}
 
-	stealth.SimulateThinking()
+	if err := stealth.SimulateThinking(ctx); err != nil {
+		return err
+	}
 
 	a.logger.Debug("Typing password")
-	if err := stealth.HumanType(
-		a.page,
-		passwordField,
-		a.cfg.Creds.Password,
-		a.cfg.Delays.MinTypingDelayMs,
-		a.cfg.Delays.MaxTypingDelayMs,
-		0,
-	); err != nil {
+	if err := a.typist.Type(page, passwordField, a.cfg.Creds.Password); err != nil {
 		return err
 	}
 
-	stealth.RandomDelay(1000, 2000)
+	if err := stealth.RandomDelayContext(ctx, 1000, 2000); err != nil {
+		return err
+	}
 
 	// Submit login form
-	loginButton, err := a.page.Element("button[type='submit']")
+	loginButton, err := dom.WaitVisibleEnabled(a.page, "button[type='submit']", dom.ControlWait)
 	if err != nil {
-		return fmt.Errorf("failed to find login button: %w", err)
+		return apperr.Selector("login button")
 	}
 
 	a.logger.Debug("Clicking login button")
-	if err := stealth.HumanClick(a.page, loginButton); err != nil {
+	if err := stealth.HumanClick(page, loginButton); err != nil {
 		return err
 	}
 
 	a.logger.Info("Waiting for login to complete")
-	time.Sleep(5 * time.Second)
+	if err := stealth.Wait(ctx, 5*time.Second); err != nil {
+		return err
+	}
 
 	// Detect CAPTCHA / 2FA
 	if a.hasSecurityChallenge() {
 		a.logger.Warn("Security challenge detected")
-		return errors.New("security challenge detected – manual intervention required")
+		if a.run.OnChallenge != nil {
+			a.run.OnChallenge("login")
+		}
+		if err := a.resolveChallenge(ctx); err != nil {
+			return fmt.Errorf("%w – %v", apperr.ErrChallenge, err)
+		}
 	}
 
 	if !a.isLoggedIn() {
@@ -147,7 +170,7 @@ func (a *Authenticator) Login() error {
 
 	a.logger.Info("Login successful")
 
-	if err := a.saveSession(); err != nil {
+	if err := a.SaveSession(); err != nil {
 		a.logger.Warn("Failed to save session: %v", err)
 	}
 
@@ -164,20 +187,23 @@ func (a *Authenticator) isLoggedIn() bool {
 func (a *Authenticator) hasSecurityChallenge() bool {
 	selectors := []string{
 		"#input__phone_verification_pin",
+		"#input__email_verification_pin",
 		"#captcha",
 		".challenge-dialog",
 		"input[name='pin']",
 	}
 
 	for _, s := range selectors {
-		if _, err := a.page.Element(s); err == nil {
+		if has, _, _ := a.page.Has(s); has {
 			return true
 		}
 	}
 	return false
 }
 
-func (a *Authenticator) saveSession() error {
+// SaveSession persists the current cookies so a later run, or a relaunched
+// browser, can restore them
+func (a *Authenticator) SaveSession() error {
 	cookies := a.page.MustCookies()
 	var stored []*proto.NetworkCookieParam
 
@@ -203,6 +229,19 @@ func (a *Authenticator) saveSession() error {
 	return os.WriteFile(a.cfg.Storage.SessionCookiePath, data, 0600)
 }
 
+// warmUp eases into a restored session instead of jumping straight to outreach
+func (a *Authenticator) warmUp(ctx context.Context) {
+	if !a.cfg.Stealth.WarmUpSession {
+		return
+	}
+
+	a.logger.Info("Warming up session")
+	if err := stealth.WarmUp(a.page.Context(ctx), a.cfg.LinkedIn.BaseURL); err != nil {
+		// Not fatal: the run can proceed without the warm-up
+		a.logger.Warn("Session warm-up failed: %v", err)
+	}
+}
+
 func (a *Authenticator) loadSession() error {
 	data, err := os.ReadFile(a.cfg.Storage.SessionCookiePath)
 	if err != nil {
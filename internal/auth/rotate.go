@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"linkedin-automation/internal/apperr"
+)
+
+// Rotate logs in afresh with the configured credentials after a password
+// change. The saved session is set aside rather than deleted and comes back
+// when the new login fails, so a mistyped password loses nothing. The Chrome
+// profile, and with it the fingerprint, stays the one every run uses
+func (a *Authenticator) Rotate(ctx context.Context) error {
+	path := a.cfg.Storage.SessionCookiePath
+	backup := path + ".pre-rotate"
+	if err := os.Rename(path, backup); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to set aside saved session: %w", err)
+	}
+	restore := func() {
+		if _, err := os.Stat(backup); err == nil {
+			if err := os.Rename(backup, path); err != nil {
+				a.logger.Warn("Failed to restore saved session from %s: %v", backup, err)
+			}
+		}
+	}
+
+	// A password change ends the old sessions; the profile's cookies would
+	// only send LinkedIn past the login form
+	if err := a.page.Browser().SetCookies(nil); err != nil {
+		restore()
+		return fmt.Errorf("failed to clear cookies: %w", err)
+	}
+
+	if err := a.Login(ctx); err != nil {
+		restore()
+		return err
+	}
+	if err := a.Verify(ctx); err != nil {
+		restore()
+		return err
+	}
+
+	if err := os.Remove(backup); err != nil && !os.IsNotExist(err) {
+		a.logger.Warn("Failed to remove old session %s: %v", backup, err)
+	}
+	return nil
+}
+
+// Verify checks that the saved session holds a valid auth cookie and that
+// LinkedIn still shows the account signed in on a fresh load
+func (a *Authenticator) Verify(ctx context.Context) error {
+	st, err := ReadSession(a.cfg.Storage.SessionCookiePath)
+	if err != nil {
+		return err
+	}
+	if !st.HasAuth(time.Now()) {
+		return errors.New("saved session has no valid li_at cookie")
+	}
+
+	feed := a.cfg.LinkedIn.BaseURL + "/feed/"
+	if err := a.page.Context(ctx).Navigate(feed); err != nil {
+		return apperr.Navigation(feed, err)
+	}
+	if err := a.page.WaitLoad(); err != nil {
+		return apperr.Navigation(feed, err)
+	}
+	if !a.isLoggedIn() {
+		return errors.New("LinkedIn did not keep the session signed in")
+	}
+	return nil
+}
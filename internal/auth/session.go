@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// sessionCookies are the cookies a LinkedIn session depends on; li_at is the
+// authentication token itself
+var sessionCookies = []string{"li_at", "JSESSIONID", "liap", "bcookie", "bscookie", "li_rm"}
+
+// CookieInfo is the expiry of one saved cookie; a zero Expires means the
+// cookie only lasts for the browser session
+type CookieInfo struct {
+	Name    string
+	Expires time.Time
+}
+
+// SessionStatus describes the saved session file without opening a browser
+type SessionStatus struct {
+	Path    string
+	SavedAt time.Time
+	Cookies []CookieInfo
+	// AuthExpires is when li_at expires; zero when it is missing
+	AuthExpires time.Time
+}
+
+// ReadSession inspects a saved session file
+func ReadSession(path string) (*SessionStatus, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("no saved session: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+
+	var cookies []*proto.NetworkCookieParam
+	if err := json.Unmarshal(data, &cookies); err != nil {
+		return nil, fmt.Errorf("failed to parse session: %w", err)
+	}
+
+	st := &SessionStatus{Path: path, SavedAt: info.ModTime()}
+	for _, name := range sessionCookies {
+		for _, c := range cookies {
+			if c.Name != name {
+				continue
+			}
+			ci := CookieInfo{Name: name}
+			if c.Expires > 0 {
+				ci.Expires = c.Expires.Time()
+			}
+			st.Cookies = append(st.Cookies, ci)
+			if name == "li_at" {
+				st.AuthExpires = ci.Expires
+			}
+			break
+		}
+	}
+	sort.SliceStable(st.Cookies, func(i, j int) bool { return st.Cookies[i].Name == "li_at" && st.Cookies[j].Name != "li_at" })
+	return st, nil
+}
+
+// HasAuth reports whether the session holds an unexpired li_at cookie
+func (s *SessionStatus) HasAuth(now time.Time) bool {
+	for _, c := range s.Cookies {
+		if c.Name == "li_at" {
+			return c.Expires.IsZero() || c.Expires.After(now)
+		}
+	}
+	return false
+}
+
+// ExpiresWithin reports whether li_at expires before now+d
+func (s *SessionStatus) ExpiresWithin(now time.Time, d time.Duration) bool {
+	return !s.AuthExpires.IsZero() && s.AuthExpires.Before(now.Add(d))
+}
+
+// checkExpiry runs after a saved session was restored: it saves the cookies
+// LinkedIn rotated on this visit, then forces a fresh login when li_at is
+// still close to expiry, so long unattended runs do not lose the session
+// mid-way
+func (a *Authenticator) checkExpiry() (refresh bool) {
+	if err := a.SaveSession(); err != nil {
+		a.logger.Warn("Failed to save refreshed session: %v", err)
+		return false
+	}
+
+	days := a.cfg.Storage.SessionRefreshDays
+	st, err := ReadSession(a.cfg.Storage.SessionCookiePath)
+	if err != nil || days <= 0 || st.AuthExpires.IsZero() {
+		return false
+	}
+
+	left := time.Until(st.AuthExpires)
+	if left > time.Duration(days)*24*time.Hour {
+		a.logger.Debug("Session cookie valid for %d more days", int(left.Hours()/24))
+		return false
+	}
+
+	a.logger.Warn("Session cookie expires %s; logging in again to refresh it", st.AuthExpires.Local().Format(time.RFC1123))
+	return true
+}
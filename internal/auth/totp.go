@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"linkedin-automation/internal/stealth"
+)
+
+// TOTP parameters LinkedIn's authenticator-app 2FA uses (RFC 6238 defaults)
+const (
+	totpPeriod = 30 * time.Second
+	totpDigits = 6
+)
+
+// totpCode returns the RFC 6238 code for a base32 secret at t
+func totpCode(secret string, t time.Time) (string, error) {
+	// Authenticator apps show the secret in groups, lower case and unpadded
+	clean := strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(secret))
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(clean, "="))
+	if err != nil {
+		return "", errors.New("invalid TOTP secret: want the base32 key shown when 2FA was set up")
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(t.Unix()/int64(totpPeriod/time.Second)))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000), nil
+}
+
+// enterTOTP answers an authenticator-app challenge with a generated code,
+// retrying once in the next period in case the clocks disagree
+func (a *Authenticator) enterTOTP(ctx context.Context) error {
+	for attempt := 1; attempt <= 2; attempt++ {
+		// A code about to expire may be stale by the time it is submitted
+		now := time.Now()
+		if left := totpPeriod - now.Sub(now.Truncate(totpPeriod)); left < 8*time.Second {
+			if err := stealth.Wait(ctx, left+time.Second); err != nil {
+				return err
+			}
+		}
+
+		code, err := totpCode(a.cfg.Creds.TOTPSecret, time.Now())
+		if err != nil {
+			return err
+		}
+		a.logger.Info("Entering authenticator code")
+		accepted, err := a.enterCode(ctx, code)
+		if err != nil || accepted {
+			return err
+		}
+		a.logger.Warn("Authenticator code was not accepted (attempt %d of 2)", attempt)
+		if err := stealth.Wait(ctx, totpPeriod-time.Since(time.Now().Truncate(totpPeriod))); err != nil {
+			return err
+		}
+	}
+	return errors.New("authenticator code rejected; check LINKEDIN_TOTP_SECRET and the system clock")
+}
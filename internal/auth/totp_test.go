@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// The SHA-1 vectors of RFC 6238 appendix B, cut to the six digits LinkedIn
+// asks for; the key is the ASCII "12345678901234567890"
+func TestTOTPCode(t *testing.T) {
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	vectors := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, v := range vectors {
+		got, err := totpCode(secret, time.Unix(v.unix, 0))
+		if err != nil {
+			t.Fatalf("totpCode at %d: %v", v.unix, err)
+		}
+		if got != v.want {
+			t.Errorf("totpCode at %d = %s, want %s", v.unix, got, v.want)
+		}
+	}
+}
+
+func TestTOTPCodeSecretFormats(t *testing.T) {
+	at := time.Unix(59, 0)
+	// As authenticator apps show it: grouped, lower case, unpadded
+	got, err := totpCode(strings.ToLower("GEZD GNBV GY3T QOJQ GEZD GNBV GY3T QOJQ"), at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "287082" {
+		t.Errorf("grouped lower-case secret gave %s, want 287082", got)
+	}
+
+	if _, err := totpCode("not base32!", at); err == nil {
+		t.Error("an invalid secret was accepted")
+	}
+}
@@ -0,0 +1,98 @@
+// Package bench runs the search-and-connect pipeline against a local
+// simulated site with the account's real pacing settings, and reports where
+// the time goes
+package bench
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-rod/rod"
+
+	"linkedin-automation/config"
+	"linkedin-automation/internal/connect"
+	"linkedin-automation/internal/logger"
+	"linkedin-automation/internal/queue"
+	"linkedin-automation/internal/runctx"
+	"linkedin-automation/internal/search"
+	"linkedin-automation/internal/stealth"
+	"linkedin-automation/internal/storage"
+	"linkedin-automation/internal/timing"
+)
+
+type Options struct {
+	Profiles int
+	// Latency is added to every simulated page load
+	Latency time.Duration
+	// MaxRuntime stops the queue worker; pacing alone can take hours
+	MaxRuntime time.Duration
+	Note       string
+}
+
+// Result is the timing breakdown of one bench run
+type Result struct {
+	Found  int
+	Sent   int
+	Wall   time.Duration
+	Stages []timing.Stat
+}
+
+// Run searches the simulated site and sends every connection request through
+// the queue worker, using a throwaway database so real history is untouched
+func Run(page *rod.Page, cfg *config.Config, log *logger.Logger, opts Options) (*Result, error) {
+	site := NewSite(opts.Profiles, opts.Latency)
+	defer site.Close()
+
+	dir, err := os.MkdirTemp("", "linkedin-bench-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bench directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := storage.New(filepath.Join(dir, "bench.db"))
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	// Same pacing settings, pointed at the simulated site with no caps in the way
+	sim := *cfg
+	sim.LinkedIn.BaseURL = site.URL()
+	sim.LinkedIn.SearchURL = site.URL() + "/search/results/people/"
+	sim.Limits.MaxConnectionsPerDay = opts.Profiles
+	sim.Throttle.Enabled = false
+	sim.Compliance.Profile = ""
+
+	scheduler, err := stealth.NewActivityScheduler(sim.Stealth.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize scheduler: %w", err)
+	}
+	scheduler.SetThinkTimeFactor(sim.Stealth.ThinkTimeFactor)
+
+	timing.Reset()
+	res := &Result{}
+
+	rc := runctx.New(&sim, log, store)
+	profiles, err := search.New(page, rc).SearchPeople(rc, "engineer", "", "", opts.Profiles)
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+	res.Found = len(profiles)
+
+	connector := connect.New(page, rc)
+	if _, err := connector.EnqueueProfiles(profiles, opts.Note, "bench"); err != nil {
+		return nil, err
+	}
+
+	worker := queue.NewWorker(store, stealth.NewRateLimiter(), scheduler, log)
+	worker.SetDeadline(time.Now().Add(opts.MaxRuntime))
+	worker.Handle(storage.QueueActionConnect, stealth.ActionConnectionReq, connector.ProcessQueueItem)
+	if res.Sent, err = worker.Run(storage.QueueActionConnect); err != nil {
+		return nil, err
+	}
+
+	res.Stages, res.Wall = timing.Snapshot()
+	return res, nil
+}
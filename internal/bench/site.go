@@ -0,0 +1,92 @@
+package bench
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+)
+
+// Site is a local stand-in for the parts of LinkedIn the connect pipeline
+// touches: a people search page and profiles whose Connect flow opens the
+// invitation dialog, takes a note and confirms with a toast
+type Site struct {
+	server   *httptest.Server
+	profiles int
+	latency  time.Duration
+}
+
+// NewSite serves a search with the given number of results; every response
+// is delayed by latency to approximate real page loads
+func NewSite(profiles int, latency time.Duration) *Site {
+	s := &Site{profiles: profiles, latency: latency}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search/results/people/", s.search)
+	mux.HandleFunc("/in/", s.profile)
+	s.server = httptest.NewServer(mux)
+	return s
+}
+
+// URL is the site's base address
+func (s *Site) URL() string {
+	return s.server.URL
+}
+
+func (s *Site) Close() {
+	s.server.Close()
+}
+
+func (s *Site) search(w http.ResponseWriter, r *http.Request) {
+	time.Sleep(s.latency)
+
+	var cards strings.Builder
+	for i := 1; i <= s.profiles; i++ {
+		url := fmt.Sprintf("%s/in/sim-%d/", s.server.URL, i)
+		fmt.Fprintf(&cards, `
+<li class="reusable-search__result-container">
+  <a class="app-aware-link" href="%[1]s"><img alt=""></a>
+  <span class="entity-result__title-text"><a class="app-aware-link" href="%[1]s"><span aria-hidden="true">Sim Person %[2]d</span></a></span>
+  <div class="entity-result__primary-subtitle">Engineer %[2]d at Example</div>
+  <div class="entity-result__secondary-subtitle">Remote</div>
+</li>`, url, i)
+	}
+
+	fmt.Fprintf(w, `<!DOCTYPE html><html><body><main>
+<ul>%s</ul>
+<button aria-label="Next" disabled>Next</button>
+</main></body></html>`, cards.String())
+}
+
+func (s *Site) profile(w http.ResponseWriter, r *http.Request) {
+	time.Sleep(s.latency)
+
+	name := html.EscapeString(strings.Trim(strings.TrimPrefix(r.URL.Path, "/in/"), "/"))
+	fmt.Fprintf(w, profilePage, name)
+}
+
+const profilePage = `<!DOCTYPE html><html><body style="min-height:3000px">
+<main>
+  <h1>%[1]s</h1>
+  <div class="pvs-profile-actions"><button aria-label="Invite %[1]s to connect" id="connect">Connect</button></div>
+</main>
+<div id="modal" role="dialog" style="display:none;position:fixed;top:20%%;left:30%%;background:#fff;padding:20px">
+  <h2>You can customize this invitation</h2>
+  <button aria-label="Add a note" id="add-note">Add a note</button>
+  <textarea id="custom-message" style="display:none"></textarea>
+  <button aria-label="Send now" id="send">Send</button>
+</div>
+<script>
+  const $ = (id) => document.getElementById(id);
+  $('connect').onclick = () => { $('modal').style.display = 'block'; };
+  $('add-note').onclick = () => { $('custom-message').style.display = 'block'; };
+  $('send').onclick = () => {
+    $('modal').remove();
+    const toast = document.createElement('div');
+    toast.className = 'artdeco-toast-item';
+    toast.textContent = 'Your invitation was sent.';
+    document.body.appendChild(toast);
+  };
+</script>
+</body></html>`
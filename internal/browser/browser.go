@@ -3,8 +3,12 @@ package browser
 import (
 	"fmt"
 	"linkedin-automation/config"
+	"linkedin-automation/internal/container"
 	"linkedin-automation/internal/logger"
+	"net/url"
 	"os"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-rod/rod"
 	"github.com/go-rod/rod/lib/launcher"
@@ -12,37 +16,171 @@ import (
 )
 
 type Browser struct {
-	page   *rod.Page
-	cfg    *config.Config
-	logger *logger.Logger
+	browser *rod.Browser
+	page    *rod.Page
+	cfg     *config.Config
+	logger  *logger.Logger
+
+	// Usage since launch, for recycling
+	launchedAt  time.Time
+	navigations atomic.Int64
+	lastMemLog  time.Time
+	// crashed is set when the page's renderer dies
+	crashed atomic.Bool
 }
 
 func New(cfg *config.Config, log *logger.Logger) (*Browser, error) {
-	// Launch browser
-	u := launcher.New().
-		Headless(cfg.Browser.Headless).
-		MustLaunch()
+	b := &Browser{cfg: cfg, logger: log}
+	if err := b.launch(); err != nil {
+		return nil, err
+	}
+
+	log.Info("Browser initialized successfully")
+	return b, nil
+}
+
+// launch starts (or connects to) Chrome and prepares a page
+func (b *Browser) launch() error {
+	u, err := controlURL(b.cfg, b.logger)
+	if err != nil {
+		return err
+	}
 
-	browser := rod.New().ControlURL(u).MustConnect()
+	browser := rod.New().ControlURL(u)
+	if err := browser.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to browser: %w", err)
+	}
+
+	// Chrome asks once per session for the proxy's credentials
+	if p, _ := parseProxy(b.cfg.Browser.Proxy); p != nil && p.username != "" && b.cfg.Browser.Driver != "remote" {
+		wait := browser.HandleAuth(p.username, p.password)
+		go func() {
+			if err := wait(); err != nil {
+				b.logger.Warn("Proxy authentication failed: %v", err)
+			}
+		}()
+	}
 
 	// Create page
-	page := browser.MustPage("")
+	page, err := browser.Page(proto.TargetCreateTarget{})
+	if err != nil {
+		return fmt.Errorf("failed to open page: %w", err)
+	}
 
 	// Set viewport
-	page.MustSetViewport(cfg.Browser.Width, cfg.Browser.Height, 1, false)
+	if err := page.SetViewport(&proto.EmulationSetDeviceMetricsOverride{
+		Width:             b.cfg.Browser.Width,
+		Height:            b.cfg.Browser.Height,
+		DeviceScaleFactor: 1,
+	}); err != nil {
+		return fmt.Errorf("failed to set viewport: %w", err)
+	}
 
 	// Apply stealth techniques
-	if err := applyStealth(page, cfg); err != nil {
-		return nil, fmt.Errorf("failed to apply stealth: %w", err)
+	if err := applyStealth(page, b.cfg); err != nil {
+		return fmt.Errorf("failed to apply stealth: %w", err)
 	}
 
-	log.Info("Browser initialized successfully")
+	b.browser, b.page = browser, page
+	b.launchedAt = time.Now()
+	b.navigations.Store(0)
+	b.crashed.Store(false)
+	b.watch(page)
+	return nil
+}
+
+// controlURL launches a local Chrome or selects a remote CDP endpoint
+func controlURL(cfg *config.Config, log *logger.Logger) (string, error) {
+	if cfg.Browser.Driver == "remote" {
+		if cfg.Browser.Proxy != "" {
+			log.Warn("browser.proxy only applies to a local browser; configure the proxy on the remote endpoints")
+		}
+		log.Debug("browser.locale and browser.spellcheck only reach a remote browser's pages, not its UI")
+		return remoteControlURL(cfg.Browser.RemoteEndpoints, cfg.Creds.Email, log)
+	}
+
+	l := launcher.New().
+		Headless(cfg.Browser.Headless)
+
+	if cfg.Browser.Bin != "" {
+		l = l.Bin(cfg.Browser.Bin)
+	}
+
+	proxy, err := parseProxy(cfg.Browser.Proxy)
+	if err != nil {
+		return "", err
+	}
+	if proxy != nil {
+		log.Info("Routing browser traffic through proxy %s", proxy.server)
+		l = l.Proxy(proxy.server)
+	}
+
+	// A persistent profile keeps local storage and cache across launches
+	if cfg.Browser.ProfileDir != "" {
+		l = l.UserDataDir(cfg.Browser.ProfileDir)
+	}
+
+	if cfg.Browser.InContainer() {
+		log.Info("Container runtime detected, applying Chrome container flags")
+		// Containers usually run as root without user namespaces
+		l = l.NoSandbox(true)
+		if container.SmallShm() {
+			log.Warn("/dev/shm is small, falling back to /tmp for Chrome shared memory (or run with --shm-size=1g)")
+			l = l.Set("disable-dev-shm-usage")
+		}
+	}
+
+	env := os.Environ()
+	l, env, err = localize(l, cfg, env)
+	if err != nil {
+		return "", err
+	}
+
+	// Render headful into an external X display so challenges can be solved over VNC
+	if cfg.Browser.Display != "" {
+		log.Info("Rendering browser on display %s for VNC debugging", cfg.Browser.Display)
+		l = l.Headless(false)
+		env = append(env, "DISPLAY="+cfg.Browser.Display)
+	}
+	l = l.Env(env...)
+
+	u, err := l.Launch()
+	if err != nil {
+		return "", fmt.Errorf("failed to launch browser: %w", err)
+	}
+	return u, nil
+}
+
+// proxy is browser.proxy split into Chrome's --proxy-server value and the
+// credentials Chrome is given when the proxy asks
+type proxy struct {
+	server   string
+	username string
+	password string
+}
 
-	return &Browser{
-		page:   page,
-		cfg:    cfg,
-		logger: log,
-	}, nil
+// parseProxy checks browser.proxy; an empty setting is no proxy
+func parseProxy(raw string) (*proxy, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid browser.proxy %q: want scheme://[user:pass@]host:port", raw)
+	}
+
+	p := &proxy{server: u.Scheme + "://" + u.Host, username: u.User.Username()}
+	p.password, _ = u.User.Password()
+	switch u.Scheme {
+	case "http", "https":
+	case "socks5", "socks4":
+		if p.username != "" {
+			return nil, fmt.Errorf("invalid browser.proxy: Chrome does not support credentials for %s proxies", u.Scheme)
+		}
+	default:
+		return nil, fmt.Errorf("invalid browser.proxy scheme %q (want http, https, socks5 or socks4)", u.Scheme)
+	}
+	return p, nil
 }
 
 func applyStealth(page *rod.Page, cfg *config.Config) error {
@@ -53,10 +191,15 @@ func applyStealth(page *rod.Page, cfg *config.Config) error {
 		});
 	}`)
 
-	// Set user agent
-	page.MustSetUserAgent(&proto.NetworkSetUserAgentOverride{
-		UserAgent: cfg.Browser.UserAgent,
-	})
+	// Set user agent, with client hints and Accept-Language to match
+	if err := page.SetUserAgent(identity(cfg.Browser.UserAgent, cfg.Browser.Languages)); err != nil {
+		return fmt.Errorf("failed to set user agent: %w", err)
+	}
+
+	// Intl formats dates and numbers the way the UI language would
+	if err := (proto.EmulationSetLocaleOverride{Locale: locale(cfg)}).Call(page); err != nil {
+		return fmt.Errorf("failed to set locale %s: %w", locale(cfg), err)
+	}
 
 	// Override plugins
 	page.MustEval(`() => {
@@ -66,11 +209,11 @@ func applyStealth(page *rod.Page, cfg *config.Config) error {
 	}`)
 
 	// Override languages
-	page.MustEval(`() => {
+	page.MustEval(`(languages) => {
 		Object.defineProperty(navigator, 'languages', {
-			get: () => ['en-US', 'en']
+			get: () => languages
 		});
-	}`)
+	}`, cfg.Browser.Languages)
 
 	// Override permissions
 	page.MustEval(`() => {
@@ -105,12 +248,23 @@ func (b *Browser) WaitLoad() error {
 	return b.page.WaitLoad()
 }
 
+// Close ends a local browser process too, releasing its profile directory
+// before another session launches
 func (b *Browser) Close() error {
 	b.logger.Info("Closing browser")
+	b.shutdown()
+	return nil
+}
+
+// shutdown ends the browser process for a local driver; remote browsers are
+// shared, so only our page is closed
+func (b *Browser) shutdown() {
 	if b.page != nil {
-		return b.page.Close()
+		b.page.Close()
+	}
+	if b.cfg.Browser.Driver != "remote" && b.browser != nil {
+		b.browser.Close()
 	}
-	return nil
 }
 
 func (b *Browser) Screenshot(path string) error {
@@ -0,0 +1,84 @@
+package browser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+var chromeVersion = regexp.MustCompile(`Chrome/((\d+)\.[\d.]+)`)
+
+// identity builds a user agent override whose Sec-CH-UA client hints,
+// navigator.userAgentData, navigator.platform and Accept-Language all agree
+// with the configured UA string; overriding the string alone leaves Chrome
+// reporting its real version and platform through the hints
+func identity(ua string, languages []string) *proto.NetworkSetUserAgentOverride {
+	override := &proto.NetworkSetUserAgentOverride{
+		UserAgent:      ua,
+		AcceptLanguage: acceptLanguage(languages),
+	}
+
+	m := chromeVersion.FindStringSubmatch(ua)
+	if m == nil {
+		// Not Chrome: there are no hints to keep consistent
+		return override
+	}
+	full, major := m[1], m[2]
+
+	platform, navPlatform, platformVersion, arch := "Windows", "Win32", "10.0.0", "x86"
+	switch {
+	case strings.Contains(ua, "Macintosh"):
+		platform, navPlatform, platformVersion, arch = "macOS", "MacIntel", macVersion(ua), "x86"
+	case strings.Contains(ua, "Linux"):
+		platform, navPlatform, platformVersion, arch = "Linux", "Linux x86_64", "", "x86"
+	}
+	override.Platform = navPlatform
+
+	// Chrome's GREASE brand, in the same position and format Chrome uses
+	brands := []*proto.EmulationUserAgentBrandVersion{
+		{Brand: "Not_A Brand", Version: "8"},
+		{Brand: "Chromium", Version: major},
+		{Brand: "Google Chrome", Version: major},
+	}
+	fullList := []*proto.EmulationUserAgentBrandVersion{
+		{Brand: "Not_A Brand", Version: "8.0.0.0"},
+		{Brand: "Chromium", Version: full},
+		{Brand: "Google Chrome", Version: full},
+	}
+
+	override.UserAgentMetadata = &proto.EmulationUserAgentMetadata{
+		Brands:          brands,
+		FullVersionList: fullList,
+		FullVersion:     full,
+		Platform:        platform,
+		PlatformVersion: platformVersion,
+		Architecture:    arch,
+		Bitness:         "64",
+	}
+	return override
+}
+
+// macVersion turns "Mac OS X 10_15_7" into "10.15.7"
+func macVersion(ua string) string {
+	m := regexp.MustCompile(`Mac OS X (\d+(?:_\d+)*)`).FindStringSubmatch(ua)
+	if m == nil {
+		return "10.15.7"
+	}
+	return strings.ReplaceAll(m[1], "_", ".")
+}
+
+// acceptLanguage weights languages the way Chrome does: "en-US,en;q=0.9"
+func acceptLanguage(languages []string) string {
+	parts := make([]string, 0, len(languages))
+	for i, lang := range languages {
+		q := 1.0 - 0.1*float64(i)
+		if i == 0 {
+			parts = append(parts, lang)
+		} else if q > 0 {
+			parts = append(parts, fmt.Sprintf("%s;q=%.1f", lang, q))
+		}
+	}
+	return strings.Join(parts, ",")
+}
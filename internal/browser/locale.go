@@ -0,0 +1,102 @@
+package browser
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/launcher/flags"
+
+	"linkedin-automation/config"
+)
+
+// locale is the browser's UI language: browser.locale, or else the first of
+// browser.languages
+func locale(cfg *config.Config) string {
+	if cfg.Browser.Locale != "" {
+		return cfg.Browser.Locale
+	}
+	if len(cfg.Browser.Languages) > 0 {
+		return cfg.Browser.Languages[0]
+	}
+	return "en-US"
+}
+
+// spellcheck is the dictionaries typed text is checked against:
+// browser.spellcheck, or else browser.languages
+func spellcheck(cfg *config.Config) []string {
+	if len(cfg.Browser.Spellcheck) > 0 {
+		return cfg.Browser.Spellcheck
+	}
+	return cfg.Browser.Languages
+}
+
+// localize launches Chrome in the account's language, so the UI, the
+// Accept-Language Chrome sends on its own and the spellcheck dictionaries
+// agree with the languages the pages see. Linux builds take the UI language
+// from LANGUAGE rather than --lang, so env gets it too
+func localize(l *launcher.Launcher, cfg *config.Config, env []string) (*launcher.Launcher, []string, error) {
+	loc := locale(cfg)
+	l = l.Set("lang", loc).Set("accept-lang", strings.Join(cfg.Browser.Languages, ","))
+	env = append(env, "LANGUAGE="+strings.ReplaceAll(loc, "-", "_"))
+
+	dir := l.Get(flags.UserDataDir)
+	if dir == "" {
+		return l, env, nil
+	}
+	if err := setPreferences(filepath.Join(dir, "Default", "Preferences"), map[string]interface{}{
+		"intl": map[string]interface{}{
+			"accept_languages": strings.Join(cfg.Browser.Languages, ","),
+		},
+		"spellcheck": map[string]interface{}{
+			"dictionaries":         spellcheck(cfg),
+			"use_spelling_service": false,
+		},
+		"browser": map[string]interface{}{
+			"enable_spellchecking": len(spellcheck(cfg)) > 0,
+		},
+	}); err != nil {
+		return nil, nil, err
+	}
+	return l, env, nil
+}
+
+// setPreferences merges prefs into Chrome's Preferences file, keeping what a
+// persistent profile already has there
+func setPreferences(path string, prefs map[string]interface{}) error {
+	current := make(map[string]interface{})
+	if data, err := os.ReadFile(path); err == nil {
+		// A file Chrome left half-written is replaced
+		_ = json.Unmarshal(data, &current)
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read browser preferences: %w", err)
+	}
+	merge(current, prefs)
+
+	data, err := json.Marshal(current)
+	if err != nil {
+		return fmt.Errorf("failed to encode browser preferences: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create browser profile: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write browser preferences: %w", err)
+	}
+	return nil
+}
+
+// merge copies src into dst, descending into objects both have
+func merge(dst, src map[string]interface{}) {
+	for k, v := range src {
+		sub, ok := v.(map[string]interface{})
+		if cur, isMap := dst[k].(map[string]interface{}); ok && isMap {
+			merge(cur, sub)
+			continue
+		}
+		dst[k] = v
+	}
+}
@@ -0,0 +1,104 @@
+package browser
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// memLogInterval is how often CheckMemory logs usage
+const memLogInterval = 15 * time.Minute
+
+// aliveTimeout bounds the liveness probe; a healthy page answers at once
+const aliveTimeout = 5 * time.Second
+
+// watch tallies top-level navigations and notices renderer crashes in the background
+func (b *Browser) watch(page *rod.Page) {
+	go page.EachEvent(func(e *proto.PageFrameNavigated) {
+		if e.Frame.ParentID == "" {
+			b.navigations.Add(1)
+		}
+	}, func(e *proto.InspectorTargetCrashed) {
+		b.crashed.Store(true)
+	})()
+}
+
+// Alive reports whether the page still answers over CDP; it fails after a
+// renderer crash, a Chrome exit or a dropped connection
+func (b *Browser) Alive() bool {
+	if b.crashed.Load() {
+		return false
+	}
+	_, err := b.page.Timeout(aliveTimeout).Eval(`() => true`)
+	return err == nil
+}
+
+// RecycleDue reports why Chrome should be relaunched, or "" if it need not be:
+// long-lived sessions leak memory until the renderer crashes mid-action
+func (b *Browser) RecycleDue() string {
+	cfg := b.cfg.Browser
+
+	if cfg.RecycleAfterHours > 0 {
+		if age := time.Since(b.launchedAt); age >= time.Duration(cfg.RecycleAfterHours*float64(time.Hour)) {
+			return fmt.Sprintf("running for %v", age.Round(time.Minute))
+		}
+	}
+	if n := b.navigations.Load(); cfg.RecycleAfterNavigations > 0 && n >= int64(cfg.RecycleAfterNavigations) {
+		return fmt.Sprintf("%d navigations", n)
+	}
+	if cfg.RecycleAboveHeapMB > 0 {
+		if heap := b.jsHeapMB(); heap >= float64(cfg.RecycleAboveHeapMB) {
+			return fmt.Sprintf("page heap at %.0f MB", heap)
+		}
+	}
+	return ""
+}
+
+// Relaunch closes Chrome and starts a fresh instance with the same settings;
+// the caller restores the session on the new Page
+func (b *Browser) Relaunch() error {
+	b.shutdown()
+	if err := b.launch(); err != nil {
+		return fmt.Errorf("failed to relaunch browser: %w", err)
+	}
+	b.logger.Info("Browser relaunched")
+	return nil
+}
+
+// CheckMemory logs the process's and the page's memory use every
+// memLogInterval, so growth over multi-day sessions shows up in the logs
+func (b *Browser) CheckMemory() {
+	if time.Since(b.lastMemLog) < memLogInterval {
+		return
+	}
+	b.lastMemLog = time.Now()
+
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	b.logger.Info("Memory: Go heap %.0f MB (sys %.0f MB, %d goroutines), page heap %.0f MB, %d navigations since launch",
+		mb(m.HeapAlloc), mb(m.Sys), runtime.NumGoroutine(), b.jsHeapMB(), b.navigations.Load())
+}
+
+// jsHeapMB reads the page's used JS heap; 0 when metrics are unavailable
+func (b *Browser) jsHeapMB() float64 {
+	if err := (proto.PerformanceEnable{}).Call(b.page); err != nil {
+		return 0
+	}
+	res, err := proto.PerformanceGetMetrics{}.Call(b.page)
+	if err != nil {
+		return 0
+	}
+	for _, metric := range res.Metrics {
+		if metric.Name == "JSHeapUsedSize" {
+			return metric.Value / (1 << 20)
+		}
+	}
+	return 0
+}
+
+func mb(n uint64) float64 {
+	return float64(n) / (1 << 20)
+}
@@ -0,0 +1,88 @@
+package browser
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"linkedin-automation/internal/logger"
+
+	"github.com/go-rod/rod/lib/launcher"
+)
+
+// remoteControlURL picks the account's sticky endpoint from the pool, moving
+// on to the next endpoint in order when the sticky one fails its health check
+func remoteControlURL(endpoints []string, account string, log *logger.Logger) (string, error) {
+	if len(endpoints) == 0 {
+		return "", errors.New("browser.driver is remote but browser.remote_endpoints is empty")
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(account))
+	start := int(h.Sum32() % uint32(len(endpoints)))
+
+	for i := 0; i < len(endpoints); i++ {
+		endpoint := endpoints[(start+i)%len(endpoints)]
+		if !endpointHealthy(endpoint) {
+			log.Warn("Remote browser %s failed health check, trying next", redactEndpoint(endpoint))
+			continue
+		}
+
+		if i > 0 {
+			log.Warn("Sticky remote browser unavailable, using %s", redactEndpoint(endpoint))
+		}
+		log.Info("Using remote browser %s", redactEndpoint(endpoint))
+
+		// ws:// endpoints (e.g. browserless) are used as-is, http endpoints are resolved
+		if strings.HasPrefix(endpoint, "ws://") || strings.HasPrefix(endpoint, "wss://") {
+			return endpoint, nil
+		}
+		return launcher.ResolveURL(endpoint)
+	}
+
+	return "", errors.New("no healthy remote browser endpoint available")
+}
+
+// endpointHealthy probes the CDP version endpoint of a remote browser
+func endpointHealthy(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return false
+	}
+
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	case "http", "https":
+	default:
+		u, err = url.Parse("http://" + endpoint)
+		if err != nil {
+			return false
+		}
+	}
+	u.Path = "/json/version"
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode == http.StatusOK
+}
+
+// redactEndpoint hides tokens in endpoint URLs before logging
+func redactEndpoint(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return endpoint
+	}
+	return fmt.Sprintf("%s://%s", u.Scheme, u.Host)
+}
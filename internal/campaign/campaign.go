@@ -0,0 +1,634 @@
+// Package campaign runs multi-step outreach campaigns defined in YAML: search,
+// connect, then follow-up messages after set waits. Each prospect's progress
+// is kept in the store, so a campaign picks up where it left off on every run
+package campaign
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"gopkg.in/yaml.v3"
+
+	"linkedin-automation/config"
+	"linkedin-automation/internal/compliance"
+	"linkedin-automation/internal/connect"
+	"linkedin-automation/internal/logger"
+	"linkedin-automation/internal/message"
+	"linkedin-automation/internal/personalize"
+	"linkedin-automation/internal/runctx"
+	"linkedin-automation/internal/search"
+	"linkedin-automation/internal/stealth"
+	"linkedin-automation/internal/storage"
+)
+
+// Search says where a campaign finds its prospects
+type Search struct {
+	Query    string `yaml:"query"`
+	Location string `yaml:"location"`
+	Company  string `yaml:"company"`
+	// Import is a prospect CSV used while search is blocked or without a query
+	Import string `yaml:"import"`
+	// Max is the campaign's total number of prospects
+	Max int `yaml:"max"`
+}
+
+// FollowUp is a message sent AfterDays after acceptance or the previous follow-up
+type FollowUp struct {
+	AfterDays int    `yaml:"after_days"`
+	Message   string `yaml:"message"`
+	// SendWindow holds the step to its own days and hours
+	SendWindow *StepWindow `yaml:"send_window"`
+}
+
+// StepWindow is a follow-up's own send window; each field left unset comes
+// from messaging.send_window, so recipient_local: false keeps one step in
+// the configured timezone while the others follow the recipient's
+type StepWindow struct {
+	Days           []string `yaml:"days"`
+	StartHour      *int     `yaml:"start_hour"`
+	EndHour        *int     `yaml:"end_hour"`
+	Timezone       string   `yaml:"timezone"`
+	RecipientLocal *bool    `yaml:"recipient_local"`
+}
+
+// JobChangeStep is a message to connections whose tracked profile shows a
+// new title or company, e.g. congratulations on the new role
+type JobChangeStep struct {
+	Message string `yaml:"message"`
+	// WithinDays leaves out changes detected longer ago (default 30)
+	WithinDays int `yaml:"within_days"`
+}
+
+// Definition is a campaign file, config/campaigns/<name>.yaml by default
+type Definition struct {
+	Name      string     `yaml:"-"`
+	Search    Search     `yaml:"search"`
+	Note      string     `yaml:"note"`
+	FollowUps []FollowUp `yaml:"follow_ups"`
+	// OnJobChange tracks the campaign's connections and messages them when
+	// they change jobs
+	OnJobChange *JobChangeStep `yaml:"on_job_change"`
+	// Account pins the campaign to one of the configured accounts, e.g. a
+	// test account for experiments; it does not run under any other
+	Account string `yaml:"account"`
+	// Compliance names one of compliance.profiles for the campaign's notes
+	// and messages, on top of the run's own profile
+	Compliance string `yaml:"compliance"`
+}
+
+// Load reads and checks the named campaign from dir
+func Load(dir, name string) (*Definition, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return nil, fmt.Errorf("invalid campaign name %q", name)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, name+".yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read campaign %q: %w", name, err)
+	}
+
+	// Unknown keys are errors so a typo cannot silently drop a step
+	def := &Definition{Name: name}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(def); err != nil {
+		return nil, fmt.Errorf("failed to parse campaign %q: %w", name, err)
+	}
+
+	if def.Search.Query == "" && def.Search.Import == "" {
+		return nil, fmt.Errorf("campaign %q needs search.query or search.import", name)
+	}
+	if def.Search.Max <= 0 {
+		return nil, fmt.Errorf("campaign %q needs a positive search.max", name)
+	}
+	// Templates may live in files next to the campaign
+	if def.Note, err = personalize.Load(def.Note, dir); err != nil {
+		return nil, fmt.Errorf("campaign %q note: %w", name, err)
+	}
+	if err := personalize.Validate(def.Note); err != nil {
+		return nil, fmt.Errorf("campaign %q note: %w", name, err)
+	}
+	for i := range def.FollowUps {
+		f := &def.FollowUps[i]
+		if f.Message, err = personalize.Load(f.Message, dir); err != nil {
+			return nil, fmt.Errorf("campaign %q: follow-up %d: %w", name, i+1, err)
+		}
+		if err := personalize.Validate(f.Message); err != nil {
+			return nil, fmt.Errorf("campaign %q: follow-up %d: %w", name, i+1, err)
+		}
+		if strings.TrimSpace(f.Message) == "" {
+			return nil, fmt.Errorf("campaign %q: follow-up %d has no message", name, i+1)
+		}
+		if f.AfterDays < 0 {
+			return nil, fmt.Errorf("campaign %q: follow-up %d waits a negative number of days", name, i+1)
+		}
+		if w := f.SendWindow; w != nil {
+			start, end := 0, 24
+			if w.StartHour != nil {
+				start = *w.StartHour
+			}
+			if w.EndHour != nil {
+				end = *w.EndHour
+			}
+			if _, err := stealth.NewSendWindow(w.Days, start, end, w.Timezone); err != nil {
+				return nil, fmt.Errorf("campaign %q: follow-up %d: send_window: %w", name, i+1, err)
+			}
+		}
+	}
+	if j := def.OnJobChange; j != nil {
+		if j.Message, err = personalize.Load(j.Message, dir); err != nil {
+			return nil, fmt.Errorf("campaign %q: on_job_change: %w", name, err)
+		}
+		if err := personalize.Validate(j.Message); err != nil {
+			return nil, fmt.Errorf("campaign %q: on_job_change: %w", name, err)
+		}
+		if strings.TrimSpace(j.Message) == "" {
+			return nil, fmt.Errorf("campaign %q: on_job_change has no message", name)
+		}
+		if j.WithinDays == 0 {
+			j.WithinDays = 30
+		}
+	}
+	return def, nil
+}
+
+// List returns the names of the campaigns defined in dir
+func List(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, p := range paths {
+		names = append(names, strings.TrimSuffix(filepath.Base(p), ".yaml"))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Runner moves a campaign's prospects through its steps; the connection
+// requests and follow-ups it queues are sent by the queue worker
+type Runner struct {
+	run       *runctx.Context
+	cfg       *config.Config
+	logger    *logger.Logger
+	store     *storage.Store
+	connector *connect.Connector
+	messenger *message.Messenger
+	// others sends the items of other campaigns the worker hands over, under
+	// the run's compliance profile, when the campaign has its own
+	others *Runner
+}
+
+func New(page *rod.Page, rc *runctx.Context) *Runner {
+	return &Runner{
+		run:       rc,
+		cfg:       rc.Cfg,
+		logger:    rc.Logger,
+		store:     rc.Store,
+		connector: connect.New(page, rc),
+		messenger: message.New(page, rc),
+	}
+}
+
+// SetPage points the runner at a new page after the browser is relaunched
+func (r *Runner) SetPage(page *rod.Page) {
+	r.connector.SetPage(page)
+	r.messenger.SetPage(page)
+	if r.others != nil {
+		r.others.SetPage(page)
+	}
+}
+
+// SetLimiter paces the runner's profile views with the session's rate limiter
+func (r *Runner) SetLimiter(rl *stealth.RateLimiter) {
+	r.connector.SetLimiter(rl)
+	r.messenger.SetLimiter(rl)
+	if r.others != nil {
+		r.others.SetLimiter(rl)
+	}
+}
+
+// SetCompliance runs the campaign under the named compliance profile as well
+// as the run's: its footer ends the campaign's messages, it decides on
+// do-not-contact, and its volume factor scales the limits further. Call it
+// before SetLimiter
+func (r *Runner) SetCompliance(page *rod.Page, name string) error {
+	if name == "" || name == r.cfg.Compliance.Profile {
+		return nil
+	}
+
+	cfg := *r.cfg
+	cfg.Compliance.Profile = name
+	profile, err := compliance.Resolve(&cfg, name)
+	if err != nil {
+		return fmt.Errorf("campaign %q: %w", r.run.Campaign, err)
+	}
+	profile.Apply(&cfg)
+
+	others := *r
+	*r = *New(page, r.run.WithConfig(&cfg))
+	r.others = &others
+	r.logger.Info("Compliance profile %q active for the campaign (volume x%.2f)", profile.Name, profile.VolumeFactor)
+	return nil
+}
+
+// owner returns the runner that sends item: the campaign's own, or the one
+// under the run's compliance profile for another campaign's
+func (r *Runner) owner(item storage.QueueItem) *Runner {
+	if r.others != nil && item.Campaign != r.run.Campaign {
+		return r.others
+	}
+	return r
+}
+
+// ProcessConnectItem sends a queued connection request
+func (r *Runner) ProcessConnectItem(ctx context.Context, item storage.QueueItem) error {
+	return r.owner(item).connector.ProcessQueueItem(ctx, item)
+}
+
+// ProcessMessageItem sends a queued follow-up
+func (r *Runner) ProcessMessageItem(ctx context.Context, item storage.QueueItem) error {
+	return r.owner(item).messenger.ProcessQueueItem(ctx, item)
+}
+
+// ProcessCongratsItem sends a queued job change message
+func (r *Runner) ProcessCongratsItem(ctx context.Context, item storage.QueueItem) error {
+	return r.owner(item).messenger.ProcessCongratsItem(ctx, item)
+}
+
+// Missing returns how many more prospects the campaign needs to reach search.max
+func (r *Runner) Missing(def *Definition) (int, error) {
+	counts, err := r.store.CountCampaignProspects(def.Name)
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	return max(def.Search.Max-total, 0), nil
+}
+
+// Enqueue adds prospects the campaign has not seen yet, up to search.max,
+// and queues their connection requests
+func (r *Runner) Enqueue(def *Definition, profiles []search.Profile) (int, error) {
+	if err := r.store.TouchCampaign(def.Name); err != nil {
+		return 0, err
+	}
+	missing, err := r.Missing(def)
+	if err != nil {
+		return 0, err
+	}
+
+	var added []search.Profile
+	for _, p := range profiles {
+		if len(added) >= missing {
+			break
+		}
+		why, err := r.capped(p.URL, true)
+		if err != nil {
+			return 0, err
+		}
+		if why != "" {
+			r.logger.Debug("Campaign %q: leaving out %s (%s)", def.Name, p.Name, why)
+			continue
+		}
+		ok, err := r.store.AddCampaignProspect(def.Name, p.URL, p.Name)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			added = append(added, p)
+		}
+	}
+	if len(added) == 0 {
+		return 0, nil
+	}
+
+	r.logger.Info("Campaign %q: %d new prospects", def.Name, len(added))
+	return r.connector.EnqueueProfiles(added, def.Note, def.Name)
+}
+
+// Advance moves every prospect as far as it can go now: sent requests are
+// checked for acceptance, finished follow-ups start the wait for the next
+// one, replies end the sequence, and follow-ups that are due are queued. It
+// returns how many follow-ups were queued
+func (r *Runner) Advance(def *Definition) (int, error) {
+	if err := r.store.TouchCampaign(def.Name); err != nil {
+		return 0, err
+	}
+
+	// Replies are found through read receipts
+	if _, err := r.messenger.CheckReceipts(r.run); err != nil {
+		r.logger.Warn("Failed to check read receipts: %v", err)
+	}
+	return r.advance(def)
+}
+
+// AdvanceSequence moves the connections on the messaging.sequence steps,
+// which join as accepted prospects of storage.SequenceCampaign, through them
+// as Advance does a campaign's. Read receipts are left to the caller
+func (r *Runner) AdvanceSequence(steps []config.SequenceStep) (int, error) {
+	def := &Definition{Name: storage.SequenceCampaign}
+	for _, step := range steps {
+		def.FollowUps = append(def.FollowUps, FollowUp{AfterDays: step.AfterDays, Message: step.Message})
+	}
+	if err := r.store.TouchCampaign(def.Name); err != nil {
+		return 0, err
+	}
+	return r.advance(def)
+}
+
+func (r *Runner) advance(def *Definition) (int, error) {
+	prospects, err := r.store.GetCampaignProspects(def.Name, "")
+	if err != nil {
+		return 0, err
+	}
+
+	// Acceptance checks read the connections list back to the oldest
+	// invitation; when they fall back to profile visits, only check as many
+	// prospects as can still be messaged today
+	today, err := r.store.GetMessagesCountToday()
+	if err != nil {
+		return 0, err
+	}
+	checks := r.cfg.Limits.MaxMessagesPerDay - today
+	oldest := time.Now()
+	for _, p := range prospects {
+		if p.Status == storage.CampaignInvited && p.UpdatedAt.Before(oldest) {
+			oldest = p.UpdatedAt
+		}
+	}
+	check := r.messenger.AcceptanceCheck(oldest)
+
+	queued := 0
+	now := time.Now()
+	for _, p := range prospects {
+		// Each prospect's progress is saved as it goes, so a stopped run
+		// leaves the rest for the next one
+		if r.run.Stopped() {
+			break
+		}
+		before := p
+		switch p.Status {
+		case storage.CampaignQueued:
+			sent, err := r.store.IsConnectionSent(p.ProfileURL)
+			if err != nil {
+				return queued, err
+			}
+			if sent {
+				p.Status = storage.CampaignInvited
+			}
+
+		case storage.CampaignInvited:
+			if !check.Bulk() {
+				if checks <= 0 {
+					continue
+				}
+				checks--
+			}
+			accepted, err := check.Accepted(r.run, p.ProfileURL)
+			if err != nil {
+				r.logger.Error("Failed to check connection status: %v", err)
+				continue
+			}
+			if accepted {
+				r.logger.Info("Campaign %q: %s accepted", def.Name, p.Name)
+				r.store.MarkConnectionAccepted(p.ProfileURL)
+				r.schedule(def, &p, now)
+			}
+
+		case storage.CampaignMessaging:
+			// The follow-up's queue item tells how it ended, so other
+			// messages to the prospect are not mistaken for it
+			status, doneAt, err := r.store.GetStepOutcome(def.Name, storage.QueueActionMessage, p.ProfileURL)
+			if err != nil {
+				return queued, err
+			}
+			switch status {
+			case storage.QueueStatusDone:
+				// Sent, or skipped by the messenger (do-not-contact, already
+				// in the thread); either way the step is over
+				if doneAt.IsZero() {
+					doneAt = now
+				}
+				p.Step++
+				r.schedule(def, &p, doneAt)
+			case storage.QueueStatusDead, "":
+				r.logger.Warn("Campaign %q: follow-up %d to %s failed for good or was removed, stopping follow-ups", def.Name, p.Step+1, p.Name)
+				p.Status, p.NextAt = storage.CampaignDone, time.Time{}
+			default:
+				replied, err := r.store.HasReplied(p.ProfileURL)
+				if err != nil {
+					return queued, err
+				}
+				if replied {
+					if _, err := r.store.RemoveQueuedProfile(p.ProfileURL, def.Name); err != nil {
+						return queued, err
+					}
+					r.logger.Info("Campaign %q: %s replied, dropping queued follow-up %d", def.Name, p.Name, p.Step+1)
+					p.Status, p.NextAt = storage.CampaignReplied, time.Time{}
+				}
+			}
+		}
+
+		if p.Status == storage.CampaignAccepted && !now.Before(p.NextAt) {
+			replied, err := r.store.HasReplied(p.ProfileURL)
+			if err != nil {
+				return queued, err
+			}
+			why, err := r.capped(p.ProfileURL, false)
+			if err != nil {
+				return queued, err
+			}
+			if replied {
+				r.logger.Info("Campaign %q: %s replied, stopping follow-ups", def.Name, p.Name)
+				p.Status = storage.CampaignReplied
+			} else if why != "" {
+				r.logger.Info("Campaign %q: %s has had %s, stopping follow-ups", def.Name, p.Name, why)
+				p.Status, p.NextAt = storage.CampaignDone, time.Time{}
+			} else if err := r.queueFollowUp(def, p); err != nil {
+				r.logger.Error("Failed to queue follow-up %d for %s: %v", p.Step+1, p.Name, err)
+			} else {
+				p.Status = storage.CampaignMessaging
+				queued++
+			}
+		}
+
+		if p != before {
+			if err := r.store.UpdateCampaignProspect(p); err != nil {
+				return queued, err
+			}
+		}
+	}
+
+	r.logger.Info("Campaign %q: queued %d follow-ups", def.Name, queued)
+
+	if def.OnJobChange != nil {
+		if _, err := r.congratulate(def, prospects); err != nil {
+			return queued, err
+		}
+	}
+	return queued, nil
+}
+
+// congratulate tracks the campaign's connections and queues the
+// on_job_change message for each job change detected within_days ago or
+// since. Prospects waiting on a queued follow-up are left until it is sent,
+// which would otherwise be mistaken for this message
+func (r *Runner) congratulate(def *Definition, prospects []storage.CampaignProspect) (int, error) {
+	changes, err := r.store.GetJobChangesSince("", time.Now().AddDate(0, 0, -def.OnJobChange.WithinDays))
+	if err != nil {
+		return 0, err
+	}
+	// Newest first, so the first change seen for a profile is the latest
+	latest := make(map[string]storage.JobChange)
+	for _, c := range changes {
+		if _, ok := latest[c.ProfileURL]; !ok {
+			latest[c.ProfileURL] = c
+		}
+	}
+
+	queued := 0
+	for _, p := range prospects {
+		switch p.Status {
+		case storage.CampaignAccepted, storage.CampaignReplied, storage.CampaignDone:
+		default:
+			continue
+		}
+		if err := r.store.TrackContact(p.ProfileURL, p.Name); err != nil {
+			return queued, err
+		}
+
+		c, ok := latest[p.ProfileURL]
+		if !ok {
+			continue
+		}
+		if why, err := r.capped(p.ProfileURL, false); err != nil {
+			return queued, err
+		} else if why != "" {
+			r.logger.Debug("Campaign %q: not congratulating %s, who has had %s", def.Name, p.Name, why)
+			continue
+		}
+		claimed, err := r.store.ClaimJobChange(def.Name, c.ID)
+		if err != nil {
+			return queued, err
+		}
+		if !claimed {
+			continue
+		}
+		if err := r.store.EnqueueStep(storage.QueueItem{
+			Campaign:   def.Name,
+			Action:     storage.QueueActionCongrats,
+			ProfileURL: p.ProfileURL,
+			Name:       p.Name,
+			Payload:    personalize.Prefill(def.OnJobChange.Message, personalize.NewData(p.Name)),
+			Source:     "campaign",
+		}); err != nil {
+			r.logger.Error("Failed to queue job change message for %s: %v", p.Name, err)
+			continue
+		}
+		queued++
+	}
+	if queued > 0 {
+		r.logger.Info("Campaign %q: queued %d job change messages", def.Name, queued)
+	}
+	return queued, nil
+}
+
+// capped returns why the profile may not be touched again, or "" when it
+// may: it has had campaigns.max_touches, or, joining a new campaign, was
+// touched within campaigns.min_days_between
+func (r *Runner) capped(profileURL string, joining bool) (string, error) {
+	touches, last, err := r.store.GetTouches(profileURL)
+	if err != nil {
+		return "", err
+	}
+	if limit := r.cfg.Campaigns.MaxTouches; limit > 0 && touches >= limit {
+		return fmt.Sprintf("%d of %d touches", touches, limit), nil
+	}
+	if days := r.cfg.Campaigns.MinDaysBetween; joining && days > 0 && !last.IsZero() &&
+		r.run.Now().Before(last.AddDate(0, 0, days)) {
+		return fmt.Sprintf("a touch on %s, within %d days", last.Local().Format("Jan 2"), days), nil
+	}
+	return "", nil
+}
+
+// schedule sets when the next follow-up is due, counting from since, or
+// finishes the prospect when none are left
+func (r *Runner) schedule(def *Definition, p *storage.CampaignProspect, since time.Time) {
+	if p.Step >= len(def.FollowUps) {
+		p.Status = storage.CampaignDone
+		p.NextAt = time.Time{}
+		return
+	}
+	p.Status = storage.CampaignAccepted
+	p.NextAt = since.AddDate(0, 0, def.FollowUps[p.Step].AfterDays)
+}
+
+func (r *Runner) queueFollowUp(def *Definition, p storage.CampaignProspect) error {
+	if p.Step >= len(def.FollowUps) {
+		return errors.New("no follow-up left")
+	}
+	step := def.FollowUps[p.Step]
+	window, err := r.stepWindow(step)
+	if err != nil {
+		return err
+	}
+	return r.store.EnqueueStep(storage.QueueItem{
+		Campaign:   def.Name,
+		Action:     storage.QueueActionMessage,
+		ProfileURL: p.ProfileURL,
+		Name:       p.Name,
+		Payload:    personalize.Prefill(step.Message, personalize.NewData(p.Name)),
+		Source:     "campaign",
+		SendWindow: window,
+	})
+}
+
+// stepWindow returns step's send window for the queue, with the fields it
+// leaves unset taken from messaging.send_window; "" when it has none
+func (r *Runner) stepWindow(step FollowUp) (string, error) {
+	if step.SendWindow == nil {
+		return "", nil
+	}
+
+	sw, w := step.SendWindow, r.cfg.Messaging.SendWindow
+	w.Enabled = true
+	if len(sw.Days) > 0 {
+		w.Days = sw.Days
+	}
+	if sw.StartHour != nil {
+		w.StartHour = *sw.StartHour
+	}
+	if sw.EndHour != nil {
+		w.EndHour = *sw.EndHour
+	}
+	if sw.Timezone != "" {
+		w.Timezone = sw.Timezone
+	}
+	if sw.RecipientLocal != nil {
+		w.RecipientLocal = *sw.RecipientLocal
+	}
+	// A bound taken from the global window may not fit the step's other one
+	if _, err := stealth.NewSendWindow(w.Days, w.StartHour, w.EndHour, w.Timezone); err != nil {
+		return "", fmt.Errorf("invalid send window: %w", err)
+	}
+
+	data, err := json.Marshal(w)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode the send window: %w", err)
+	}
+	return string(data), nil
+}
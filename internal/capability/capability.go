@@ -0,0 +1,197 @@
+package capability
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"linkedin-automation/config"
+	"linkedin-automation/internal/logger"
+	"linkedin-automation/internal/stealth"
+	"linkedin-automation/internal/storage"
+
+	"github.com/go-rod/rod"
+)
+
+// ErrMissing means the account's subscription does not include a feature
+var ErrMissing = errors.New("capability not available for this account")
+
+// Feature is a subscription-gated capability
+type Feature string
+
+const (
+	FeatureInMail         Feature = "inmail"
+	FeatureSalesNavSearch Feature = "sales_navigator_search"
+	FeatureSalesNavLists  Feature = "sales_navigator_lists"
+	FeatureRecruiter      Feature = "recruiter"
+)
+
+// freeNoteMaxLen is LinkedIn's note length limit for accounts without Premium
+const freeNoteMaxLen = 200
+
+// freeNotesPerMonth is how many personalized invitations a free account gets
+const freeNotesPerMonth = 5
+
+// Weekly invitation allowances of free accounts and of Sales Navigator or
+// Recruiter seats
+const (
+	freeWeeklyInvites = 100
+	seatWeeklyInvites = 150
+)
+
+// Re-detect weekly; subscriptions rarely change and detection costs page views
+const refreshInterval = 7 * 24 * time.Hour
+
+// Capabilities records which paid LinkedIn products the account has
+type Capabilities struct {
+	Premium        bool      `json:"premium"`
+	SalesNavigator bool      `json:"sales_navigator"`
+	Recruiter      bool      `json:"recruiter"`
+	DetectedAt     time.Time `json:"detected_at"`
+}
+
+// Has reports whether a feature is available
+func (c Capabilities) Has(f Feature) bool {
+	switch f {
+	case FeatureInMail:
+		return c.Premium || c.SalesNavigator || c.Recruiter
+	case FeatureSalesNavSearch, FeatureSalesNavLists:
+		return c.SalesNavigator
+	case FeatureRecruiter:
+		return c.Recruiter
+	}
+	return false
+}
+
+// Require returns ErrMissing when the feature is unavailable
+func (c Capabilities) Require(f Feature) error {
+	if c.Has(f) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrMissing, f)
+}
+
+func (c Capabilities) String() string {
+	var tiers []string
+	if c.Premium {
+		tiers = append(tiers, "Premium")
+	}
+	if c.SalesNavigator {
+		tiers = append(tiers, "Sales Navigator")
+	}
+	if c.Recruiter {
+		tiers = append(tiers, "Recruiter")
+	}
+	if len(tiers) == 0 {
+		return "free account"
+	}
+	return strings.Join(tiers, ", ")
+}
+
+// Apply adjusts limits that depend on the subscription
+func (c Capabilities) Apply(cfg *config.Config) {
+	if !c.Premium && !c.SalesNavigator && cfg.Limits.ConnectionNoteMaxLen > freeNoteMaxLen {
+		cfg.Limits.ConnectionNoteMaxLen = freeNoteMaxLen
+	}
+	if !c.Premium && !c.SalesNavigator && cfg.Limits.MaxNotesPerMonth == 0 {
+		cfg.Limits.MaxNotesPerMonth = freeNotesPerMonth
+	}
+
+	// The weekly limit defaults to the tier's invitation allowance, and a
+	// configured one is only capped at it
+	allowance := freeWeeklyInvites
+	if c.SalesNavigator || c.Recruiter {
+		allowance = seatWeeklyInvites
+	}
+	if week := cfg.Limits.MaxConnectionsPerWeek; week == 0 {
+		cfg.Limits.MaxConnectionsPerWeek = allowance
+	} else {
+		cfg.Limits.MaxConnectionsPerWeek = min(week, allowance)
+	}
+}
+
+// Ensure returns cached capabilities, re-detecting them when stale
+func Ensure(page *rod.Page, cfg *config.Config, log *logger.Logger, store *storage.Store) (Capabilities, error) {
+	if caps, err := load(store); err == nil && time.Since(caps.DetectedAt) < refreshInterval {
+		return caps, nil
+	}
+
+	caps, err := Detect(page, cfg, log)
+	if err != nil {
+		return caps, err
+	}
+
+	data, err := json.Marshal(caps)
+	if err != nil {
+		return caps, err
+	}
+	if err := store.SetState(storage.StateCapabilities, string(data)); err != nil {
+		log.Warn("Failed to persist capabilities: %v", err)
+	}
+	return caps, nil
+}
+
+func load(store *storage.Store) (Capabilities, error) {
+	var caps Capabilities
+	value, err := store.GetState(storage.StateCapabilities)
+	if err != nil {
+		return caps, err
+	}
+	if value == "" {
+		return caps, errors.New("capabilities not detected yet")
+	}
+	err = json.Unmarshal([]byte(value), &caps)
+	return caps, err
+}
+
+// Detect inspects the logged-in UI for Premium, Sales Navigator and Recruiter
+func Detect(page *rod.Page, cfg *config.Config, log *logger.Logger) (Capabilities, error) {
+	log.Info("Detecting account capabilities")
+	caps := Capabilities{DetectedAt: time.Now()}
+
+	// Premium members get a badge on their own feed identity card
+	if err := page.Navigate(cfg.LinkedIn.BaseURL + "/feed/"); err != nil {
+		return caps, err
+	}
+	page.WaitLoad()
+	stealth.RandomDelay(1500, 3000)
+
+	for _, selector := range []string{
+		"li-icon[type='linkedin-premium-gold-icon']",
+		".feed-identity-module .premium-icon",
+		"[data-test-premium-badge]",
+	} {
+		if has, _, _ := page.Has(selector); has {
+			caps.Premium = true
+			break
+		}
+	}
+
+	// Paid products redirect to an upsell page when the seat is missing
+	caps.SalesNavigator = reachable(page, cfg.LinkedIn.BaseURL+"/sales/home", "/sales/home")
+	caps.Recruiter = reachable(page, cfg.LinkedIn.BaseURL+"/talent/home", "/talent/")
+
+	// Sales Navigator and Recruiter include Premium features
+	if caps.SalesNavigator || caps.Recruiter {
+		caps.Premium = true
+	}
+
+	log.Info("Account capabilities: %s", caps)
+	return caps, nil
+}
+
+func reachable(page *rod.Page, url, marker string) bool {
+	if err := page.Navigate(url); err != nil {
+		return false
+	}
+	page.WaitLoad()
+	stealth.RandomDelay(1500, 3000)
+
+	info, err := page.Info()
+	if err != nil {
+		return false
+	}
+	return strings.Contains(info.URL, marker) && !strings.Contains(info.URL, "/premium")
+}
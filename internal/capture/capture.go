@@ -0,0 +1,101 @@
+// Package capture keeps evidence of what was sent: the page just before a
+// connection request or message goes out and just after, as a URL and a
+// screenshot under captures.dir/<timestamp>/, with the paths recorded in the
+// database. A send someone disputes, or one that failed, can then be looked
+// at as it was on screen
+package capture
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-rod/rod"
+
+	"linkedin-automation/internal/runctx"
+	"linkedin-automation/internal/stealth"
+	"linkedin-automation/internal/storage"
+)
+
+// settle is how long the page is given to show the outcome of Send
+const settle = 1500 * time.Millisecond
+
+// Capture is one send being captured
+type Capture struct {
+	rc    *runctx.Context
+	page  *rod.Page
+	entry storage.SendCapture
+}
+
+// Before captures the page as it is about to be sent, and returns nil when
+// captures are disabled or the directory cannot be created; a nil Capture
+// ignores After
+func Before(rc *runctx.Context, page *rod.Page, action, profileURL string) *Capture {
+	if !rc.Cfg.Captures.Enabled {
+		return nil
+	}
+	dir := filepath.Join(rc.Cfg.Captures.Dir, rc.Now().Format("20060102-150405.000"))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		rc.Logger.Warn("Failed to create capture directory: %v", err)
+		return nil
+	}
+
+	c := &Capture{rc: rc, page: page, entry: storage.SendCapture{
+		Action:     action,
+		ProfileURL: profileURL,
+		Campaign:   rc.Campaign,
+		Dir:        dir,
+	}}
+	c.entry.BeforeURL, c.entry.BeforeShot = c.take("before")
+	return c
+}
+
+// After captures the page once the outcome of Send has had a moment to show
+// and records the capture; err is what sending returned
+func (c *Capture) After(ctx context.Context, err error) {
+	if c == nil {
+		return
+	}
+	if err == nil {
+		// Stopping cuts the wait short; the page is still captured
+		stealth.Wait(ctx, settle)
+	}
+	c.Settled(err)
+}
+
+// Settled captures the page and records the capture at once, for senders
+// that waited for the outcome themselves; err is the outcome
+func (c *Capture) Settled(err error) {
+	if c == nil {
+		return
+	}
+	c.entry.AfterURL, c.entry.AfterShot = c.take("after")
+
+	c.entry.Outcome = storage.CaptureSent
+	if err != nil {
+		c.entry.Outcome, c.entry.Detail = storage.CaptureFailed, err.Error()
+	}
+	if err := c.rc.Store.SaveSendCapture(c.entry); err != nil {
+		c.rc.Logger.Warn("%v", err)
+	}
+}
+
+// take saves a screenshot of the page as stage.png and returns the page URL
+// and the file's path, empty when it could not be taken
+func (c *Capture) take(stage string) (url, path string) {
+	if info, err := c.page.Info(); err == nil {
+		url = info.URL
+	}
+	data, err := c.page.Screenshot(false, nil)
+	if err != nil {
+		c.rc.Logger.Warn("Failed to capture the page %s sending: %v", stage, err)
+		return url, ""
+	}
+	path = filepath.Join(c.entry.Dir, stage+".png")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		c.rc.Logger.Warn("Failed to save capture: %v", err)
+		return url, ""
+	}
+	return url, path
+}
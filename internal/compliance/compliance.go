@@ -0,0 +1,112 @@
+package compliance
+
+import (
+	"fmt"
+	"strings"
+
+	"linkedin-automation/config"
+)
+
+// researchMaxPerDay is the most research mode allows of any action a day,
+// whatever research.max_per_day says
+const researchMaxPerDay = 5
+
+// Profile adjusts outreach for jurisdictions with stricter rules
+type Profile struct {
+	Name              string
+	Footer            string
+	VolumeFactor      float64
+	HonorDoNotContact bool
+	// Disclosure ends every note and message in research mode, and
+	// ResearchCap caps daily volumes (0 = not in research mode)
+	Disclosure  string
+	ResearchCap int
+}
+
+// Resolve returns the named profile; an empty name yields a permissive default
+func Resolve(cfg *config.Config, name string) (*Profile, error) {
+	p := &Profile{
+		VolumeFactor:      1,
+		HonorDoNotContact: true,
+	}
+	if cfg.Research.Enabled {
+		p.Disclosure = strings.TrimSpace(cfg.Research.Disclosure)
+		p.ResearchCap = max(1, min(cfg.Research.MaxPerDay, researchMaxPerDay))
+	}
+	if name == "" {
+		return p, nil
+	}
+
+	pc, ok := cfg.Compliance.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown compliance profile %q", name)
+	}
+
+	p.Name = name
+	p.Footer = pc.Footer
+	p.HonorDoNotContact = pc.HonorDoNotContact
+	if pc.VolumeFactor > 0 && pc.VolumeFactor < 1 {
+		p.VolumeFactor = pc.VolumeFactor
+	}
+	return p, nil
+}
+
+// ForConfig returns the profile selected in config, falling back to the default
+// when it is invalid (main validates the selection at startup)
+func ForConfig(cfg *config.Config) *Profile {
+	p, err := Resolve(cfg, cfg.Compliance.Profile)
+	if err != nil {
+		p, _ = Resolve(cfg, "")
+	}
+	return p
+}
+
+// Apply scales volume limits by the profile's factor, then caps them in
+// research mode
+func (p *Profile) Apply(cfg *config.Config) {
+	if p.VolumeFactor < 1 {
+		cfg.Limits.MaxConnectionsPerDay = scale(cfg.Limits.MaxConnectionsPerDay, p.VolumeFactor)
+		cfg.Limits.MaxConnectionsPerWeek = scale(cfg.Limits.MaxConnectionsPerWeek, p.VolumeFactor)
+		cfg.Limits.MaxMessagesPerDay = scale(cfg.Limits.MaxMessagesPerDay, p.VolumeFactor)
+	}
+	if p.ResearchCap > 0 {
+		cfg.Limits.Cap(p.ResearchCap)
+	}
+}
+
+// DecorateMessage appends the mandatory footer (e.g. opt-out text) and the
+// research disclosure
+func (p *Profile) DecorateMessage(text string) string {
+	if p.Footer != "" && !strings.HasSuffix(text, p.Footer) {
+		text += p.Footer
+	}
+	if p.Disclosure != "" && !strings.HasSuffix(text, p.Disclosure) {
+		text += "\n\n" + p.Disclosure
+	}
+	return text
+}
+
+// DecorateNote appends the research disclosure to a connection note,
+// shortening the note so both fit in limit characters; with no note the
+// disclosure is the note
+func (p *Profile) DecorateNote(note string, limit int) string {
+	if p.Disclosure == "" || strings.HasSuffix(note, p.Disclosure) {
+		return note
+	}
+	if note == "" {
+		return p.Disclosure
+	}
+	suffix := []rune("\n\n" + p.Disclosure)
+	if r := []rune(note); len(r)+len(suffix) > limit {
+		note = strings.TrimSpace(string(r[:max(0, limit-len(suffix)-3)])) + "..."
+	}
+	return note + string(suffix)
+}
+
+func scale(limit int, factor float64) int {
+	scaled := int(float64(limit) * factor)
+	if scaled < 1 && limit > 0 {
+		scaled = 1
+	}
+	return scaled
+}
@@ -1,58 +1,83 @@
 package connect
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"linkedin-automation/config"
+	"linkedin-automation/internal/apperr"
+	"linkedin-automation/internal/capture"
+	"linkedin-automation/internal/compliance"
+	"linkedin-automation/internal/dom"
 	"linkedin-automation/internal/logger"
+	"linkedin-automation/internal/personalize"
+	"linkedin-automation/internal/profile"
+	"linkedin-automation/internal/queue"
+	"linkedin-automation/internal/risk"
+	"linkedin-automation/internal/runctx"
+	"linkedin-automation/internal/scoring"
 	"linkedin-automation/internal/search"
 	"linkedin-automation/internal/stealth"
 	"linkedin-automation/internal/storage"
+	"linkedin-automation/internal/throttle"
+	"linkedin-automation/internal/timing"
 	"strings"
+	"time"
 
 	"github.com/go-rod/rod"
 )
 
 type Connector struct {
-	page   *rod.Page
-	cfg    *config.Config
-	logger *logger.Logger
-	store  *storage.Store
+	page       *rod.Page
+	run        *runctx.Context
+	cfg        *config.Config
+	logger     *logger.Logger
+	store      *storage.Store
+	throttle   *throttle.Coordinator
+	compliance *compliance.Profile
+	typist     stealth.TypingProfile
+	limiter    *stealth.RateLimiter
 }
 
-func New(page *rod.Page, cfg *config.Config, log *logger.Logger, store *storage.Store) *Connector {
+func New(page *rod.Page, rc *runctx.Context) *Connector {
+	cfg := rc.Cfg
 	return &Connector{
-		page:   page,
-		cfg:    cfg,
-		logger: log,
-		store:  store,
+		page:       page,
+		run:        rc,
+		cfg:        cfg,
+		logger:     rc.Logger,
+		store:      rc.Store,
+		throttle:   throttle.New(cfg, rc.Logger, rc.Store),
+		compliance: compliance.ForConfig(cfg),
+		typist: stealth.TypingProfileFor(cfg.Stealth.TypingProfile, cfg.Creds.Email,
+			cfg.Delays.MinTypingDelayMs, cfg.Delays.MaxTypingDelayMs, cfg.Stealth.TypoProbability),
 	}
 }
 
-func (c *Connector) SendConnectionRequests(profiles []search.Profile, note string) error {
-	c.logger.Info("Starting to send connection requests to %d profiles", len(profiles))
+// SetPage points the connector at a new page after the browser is relaunched
+func (c *Connector) SetPage(page *rod.Page) {
+	c.page = page
+}
 
-	// Check today's limit
-	todayCount, err := c.store.GetConnectionsCountToday()
-	if err != nil {
-		return fmt.Errorf("failed to get connection count: %w", err)
-	}
+// SetLimiter paces the profile views behind each request with the session's
+// rate limiter
+func (c *Connector) SetLimiter(rl *stealth.RateLimiter) {
+	c.limiter = rl
+}
 
-	if todayCount >= c.cfg.Limits.MaxConnectionsPerDay {
-		c.logger.Warn("Daily connection limit reached (%d/%d)", todayCount, c.cfg.Limits.MaxConnectionsPerDay)
-		return errors.New("daily connection limit reached")
+// EnqueueProfiles queues a connection request for every profile not contacted
+// yet, the likeliest to accept first
+func (c *Connector) EnqueueProfiles(profiles []search.Profile, note, campaign string) (int, error) {
+	// The model is nil until there is enough history
+	model, err := scoring.Load(c.store)
+	if err != nil {
+		c.logger.Warn("Acceptance model unavailable: %v", err)
+	} else if model == nil {
+		c.logger.Debug("Not enough history for the acceptance model yet; queueing in search order")
 	}
 
-	remaining := c.cfg.Limits.MaxConnectionsPerDay - todayCount
-	c.logger.Info("Can send %d more connections today", remaining)
-
-	sent := 0
-	for i, profile := range profiles {
-		if sent >= remaining {
-			c.logger.Info("Reached daily limit")
-			break
-		}
-
+	queued := 0
+	for _, profile := range profiles {
 		// Check if already sent
 		alreadySent, err := c.store.IsConnectionSent(profile.URL)
 		if err != nil {
@@ -65,179 +90,493 @@ func (c *Connector) SendConnectionRequests(profiles []search.Profile, note strin
 			continue
 		}
 
-		c.logger.Info("[%d/%d] Sending connection to: %s (%s)", i+1, len(profiles), profile.Name, profile.Title)
+		if c.doNotContact(profile.URL) {
+			c.logger.Debug("%s is on the do-not-contact list, skipping", profile.Name)
+			continue
+		}
 
-		// Personalize note
-		personalizedNote := c.personalizeNote(note, profile)
+		payload := c.personalizeNote(note, profile)
+		features := storage.ProspectFeatures{
+			TitleMatch:    profile.TitleMatch,
+			LocationMatch: profile.LocationMatch,
+			Mutuals:       profile.Mutuals,
+			OpenToWork:    profile.OpenToWork,
+			Note:          payload != "",
+		}
+		if err := c.store.SaveProspectFeatures(profile.URL, features); err != nil {
+			c.logger.Warn("%v", err)
+		}
 
-		// Send connection request
-		if err := c.sendConnection(profile, personalizedNote); err != nil {
-			c.logger.Error("Failed to send connection to %s: %v", profile.Name, err)
-			continue
+		priority := 0
+		if model != nil {
+			priority = model.Priority(features)
+			c.logger.Debug("Predicted acceptance for %s: %d%%", profile.Name, priority)
 		}
 
-		// Save to database
-		if err := c.store.SaveConnectionRequest(profile.URL, profile.Name, personalizedNote); err != nil {
-			c.logger.Error("Failed to save connection request: %v", err)
+		if err := c.store.Enqueue(storage.QueueItem{
+			Campaign:   campaign,
+			Action:     storage.QueueActionConnect,
+			ProfileURL: profile.URL,
+			Name:       profile.Name,
+			Payload:    payload,
+			Source:     profile.Source,
+			Priority:   priority,
+		}); err != nil {
+			return queued, err
 		}
+		queued++
+	}
+
+	c.logger.Info("Queued %d connection requests", queued)
+	return queued, nil
+}
+
+// ProcessQueueItem sends a single queued connection request
+func (c *Connector) ProcessQueueItem(ctx context.Context, item storage.QueueItem) error {
+	// LinkedIn's weekly invitation limit is still in force
+	if until, err := c.store.GetStateTime(storage.StateInviteLimitUntil); err == nil && c.run.Now().Before(until) {
+		return fmt.Errorf("%w: weekly invitation limit in force until %s", apperr.ErrRateLimited, until.Local().Format(time.RFC1123))
+	}
 
-		sent++
-		c.logger.LogAction("CONNECTION_SENT", map[string]interface{}{
-			"name": profile.Name,
-			"url":  profile.URL,
+	// Check today's limit
+	todayCount, err := c.store.GetConnectionsCountToday()
+	if err != nil {
+		return fmt.Errorf("failed to get connection count: %w", err)
+	}
+
+	if todayCount >= c.cfg.Limits.MaxConnectionsPerDay {
+		c.logger.Warn("Daily connection limit reached (%d/%d)", todayCount, c.cfg.Limits.MaxConnectionsPerDay)
+		return fmt.Errorf("%w: daily connection limit reached", apperr.ErrRateLimited)
+	}
+
+	// The profile may have been contacted since it was queued
+	alreadySent, err := c.store.IsConnectionSent(item.ProfileURL)
+	if err != nil {
+		return fmt.Errorf("failed to check connection status: %w", err)
+	}
+	if alreadySent {
+		c.logger.Debug("Already sent connection to %s, skipping", item.Name)
+		return nil
+	}
+
+	if c.doNotContact(item.ProfileURL) {
+		c.logger.Info("%s is on the do-not-contact list, skipping", item.Name)
+		return nil
+	}
+
+	c.logger.Info("Sending connection to: %s", item.Name)
+
+	if err := c.throttle.Acquire(ctx); err != nil {
+		return fmt.Errorf("failed to acquire throttle: %w", err)
+	}
+	defer c.throttle.Release()
+
+	// Another instance may have used up today's budget while we waited
+	if count, err := c.store.GetConnectionsCountToday(); err == nil && count >= c.cfg.Limits.MaxConnectionsPerDay {
+		return fmt.Errorf("%w: daily connection limit reached", apperr.ErrRateLimited)
+	}
+
+	// Send connection request
+	profile := search.Profile{URL: item.ProfileURL, Name: item.Name}
+	note, err := c.sendConnection(ctx, profile, item.Payload)
+	if errors.Is(err, errInvitePending) {
+		if c.cfg.DryRun {
+			return queue.Planned("record the invitation already pending on the profile")
+		}
+		// Sent by hand on LinkedIn; record it so it is never sent again and
+		// acceptance is still tracked, without spending today's budget
+		c.logger.Info("%s already has a pending invitation sent outside the tool; recording it", item.Name)
+		return c.store.SaveConnectionRequest(storage.ConnectionRequest{
+			ProfileURL: item.ProfileURL,
+			Name:       item.Name,
+			Source:     item.Source,
+			Campaign:   item.Campaign,
+			External:   true,
 		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to send connection to %s: %w", item.Name, err)
+	}
 
-		// Random delay between requests
-		stealth.HumanDelay(
-			c.cfg.Delays.MinActionDelayMs*2,
-			c.cfg.Delays.MaxActionDelayMs*2,
-		)
+	mode := storage.NoteModeNote
+	switch {
+	case item.Payload == "" && note == "":
+		mode = storage.NoteModeNone
+	case note == "":
+		mode = storage.NoteModeFallback
+	}
 
-		// Occasional break
-		stealth.RandomBreak()
+	// Save to database
+	if err := c.store.SaveConnectionRequest(storage.ConnectionRequest{
+		ProfileURL: item.ProfileURL,
+		Name:       item.Name,
+		Note:       note,
+		Source:     item.Source,
+		Campaign:   item.Campaign,
+		NoteMode:   mode,
+	}); err != nil {
+		c.logger.Error("Failed to save connection request: %v", err)
 	}
 
-	c.logger.Info("Completed: sent %d connection requests", sent)
+	c.logger.LogAction(logger.Action{
+		Type:       "CONNECTION_SENT",
+		Action:     item.Action,
+		ProfileURL: item.ProfileURL,
+		Campaign:   item.Campaign,
+		Duration:   queue.Elapsed(ctx),
+		Outcome:    logger.OutcomeSuccess,
+		Details: risk.Annotate(c.store, c.cfg, item.Action, map[string]interface{}{
+			"name":      item.Name,
+			"note_mode": mode,
+		}),
+	})
+
 	return nil
 }
 
-func (c *Connector) sendConnection(profile search.Profile, note string) error {
+// doNotContact reports whether the active compliance profile forbids contacting the profile
+func (c *Connector) doNotContact(profileURL string) bool {
+	if !c.compliance.HonorDoNotContact {
+		return false
+	}
+
+	dnc, err := c.store.IsDoNotContact(profileURL)
+	if err != nil {
+		// Fail closed: never contact someone we cannot verify
+		c.logger.Error("%v", err)
+		return true
+	}
+	return dnc
+}
+
+// sendConnection sends the request from the profile page and returns the
+// note that went with it, completed with the profile's details
+func (c *Connector) sendConnection(ctx context.Context, profile search.Profile, note string) (string, error) {
+	if err := c.limiter.AcquireContext(ctx, stealth.ActionProfileView); err != nil {
+		return note, err
+	}
+
 	// Navigate to profile
 	c.logger.Debug("Navigating to profile: %s", profile.URL)
-	if err := c.page.Navigate(profile.URL); err != nil {
-		return fmt.Errorf("failed to navigate to profile: %w", err)
+	navigated := timing.Start(timing.Navigation)
+	if err := c.page.Context(ctx).Navigate(profile.URL); err != nil {
+		return note, apperr.Navigation(profile.URL, err)
 	}
 
-	if err := c.page.WaitLoad(); err != nil {
-		return err
+	if err := c.page.Context(ctx).WaitLoad(); err != nil {
+		return note, apperr.Navigation(profile.URL, err)
 	}
+	navigated()
 
-	stealth.RandomDelay(2000, 4000)
+	if err := stealth.RandomDelayContext(ctx, 2000, 4000); err != nil {
+		return note, err
+	}
 
 	// Random scrolling to appear human
 	if c.cfg.Stealth.EnableRandomScrolling {
-		stealth.PageThroughContent(c.page, 2)
+		stealth.PageThroughContent(c.page.Context(ctx), 2)
 	}
 
+	// The local record only knows what this tool sent
+	if c.invitationPending() {
+		return note, errInvitePending
+	}
+
+	note = c.compliance.DecorateNote(c.completeNote(profile, note), c.cfg.Limits.ConnectionNoteMaxLen)
+
 	// Find Connect button
 	connectButton, err := c.findConnectButton()
 	if err != nil {
-		return fmt.Errorf("failed to find connect button: %w", err)
+		return note, err
 	}
 
 	// Scroll to button
 	stealth.ScrollToElement(c.page, connectButton)
-	stealth.RandomDelay(500, 1000)
+	if err := stealth.RandomDelayContext(ctx, 500, 1000); err != nil {
+		return note, err
+	}
 
 	// Hover before clicking
 	if c.cfg.Stealth.EnableMouseHovering {
-		stealth.HoverElement(c.page, connectButton)
-		stealth.RandomDelay(200, 500)
+		stealth.HoverElement(c.page.Context(ctx), connectButton)
+		stealth.RandomDelayContext(ctx, 200, 500)
+	}
+
+	if note != "" {
+		if left, err := c.noteAllowance(); err != nil {
+			c.logger.Warn("%v", err)
+		} else if left == 0 {
+			if c.compliance.Disclosure != "" {
+				return note, errNoDisclosure
+			}
+			c.logger.Info("No personalized invitations left this month; sending without the note")
+			note = ""
+		}
+	}
+
+	if c.cfg.DryRun {
+		if note == "" {
+			return note, queue.Planned("send a connection request without a note")
+		}
+		return note, queue.Planned("send a connection request with the note %q", note)
 	}
 
 	// Click Connect
 	c.logger.Debug("Clicking Connect button")
-	if err := stealth.HumanClick(c.page, connectButton); err != nil {
-		return err
+	if err := stealth.HumanClick(c.page.Context(ctx), connectButton); err != nil {
+		return note, err
 	}
 
-	stealth.RandomDelay(1000, 2000)
+	stealth.RandomDelayContext(ctx, 1000, 2000)
+
+	if c.inviteLimitReached() {
+		return note, c.handleInviteLimit()
+	}
 
 	// Check if note dialog appeared
-	if c.hasNoteDialog() {
-		if err := c.addNote(note); err != nil {
+	if c.hasNoteDialog() && note != "" {
+		// Free accounts see how many personalized invitations are left
+		left := c.notesLeft()
+		if left >= 0 {
+			c.logger.Debug("%d personalized invitations left this month", left)
+		}
+
+		err := errNoteLimit
+		if left != 0 {
+			err = c.addNote(ctx, note)
+		}
+		switch {
+		case errors.Is(err, errNoteLimit):
+			c.notesUsedUp()
+			if c.compliance.Disclosure != "" {
+				return note, errNoDisclosure
+			}
+			note = ""
+			if left != 0 {
+				if err := c.skipNoteUpsell(ctx); err != nil {
+					return note, err
+				}
+			}
+		case err != nil && c.compliance.Disclosure != "":
+			return note, fmt.Errorf("%w: %v", errNoDisclosure, err)
+		case err != nil:
 			c.logger.Warn("Failed to add note: %v", err)
+		case left == 1:
+			// This note was the last one
+			c.notesUsedUp()
 		}
+	} else if note != "" && c.compliance.Disclosure != "" {
+		return note, errNoDisclosure
 	}
 
 	// Click Send
-	if err := c.clickSend(); err != nil {
-		return err
+	shot := capture.Before(c.run, c.page, "connect", profile.URL)
+	if err := c.clickSend(ctx); err != nil {
+		shot.After(ctx, err)
+		return note, err
+	}
+
+	// The limit modal can also appear in response to Send; the page is
+	// captured once the toast or the modal has told how it went
+	if toast, err := dom.WaitToast(c.page, dom.ToastWait); err == nil {
+		c.logger.Debug("LinkedIn: %s", toast)
+	}
+	if c.inviteLimitReached() {
+		shot.Settled(errInviteLimit)
+		return note, c.handleInviteLimit()
 	}
+	shot.Settled(nil)
 
 	c.logger.Info("Connection request sent successfully")
-	return nil
+	return note, nil
+}
+
+// errNoDisclosure means research mode held back an invitation that could not
+// carry the disclosure in its note
+var errNoDisclosure = errors.New("research mode: invitation cannot carry the disclosure without a note")
+
+// errInviteLimit records a Send that LinkedIn's weekly invitation limit blocked
+var errInviteLimit = errors.New("blocked by the weekly invitation limit")
+
+// errInvitePending means the profile shows an invitation already pending
+var errInvitePending = errors.New("invitation already pending")
+
+// invitationPending detects the "Pending" state LinkedIn shows in place of
+// Connect once an invitation has been sent
+func (c *Connector) invitationPending() bool {
+	for _, sel := range []string{
+		"main button[aria-label*='Pending']",
+		"main button[aria-label^='Withdraw invitation']",
+	} {
+		if has, _, _ := c.page.Has(sel); has {
+			return true
+		}
+	}
+	has, _, _ := c.page.HasR("main button", `^\s*(Pending|En attente|Ausstehend|Pendiente|In attesa|In afwachting)\s*$`)
+	return has
 }
 
 func (c *Connector) findConnectButton() (*rod.Element, error) {
-	// Try different selectors
+	return dom.Find(c.page, c.logger, dom.ConnectButton)
+}
+
+func (c *Connector) inviteLimitReached() bool {
+	return InviteLimitShown(c.page)
+}
+
+// InviteLimitShown detects LinkedIn's "weekly invitation limit" modal
+func InviteLimitShown(page *rod.Page) bool {
 	selectors := []string{
-		"button[aria-label*='Connect']",
-		"button[aria-label*='Invite']",
-		".pvs-profile-actions button:has-text('Connect')",
-		"button:has-text('Connect')",
+		".ip-fuse-limit-alert",
+		"[data-test-modal-id='fuse-limit-alert']",
 	}
 
 	for _, selector := range selectors {
-		if btn, err := c.page.Element(selector); err == nil {
-			return btn, nil
+		if has, _, _ := page.Has(selector); has {
+			return true
 		}
 	}
 
-	return nil, errors.New("connect button not found")
+	has, _, _ := page.HasR("[role='dialog']", "/weekly invitation limit|reached the weekly/i")
+	return has
+}
+
+// handleInviteLimit closes the modal, persists the enforced limit and stops the run
+func (c *Connector) handleInviteLimit() error {
+	c.logger.Warn("LinkedIn weekly invitation limit reached")
+
+	// Close the modal cleanly
+	for _, selector := range []string{
+		"[role='dialog'] button[aria-label='Got it']",
+		"[role='dialog'] button[aria-label='Dismiss']",
+		".ip-fuse-limit-alert__primary-action",
+	} {
+		if has, btn, _ := c.page.Has(selector); has {
+			stealth.HumanClick(c.page, btn)
+			break
+		}
+	}
+
+	// The weekly window is rolling: it frees up a week after the oldest invite in it
+	now := c.run.Now()
+	reset := now.Add(7 * 24 * time.Hour)
+	if oldest, err := c.store.GetOldestConnectionSince(now.Add(-7 * 24 * time.Hour)); err == nil && !oldest.IsZero() {
+		reset = oldest.Add(7 * 24 * time.Hour)
+	}
+
+	if err := c.store.SetStateTime(storage.StateInviteLimitUntil, reset); err != nil {
+		c.logger.Error("Failed to persist invitation limit: %v", err)
+	}
+	c.store.RecordIncident(storage.IncidentInviteLimit, "reset "+reset.Format(time.RFC3339))
+
+	return fmt.Errorf("%w: weekly invitation limit reached until %s", apperr.ErrRateLimited, reset.Local().Format(time.RFC1123))
 }
 
 func (c *Connector) hasNoteDialog() bool {
-	_, err := c.page.Element("#custom-message")
+	_, err := dom.WaitModalOpen(c.page, dom.ModalWait)
 	return err == nil
 }
 
-func (c *Connector) addNote(note string) error {
+func (c *Connector) addNote(ctx context.Context, note string) error {
 	// Find Add a note button
-	addNoteBtn, err := c.page.Element("button[aria-label='Add a note']")
+	addNoteBtn, err := dom.Find(c.page, c.logger, dom.AddNoteButton)
 	if err != nil {
 		return err
 	}
 
-	stealth.HumanClick(c.page, addNoteBtn)
-	stealth.RandomDelay(500, 1000)
+	stealth.HumanClick(c.page.Context(ctx), addNoteBtn)
+	if err := stealth.RandomDelayContext(ctx, 500, 1000); err != nil {
+		return err
+	}
 
 	// Find note textarea
-	noteField, err := c.page.Element("#custom-message")
+	noteField, err := dom.WaitVisibleEnabled(c.page, "#custom-message", dom.ControlWait)
 	if err != nil {
+		if NoteLimitShown(c.page) {
+			return errNoteLimit
+		}
 		return err
 	}
 
 	// Type note
 	c.logger.Debug("Adding personalized note")
-	if err := stealth.HumanType(
-		c.page,
-		noteField,
-		note,
-		c.cfg.Delays.MinTypingDelayMs,
-		c.cfg.Delays.MaxTypingDelayMs,
-		c.cfg.Stealth.TypoProbability,
-	); err != nil {
+	if err := c.typist.Type(c.page.Context(ctx), noteField, note); err != nil {
 		return err
 	}
 
-	stealth.RandomDelay(500, 1000)
-	return nil
+	return stealth.RandomDelayContext(ctx, 500, 1000)
 }
 
-func (c *Connector) clickSend() error {
-	sendButton, err := c.page.Element("button[aria-label='Send now']")
+func (c *Connector) clickSend(ctx context.Context) error {
+	sendButton, err := dom.Find(c.page, c.logger, dom.SendInvitationButton)
 	if err != nil {
-		sendButton, err = c.page.Element("button[aria-label='Send invitation']")
-		if err != nil {
-			return errors.New("send button not found")
-		}
+		return err
 	}
 
-	stealth.RandomDelay(500, 1000)
-	return stealth.HumanClick(c.page, sendButton)
+	if err := stealth.RandomDelayContext(ctx, 500, 1000); err != nil {
+		return err
+	}
+	return stealth.HumanClick(c.page.Context(ctx), sendButton)
+}
+
+// personalizeNote renders the note with what the search result shows. Notes
+// that use profile details are rendered on the profile page, where the length
+// can only be checked
+func (c *Connector) personalizeNote(template string, prospect search.Profile) string {
+	if personalize.NeedsProfile(template) {
+		return template
+	}
+	d := personalize.NewData(prospect.Name)
+	d.Title, d.Location = prospect.Title, prospect.Location
+	return c.renderNote(template, d, prospect.Name)
 }
 
-func (c *Connector) personalizeNote(template string, profile search.Profile) string {
-	note := template
-	note = strings.ReplaceAll(note, "{name}", profile.Name)
-	note = strings.ReplaceAll(note, "{title}", profile.Title)
-	note = strings.ReplaceAll(note, "{location}", profile.Location)
+// completeNote reads the open profile page, stores its details and renders
+// the note's profile fields with them
+func (c *Connector) completeNote(prospect search.Profile, note string) string {
+	var p *profile.Profile
+	if c.cfg.Profiles.Scrape || personalize.NeedsProfile(note) {
+		var err error
+		if p, err = profile.Scrape(c.page); err != nil {
+			c.logger.Warn("Failed to read profile details: %v", err)
+		} else if err := profile.Save(c.store, prospect.URL, p); err != nil {
+			c.logger.Warn("%v", err)
+		}
+	}
 
-	// Truncate if too long
-	if len(note) > c.cfg.Limits.ConnectionNoteMaxLen {
-		note = note[:c.cfg.Limits.ConnectionNoteMaxLen-3] + "..."
+	if !personalize.NeedsProfile(note) {
+		return note
 	}
+	return c.renderNote(note, personalize.NewData(prospect.Name).WithProfile(p), prospect.Name)
+}
 
+// renderNote renders the note for one profile. A note printing a field the
+// profile has no value for is replaced by limits.connection_note_fallback,
+// and one that fails to render is left out
+func (c *Connector) renderNote(template string, d personalize.Data, name string) string {
+	missing, err := personalize.Missing(template, d)
+	if err == nil && len(missing) > 0 {
+		fallback := c.cfg.Limits.ConnectionNoteFallback
+		if fallback == "" {
+			c.logger.Warn("Connection note for %s: no %s; sending without a note", name, strings.Join(missing, ", "))
+			return ""
+		}
+		c.logger.Info("Connection note for %s: no %s; sending the fallback note", name, strings.Join(missing, ", "))
+		return c.fitNote(fallback)
+	}
+	note, err := personalize.Render(template, d)
+	if err != nil {
+		c.logger.Warn("Connection note for %s: %v; sending without a note", name, err)
+		return ""
+	}
+	return c.fitNote(note)
+}
+
+// fitNote truncates the note to LinkedIn's length limit
+func (c *Connector) fitNote(note string) string {
+	limit := c.cfg.Limits.ConnectionNoteMaxLen
+	if r := []rune(note); len(r) > limit {
+		note = string(r[:limit-3]) + "..."
+	}
 	return note
 }
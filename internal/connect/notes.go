@@ -0,0 +1,116 @@
+package connect
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/go-rod/rod"
+
+	"linkedin-automation/internal/dom"
+	"linkedin-automation/internal/stealth"
+	"linkedin-automation/internal/storage"
+)
+
+// errNoteLimit means LinkedIn showed its Premium upsell instead of the note field
+var errNoteLimit = errors.New("personalized invitation allowance used up")
+
+// "You have 3 personalized invitations left this month"
+var notesLeftRe = regexp.MustCompile(`(?i)(\d+) personali[sz]ed invitations? (left|remaining)`)
+
+// noteAllowance returns how many more invitations may carry a note this
+// month, or -1 when there is no cap
+func (c *Connector) noteAllowance() (int, error) {
+	now := c.run.Now()
+	if until, err := c.store.GetStateTime(storage.StateNoteAllowanceUntil); err == nil && now.Before(until) {
+		return 0, nil
+	}
+	limit := c.cfg.Limits.MaxNotesPerMonth
+	if limit <= 0 {
+		return -1, nil
+	}
+	sent, err := c.store.GetNotesCountSince(monthStart(now))
+	if err != nil {
+		return 0, err
+	}
+	return max(0, limit-sent), nil
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// notesLeft reads the allowance counter the invitation dialog shows free
+// accounts; -1 when it is not shown
+func (c *Connector) notesLeft() int {
+	has, el, _ := c.page.HasR("[role='dialog'] *", `/\d+ personali[sz]ed invitations? (left|remaining)/i`)
+	if !has {
+		return -1
+	}
+	text, _ := el.Text()
+	m := notesLeftRe.FindStringSubmatch(text)
+	if m == nil {
+		return -1
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
+
+// NoteLimitShown detects the Premium upsell LinkedIn opens in place of the
+// note field once the month's personalized invitations are used up
+func NoteLimitShown(page *rod.Page) bool {
+	if has, _, _ := page.Has("#custom-message"); has {
+		return false
+	}
+	has, _, _ := page.HasR("[role='dialog']", "/personali[sz]ed invitations|unlimited personali[sz]ed|invitations personnalisées|personalisierte Einladungen|invitaciones personalizadas/i")
+	return has
+}
+
+// notesUsedUp records that no more notes go out until next month
+func (c *Connector) notesUsedUp() {
+	now := c.run.Now()
+	reset := monthStart(now).AddDate(0, 1, 0)
+	c.logger.Warn("Personalized invitations used up until %s; sending invitations without a note", reset.Format("Jan 2"))
+	if err := c.store.SetStateTime(storage.StateNoteAllowanceUntil, reset); err != nil {
+		c.logger.Error("Failed to persist note allowance: %v", err)
+	}
+	c.store.RecordIncident(storage.IncidentNoteLimit, "reset "+reset.Format(time.RFC3339))
+}
+
+// skipNoteUpsell dismisses the upsell and makes sure the invitation dialog
+// is open again, reopening it from the Connect button when the upsell
+// replaced it
+func (c *Connector) skipNoteUpsell(ctx context.Context) error {
+	for _, sel := range []string{
+		"[role='dialog'] button[aria-label='Dismiss']",
+		"[role='dialog'] button[aria-label='Close']",
+	} {
+		if has, btn, _ := c.page.Has(sel); has {
+			stealth.HumanClick(c.page.Context(ctx), btn)
+			break
+		}
+	}
+	if err := stealth.RandomDelayContext(ctx, 800, 1500); err != nil {
+		return err
+	}
+
+	if has, _, _ := c.page.Has(dom.SendInvitationButton.Selectors[0]); has {
+		return nil
+	}
+	if has, _, _ := c.page.Has("button[aria-label='Send without a note']"); has {
+		return nil
+	}
+
+	connectButton, err := c.findConnectButton()
+	if err != nil {
+		return err
+	}
+	if err := stealth.HumanClick(c.page.Context(ctx), connectButton); err != nil {
+		return err
+	}
+	stealth.RandomDelayContext(ctx, 1000, 2000)
+	c.hasNoteDialog()
+	return nil
+}
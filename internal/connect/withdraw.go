@@ -0,0 +1,261 @@
+package connect
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+
+	"linkedin-automation/internal/apperr"
+	"linkedin-automation/internal/dates"
+	"linkedin-automation/internal/dom"
+	"linkedin-automation/internal/logger"
+	"linkedin-automation/internal/stealth"
+	"linkedin-automation/internal/storage"
+	"linkedin-automation/internal/timing"
+)
+
+// sentInvitation is a card on the Sent invitations page
+type sentInvitation struct {
+	url  string
+	name string
+	sent time.Time
+}
+
+// WithdrawStaleInvitations withdraws the invitations pending for more than
+// olderThanDays, within the daily withdrawal limit, and marks the requests
+// the tool sent as withdrawn. Cards whose age cannot be read are left alone.
+// LinkedIn does not let a withdrawn invitation be sent again for three weeks.
+// When ctx is done it stops between withdrawals
+func (c *Connector) WithdrawStaleInvitations(ctx context.Context, olderThanDays int) (int, error) {
+	if olderThanDays <= 0 {
+		return 0, nil
+	}
+
+	done, err := c.store.GetWithdrawalsCountToday()
+	if err != nil {
+		return 0, err
+	}
+	budget := c.cfg.Limits.MaxWithdrawalsPerDay - done
+	if budget <= 0 {
+		c.logger.Warn("Daily withdrawal limit reached")
+		return 0, nil
+	}
+
+	url := strings.TrimSuffix(c.cfg.LinkedIn.BaseURL, "/") + "/mynetwork/invitation-manager/sent/"
+	c.logger.Info("Withdrawing invitations pending for more than %d days", olderThanDays)
+
+	navigated := timing.Start(timing.Navigation)
+	if err := c.page.Context(ctx).Navigate(url); err != nil {
+		return 0, apperr.Navigation(url, err)
+	}
+	if err := c.page.Context(ctx).WaitLoad(); err != nil {
+		return 0, apperr.Navigation(url, err)
+	}
+	navigated()
+	if err := stealth.RandomDelayContext(ctx, 2000, 4000); err != nil {
+		return 0, err
+	}
+
+	cutoff := c.run.Now().AddDate(0, 0, -olderThanDays)
+	withdrawn := 0
+
+	// The list is newest first and paginated, so stale invitations sit at the
+	// bottom of the last pages
+	for {
+		stale, err := c.staleInvitations(ctx, cutoff)
+		if err != nil {
+			return withdrawn, err
+		}
+
+		for _, inv := range stale {
+			if ctx.Err() != nil {
+				c.logger.Info("Stop requested; the remaining stale invitations are withdrawn next run")
+				return withdrawn, nil
+			}
+			if withdrawn >= budget {
+				c.logger.Warn("Daily withdrawal limit reached; leaving the remaining invitations pending")
+				return withdrawn, nil
+			}
+
+			if c.cfg.DryRun {
+				what := fmt.Sprintf("would withdraw the invitation sent %s", inv.sent.Local().Format("Jan 2"))
+				c.logger.Info("[dry run] %s: %s", inv.name, what)
+				if err := c.store.SavePendingAction(storage.PendingAction{
+					Action:     "withdraw",
+					ProfileURL: inv.url,
+					Name:       inv.name,
+					Detail:     what,
+				}); err != nil {
+					c.logger.Warn("%v", err)
+				}
+				withdrawn++
+				continue
+			}
+
+			if err := c.withdraw(inv); err != nil {
+				return withdrawn, err
+			}
+			if err := c.store.MarkConnectionWithdrawn(inv.url); err != nil {
+				c.logger.Error("%v", err)
+			}
+			c.logger.LogAction(logger.Action{
+				Type:       "INVITATION_WITHDRAWN",
+				Action:     "withdraw",
+				ProfileURL: inv.url,
+				Outcome:    logger.OutcomeSuccess,
+				Details: map[string]interface{}{
+					"name":    inv.name,
+					"sent_at": inv.sent.Format(time.RFC3339),
+				},
+			})
+			withdrawn++
+
+			stealth.RandomDelayContext(ctx, c.cfg.Delays.MinActionDelayMs, c.cfg.Delays.MaxActionDelayMs)
+		}
+		if ctx.Err() != nil {
+			c.logger.Info("Stop requested; the remaining stale invitations are withdrawn next run")
+			return withdrawn, nil
+		}
+
+		has, next, _ := c.page.Has(dom.NextPageButton.Selectors[0])
+		if !has {
+			break
+		}
+		if disabled, err := next.Property("disabled"); err == nil && disabled.Bool() {
+			break
+		}
+		if err := stealth.HumanClick(c.page.Context(ctx), next); err != nil {
+			return withdrawn, err
+		}
+		c.page.WaitLoad()
+		stealth.RandomDelayContext(ctx, 2000, 4000)
+	}
+
+	c.logger.Info("Withdrew %d stale invitations", withdrawn)
+	return withdrawn, nil
+}
+
+// staleInvitations scrolls the current page of sent invitations to its end
+// and returns the cards sent before cutoff, or those found so far when ctx
+// is done
+func (c *Connector) staleInvitations(ctx context.Context, cutoff time.Time) ([]sentInvitation, error) {
+	var stale []sentInvitation
+	seen := make(map[string]bool)
+
+	for unchanged := 0; unchanged < 3; {
+		cards, err := c.page.Elements("li.invitation-card")
+		if err != nil {
+			return nil, fmt.Errorf("%w: invitation cards: %v", apperr.ErrSelectorMissing, err)
+		}
+
+		before := len(seen)
+		for _, card := range cards {
+			inv, ok := readInvitation(card)
+			if !ok || seen[inv.url] {
+				continue
+			}
+			seen[inv.url] = true
+			if !inv.sent.IsZero() && inv.sent.Before(cutoff) {
+				stale = append(stale, inv)
+			}
+		}
+
+		if len(seen) == before {
+			unchanged++
+		} else {
+			unchanged = 0
+		}
+
+		stealth.HumanScroll(c.page.Context(ctx), "down", 800+c.run.Rand.Intn(600))
+		if err := stealth.RandomDelayContext(ctx, 1000, 2000); err != nil {
+			break
+		}
+	}
+
+	c.logger.Debug("%d sent invitations on the page, %d stale", len(seen), len(stale))
+	return stale, nil
+}
+
+// readInvitation reads a card's profile, name and age ("Sent 3 weeks ago")
+func readInvitation(card *rod.Element) (sentInvitation, bool) {
+	var inv sentInvitation
+
+	has, link, _ := card.Has("a[href*='/in/']")
+	if !has {
+		return inv, false
+	}
+	href, err := link.Property("href")
+	if err != nil {
+		return inv, false
+	}
+	inv.url = strings.Split(href.String(), "?")[0]
+
+	for _, sel := range []string{".invitation-card__title", ".invitation-card__tvm-title"} {
+		if has, el, _ := card.Has(sel); has {
+			text, _ := el.Text()
+			inv.name = strings.TrimSpace(text)
+			break
+		}
+	}
+	for _, sel := range []string{"time", ".time-badge"} {
+		if has, el, _ := card.Has(sel); has {
+			text, _ := el.Text()
+			inv.sent, _ = dates.Parse(text, time.Now())
+			break
+		}
+	}
+
+	return inv, inv.url != ""
+}
+
+// withdraw finds the invitation's card again, since the list re-renders
+// after every withdrawal, and withdraws it
+func (c *Connector) withdraw(inv sentInvitation) error {
+	cards, err := c.page.Elements("li.invitation-card")
+	if err != nil {
+		return fmt.Errorf("%w: invitation cards: %v", apperr.ErrSelectorMissing, err)
+	}
+
+	var button *rod.Element
+	for _, card := range cards {
+		if got, ok := readInvitation(card); !ok || got.url != inv.url {
+			continue
+		}
+		if has, el, _ := card.Has("button[aria-label^='Withdraw']"); has {
+			button = el
+		} else if has, el, _ := card.HasR("button", "/withdraw|retirer|zurückziehen|retirar|ritira|intrekken/i"); has {
+			button = el
+		}
+		break
+	}
+	if button == nil {
+		return apperr.Selector("withdraw button for " + inv.url)
+	}
+
+	stealth.ScrollToElement(c.page, button)
+	stealth.RandomDelay(500, 1000)
+	if err := stealth.HumanClick(c.page, button); err != nil {
+		return err
+	}
+
+	if _, err := dom.WaitModalOpen(c.page, dom.ModalWait); err != nil {
+		return err
+	}
+	confirm, err := dom.Find(c.page, c.logger, dom.WithdrawConfirmButton)
+	if err != nil {
+		return err
+	}
+	stealth.RandomDelay(500, 1000)
+	if err := stealth.HumanClick(c.page, confirm); err != nil {
+		return err
+	}
+
+	if toast, err := dom.WaitToast(c.page, dom.ToastWait); err == nil {
+		c.logger.Debug("LinkedIn: %s", toast)
+	}
+	c.logger.Info("Withdrew the invitation to %s", inv.name)
+	return nil
+}
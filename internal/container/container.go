@@ -0,0 +1,32 @@
+package container
+
+import (
+	"os"
+	"strings"
+)
+
+// DataDir is where persistent state lives when running inside a container,
+// so a single volume mount keeps cookies, database, and logs
+const DataDir = "/data"
+
+// Detect reports whether the process runs inside a Docker/Podman/Kubernetes container
+func Detect() bool {
+	if _, err := os.Stat("/.dockerenv"); err == nil {
+		return true
+	}
+	if _, err := os.Stat("/run/.containerenv"); err == nil {
+		return true
+	}
+	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" || os.Getenv("container") != "" {
+		return true
+	}
+
+	data, err := os.ReadFile("/proc/1/cgroup")
+	if err != nil {
+		return false
+	}
+	cgroup := string(data)
+	return strings.Contains(cgroup, "docker") ||
+		strings.Contains(cgroup, "containerd") ||
+		strings.Contains(cgroup, "kubepods")
+}
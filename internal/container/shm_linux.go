@@ -0,0 +1,17 @@
+//go:build linux
+
+package container
+
+import "syscall"
+
+// minShmBytes is the /dev/shm size below which Chromium tabs crash under load
+const minShmBytes = 512 << 20
+
+// SmallShm reports whether /dev/shm is too small for Chromium (Docker defaults to 64MB)
+func SmallShm() bool {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs("/dev/shm", &st); err != nil {
+		return true
+	}
+	return uint64(st.Blocks)*uint64(st.Bsize) < minShmBytes
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package container
+
+// SmallShm is only meaningful on Linux hosts
+func SmallShm() bool {
+	return false
+}
@@ -0,0 +1,186 @@
+// Package cooldown escalates the mandatory pause after security challenges.
+// A single challenge is handled where it appears; another within the window
+// raises the level, and each level waits longer, from hours to days. The
+// level decays one step for every stretch of incident-free days, so pacing
+// follows how the account has fared recently instead of fixed settings.
+// Everything is kept in the account's database and survives restarts
+package cooldown
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"linkedin-automation/config"
+	"linkedin-automation/internal/storage"
+)
+
+// defaultLevels are the cooldowns of each level when none are configured
+var defaultLevels = []time.Duration{6 * time.Hour, 24 * time.Hour, 72 * time.Hour, 7 * 24 * time.Hour}
+
+// quietKinds are the incidents that hold back the decay; a challenge always does
+var quietKinds = []string{
+	storage.IncidentChallenge, storage.IncidentInviteLimit, storage.IncidentSelfAudit,
+	storage.IncidentWarning, storage.IncidentRestriction, storage.IncidentCheckpoint,
+}
+
+// State is an account's escalation
+type State struct {
+	// Level is 0 until challenges repeat, and at most len(Levels)
+	Level  int
+	Levels []time.Duration
+	// Until is when the current cooldown ends
+	Until time.Time
+	// Since is when the level last changed; it decays from the later of
+	// Since and the last incident
+	Since        time.Time
+	LastIncident time.Time
+	DecayDays    int
+}
+
+// Levels returns the configured cooldowns, or the defaults when none are set
+func Levels(cfg *config.Config) []time.Duration {
+	if len(cfg.Cooldown.LevelHours) == 0 {
+		return defaultLevels
+	}
+	levels := make([]time.Duration, len(cfg.Cooldown.LevelHours))
+	for i, h := range cfg.Cooldown.LevelHours {
+		levels[i] = time.Duration(h) * time.Hour
+	}
+	return levels
+}
+
+// Load reads the escalation, dropping the level for the incident-free days
+// since it last changed
+func Load(cfg *config.Config, store *storage.Store, now time.Time) (State, error) {
+	st := State{Levels: Levels(cfg), DecayDays: cfg.Cooldown.DecayDays}
+
+	level, err := store.GetState(storage.StateCooldownLevel)
+	if err != nil {
+		return st, err
+	}
+	if level != "" {
+		if st.Level, err = strconv.Atoi(level); err != nil {
+			return st, fmt.Errorf("invalid cooldown level %q: %w", level, err)
+		}
+	}
+	st.Level = min(st.Level, len(st.Levels))
+	if st.Until, err = store.GetStateTime(storage.StateCooldownUntil); err != nil {
+		return st, err
+	}
+	if st.Since, err = store.GetStateTime(storage.StateCooldownSince); err != nil {
+		return st, err
+	}
+	if st.LastIncident, err = store.GetLastIncident(quietKinds...); err != nil {
+		return st, err
+	}
+
+	if st.Level == 0 || st.DecayDays < 1 {
+		return st, nil
+	}
+	from := st.Since
+	if st.LastIncident.After(from) {
+		from = st.LastIncident
+	}
+	step := time.Duration(st.DecayDays) * 24 * time.Hour
+	n := int(now.Sub(from) / step)
+	if n < 1 {
+		return st, nil
+	}
+	st.Level = max(st.Level-n, 0)
+	st.Since = from.Add(time.Duration(n) * step)
+	return st, st.save(store)
+}
+
+// Challenge records a security challenge. When it follows another within
+// cooldown.window_hours the level goes up one and that level's cooldown
+// starts now; escalated reports whether it did
+func Challenge(cfg *config.Config, store *storage.Store, now time.Time, detail string) (st State, escalated bool, err error) {
+	if st, err = Load(cfg, store, now); err != nil {
+		return st, false, err
+	}
+	window := time.Duration(cfg.Cooldown.WindowHours) * time.Hour
+	counts, err := store.CountIncidentsSince(now.Add(-window))
+	if err != nil {
+		return st, false, err
+	}
+	if err := store.RecordIncident(storage.IncidentChallenge, detail); err != nil {
+		return st, false, err
+	}
+	st.LastIncident = now
+	if counts[storage.IncidentChallenge] == 0 {
+		return st, false, nil
+	}
+
+	st.Level = min(st.Level+1, len(st.Levels))
+	st.Since = now
+	if until := now.Add(st.Levels[st.Level-1]); until.After(st.Until) {
+		st.Until = until
+	}
+	return st, true, st.save(store)
+}
+
+// Hold extends the cooldown to at least until without changing the level,
+// for warnings and restrictions that call for a pause of their own
+func Hold(store *storage.Store, until time.Time) error {
+	cur, err := store.GetStateTime(storage.StateCooldownUntil)
+	if err != nil || !until.After(cur) {
+		return err
+	}
+	return store.SetStateTime(storage.StateCooldownUntil, until)
+}
+
+// Reset clears the escalation, for an operator who has dealt with the cause
+func Reset(store *storage.Store, now time.Time) error {
+	st := State{Since: now}
+	return st.save(store)
+}
+
+func (st State) save(store *storage.Store) error {
+	if err := store.SetState(storage.StateCooldownLevel, strconv.Itoa(st.Level)); err != nil {
+		return err
+	}
+	if err := store.SetStateTime(storage.StateCooldownUntil, st.Until); err != nil {
+		return err
+	}
+	return store.SetStateTime(storage.StateCooldownSince, st.Since)
+}
+
+// Active reports whether a cooldown is in force at now
+func (st State) Active(now time.Time) bool {
+	return now.Before(st.Until)
+}
+
+// NextDecay is when the level drops next if no incident comes first; zero at
+// level 0
+func (st State) NextDecay() time.Time {
+	if st.Level == 0 || st.DecayDays < 1 {
+		return time.Time{}
+	}
+	from := st.Since
+	if st.LastIncident.After(from) {
+		from = st.LastIncident
+	}
+	return from.Add(time.Duration(st.DecayDays) * 24 * time.Hour)
+}
+
+func (st State) String() string {
+	if st.Level == 0 {
+		return "cooldown level 0 (no repeated challenges)"
+	}
+	return fmt.Sprintf("cooldown level %d of %d (%s after a repeated challenge)", st.Level, len(st.Levels), Describe(st.Levels[st.Level-1]))
+}
+
+// Describe renders a cooldown in whole days or hours
+func Describe(d time.Duration) string {
+	switch {
+	case d == 24*time.Hour:
+		return "1 day"
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%d days", d/(24*time.Hour))
+	case d == time.Hour:
+		return "1 hour"
+	default:
+		return fmt.Sprintf("%d hours", int(d.Hours()))
+	}
+}
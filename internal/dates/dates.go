@@ -0,0 +1,261 @@
+// Package dates reads the timestamps LinkedIn shows on posts, invitations
+// and messages: relative ones ("2w", "3 days ago", "il y a 3 jours"), words
+// like "yesterday", weekday names, clock times and dates with localized
+// month names. English, French, German, Spanish, Italian, Dutch and
+// Portuguese are understood.
+package dates
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	day   = 24 * time.Hour
+	week  = 7 * day
+	month = 30 * day
+	year  = 365 * day
+)
+
+// units maps the unit words of relative timestamps, full and abbreviated
+var units = map[string]time.Duration{}
+
+func init() {
+	for d, words := range map[time.Duration]string{
+		time.Second: "s sec secs second seconds seconde secondes sek sekunde sekunden seg segundo segundos secondo secondi seconden",
+		time.Minute: "m min mins minute minutes minuten minuto minuti minutos minuut",
+		time.Hour:   "h hr hrs hour hours heure heures std stunde stunden hora horas ora ore uur",
+		day:         "d day days j jour jours tag tage tagen día días dia dias giorno giorni dag dagen",
+		week:        "w wk wks week weeks sem semaine semaines woche wochen semana semanas settimana settimane weken",
+		month:       "mo mos mth month months mois monat monate monaten mes meses mese mesi mês maand maanden",
+		year:        "y yr yrs year years an ans année années jahr jahre jahren año años ano anos anno anni jaar jaren",
+	} {
+		for _, w := range strings.Fields(words) {
+			units[w] = d
+		}
+	}
+}
+
+// nowWords stand for the present moment
+var nowWords = []string{"now", "just now", "à l'instant", "gerade eben", "jetzt", "ahora", "adesso", "ora", "zojuist", "nu", "agora"}
+
+// Day words by how many days back they are; phrases of several words are
+// matched before single words
+var (
+	dayPhrases = map[string]int{
+		"day before yesterday": 2, "avant-hier": 2, "l'altro ieri": 2,
+	}
+	dayWords = map[string]int{
+		"today": 0, "aujourd'hui": 0, "heute": 0, "hoy": 0, "oggi": 0, "vandaag": 0, "hoje": 0,
+		"yesterday": 1, "hier": 1, "gestern": 1, "ayer": 1, "ieri": 1, "gisteren": 1, "ontem": 1,
+		"vorgestern": 2, "anteayer": 2, "eergisteren": 2, "anteontem": 2,
+	}
+)
+
+// monthNames and weekdayNames list each language in calendar order; months
+// start at January and weekdays at Sunday, as in package time
+var (
+	monthNames = []string{
+		"january february march april may june july august september october november december",
+		"janvier février mars avril mai juin juillet août septembre octobre novembre décembre",
+		"januar februar märz april mai juni juli august september oktober november dezember",
+		"enero febrero marzo abril mayo junio julio agosto septiembre octubre noviembre diciembre",
+		"gennaio febbraio marzo aprile maggio giugno luglio agosto settembre ottobre novembre dicembre",
+		"januari februari maart april mei juni juli augustus september oktober november december",
+		"janeiro fevereiro março abril maio junho julho agosto setembro outubro novembro dezembro",
+	}
+	weekdayNames = []string{
+		"sunday monday tuesday wednesday thursday friday saturday",
+		"dimanche lundi mardi mercredi jeudi vendredi samedi",
+		"sonntag montag dienstag mittwoch donnerstag freitag samstag",
+		"domingo lunes martes miércoles jueves viernes sábado",
+		"domenica lunedì martedì mercoledì giovedì venerdì sabato",
+		"zondag maandag dinsdag woensdag donderdag vrijdag zaterdag",
+		"domingo segunda terça quarta quinta sexta sábado",
+	}
+)
+
+var (
+	relativeRe = regexp.MustCompile(`(\d+)\s*(\pL+)(?:[^\pL\d]|$)`)
+	clockRe    = regexp.MustCompile(`\b(\d{1,2})(?::|h)(\d{2})\b(?:\s*([ap])\.?\s?m\b\.?)?`)
+	isoRe      = regexp.MustCompile(`\b(\d{4})-(\d{2})-(\d{2})\b`)
+)
+
+// Parse returns the time text stands for, relative to now and in now's
+// location; ok is false when text is not a timestamp Parse knows. Results
+// are as precise as the text: "2w" is two weeks before now, "Mar 5" is
+// midnight on the last March 5 that is not in the future
+func Parse(text string, now time.Time) (t time.Time, ok bool) {
+	s := strings.ToLower(strings.Join(strings.Fields(text), " "))
+	if s == "" {
+		return time.Time{}, false
+	}
+
+	if d, ok := relative(s); ok {
+		return now.Add(-d), true
+	}
+
+	// A clock time stands alone (today) or follows a day
+	rest := s
+	hour, minute, hasClock := 0, 0, false
+	if m := clockRe.FindStringSubmatchIndex(s); m != nil {
+		hour, _ = strconv.Atoi(s[m[2]:m[3]])
+		minute, _ = strconv.Atoi(s[m[4]:m[5]])
+		if m[6] >= 0 {
+			pm := s[m[6]:m[7]] == "p"
+			if hour == 12 {
+				hour = 0
+			}
+			if pm {
+				hour += 12
+			}
+		}
+		hasClock = hour < 24 && minute < 60
+		rest = s[:m[0]] + " " + s[m[1]:]
+	}
+
+	date, ok := calendarDay(rest, now)
+	if !ok {
+		if !hasClock || strings.ContainsAny(rest, "0123456789") {
+			return time.Time{}, false
+		}
+		date = midnight(now)
+	}
+	if !hasClock {
+		return date, true
+	}
+	return date.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute), true
+}
+
+// relative reads "2w", "3 days ago", "vor 2 Tagen" and the like
+func relative(s string) (time.Duration, bool) {
+	for _, w := range nowWords {
+		if s == w {
+			return 0, true
+		}
+	}
+	for _, m := range relativeRe.FindAllStringSubmatch(s, -1) {
+		unit, ok := units[m[2]]
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return 0, false
+		}
+		return time.Duration(n) * unit, true
+	}
+	return 0, false
+}
+
+// calendarDay finds the day s names: a day word, a date or a weekday
+func calendarDay(s string, now time.Time) (time.Time, bool) {
+	today := midnight(now)
+	for phrase, back := range dayPhrases {
+		if strings.Contains(s, phrase) {
+			return today.AddDate(0, 0, -back), true
+		}
+	}
+
+	if m := isoRe.FindStringSubmatch(s); m != nil {
+		if t, err := time.ParseInLocation("2006-01-02", m[0], now.Location()); err == nil {
+			return t, true
+		}
+	}
+
+	words, numbers := tokens(s)
+	for _, w := range words {
+		if back, ok := dayWords[w]; ok {
+			return today.AddDate(0, 0, -back), true
+		}
+	}
+
+	// A month name with a day number; the year defaults to the last one in
+	// which the date is not in the future
+	for _, w := range words {
+		mon, ok := lookup(monthNames, w)
+		if !ok {
+			continue
+		}
+		dom, yr := 0, 0
+		for _, n := range numbers {
+			v, _ := strconv.Atoi(n)
+			switch {
+			case len(n) == 4 && yr == 0:
+				yr = v
+			case len(n) <= 2 && v >= 1 && v <= 31 && dom == 0:
+				dom = v
+			}
+		}
+		if dom == 0 {
+			break
+		}
+		if yr == 0 {
+			yr = now.Year()
+			if time.Date(yr, time.Month(mon+1), dom, 0, 0, 0, 0, now.Location()).After(now) {
+				yr--
+			}
+		}
+		return time.Date(yr, time.Month(mon+1), dom, 0, 0, 0, 0, now.Location()), true
+	}
+
+	// A weekday is the last one before today; today itself shows a clock time
+	if len(numbers) == 0 {
+		for _, w := range words {
+			if wd, ok := lookup(weekdayNames, w); ok {
+				back := (int(now.Weekday()) - wd + 7) % 7
+				if back == 0 {
+					back = 7
+				}
+				return today.AddDate(0, 0, -back), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// lookup finds the index of the name w stands for in any of the languages:
+// the full name, or an abbreviation of at least three letters that is not
+// shared by two different names
+func lookup(languages []string, w string) (int, bool) {
+	if len([]rune(w)) < 3 {
+		return 0, false
+	}
+	found := -1
+	for _, lang := range languages {
+		for i, name := range strings.Fields(lang) {
+			if !strings.HasPrefix(name, w) {
+				continue
+			}
+			if found >= 0 && found != i {
+				return 0, false
+			}
+			found = i
+		}
+	}
+	return found, found >= 0
+}
+
+// tokens splits s into words and runs of digits
+func tokens(s string) (words, numbers []string) {
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	}) {
+		word := strings.TrimFunc(f, unicode.IsDigit)
+		if word != "" && strings.IndexFunc(word, unicode.IsDigit) < 0 {
+			words = append(words, word)
+		}
+		if num := strings.TrimFunc(f, unicode.IsLetter); num != "" && strings.IndexFunc(num, unicode.IsLetter) < 0 {
+			numbers = append(numbers, num)
+		}
+	}
+	return words, numbers
+}
+
+func midnight(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,144 @@
+package dom
+
+// Control describes a critical page control: the CSS selectors that normally
+// find it and the accessible names it carries in the UI languages LinkedIn ships
+type Control struct {
+	Name      string
+	Selectors []string
+	// Labels are matched against aria-label and visible text when every selector fails
+	Labels []string
+	// Scope limits the text search to a container, e.g. the open dialog;
+	// several may be given separated by commas
+	Scope string
+}
+
+var (
+	ConnectButton = Control{
+		Name: "connect button",
+		// Only the profile's top card: the "People you may know" and "People
+		// also viewed" cards carry Connect buttons for other people
+		Selectors: []string{
+			".pvs-profile-actions button[aria-label*='Invite']",
+			".pv-top-card button[aria-label*='Invite']",
+			".pvs-profile-actions button[aria-label*='Connect']",
+			".pv-top-card button[aria-label*='Connect']",
+			".pvs-profile-actions button:has-text('Connect')",
+		},
+		Labels: []string{
+			"Connect", "Se connecter", "Vernetzen", "Conectar", "Collegati", "Connectie maken",
+		},
+		Scope: ".pvs-profile-actions, .pv-top-card, main section.artdeco-card:first-of-type",
+	}
+
+	AddNoteButton = Control{
+		Name:      "add note button",
+		Selectors: []string{"button[aria-label='Add a note']"},
+		Labels: []string{
+			"Add a note", "Ajouter une note", "Notiz hinzufügen", "Añadir una nota", "Adicionar nota", "Aggiungi una nota",
+		},
+		Scope: "[role='dialog']",
+	}
+
+	SendInvitationButton = Control{
+		Name: "send invitation button",
+		Selectors: []string{
+			"button[aria-label='Send now']",
+			"button[aria-label='Send invitation']",
+			"button[aria-label='Send without a note']",
+		},
+		Labels: []string{
+			"Send", "Send now", "Send invitation", "Envoyer", "Senden", "Enviar", "Invia", "Verzenden",
+		},
+		Scope: "[role='dialog']",
+	}
+
+	// WithdrawConfirmButton confirms withdrawing a sent invitation
+	WithdrawConfirmButton = Control{
+		Name: "withdraw confirm button",
+		Selectors: []string{
+			"[role='dialog'] button.artdeco-modal__confirm-dialog-btn.artdeco-button--primary",
+			"[role='dialog'] button.artdeco-button--primary",
+		},
+		Labels: []string{
+			"Withdraw", "Retirer", "Zurückziehen", "Retirar", "Ritira", "Intrekken",
+		},
+		Scope: "[role='dialog']",
+	}
+
+	MessageSendButton = Control{
+		Name:      "message send button",
+		Selectors: []string{"button[type='submit']"},
+		Labels: []string{
+			"Send", "Envoyer", "Senden", "Enviar", "Invia", "Verzenden",
+		},
+		Scope: ".msg-form",
+	}
+
+	NextPageButton = Control{
+		Name:      "next page button",
+		Selectors: []string{"button[aria-label='Next']"},
+		Labels: []string{
+			"Next", "Suivant", "Weiter", "Siguiente", "Próximo", "Avanti", "Volgende",
+		},
+	}
+)
+
+var (
+	FollowButton = Control{
+		Name: "follow button",
+		Selectors: []string{
+			"main button[aria-label^='Follow']",
+			".org-top-card button.follow",
+		},
+		Labels: []string{
+			"Follow", "Suivre", "Folgen", "Seguir", "Segui", "Volgen",
+		},
+	}
+
+	UnfollowButton = Control{
+		Name: "unfollow button",
+		Selectors: []string{
+			"main button[aria-label^='Unfollow']",
+			"main button[aria-label^='Following']",
+		},
+		Labels: []string{
+			"Following", "Unfollow", "Abonné", "Ne plus suivre", "Gefolgt", "Nicht mehr folgen", "Siguiendo", "Dejar de seguir",
+		},
+	}
+
+	// MoreActionsButton opens the profile overflow menu, where Follow sits
+	// when Connect is the primary action
+	MoreActionsButton = Control{
+		Name: "more actions button",
+		Selectors: []string{
+			"main button[aria-label='More actions']",
+		},
+		Labels: []string{
+			"More", "More actions", "Plus", "Mehr", "Más", "Altro", "Meer",
+		},
+	}
+)
+
+var (
+	EventInviteButton = Control{
+		Name: "event invite button",
+		Selectors: []string{
+			"button[aria-label*='Invite']",
+			".events-top-card__actions button:has-text('Invite')",
+		},
+		Labels: []string{
+			"Invite", "Share and invite", "Inviter", "Einladen", "Invitar", "Invita", "Uitnodigen",
+		},
+	}
+
+	EventInviteSendButton = Control{
+		Name: "event invite send button",
+		Selectors: []string{
+			"[role='dialog'] button.artdeco-button--primary",
+		},
+		Labels: []string{
+			"Invite", "Send", "Inviter", "Einladen", "Invitar", "Invita", "Uitnodigen",
+		},
+		Scope: "[role='dialog']",
+	}
+)
@@ -0,0 +1,163 @@
+package dom
+
+import (
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-rod/rod"
+
+	"linkedin-automation/internal/apperr"
+	"linkedin-automation/internal/logger"
+)
+
+// selectorWait is how long the first selector may wait for the page to render
+const selectorWait = 5 * time.Second
+
+// Find locates a control by its selectors and, when they all fail, by fuzzy
+// matching the accessible names of visible buttons
+func Find(page *rod.Page, log *logger.Logger, c Control) (*rod.Element, error) {
+	for i, sel := range c.Selectors {
+		// Give the page time to render once, then probe the rest without waiting
+		if i == 0 {
+			if el, err := page.Timeout(selectorWait).Element(sel); err == nil {
+				return el.CancelTimeout(), nil
+			}
+			continue
+		}
+		if has, el, _ := page.Has(sel); has {
+			return el, nil
+		}
+	}
+
+	el, name, err := FindByText(page, c)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Warn("Selector fallback: %s matched by accessible name %q", c.Name, name)
+	log.Info("Consider adding this selector for the %s: %s", c.Name, proposeSelector(el, name))
+	return el, nil
+}
+
+// FindByText searches visible, enabled buttons for the best label match and
+// returns the element with the name that matched
+func FindByText(page *rod.Page, c Control) (*rod.Element, string, error) {
+	query := "button, [role='button']"
+	if c.Scope != "" {
+		var parts []string
+		for _, scope := range strings.Split(c.Scope, ",") {
+			scope = strings.TrimSpace(scope)
+			parts = append(parts, scope+" button", scope+" [role='button']")
+		}
+		query = strings.Join(parts, ", ")
+	}
+
+	candidates, err := page.Elements(query)
+	if err != nil {
+		return nil, "", apperr.Selector(c.Name)
+	}
+
+	var best *rod.Element
+	var bestName string
+	bestScore := 0
+
+	for _, el := range candidates {
+		if visible, err := el.Visible(); err != nil || !visible {
+			continue
+		}
+		if disabled, err := el.Property("disabled"); err == nil && disabled.Bool() {
+			continue
+		}
+
+		for _, name := range accessibleNames(el) {
+			for _, label := range c.Labels {
+				if score := matchScore(name, label); score > bestScore {
+					best, bestName, bestScore = el, name, score
+				}
+			}
+		}
+	}
+
+	if best == nil {
+		return nil, "", apperr.Selector(c.Name)
+	}
+	return best, bestName, nil
+}
+
+func accessibleNames(el *rod.Element) []string {
+	var names []string
+	if label, err := el.Attribute("aria-label"); err == nil && label != nil && *label != "" {
+		names = append(names, *label)
+	}
+	if text, err := el.Text(); err == nil && strings.TrimSpace(text) != "" {
+		names = append(names, text)
+	}
+	return names
+}
+
+// matchScore rates how well an accessible name matches a label:
+// 4 exact, 3 leading words, 2 contained words, 1 within a small edit distance
+func matchScore(name, label string) int {
+	n, l := normalize(name), normalize(label)
+	if n == "" || l == "" {
+		return 0
+	}
+
+	switch {
+	case n == l:
+		return 4
+	case strings.HasPrefix(n, l+" "):
+		return 3
+	case strings.Contains(" "+n+" ", " "+l+" "):
+		return 2
+	}
+
+	// Short labels like "Send" must not fuzzily match "Sent"
+	if len([]rune(l)) >= 6 && levenshtein(n, l) <= len([]rune(l))/6 {
+		return 1
+	}
+	return 0
+}
+
+// normalize lowercases, drops punctuation and collapses whitespace
+func normalize(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+// proposeSelector suggests a stable selector for the element the fallback found
+func proposeSelector(el *rod.Element, name string) string {
+	if label, err := el.Attribute("aria-label"); err == nil && label != nil && *label != "" {
+		return "button[aria-label='" + strings.ReplaceAll(*label, "'", "\\'") + "']"
+	}
+	return `ElementR("button", "^` + regexp.QuoteMeta(strings.TrimSpace(name)) + `$")`
+}
@@ -0,0 +1,32 @@
+package dom
+
+import "testing"
+
+func TestMatchScore(t *testing.T) {
+	cases := []struct {
+		name, label string
+		want        int
+	}{
+		{"Connect", "connect", 4},
+		{"  Send   now! ", "send now", 4},
+		{"Send without a note", "send", 3},
+		{"Invite Ada Lovelace to connect", "connect", 2},
+		{"Invite Ada to connect now", "to connect", 2},
+		{"Messsage", "message", 1},
+		{"Massaje", "message", 0},
+		{"Withdraww", "withdraw", 1},
+		{"Add a notte", "add a note", 1},
+		// Short labels only match whole words
+		{"Sent", "send", 0},
+		{"Disconnect", "connect", 0},
+		{"Connected", "connect", 0},
+		{"", "connect", 0},
+		{"Connect", "", 0},
+		{"!!!", "connect", 0},
+	}
+	for _, c := range cases {
+		if got := matchScore(c.name, c.label); got != c.want {
+			t.Errorf("matchScore(%q, %q) = %d, want %d", c.name, c.label, got, c.want)
+		}
+	}
+}
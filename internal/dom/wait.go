@@ -0,0 +1,127 @@
+package dom
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+
+	"linkedin-automation/internal/apperr"
+)
+
+// Default waits for the helpers below
+const (
+	ControlWait = 10 * time.Second
+	ModalWait   = 8 * time.Second
+	ToastWait   = 6 * time.Second
+)
+
+const pollInterval = 250 * time.Millisecond
+
+// modalSelector matches LinkedIn's dialogs, including ones without a role
+const modalSelector = "[role='dialog'], [role='alertdialog'], .artdeco-modal"
+
+// toastSelector matches the confirmation toasts shown after sends and invites
+const toastSelector = ".artdeco-toast-item, [data-test-artdeco-toast-item-type]"
+
+// readyJS reports why an element cannot be interacted with yet, or "" when it
+// can: it must be attached, rendered, enabled, and the topmost element at its
+// centre (not covered by an overlay or a closing modal)
+const readyJS = `function () {
+	if (!this.isConnected) return 'detached';
+	const style = getComputedStyle(this);
+	if (style.visibility === 'hidden' || style.display === 'none' || Number(style.opacity) === 0) return 'hidden';
+	const r = this.getBoundingClientRect();
+	if (r.width === 0 || r.height === 0) return 'hidden';
+	if (this.disabled || this.getAttribute('aria-disabled') === 'true') return 'disabled';
+	if (r.bottom < 0 || r.top > innerHeight) return '';
+	const x = Math.min(Math.max(r.left + r.width / 2, 0), innerWidth - 1);
+	const y = Math.min(Math.max(r.top + r.height / 2, 0), innerHeight - 1);
+	const top = document.elementFromPoint(x, y);
+	if (top && top !== this && !this.contains(top) && !top.contains(this)) return 'covered';
+	return '';
+}`
+
+// notReady returns why el is not interactable, or "" when it is
+func notReady(el *rod.Element) string {
+	res, err := el.Eval(readyJS)
+	if err != nil {
+		return "detached"
+	}
+	return res.Value.Str()
+}
+
+// WaitVisibleEnabled waits until an element matching selector is attached,
+// visible, enabled and not covered, and returns it. Off-screen elements count
+// as ready, since callers scroll them into view before clicking
+func WaitVisibleEnabled(page *rod.Page, selector string, timeout time.Duration) (*rod.Element, error) {
+	deadline := time.Now().Add(timeout)
+	reason := "missing"
+
+	for {
+		if els, err := page.Elements(selector); err == nil {
+			for _, el := range els {
+				if reason = notReady(el); reason == "" {
+					return el, nil
+				}
+			}
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w: %s (%s)", apperr.ErrSelectorMissing, selector, reason)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// WaitModalOpen waits for a dialog to be shown and finish its opening
+// animation, and returns it
+func WaitModalOpen(page *rod.Page, timeout time.Duration) (*rod.Element, error) {
+	deadline := time.Now().Add(timeout)
+	var last string
+
+	for {
+		if els, err := page.Elements(modalSelector); err == nil {
+			for _, el := range els {
+				if visible, err := el.Visible(); err != nil || !visible {
+					continue
+				}
+				// The dialog has settled once its box stops moving between polls
+				box := boxOf(el)
+				if box != "" && box == last {
+					return el, nil
+				}
+				last = box
+				break
+			}
+		}
+		if time.Now().After(deadline) {
+			return nil, apperr.Selector("modal dialog")
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// WaitToast waits for a confirmation toast and returns its text
+func WaitToast(page *rod.Page, timeout time.Duration) (string, error) {
+	el, err := WaitVisibleEnabled(page, toastSelector, timeout)
+	if err != nil {
+		return "", apperr.Selector("confirmation toast")
+	}
+	text, err := el.Text()
+	if err != nil {
+		return "", fmt.Errorf("failed to read toast: %w", err)
+	}
+	return strings.TrimSpace(text), nil
+}
+
+func boxOf(el *rod.Element) string {
+	res, err := el.Eval(`function () {
+		const r = this.getBoundingClientRect();
+		return [r.left, r.top, r.width, r.height].map(Math.round).join(',');
+	}`)
+	if err != nil {
+		return ""
+	}
+	return res.Value.Str()
+}
@@ -0,0 +1,277 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-rod/rod"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+
+	"linkedin-automation/config"
+	"linkedin-automation/internal/apperr"
+	"linkedin-automation/internal/compliance"
+	"linkedin-automation/internal/dom"
+	"linkedin-automation/internal/logger"
+	"linkedin-automation/internal/network"
+	"linkedin-automation/internal/queue"
+	"linkedin-automation/internal/risk"
+	"linkedin-automation/internal/stealth"
+	"linkedin-automation/internal/storage"
+	"linkedin-automation/internal/throttle"
+)
+
+// Inviter invites 1st-degree connections to an event the account hosts,
+// through the event's own invite dialog
+type Inviter struct {
+	page       *rod.Page
+	cfg        *config.Config
+	logger     *logger.Logger
+	store      *storage.Store
+	throttle   *throttle.Coordinator
+	compliance *compliance.Profile
+	typist     stealth.TypingProfile
+}
+
+func New(page *rod.Page, cfg *config.Config, log *logger.Logger, store *storage.Store) *Inviter {
+	return &Inviter{
+		page:       page,
+		cfg:        cfg,
+		logger:     log,
+		store:      store,
+		throttle:   throttle.New(cfg, log, store),
+		compliance: compliance.ForConfig(cfg),
+		typist: stealth.TypingProfileFor(cfg.Stealth.TypingProfile, cfg.Creds.Email,
+			cfg.Delays.MinTypingDelayMs, cfg.Delays.MaxTypingDelayMs, cfg.Stealth.TypoProbability),
+	}
+}
+
+// SetPage points the inviter at a new page after the browser is relaunched
+func (i *Inviter) SetPage(page *rod.Page) {
+	i.page = page
+}
+
+// EnqueueInvites queues an invite to eventURL for every connection not invited yet
+func (i *Inviter) EnqueueInvites(eventURL string, conns []network.Connection, campaign string) (int, error) {
+	queued := 0
+	for _, conn := range conns {
+		invited, err := i.store.IsEventInvited(eventURL, conn.URL)
+		if err != nil {
+			return queued, err
+		}
+		if invited {
+			continue
+		}
+
+		if err := i.store.Enqueue(storage.QueueItem{
+			Campaign:   campaign,
+			Action:     storage.QueueActionEventInvite,
+			ProfileURL: conn.URL,
+			Name:       conn.Name,
+			Payload:    eventURL,
+			Source:     "connections",
+		}); err != nil {
+			return queued, err
+		}
+		queued++
+	}
+
+	i.logger.Info("Queued %d event invites", queued)
+	return queued, nil
+}
+
+// ProcessQueueItem invites a single queued connection to the event in the payload
+func (i *Inviter) ProcessQueueItem(ctx context.Context, item storage.QueueItem) error {
+	eventURL := item.Payload
+
+	count, err := i.store.GetEventInvitesCountToday()
+	if err != nil {
+		return err
+	}
+	if count >= i.cfg.Limits.MaxEventInvitesPerDay {
+		i.logger.Warn("Daily event invite limit reached")
+		return fmt.Errorf("%w: daily event invite limit reached", apperr.ErrRateLimited)
+	}
+
+	if invited, err := i.store.IsEventInvited(eventURL, item.ProfileURL); err != nil || invited {
+		return err
+	}
+
+	if i.compliance.HonorDoNotContact {
+		dnc, err := i.store.IsDoNotContact(item.ProfileURL)
+		if err != nil {
+			// Fail closed: never contact someone we cannot verify
+			i.logger.Error("Failed to check the do-not-contact list for %s: %v", item.Name, err)
+			return fmt.Errorf("failed to check do-not-contact: %w", err)
+		}
+		if dnc {
+			i.logger.Info("%s is on the do-not-contact list, skipping event invite", item.Name)
+			return fmt.Errorf("%w: %s is on the do-not-contact list", queue.ErrSkipped, item.Name)
+		}
+	}
+
+	if err := i.throttle.Acquire(ctx); err != nil {
+		return fmt.Errorf("failed to acquire throttle: %w", err)
+	}
+	defer i.throttle.Release()
+
+	i.logger.Info("Inviting %s to the event", item.Name)
+
+	alreadyInvited, err := i.invite(eventURL, item.Name)
+	if err != nil {
+		i.closeDialog()
+		return err
+	}
+
+	if alreadyInvited && i.cfg.DryRun {
+		return queue.Planned("record that %s was already invited", item.Name)
+	}
+	if err := i.store.SaveEventInvite(eventURL, item.ProfileURL, item.Name); err != nil {
+		i.logger.Error("Failed to save event invite: %v", err)
+	}
+
+	if alreadyInvited {
+		i.logger.Info("%s was already invited", item.Name)
+		return nil
+	}
+
+	i.logger.LogAction(logger.Action{
+		Type:       "EVENT_INVITE_SENT",
+		Action:     item.Action,
+		ProfileURL: item.ProfileURL,
+		Campaign:   item.Campaign,
+		Duration:   queue.Elapsed(ctx),
+		Outcome:    logger.OutcomeSuccess,
+		Details: risk.Annotate(i.store, i.cfg, item.Action, map[string]interface{}{
+			"name":  item.Name,
+			"event": eventURL,
+		}),
+	})
+	return nil
+}
+
+// invite runs the search-and-check flow in the event's invite dialog
+func (i *Inviter) invite(eventURL, name string) (alreadyInvited bool, err error) {
+	if info, err := i.page.Info(); err != nil || !strings.HasPrefix(info.URL, eventURL) {
+		if err := i.page.Navigate(eventURL); err != nil {
+			return false, apperr.Navigation(eventURL, err)
+		}
+		i.page.WaitLoad()
+		stealth.RandomDelay(2000, 4000)
+	}
+
+	inviteBtn, err := dom.Find(i.page, i.logger, dom.EventInviteButton)
+	if err != nil {
+		return false, err
+	}
+	if err := stealth.HumanClick(i.page, inviteBtn); err != nil {
+		return false, err
+	}
+	stealth.RandomDelay(1500, 3000)
+
+	// Search the dialog for the connection by name
+	has, searchBox, _ := i.page.Has("[role='dialog'] input[type='text']")
+	if !has {
+		return false, apperr.Selector("event invite search box")
+	}
+	if err := stealth.HumanClick(i.page, searchBox); err != nil {
+		return false, err
+	}
+	if err := i.typist.WithoutTypos().Type(i.page, searchBox, name); err != nil {
+		return false, err
+	}
+	stealth.RandomDelay(1500, 3000)
+
+	// Only check a result whose name is unambiguous
+	results, err := i.page.Elements("[role='dialog'] li")
+	if err != nil {
+		return false, apperr.Selector("event invite results")
+	}
+
+	var match *rod.Element
+	for _, r := range results {
+		text, _ := r.Text()
+		if !mentions(text, name) {
+			continue
+		}
+		if match != nil {
+			return false, fmt.Errorf("several invite results match %q; invite manually", name)
+		}
+		match = r
+	}
+	if match == nil {
+		return false, fmt.Errorf("%w: %s not offered in the event invite dialog", apperr.ErrNotConnected, name)
+	}
+
+	has, checkbox, _ := match.Has("input[type='checkbox']")
+	if !has {
+		return false, apperr.Selector("event invite checkbox")
+	}
+
+	// LinkedIn disables the checkbox for people already invited
+	if disabled, err := checkbox.Property("disabled"); err == nil && disabled.Bool() {
+		i.closeDialog()
+		return true, nil
+	}
+	if i.cfg.DryRun {
+		return false, queue.Planned("invite %s to %s", name, eventURL)
+	}
+
+	if err := stealth.HumanClick(i.page, match); err != nil {
+		return false, err
+	}
+	stealth.RandomDelay(800, 1500)
+
+	sendBtn, err := dom.Find(i.page, i.logger, dom.EventInviteSendButton)
+	if err != nil {
+		return false, err
+	}
+	if err := stealth.HumanClick(i.page, sendBtn); err != nil {
+		return false, err
+	}
+	stealth.RandomDelay(1500, 3000)
+	return false, nil
+}
+
+func (i *Inviter) closeDialog() {
+	if has, btn, _ := i.page.Has("[role='dialog'] button[aria-label='Dismiss']"); has {
+		stealth.HumanClick(i.page, btn)
+	}
+}
+
+// mentions reports whether text contains name as whole words. Both are
+// NFC-normalized and case-folded, and the boundaries are Unicode letters
+// and digits, so "José" or "Émile Zola" match however the accents were
+// composed
+func mentions(text, name string) bool {
+	t, n := fold(text), fold(strings.TrimSpace(name))
+	if n == "" {
+		return false
+	}
+	for i := 0; i < len(t); {
+		j := strings.Index(t[i:], n)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(n)
+		before, _ := utf8.DecodeLastRuneInString(t[:start])
+		after, _ := utf8.DecodeRuneInString(t[end:])
+		if !inWord(before) && !inWord(after) {
+			return true
+		}
+		_, size := utf8.DecodeRuneInString(t[start:])
+		i = start + size
+	}
+	return false
+}
+
+func fold(s string) string {
+	return cases.Fold().String(norm.NFC.String(s))
+}
+
+func inWord(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
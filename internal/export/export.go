@@ -0,0 +1,66 @@
+// Package export writes stored tables as CSV, JSON or XLSX for analysis in
+// spreadsheets and CRMs.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Formats are the supported output formats
+var Formats = []string{"csv", "json", "xlsx"}
+
+// Table is one exported table
+type Table struct {
+	Name    string
+	Columns []string
+	Rows    [][]string
+}
+
+// WriteCSV writes t with a header row. Cells a spreadsheet would read as a
+// formula are prefixed with a quote, since names and notes come from LinkedIn
+func WriteCSV(w io.Writer, t Table) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(t.Columns); err != nil {
+		return err
+	}
+	for _, row := range t.Rows {
+		safe := make([]string, len(row))
+		for i, v := range row {
+			safe[i] = defuse(v)
+		}
+		if err := cw.Write(safe); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func defuse(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+// WriteJSON writes t as an array of objects keyed by column
+func WriteJSON(w io.Writer, t Table) error {
+	records := make([]map[string]string, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		r := make(map[string]string, len(t.Columns))
+		for i, c := range t.Columns {
+			r[c] = row[i]
+		}
+		records = append(records, r)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(records); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", t.Name, err)
+	}
+	return nil
+}
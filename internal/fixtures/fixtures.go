@@ -0,0 +1,193 @@
+// Package fixtures replays saved LinkedIn pages through the extractors and
+// compares what they read with golden files, so selector and parser changes
+// can be verified without touching the live site.
+//
+// Snapshots live in <dir>/<extractor>/<case>.html next to the expected
+// output in <case>.golden.json. Page extractors read the rendered DOM, so
+// pages are saved as HTML; save them with absolute link URLs, since the
+// document is loaded without LinkedIn's origin. Voyager API responses are
+// saved as <case>.json under the directory of the decoder a test supplies
+// for them, and need no browser.
+package fixtures
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-rod/rod"
+
+	"linkedin-automation/internal/connect"
+	"linkedin-automation/internal/message"
+	"linkedin-automation/internal/network"
+	"linkedin-automation/internal/profile"
+	"linkedin-automation/internal/search"
+	"linkedin-automation/internal/selfaudit"
+)
+
+// DefaultDir is where the snapshots are kept in the source tree
+const DefaultDir = "internal/fixtures/testdata"
+
+// Extractor reads structured data from the page currently loaded
+type Extractor func(page *rod.Page) (interface{}, error)
+
+// Extractors maps fixture directory names to the code under test
+var Extractors = map[string]Extractor{
+	"search_results": func(page *rod.Page) (interface{}, error) {
+		profiles, _, err := search.ParseResults(page)
+		return profiles, err
+	},
+	"connections": func(page *rod.Page) (interface{}, error) {
+		return network.ParseConnections(page)
+	},
+	"profile": func(page *rod.Page) (interface{}, error) {
+		return profile.Scrape(page)
+	},
+	"own_profile": func(page *rod.Page) (interface{}, error) {
+		return selfaudit.Scrape(page)
+	},
+	"invite_limit_modal": func(page *rod.Page) (interface{}, error) {
+		return connect.InviteLimitShown(page), nil
+	},
+	"inbox_thread": func(page *rod.Page) (interface{}, error) {
+		return message.ReadThread(page)
+	},
+}
+
+// Decoder reads structured data from a saved voyager response
+type Decoder func(data []byte) (interface{}, error)
+
+// Result is the outcome of replaying one snapshot
+type Result struct {
+	Extractor string
+	Case      string
+	Passed    bool
+	Updated   bool
+	// Diff shows the expected and actual output when the case failed
+	Diff string
+	Err  error
+}
+
+// Verify replays every page snapshot under dir; with update set, golden
+// files are rewritten from the current output instead of compared
+func Verify(page *rod.Page, dir string, update bool) ([]Result, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*", "*.html"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fixtures: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no fixtures found in %s", dir)
+	}
+	return verify(files, update, func(name, file string) ([]byte, error) {
+		extract, ok := Extractors[name]
+		if !ok {
+			return nil, fmt.Errorf("no extractor named %q", name)
+		}
+		return replay(page, file, extract)
+	})
+}
+
+// VerifyPayloads runs the voyager snapshots under dir through decoders, keyed
+// by directory name like Extractors
+func VerifyPayloads(dir string, decoders map[string]Decoder, update bool) ([]Result, error) {
+	all, err := filepath.Glob(filepath.Join(dir, "*", "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fixtures: %w", err)
+	}
+	var files []string
+	for _, file := range all {
+		if !strings.HasSuffix(file, goldenExt) {
+			files = append(files, file)
+		}
+	}
+	return verify(files, update, func(name, file string) ([]byte, error) {
+		decode, ok := decoders[name]
+		if !ok {
+			return nil, fmt.Errorf("no decoder named %q", name)
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture: %w", err)
+		}
+		out, err := decode(data)
+		if err != nil {
+			return nil, err
+		}
+		return encode(out)
+	})
+}
+
+const goldenExt = ".golden.json"
+
+// verify compares run's output for each snapshot with its golden file; run
+// gets the snapshot's directory name and path
+func verify(files []string, update bool, run func(name, file string) ([]byte, error)) ([]Result, error) {
+	sort.Strings(files)
+
+	var results []Result
+	for _, file := range files {
+		base := strings.TrimSuffix(file, filepath.Ext(file))
+		name := filepath.Base(filepath.Dir(file))
+		res := Result{Extractor: name, Case: filepath.Base(base)}
+
+		got, err := run(name, file)
+		if err != nil {
+			res.Err = err
+			results = append(results, res)
+			continue
+		}
+
+		golden := base + goldenExt
+		if update {
+			if err := os.WriteFile(golden, got, 0644); err != nil {
+				return results, fmt.Errorf("failed to write golden file: %w", err)
+			}
+			res.Passed, res.Updated = true, true
+			results = append(results, res)
+			continue
+		}
+
+		want, err := os.ReadFile(golden)
+		if err != nil {
+			res.Err = fmt.Errorf("missing golden file (run with -update to create it): %w", err)
+			results = append(results, res)
+			continue
+		}
+		res.Passed = bytes.Equal(bytes.TrimSpace(want), bytes.TrimSpace(got))
+		if !res.Passed {
+			res.Diff = fmt.Sprintf("want:\n%s\ngot:\n%s", want, got)
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// replay loads a snapshot into the page and returns the extractor's output as indented JSON
+func replay(page *rod.Page, file string, extract Extractor) ([]byte, error) {
+	html, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+	if err := page.SetDocumentContent(string(html)); err != nil {
+		return nil, fmt.Errorf("failed to load fixture: %w", err)
+	}
+
+	out, err := extract(page)
+	if err != nil {
+		return nil, err
+	}
+	return encode(out)
+}
+
+// encode returns out as the indented JSON golden files hold
+func encode(out interface{}) ([]byte, error) {
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode output: %w", err)
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,66 @@
+package fixtures
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files from the current output")
+
+const testDir = "testdata"
+
+func TestPayloads(t *testing.T) {
+	results, err := VerifyPayloads(testDir, decoders, *update)
+	check(t, results, err)
+}
+
+// The page extractors run scripts in the rendered DOM, so they need Chrome
+func TestPages(t *testing.T) {
+	if testing.Short() {
+		t.Skip("page fixtures need a browser")
+	}
+	bin, ok := launcher.LookPath()
+	if !ok {
+		t.Skip("no browser found for the page fixtures")
+	}
+	u, err := launcher.New().Bin(bin).Headless(true).Launch()
+	if err != nil {
+		t.Skipf("failed to start the browser: %v", err)
+	}
+	browser := rod.New().ControlURL(u)
+	if err := browser.Connect(); err != nil {
+		t.Skipf("failed to connect to the browser: %v", err)
+	}
+	defer browser.Close()
+
+	page, err := browser.Page(proto.TargetCreateTarget{URL: "about:blank"})
+	if err != nil {
+		t.Fatalf("failed to open a page: %v", err)
+	}
+	results, err := Verify(page, testDir, *update)
+	check(t, results, err)
+}
+
+func check(t *testing.T, results []Result, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) == 0 {
+		t.Fatalf("no fixtures found in %s", testDir)
+	}
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			t.Errorf("%s/%s: %v", r.Extractor, r.Case, r.Err)
+		case r.Updated:
+			t.Logf("%s/%s: golden file updated", r.Extractor, r.Case)
+		case !r.Passed:
+			t.Errorf("%s/%s: output differs from the golden file\n%s", r.Extractor, r.Case, r.Diff)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package fixtures
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"linkedin-automation/internal/profile"
+	"linkedin-automation/internal/search"
+)
+
+// decoders read the saved voyager responses into the types the page
+// extractors return, so both kinds of snapshot share one golden format
+var decoders = map[string]Decoder{
+	"search_results_voyager": func(data []byte) (interface{}, error) {
+		return parseVoyagerResults(data)
+	},
+	"profile_voyager": func(data []byte) (interface{}, error) {
+		return parseVoyagerProfile(data)
+	},
+}
+
+// voyagerResponse is a normalized voyager response: the requested data plus
+// the entities it references, each tagged with its $type
+type voyagerResponse struct {
+	Data     json.RawMessage   `json:"data"`
+	Included []json.RawMessage `json:"included"`
+}
+
+type (
+	voyagerText struct {
+		Text string `json:"text"`
+	}
+	// voyagerDate is a partial date; Month is 0 when only the year is known
+	voyagerDate struct {
+		Month int `json:"month"`
+		Year  int `json:"year"`
+	}
+	// voyagerPeriod is a start and optional end date; no end means ongoing
+	voyagerPeriod struct {
+		StartDate *voyagerDate `json:"startDate"`
+		EndDate   *voyagerDate `json:"endDate"`
+	}
+)
+
+// included decodes the included entities whose $type ends in typ, in the
+// order the response lists them
+func included[T any](data []byte, typ string) ([]T, error) {
+	var r voyagerResponse
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to decode voyager response: %w", err)
+	}
+	var out []T
+	for _, raw := range r.Included {
+		var e struct {
+			Type string `json:"$type"`
+		}
+		if err := json.Unmarshal(raw, &e); err != nil {
+			return nil, fmt.Errorf("failed to decode voyager entity: %w", err)
+		}
+		if !strings.HasSuffix(e.Type, "."+typ) {
+			continue
+		}
+		var v T
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, fmt.Errorf("failed to decode voyager %s: %w", typ, err)
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+var months = []string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"}
+
+// String formats the period like the profile page does, "Jan 2021 - Present"
+// or "2015 - 2020"
+func (p *voyagerPeriod) String() string {
+	if p == nil || p.StartDate == nil {
+		return ""
+	}
+	end := "Present"
+	if p.EndDate != nil {
+		end = p.EndDate.String()
+	}
+	return p.StartDate.String() + " - " + end
+}
+
+func (d voyagerDate) String() string {
+	if d.Month >= 1 && d.Month <= 12 {
+		return fmt.Sprintf("%s %d", months[d.Month-1], d.Year)
+	}
+	return fmt.Sprint(d.Year)
+}
+
+type voyagerResult struct {
+	Title             voyagerText `json:"title"`
+	PrimarySubtitle   voyagerText `json:"primarySubtitle"`
+	SecondarySubtitle voyagerText `json:"secondarySubtitle"`
+	NavigationURL     string      `json:"navigationUrl"`
+	Image             struct {
+		AccessibilityText string `json:"accessibilityText"`
+	} `json:"image"`
+	Insights []struct {
+		SimpleInsight struct {
+			Title voyagerText `json:"title"`
+		} `json:"simpleInsight"`
+	} `json:"insightsResolutionResults"`
+}
+
+var mutuals = regexp.MustCompile(`(\d+)\s+(other\s+)?mutual`)
+
+// parseVoyagerResults reads the people in a search results response, leaving
+// out anonymous members and non-profile cards as search.ParseResults does
+func parseVoyagerResults(data []byte) ([]search.Profile, error) {
+	results, err := included[voyagerResult](data, "EntityResultViewModel")
+	if err != nil {
+		return nil, err
+	}
+
+	var profiles []search.Profile
+	for _, r := range results {
+		p := search.Profile{
+			URL:      r.NavigationURL,
+			Name:     strings.TrimSpace(r.Title.Text),
+			Title:    strings.TrimSpace(r.PrimarySubtitle.Text),
+			Location: strings.TrimSpace(r.SecondarySubtitle.Text),
+		}
+		if p.Name == "" || strings.EqualFold(p.Name, "LinkedIn Member") || !strings.Contains(p.URL, "/in/") {
+			continue
+		}
+		for _, in := range r.Insights {
+			text := in.SimpleInsight.Title.Text
+			if m := mutuals.FindStringSubmatch(text); m != nil {
+				p.Mutuals, _ = strconv.Atoi(m[1])
+				if m[2] != "" {
+					// Plus the names listed before "and"
+					p.Mutuals += strings.Count(strings.Split(text, " and ")[0], ",") + 1
+				}
+				break
+			}
+		}
+		p.OpenToWork = strings.Contains(strings.ToLower(r.Image.AccessibilityText), "open to work")
+		profiles = append(profiles, p)
+	}
+	return profiles, nil
+}
+
+type (
+	voyagerProfile struct {
+		FirstName    string `json:"firstName"`
+		LastName     string `json:"lastName"`
+		Headline     string `json:"headline"`
+		LocationName string `json:"locationName"`
+		Summary      string `json:"summary"`
+	}
+	voyagerPosition struct {
+		Title       string         `json:"title"`
+		CompanyName string         `json:"companyName"`
+		TimePeriod  *voyagerPeriod `json:"timePeriod"`
+	}
+	voyagerEducation struct {
+		SchoolName   string         `json:"schoolName"`
+		DegreeName   string         `json:"degreeName"`
+		FieldOfStudy string         `json:"fieldOfStudy"`
+		TimePeriod   *voyagerPeriod `json:"timePeriod"`
+	}
+	voyagerSkill struct {
+		Name string `json:"name"`
+	}
+)
+
+// parseVoyagerProfile reads a profile from a profileView response. The
+// response carries no URL or mutual connections, so those are left unset
+func parseVoyagerProfile(data []byte) (*profile.Profile, error) {
+	profiles, err := included[voyagerProfile](data, "Profile")
+	if err != nil {
+		return nil, err
+	}
+	if len(profiles) == 0 {
+		return nil, errors.New("no profile in the voyager response")
+	}
+	vp := profiles[0]
+	p := &profile.Profile{
+		Name:     strings.TrimSpace(vp.FirstName + " " + vp.LastName),
+		Headline: vp.Headline,
+		Location: vp.LocationName,
+		About:    vp.Summary,
+	}
+
+	positions, err := included[voyagerPosition](data, "Position")
+	if err != nil {
+		return nil, err
+	}
+	for _, pos := range positions {
+		p.Experience = append(p.Experience, profile.Experience{Title: pos.Title, Company: pos.CompanyName, Dates: pos.TimePeriod.String()})
+	}
+
+	schools, err := included[voyagerEducation](data, "Education")
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range schools {
+		degree := e.DegreeName
+		if e.FieldOfStudy != "" {
+			degree = strings.TrimPrefix(degree+", "+e.FieldOfStudy, ", ")
+		}
+		p.Education = append(p.Education, profile.Education{School: e.SchoolName, Degree: degree, Dates: e.TimePeriod.String()})
+	}
+
+	skills, err := included[voyagerSkill](data, "Skill")
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range skills {
+		p.Skills = append(p.Skills, s.Name)
+	}
+
+	if len(p.Experience) > 0 {
+		p.Company = p.Experience[0].Company
+	}
+	return p, nil
+}
@@ -0,0 +1,290 @@
+// Package follow follows and unfollows profiles and company pages, and
+// cleans up follows left behind by old campaigns
+package follow
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+
+	"linkedin-automation/config"
+	"linkedin-automation/internal/apperr"
+	"linkedin-automation/internal/dom"
+	"linkedin-automation/internal/logger"
+	"linkedin-automation/internal/queue"
+	"linkedin-automation/internal/risk"
+	"linkedin-automation/internal/stealth"
+	"linkedin-automation/internal/storage"
+	"linkedin-automation/internal/throttle"
+	"linkedin-automation/internal/timing"
+)
+
+type Follower struct {
+	page     *rod.Page
+	cfg      *config.Config
+	logger   *logger.Logger
+	store    *storage.Store
+	throttle *throttle.Coordinator
+}
+
+func New(page *rod.Page, cfg *config.Config, log *logger.Logger, store *storage.Store) *Follower {
+	return &Follower{
+		page:     page,
+		cfg:      cfg,
+		logger:   log,
+		store:    store,
+		throttle: throttle.New(cfg, log, store),
+	}
+}
+
+// SetPage points the follower at a new page after the browser is relaunched
+func (f *Follower) SetPage(page *rod.Page) {
+	f.page = page
+}
+
+// Kind tells a company or showcase page from a member profile
+func Kind(url string) string {
+	if strings.Contains(url, "/company/") || strings.Contains(url, "/showcase/") {
+		return storage.FollowKindPage
+	}
+	return storage.FollowKindProfile
+}
+
+// EnqueueFollows queues a follow for every target not already followed
+func (f *Follower) EnqueueFollows(urls []string, campaign string) (int, error) {
+	queued := 0
+	for _, url := range urls {
+		following, err := f.store.IsFollowing(url)
+		if err != nil {
+			return queued, err
+		}
+		if following {
+			continue
+		}
+		if err := f.store.Enqueue(storage.QueueItem{
+			Campaign:   campaign,
+			Action:     storage.QueueActionFollow,
+			ProfileURL: url,
+			Source:     "follow",
+		}); err != nil {
+			return queued, err
+		}
+		queued++
+	}
+
+	f.logger.Info("Queued %d follows", queued)
+	return queued, nil
+}
+
+// QueueCleanup queues an unfollow for every follow older than
+// follow.cleanup_after_days made by a campaign matching follow.cleanup_campaigns
+func (f *Follower) QueueCleanup() (int, error) {
+	days := f.cfg.Follow.CleanupAfterDays
+	if days <= 0 {
+		return 0, nil
+	}
+
+	follows, err := f.store.GetFollowsBefore(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	for _, fl := range follows {
+		if !matchCampaign(f.cfg.Follow.CleanupCampaigns, fl.Campaign) {
+			continue
+		}
+		if err := f.store.Enqueue(storage.QueueItem{
+			Campaign:   fl.Campaign,
+			Action:     storage.QueueActionUnfollow,
+			ProfileURL: fl.TargetURL,
+			Name:       fl.Name,
+			Source:     "follow-cleanup",
+		}); err != nil {
+			return queued, err
+		}
+		queued++
+	}
+
+	f.logger.Info("Queued %d unfollows of follows older than %d days", queued, days)
+	return queued, nil
+}
+
+// matchCampaign matches campaign names against glob patterns, e.g. "warmup*"
+func matchCampaign(patterns []string, campaign string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, campaign); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ProcessFollowItem follows a single queued target
+func (f *Follower) ProcessFollowItem(ctx context.Context, item storage.QueueItem) error {
+	follows, _, err := f.store.GetFollowCountsToday()
+	if err != nil {
+		return err
+	}
+	if follows >= f.cfg.Limits.MaxFollowsPerDay {
+		f.logger.Warn("Daily follow limit reached")
+		return fmt.Errorf("%w: daily follow limit reached", apperr.ErrRateLimited)
+	}
+
+	if err := f.throttle.Acquire(ctx); err != nil {
+		return fmt.Errorf("failed to acquire throttle: %w", err)
+	}
+	defer f.throttle.Release()
+
+	name, err := f.open(item.ProfileURL)
+	if err != nil {
+		return err
+	}
+
+	// Already following, perhaps from before the tool was used
+	if has, _, _ := f.page.Has("main button[aria-label^='Following'], main button[aria-label^='Unfollow']"); has {
+		f.logger.Info("Already following %s", name)
+		if f.cfg.DryRun {
+			return queue.Planned("record the existing follow of %s", name)
+		}
+		return f.store.SaveFollow(storage.Follow{TargetURL: item.ProfileURL, Name: name, Kind: Kind(item.ProfileURL), Campaign: item.Campaign})
+	}
+
+	btn, err := f.followButton()
+	if err != nil {
+		return err
+	}
+	if f.cfg.DryRun {
+		return queue.Planned("follow %s", name)
+	}
+	if err := stealth.HumanClick(f.page, btn); err != nil {
+		return err
+	}
+	stealth.RandomDelay(1000, 2500)
+
+	if err := f.store.SaveFollow(storage.Follow{TargetURL: item.ProfileURL, Name: name, Kind: Kind(item.ProfileURL), Campaign: item.Campaign}); err != nil {
+		f.logger.Error("Failed to save follow: %v", err)
+	}
+
+	f.logger.LogAction(logger.Action{
+		Type:       "FOLLOWED",
+		Action:     item.Action,
+		ProfileURL: item.ProfileURL,
+		Campaign:   item.Campaign,
+		Duration:   queue.Elapsed(ctx),
+		Outcome:    logger.OutcomeSuccess,
+		Details: risk.Annotate(f.store, f.cfg, item.Action, map[string]interface{}{
+			"name": name,
+			"kind": Kind(item.ProfileURL),
+		}),
+	})
+	return nil
+}
+
+// ProcessUnfollowItem unfollows a single queued target
+func (f *Follower) ProcessUnfollowItem(ctx context.Context, item storage.QueueItem) error {
+	_, unfollows, err := f.store.GetFollowCountsToday()
+	if err != nil {
+		return err
+	}
+	if unfollows >= f.cfg.Limits.MaxUnfollowsPerDay {
+		f.logger.Warn("Daily unfollow limit reached")
+		return fmt.Errorf("%w: daily unfollow limit reached", apperr.ErrRateLimited)
+	}
+
+	if err := f.throttle.Acquire(ctx); err != nil {
+		return fmt.Errorf("failed to acquire throttle: %w", err)
+	}
+	defer f.throttle.Release()
+
+	name, err := f.open(item.ProfileURL)
+	if err != nil {
+		return err
+	}
+
+	btn, err := dom.Find(f.page, f.logger, dom.UnfollowButton)
+	if err != nil {
+		// Nothing to undo: unfollowed by hand, or the follow never took
+		if has, _, _ := f.page.Has("main button[aria-label^='Follow']"); has {
+			f.logger.Info("Not following %s any more", name)
+			if f.cfg.DryRun {
+				return queue.Planned("record that %s is no longer followed", name)
+			}
+			return f.store.MarkUnfollowed(item.ProfileURL)
+		}
+		return err
+	}
+	if f.cfg.DryRun {
+		return queue.Planned("unfollow %s", name)
+	}
+	if err := stealth.HumanClick(f.page, btn); err != nil {
+		return err
+	}
+
+	// Company pages and some profiles confirm the unfollow in a dialog
+	if _, err := dom.WaitModalOpen(f.page, dom.ModalWait/4); err == nil {
+		if has, confirm, _ := f.page.Has("[role='dialog'] button.artdeco-button--primary"); has {
+			stealth.RandomDelay(500, 1200)
+			stealth.HumanClick(f.page, confirm)
+		}
+	}
+	stealth.RandomDelay(1000, 2500)
+
+	if err := f.store.MarkUnfollowed(item.ProfileURL); err != nil {
+		f.logger.Error("Failed to record unfollow: %v", err)
+	}
+
+	f.logger.LogAction(logger.Action{
+		Type:       "UNFOLLOWED",
+		Action:     item.Action,
+		ProfileURL: item.ProfileURL,
+		Campaign:   item.Campaign,
+		Duration:   queue.Elapsed(ctx),
+		Outcome:    logger.OutcomeSuccess,
+		Details: risk.Annotate(f.store, f.cfg, item.Action, map[string]interface{}{
+			"name": name,
+		}),
+	})
+	return nil
+}
+
+// open loads the target and returns its display name
+func (f *Follower) open(url string) (string, error) {
+	navigated := timing.Start(timing.Navigation)
+	if err := f.page.Navigate(url); err != nil {
+		return "", apperr.Navigation(url, err)
+	}
+	f.page.WaitLoad()
+	navigated()
+	stealth.RandomDelay(2000, 4000)
+
+	name := url
+	if has, h1, _ := f.page.Has("main h1"); has {
+		if text, err := h1.Text(); err == nil && strings.TrimSpace(text) != "" {
+			name = strings.TrimSpace(text)
+		}
+	}
+	return name, nil
+}
+
+// followButton finds Follow on the page, or in the overflow menu where
+// profiles that offer Connect keep it
+func (f *Follower) followButton() (*rod.Element, error) {
+	if has, btn, _ := f.page.Has(dom.FollowButton.Selectors[0]); has {
+		return btn, nil
+	}
+	if Kind(f.page.MustInfo().URL) == storage.FollowKindProfile {
+		if more, err := dom.Find(f.page, f.logger, dom.MoreActionsButton); err == nil {
+			stealth.HumanClick(f.page, more)
+			stealth.RandomDelay(600, 1200)
+			if has, item, _ := f.page.Has("[role='menu'] [aria-label^='Follow'], .artdeco-dropdown__content [aria-label^='Follow']"); has {
+				return item, nil
+			}
+		}
+	}
+	return dom.Find(f.page, f.logger, dom.FollowButton)
+}
@@ -0,0 +1,124 @@
+package links
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"linkedin-automation/config"
+	"linkedin-automation/internal/logger"
+	"linkedin-automation/internal/storage"
+)
+
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+// Wrapper replaces links in outgoing messages with per-contact tracking
+// redirects, so clicks can be attributed to the contact who received them
+type Wrapper struct {
+	cfg    config.LinksConfig
+	logger *logger.Logger
+	store  *storage.Store
+	client *http.Client
+}
+
+func New(cfg *config.Config, log *logger.Logger, store *storage.Store) *Wrapper {
+	return &Wrapper{
+		cfg:    cfg.Links,
+		logger: log,
+		store:  store,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Wrap rewrites every link in text for the given contact. Links of the
+// queued message queueID keep the token they got on an earlier attempt, so
+// retries do not leave orphan tokens behind (0 = not queued)
+func (w *Wrapper) Wrap(text, profileURL string, queueID int64) (string, error) {
+	if !w.cfg.Enabled {
+		return text, nil
+	}
+
+	var wrapErr error
+	wrapped := urlPattern.ReplaceAllStringFunc(text, func(target string) string {
+		if wrapErr != nil || strings.HasPrefix(target, w.cfg.BaseURL) {
+			return target
+		}
+
+		token, err := w.token(profileURL, target, queueID)
+		if err != nil {
+			wrapErr = err
+			return target
+		}
+
+		tracking := strings.TrimSuffix(w.cfg.BaseURL, "/") + "/r/" + token
+		if w.cfg.ShortenerURL != "" {
+			short, err := w.shorten(tracking)
+			if err != nil {
+				w.logger.Warn("Shortener failed, using tracking URL: %v", err)
+				return tracking
+			}
+			return short
+		}
+		return tracking
+	})
+
+	return wrapped, wrapErr
+}
+
+// token returns the queued message's existing token for target, or records a new one
+func (w *Wrapper) token(profileURL, target string, queueID int64) (string, error) {
+	if queueID != 0 {
+		if token, err := w.store.GetTrackedLinkToken(queueID, target); err != nil || token != "" {
+			return token, err
+		}
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return "", err
+	}
+	if err := w.store.SaveTrackedLink(token, profileURL, target, queueID); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// shorten calls a shortener API of the form https://example.com/create?url={url}
+// that responds with the short link as plain text
+func (w *Wrapper) shorten(link string) (string, error) {
+	endpoint := strings.ReplaceAll(w.cfg.ShortenerURL, "{url}", url.QueryEscape(link))
+	resp, err := w.client.Get(endpoint)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("shortener returned %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 2048))
+	if err != nil {
+		return "", err
+	}
+
+	short := strings.TrimSpace(string(body))
+	if !strings.HasPrefix(short, "http") {
+		return "", fmt.Errorf("unexpected shortener response: %q", short)
+	}
+	return short, nil
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,59 @@
+package links
+
+import (
+	"net/http"
+	"strings"
+
+	"linkedin-automation/internal/logger"
+	"linkedin-automation/internal/storage"
+)
+
+// Link previews fetch every link as soon as a message is sent, LinkedIn's own
+// first; their user agents contain one of these
+var previewAgents = []string{
+	"linkedinbot", "facebookexternalhit", "twitterbot", "slackbot", "discordbot",
+	"whatsapp", "telegrambot", "skypeuripreview", "googlebot", "bingbot",
+	"embedly", "bot", "crawler", "spider", "preview",
+}
+
+// isPreview reports whether r is a preview fetch or crawler rather than a person
+func isPreview(r *http.Request) bool {
+	if r.Method == http.MethodHead {
+		return true
+	}
+	ua := strings.ToLower(r.UserAgent())
+	if ua == "" {
+		return true
+	}
+	for _, a := range previewAgents {
+		if strings.Contains(ua, a) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handler serves /r/<token> redirects and records each click by a person
+func Handler(store *storage.Store, log *logger.Logger) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/r/", func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.URL.Path, "/r/")
+
+		link, err := store.GetTrackedLink(token)
+		if err != nil || link.Target == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		if isPreview(r) {
+			log.Debug("Link preview for %s not counted as a click (%s %q)", token, r.Method, r.UserAgent())
+		} else if err := store.RecordLinkClick(token, r.UserAgent()); err != nil {
+			log.Warn("Failed to record click for %s: %v", token, err)
+		} else {
+			log.Info("Link click: %s -> %s", link.ProfileURL, link.Target)
+		}
+
+		http.Redirect(w, r, link.Target, http.StatusFound)
+	})
+	return mux
+}
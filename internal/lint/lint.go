@@ -0,0 +1,145 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"linkedin-automation/config"
+)
+
+// Severity controls whether a finding blocks sending
+type Severity string
+
+const (
+	SeverityOff     Severity = "off"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// Rule names, used as keys in the lint.severity config block
+const (
+	RuleURLInNote    = "url_in_note"
+	RuleSpamWords    = "spam_words"
+	RuleOverLength   = "over_length"
+	RulePersonalData = "personal_data"
+)
+
+// Kind of content being linted
+const (
+	KindNote    = "note"
+	KindMessage = "message"
+)
+
+// LinkedIn rejects messages longer than this
+const maxMessageLen = 8000
+
+var defaultSeverity = map[string]Severity{
+	RuleURLInNote:    SeverityError,
+	RuleSpamWords:    SeverityWarning,
+	RuleOverLength:   SeverityError,
+	RulePersonalData: SeverityWarning,
+}
+
+var defaultSpamWords = []string{
+	"act now", "limited time", "click here", "guaranteed", "risk-free",
+	"earn money", "make money", "100% free", "buy now", "special offer",
+	"no obligation", "winner", "crypto",
+}
+
+var (
+	urlPattern         = regexp.MustCompile(`(?i)\b(https?://|www\.)\S+|\b[a-z0-9-]+\.(com|io|net|org|co|ly|me)(/\S*)?\b`)
+	placeholderPattern = regexp.MustCompile(`\{[a-z_]+\}|\{\{[^}]*\}\}`)
+	personalDataRe     = regexp.MustCompile(`(?i)\b(phone number|mobile number|home address|date of birth|social security|ssn|passport|bank account|credit card|password|your salary)\b`)
+)
+
+// Finding is a single lint result
+type Finding struct {
+	Rule     string
+	Severity Severity
+	Message  string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("[%s] %s: %s", f.Severity, f.Rule, f.Message)
+}
+
+// Linter flags risky patterns in notes and messages before anything is sent
+type Linter struct {
+	severity  map[string]Severity
+	spamWords []string
+	noteMax   int
+}
+
+func New(cfg *config.Config) *Linter {
+	l := &Linter{
+		severity:  make(map[string]Severity),
+		spamWords: append([]string{}, defaultSpamWords...),
+		noteMax:   cfg.Limits.ConnectionNoteMaxLen,
+	}
+
+	for rule, sev := range defaultSeverity {
+		l.severity[rule] = sev
+	}
+	for rule, sev := range cfg.Lint.Severity {
+		l.severity[rule] = Severity(strings.ToLower(sev))
+	}
+	for _, w := range cfg.Lint.SpamWords {
+		l.spamWords = append(l.spamWords, strings.ToLower(w))
+	}
+
+	return l
+}
+
+// Lint checks a note or message template
+func (l *Linter) Lint(kind, text string) []Finding {
+	var findings []Finding
+	add := func(rule, format string, v ...interface{}) {
+		sev := l.severity[rule]
+		if sev == "" || sev == SeverityOff {
+			return
+		}
+		findings = append(findings, Finding{Rule: rule, Severity: sev, Message: fmt.Sprintf(format, v...)})
+	}
+
+	// Links in invitations are a strong spam signal
+	if kind == KindNote {
+		if m := urlPattern.FindString(text); m != "" {
+			add(RuleURLInNote, "connection note contains a link (%s)", m)
+		}
+	}
+
+	lower := strings.ToLower(text)
+	for _, w := range l.spamWords {
+		if strings.Contains(lower, w) {
+			add(RuleSpamWords, "contains spam-trigger phrase %q", w)
+		}
+	}
+
+	// Placeholders expand per profile; judge the length of the fixed text
+	fixed := placeholderPattern.ReplaceAllString(text, "")
+	limit := maxMessageLen
+	if kind == KindNote {
+		limit = l.noteMax
+	}
+	if n := utf8.RuneCountInString(fixed); limit > 0 && n > limit {
+		add(RuleOverLength, "%s is %d characters before personalization (limit %d)", kind, n, limit)
+	}
+
+	if m := personalDataRe.FindString(text); m != "" {
+		add(RulePersonalData, "asks for personal data (%q)", m)
+	}
+
+	return findings
+}
+
+// HasErrors reports whether any finding should block sending
+func HasErrors(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// consoleHandler writes a record as one readable line: the level, the time,
+// the logger's attributes in brackets, the message and the record's own
+// attributes after a bar. Debug and error lines name the file and line
+type consoleHandler struct {
+	mu    *sync.Mutex
+	w     io.Writer
+	level slog.Leveler
+	// prefix is the rendered attributes of With, group the open WithGroup
+	prefix string
+	group  string
+}
+
+func newConsoleHandler(w io.Writer, level slog.Leveler) *consoleHandler {
+	return &consoleHandler{mu: &sync.Mutex{}, w: w, level: level}
+}
+
+func (h *consoleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *consoleHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	switch {
+	case r.Level >= slog.LevelError:
+		b.WriteString("[ERROR] ")
+	case r.Level >= slog.LevelWarn:
+		b.WriteString("[WARN]  ")
+	case r.Level >= slog.LevelInfo:
+		b.WriteString("[INFO]  ")
+	default:
+		b.WriteString("[DEBUG] ")
+	}
+	b.WriteString(r.Time.Format("2006/01/02 15:04:05 "))
+	if (r.Level >= slog.LevelError || r.Level < slog.LevelInfo) && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		fmt.Fprintf(&b, "%s:%d: ", filepath.Base(frame.File), frame.Line)
+	}
+	if h.prefix != "" {
+		fmt.Fprintf(&b, "[%s] ", h.prefix)
+	}
+
+	var attrs []string
+	action := false
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == "event" && a.Value.String() == "action" {
+			action = true
+			return true
+		}
+		attrs = appendAttr(attrs, h.group, a)
+		return true
+	})
+	if action {
+		b.WriteString("[ACTION] ")
+	}
+	b.WriteString(r.Message)
+	if len(attrs) > 0 {
+		b.WriteString(" | ")
+		b.WriteString(strings.Join(attrs, " "))
+	}
+	b.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var fields []string
+	for _, a := range attrs {
+		fields = appendAttr(fields, h.group, a)
+	}
+	c := *h
+	c.prefix = strings.TrimSpace(h.prefix + " " + strings.Join(fields, " "))
+	return &c
+}
+
+func (h *consoleHandler) WithGroup(name string) slog.Handler {
+	c := *h
+	c.group = h.group + name + "."
+	return &c
+}
+
+// appendAttr renders a as key=value, and the attributes of a group with the
+// group's name before their keys. A true flag renders as its bare key
+func appendAttr(fields []string, group string, a slog.Attr) []string {
+	a.Value = a.Value.Resolve()
+	switch {
+	case a.Equal(slog.Attr{}):
+		return fields
+	case a.Value.Kind() == slog.KindGroup:
+		for _, ga := range a.Value.Group() {
+			fields = appendAttr(fields, group+a.Key+".", ga)
+		}
+		return fields
+	case a.Value.Kind() == slog.KindBool && a.Value.Bool():
+		return append(fields, group+a.Key)
+	}
+	return append(fields, fmt.Sprintf("%s%s=%v", group, a.Key, a.Value.Any()))
+}
+
+// multiHandler passes every record to each of its handlers that takes its level
+type multiHandler []slog.Handler
+
+func (m multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range m {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range m {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := make(multiHandler, len(m))
+	for i, h := range m {
+		c[i] = h.WithAttrs(attrs)
+	}
+	return c
+}
+
+func (m multiHandler) WithGroup(name string) slog.Handler {
+	c := make(multiHandler, len(m))
+	for i, h := range m {
+		c[i] = h.WithGroup(name)
+	}
+	return c
+}
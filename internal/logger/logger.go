@@ -1,54 +1,42 @@
+// Package logger writes the run's log through log/slog: JSON lines to the log
+// file, for tools that read it back, and the familiar one line per message
+// to the console. Messages stay printf-style; what identifies a run and the
+// actions it takes are attributes, so they can be filtered on in the file
 package logger
 
 import (
+	"context"
 	"fmt"
-	"io"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"time"
 )
 
-type Level int
-
-const (
-	DEBUG Level = iota
-	INFO
-	WARN
-	ERROR
-)
-
 type Logger struct {
-	level    Level
-	infoLog  *log.Logger
-	warnLog  *log.Logger
-	errorLog *log.Logger
-	debugLog *log.Logger
-	file     *os.File
+	slog *slog.Logger
+	file *os.File
 }
 
 func New(level string, logFile string, console bool) (*Logger, error) {
-	l := &Logger{}
-
-	// Parse level
+	var lvl slog.Level
 	switch level {
 	case "debug":
-		l.level = DEBUG
-	case "info":
-		l.level = INFO
+		lvl = slog.LevelDebug
 	case "warn":
-		l.level = WARN
+		lvl = slog.LevelWarn
 	case "error":
-		l.level = ERROR
+		lvl = slog.LevelError
 	default:
-		l.level = INFO
+		lvl = slog.LevelInfo
 	}
 
-	// Create writers
-	writers := []io.Writer{}
-
+	l := &Logger{}
+	var handlers multiHandler
 	if console {
-		writers = append(writers, os.Stdout)
+		handlers = append(handlers, newConsoleHandler(os.Stdout, lvl))
 	}
 
 	if logFile != "" {
@@ -63,42 +51,49 @@ func New(level string, logFile string, console bool) (*Logger, error) {
 			return nil, fmt.Errorf("failed to open log file: %w", err)
 		}
 		l.file = file
-		writers = append(writers, file)
+		handlers = append(handlers, slog.NewJSONHandler(file, &slog.HandlerOptions{Level: lvl}))
 	}
 
-	writer := io.MultiWriter(writers...)
-
-	// Create loggers
-	l.debugLog = log.New(writer, "[DEBUG] ", log.Ldate|log.Ltime|log.Lshortfile)
-	l.infoLog = log.New(writer, "[INFO]  ", log.Ldate|log.Ltime)
-	l.warnLog = log.New(writer, "[WARN]  ", log.Ldate|log.Ltime)
-	l.errorLog = log.New(writer, "[ERROR] ", log.Ldate|log.Ltime|log.Lshortfile)
-
+	l.slog = slog.New(handlers)
 	return l, nil
 }
 
 func (l *Logger) Debug(format string, v ...interface{}) {
-	if l.level <= DEBUG {
-		l.debugLog.Output(2, fmt.Sprintf(format, v...))
-	}
+	l.log(slog.LevelDebug, fmt.Sprintf(format, v...))
 }
 
 func (l *Logger) Info(format string, v ...interface{}) {
-	if l.level <= INFO {
-		l.infoLog.Output(2, fmt.Sprintf(format, v...))
-	}
+	l.log(slog.LevelInfo, fmt.Sprintf(format, v...))
 }
 
 func (l *Logger) Warn(format string, v ...interface{}) {
-	if l.level <= WARN {
-		l.warnLog.Output(2, fmt.Sprintf(format, v...))
-	}
+	l.log(slog.LevelWarn, fmt.Sprintf(format, v...))
 }
 
 func (l *Logger) Error(format string, v ...interface{}) {
-	if l.level <= ERROR {
-		l.errorLog.Output(2, fmt.Sprintf(format, v...))
+	l.log(slog.LevelError, fmt.Sprintf(format, v...))
+}
+
+// log hands a record to the handlers with the caller of Debug, Info, Warn or
+// Error as its source
+func (l *Logger) log(level slog.Level, msg string, attrs ...slog.Attr) {
+	ctx := context.Background()
+	if !l.slog.Enabled(ctx, level) {
+		return
 	}
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	r.AddAttrs(attrs...)
+	_ = l.slog.Handler().Handle(ctx, r)
+}
+
+// With returns a logger writing to the same outputs that adds the key-value
+// pairs in args to every message; closing either closes the log file
+func (l *Logger) With(args ...any) *Logger {
+	c := *l
+	c.slog = l.slog.With(args...)
+	return &c
 }
 
 func (l *Logger) Close() error {
@@ -108,11 +103,53 @@ func (l *Logger) Close() error {
 	return nil
 }
 
-func (l *Logger) LogAction(action string, details map[string]interface{}) {
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	msg := fmt.Sprintf("[ACTION] %s - %s", timestamp, action)
-	for k, v := range details {
-		msg += fmt.Sprintf(" | %s=%v", k, v)
+// Action is one thing done to a profile. Type names the event, such as
+// CONNECTION_SENT; Details holds what only that type has
+type Action struct {
+	Type       string
+	Action     string
+	ProfileURL string
+	Campaign   string
+	Duration   time.Duration
+	Outcome    string
+	Details    map[string]interface{}
+}
+
+// Outcomes of an action
+const (
+	OutcomeSuccess = "success"
+)
+
+// LogAction writes a as a structured event at info level
+func (l *Logger) LogAction(a Action) {
+	attrs := []slog.Attr{slog.String("event", "action")}
+	if a.Action != "" {
+		attrs = append(attrs, slog.String("action", a.Action))
+	}
+	if a.ProfileURL != "" {
+		attrs = append(attrs, slog.String("profile_url", a.ProfileURL))
+	}
+	if a.Campaign != "" {
+		attrs = append(attrs, slog.String("campaign_id", a.Campaign))
+	}
+	if a.Duration > 0 {
+		attrs = append(attrs, slog.Int64("duration_ms", a.Duration.Milliseconds()))
+	}
+	if a.Outcome != "" {
+		attrs = append(attrs, slog.String("outcome", a.Outcome))
+	}
+	if len(a.Details) > 0 {
+		// Stable key order keeps action lines comparable across a log
+		keys := make([]string, 0, len(a.Details))
+		for k := range a.Details {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		details := make([]any, 0, len(keys))
+		for _, k := range keys {
+			details = append(details, slog.Any(k, a.Details[k]))
+		}
+		attrs = append(attrs, slog.Group("details", details...))
 	}
-	l.Info(msg)
+	l.log(slog.LevelInfo, a.Type, attrs...)
 }
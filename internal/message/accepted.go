@@ -0,0 +1,130 @@
+package message
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"linkedin-automation/internal/apperr"
+	"linkedin-automation/internal/network"
+	"linkedin-automation/internal/storage"
+)
+
+// maxRecentConnections bounds how far down the connections list one
+// acceptance sync scrolls
+const maxRecentConnections = 500
+
+// AcceptanceCheck tells which connection requests were accepted from one
+// read of the recently added connections, instead of a profile visit per
+// request. When the list cannot be read it falls back to profile visits
+type AcceptanceCheck struct {
+	m      *Messenger
+	since  time.Time
+	loaded bool
+	// recent holds the profile IDs on the list; nil after a failed read
+	recent map[string]bool
+}
+
+// AcceptanceCheck prepares a check of requests sent since; the list is read
+// on the first call to Accepted
+func (m *Messenger) AcceptanceCheck(since time.Time) *AcceptanceCheck {
+	// Dates on the list are rounded to the day
+	return &AcceptanceCheck{m: m, since: since.AddDate(0, 0, -1)}
+}
+
+// Accepted reports whether the request to profileURL was accepted
+func (a *AcceptanceCheck) Accepted(ctx context.Context, profileURL string) (bool, error) {
+	a.load()
+	if a.recent == nil {
+		return a.m.CheckConnectionAccepted(ctx, profileURL)
+	}
+	return a.recent[profileKey(profileURL)], nil
+}
+
+// Bulk reports whether answers come from the connections list, so checking
+// costs no profile views
+func (a *AcceptanceCheck) Bulk() bool {
+	a.load()
+	return a.recent != nil
+}
+
+func (a *AcceptanceCheck) load() {
+	if a.loaded {
+		return
+	}
+	a.loaded = true
+
+	conns, err := network.New(a.m.page, a.m.cfg, a.m.logger).RecentConnections(a.since, maxRecentConnections)
+	if err != nil {
+		a.m.logger.Warn("Failed to read recent connections, checking profiles one by one: %v", err)
+		return
+	}
+
+	a.recent = make(map[string]bool, len(conns))
+	for _, c := range conns {
+		a.recent[profileKey(c.URL)] = true
+	}
+}
+
+// Acceptance is a pending request as CheckAccepted left it
+type Acceptance struct {
+	storage.ConnectionRequest
+	// Checked is false for requests the check did not get to
+	Checked bool
+}
+
+// CheckAccepted looks at every pending connection request and records the
+// accepted ones, leaving their follow-ups to the next -message run. It stops
+// early when ctx is done or the profile views run out; the requests not
+// reached are returned unchecked
+func (m *Messenger) CheckAccepted(ctx context.Context) ([]Acceptance, error) {
+	connections, err := m.store.GetPendingConnections()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending connections: %w", err)
+	}
+	m.logger.Info("Checking %d pending connections for acceptance", len(connections))
+
+	results := make([]Acceptance, len(connections))
+	if len(connections) == 0 {
+		return results, nil
+	}
+	// Newest first, so the last request is the oldest the list must reach
+	check := m.AcceptanceCheck(connections[len(connections)-1].SentAt)
+
+	stopped := false
+	for i, conn := range connections {
+		results[i].ConnectionRequest = conn
+		if stopped || ctx.Err() != nil {
+			continue
+		}
+
+		accepted, err := check.Accepted(ctx, conn.ProfileURL)
+		if errors.Is(err, apperr.ErrRateLimited) {
+			m.logger.Warn("Stopping the acceptance check: %v", err)
+			stopped = true
+			continue
+		}
+		if err != nil {
+			m.logger.Warn("Failed to check %s: %v", conn.Name, err)
+			continue
+		}
+		results[i].Checked = true
+		if !accepted {
+			continue
+		}
+
+		if err := m.store.RecordAcceptance(conn.ProfileURL); err != nil {
+			return results, err
+		}
+		results[i].Accepted = true
+		m.logger.Info("Connection accepted: %s", conn.Name)
+	}
+	return results, nil
+}
+
+// profileKey identifies a profile whatever form its URL was saved in
+func profileKey(profileURL string) string {
+	return strings.ToLower(strings.Trim(extractProfileID(strings.Split(profileURL, "?")[0]), "/"))
+}
@@ -0,0 +1,64 @@
+package message
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"linkedin-automation/internal/stealth"
+	"linkedin-automation/internal/timing"
+)
+
+// duplicateWindow is how far back an identical message counts as already sent
+const duplicateWindow = 30 * 24 * time.Hour
+
+// errAlreadySent means the thread already holds the message, typically because
+// an earlier run crashed between sending and recording it
+var errAlreadySent = errors.New("identical message already in thread")
+
+var linkPattern = regexp.MustCompile(`https?://\S+`)
+
+// normalize strips what legitimately differs between two sends of the same
+// message: tracking links get a new token each time, and LinkedIn collapses
+// whitespace when rendering the thread
+func normalize(text string) string {
+	text = linkPattern.ReplaceAllString(stealth.FinalText(text), "")
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
+
+// alreadySent reports whether an identical message to the profile was
+// recorded within duplicateWindow
+func (m *Messenger) alreadySent(profileURL, text string) (bool, error) {
+	msgs, err := m.store.GetMessagesSince(profileURL, m.run.Now().Add(-duplicateWindow))
+	if err != nil {
+		return false, err
+	}
+
+	want := normalize(text)
+	for _, msg := range msgs {
+		if normalize(msg.Content) == want {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// inThread reports whether the open conversation already contains the message
+func (m *Messenger) inThread(text string) bool {
+	extracted := timing.Start(timing.Extraction)
+	msgs, err := ReadThread(m.page)
+	extracted()
+	if err != nil {
+		m.logger.Debug("Could not read thread for duplicate check: %v", err)
+		return false
+	}
+
+	want := normalize(text)
+	for _, msg := range msgs {
+		if normalize(msg.Text) == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package message
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+
+	"linkedin-automation/internal/apperr"
+	"linkedin-automation/internal/stealth"
+	"linkedin-automation/internal/storage"
+)
+
+const inboxURL = "https://www.linkedin.com/messaging/"
+
+// unreadCountJS reads the badge on the global nav's Messaging item: -1 when
+// the nav is not on the page, 0 when it shows no badge
+const unreadCountJS = `() => {
+	const link = document.querySelector('#global-nav a[href*="/messaging"], a.global-nav__primary-link[href*="/messaging"]');
+	if (!link) return -1;
+	const badge = link.querySelector('.notification-badge__count, .notification-badge');
+	const m = (badge?.innerText || badge?.textContent || '').match(/\d+/);
+	return m ? parseInt(m[0], 10) : 0;
+}`
+
+// unreadSendersJS lists the participants of the unread conversations in the
+// inbox's conversation list
+const unreadSendersJS = `() => Array.from(document.querySelectorAll(
+		'.msg-conversation-listitem .msg-conversation-card__convo-item--unread, .msg-conversation-listitem__link--unread, .msg-conversation-listitem--unread'))
+	.map(el => (el.querySelector('.msg-conversation-listitem__participant-names, .msg-conversation-card__participant-names')?.innerText || '').trim())
+	.filter(Boolean)`
+
+// UnreadCount reads the unread conversation count LinkedIn shows in its
+// navigation on whatever page is open, without loading anything; ok is false
+// when the page has no LinkedIn navigation
+func UnreadCount(page *rod.Page) (n int, ok bool) {
+	res, err := page.Eval(unreadCountJS)
+	if err != nil || res.Value.Int() < 0 {
+		return 0, false
+	}
+	return res.Value.Int(), true
+}
+
+// UnreadSenders opens the inbox and returns the names on its unread
+// conversations
+func (m *Messenger) UnreadSenders(ctx context.Context) ([]string, error) {
+	if err := m.page.Context(ctx).Navigate(inboxURL); err != nil {
+		return nil, apperr.Navigation(inboxURL, err)
+	}
+	m.page.WaitLoad()
+	if err := stealth.RandomDelayContext(ctx, 1500, 3000); err != nil {
+		return nil, err
+	}
+
+	res, err := m.page.Eval(unreadSendersJS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read inbox: %w", err)
+	}
+	var names []string
+	if err := res.Value.Unmarshal(&names); err != nil {
+		return nil, fmt.Errorf("failed to read inbox: %w", err)
+	}
+	return names, nil
+}
+
+// CheckReplies checks the threads of unanswered messages sent to the named
+// people, whatever the receipt check schedule, and returns the ones that
+// got a reply. Opening a thread marks it read on LinkedIn, as receipt
+// checks do. When ctx is done the rest are checked next time
+func (m *Messenger) CheckReplies(ctx context.Context, names []string) ([]storage.ReceiptCheck, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	unread := make(map[string]bool)
+	for _, n := range names {
+		unread[strings.ToLower(n)] = true
+	}
+
+	checks, err := m.store.GetReceiptChecks(m.run.Now().Add(-receiptWindow), 0, 1000)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the latest message to each person needs its thread read
+	latest := make(map[string]storage.ReceiptCheck)
+	for _, c := range checks {
+		key := strings.ToLower(c.Name)
+		if prev, ok := latest[key]; unread[key] && (!ok || c.SentAt.After(prev.SentAt)) {
+			latest[key] = c
+		}
+	}
+
+	var replies []storage.ReceiptCheck
+	for _, c := range latest {
+		if ctx.Err() != nil {
+			break
+		}
+		seen, repliedAt, err := m.readReceipt(ctx, c)
+		if err != nil {
+			m.logger.Warn("Failed to check reply from %s: %v", c.Name, err)
+			continue
+		}
+		var seenAt time.Time
+		if !repliedAt.IsZero() {
+			seenAt = repliedAt
+		} else if seen {
+			seenAt = time.Now()
+		}
+		if err := m.store.UpdateReceipt(c.ID, seenAt, repliedAt); err != nil {
+			return replies, err
+		}
+		if !repliedAt.IsZero() {
+			m.logger.Info("%s replied", c.Name)
+			replies = append(replies, c)
+		}
+		stealth.RandomDelayContext(ctx, 2000, 5000)
+	}
+	return replies, nil
+}
@@ -1,128 +1,551 @@
 package message
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/go-rod/rod"
 
 	"linkedin-automation/config"
+	"linkedin-automation/internal/apperr"
+	"linkedin-automation/internal/capture"
+	"linkedin-automation/internal/compliance"
+	"linkedin-automation/internal/dom"
+	"linkedin-automation/internal/links"
 	"linkedin-automation/internal/logger"
+	"linkedin-automation/internal/network"
+	"linkedin-automation/internal/personalize"
+	"linkedin-automation/internal/profile"
+	"linkedin-automation/internal/queue"
+	"linkedin-automation/internal/risk"
+	"linkedin-automation/internal/runctx"
 	"linkedin-automation/internal/stealth"
 	"linkedin-automation/internal/storage"
+	"linkedin-automation/internal/throttle"
+	"linkedin-automation/internal/timing"
 )
 
 type Messenger struct {
-	page   *rod.Page
-	cfg    *config.Config
-	logger *logger.Logger
-	store  *storage.Store
+	page       *rod.Page
+	run        *runctx.Context
+	cfg        *config.Config
+	logger     *logger.Logger
+	store      *storage.Store
+	throttle   *throttle.Coordinator
+	links      *links.Wrapper
+	compliance *compliance.Profile
+	typist     stealth.TypingProfile
+	limiter    *stealth.RateLimiter
+	// sequence replaces the single follow-up when set; see SetSequence
+	sequence []config.SequenceStep
 }
 
-func New(page *rod.Page, cfg *config.Config, log *logger.Logger, store *storage.Store) *Messenger {
+func New(page *rod.Page, rc *runctx.Context) *Messenger {
+	cfg := rc.Cfg
 	return &Messenger{
-		page:   page,
-		cfg:    cfg,
-		logger: log,
-		store:  store,
+		page:       page,
+		run:        rc,
+		cfg:        cfg,
+		logger:     rc.Logger,
+		store:      rc.Store,
+		throttle:   throttle.New(cfg, rc.Logger, rc.Store),
+		links:      links.New(cfg, rc.Logger, rc.Store),
+		compliance: compliance.ForConfig(cfg),
+		typist: stealth.TypingProfileFor(cfg.Stealth.TypingProfile, cfg.Creds.Email,
+			cfg.Delays.MinTypingDelayMs, cfg.Delays.MaxTypingDelayMs, cfg.Stealth.TypoProbability),
 	}
 }
 
-func (m *Messenger) SendFollowUpMessages(messageTemplate string) error {
+// SetPage points the messenger at a new page after the browser is relaunched
+func (m *Messenger) SetPage(page *rod.Page) {
+	m.page = page
+}
+
+// SetLimiter paces the profile views the messenger makes with the session's
+// rate limiter
+func (m *Messenger) SetLimiter(rl *stealth.RateLimiter) {
+	m.limiter = rl
+}
+
+// QueueFollowUps checks pending connections and queues a follow-up for every
+// accepted one, or starts it on the sequence when one is set. When ctx is
+// done the rest are checked next run
+func (m *Messenger) QueueFollowUps(ctx context.Context, messageTemplate string) (int, error) {
 	m.logger.Info("Checking for accepted connections")
 
 	// Get pending connections
 	connections, err := m.store.GetPendingConnections()
 	if err != nil {
-		return fmt.Errorf("failed to get pending connections: %w", err)
+		return 0, fmt.Errorf("failed to get pending connections: %w", err)
 	}
 
 	m.logger.Info("Found %d pending connections", len(connections))
 
-	// Check message limit
+	// Acceptances an acceptance check recorded need no second look
+	due, err := m.store.GetFollowUpsDue()
+	if err != nil {
+		return 0, err
+	}
+	if len(due) > 0 {
+		m.logger.Info("Found %d accepted connections awaiting a follow-up", len(due))
+	}
+
+	// Check message limit; acceptance checks can cost profile views, so
+	// only check as many connections as can still be messaged today
 	todayCount, err := m.store.GetMessagesCountToday()
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	if todayCount >= m.cfg.Limits.MaxMessagesPerDay {
 		m.logger.Warn("Daily message limit reached")
-		return errors.New("daily message limit reached")
+		return 0, nil
+	}
+
+	if _, err := m.sendWindow(""); err != nil {
+		return 0, err
+	}
+
+	if len(connections) == 0 && len(due) == 0 {
+		return 0, nil
 	}
 
-	sent := 0
+	// Newest first, so the last request is the oldest the list must reach
+	var check *AcceptanceCheck
+	if len(connections) > 0 {
+		check = m.AcceptanceCheck(connections[len(connections)-1].SentAt)
+	}
+
+	queued := 0
 	remaining := m.cfg.Limits.MaxMessagesPerDay - todayCount
 
-	for _, conn := range connections {
-		if sent >= remaining {
+	for _, conn := range append(due, connections...) {
+		if queued >= remaining || ctx.Err() != nil {
 			break
 		}
 
 		// Check if connection is accepted
-		accepted, err := m.checkConnectionAccepted(conn.ProfileURL)
-		if err != nil {
-			m.logger.Error("Failed to check connection status: %v", err)
-			continue
+		accepted := conn.Accepted
+		if !accepted {
+			if accepted, err = check.Accepted(ctx, conn.ProfileURL); err != nil {
+				m.logger.Error("Failed to check connection status: %v", err)
+				continue
+			}
 		}
 
 		if !accepted {
 			continue
 		}
 
-		m.logger.Info("Connection accepted: %s. Sending follow-up message", conn.Name)
+		if m.doNotContact(conn.ProfileURL) {
+			m.logger.Debug("%s is on the do-not-contact list, skipping follow-up", conn.Name)
+			m.store.MarkConnectionAccepted(conn.ProfileURL)
+			continue
+		}
+
+		if len(m.sequence) > 0 {
+			if err := m.startSequence(conn); err != nil {
+				m.logger.Error("Failed to start the sequence for %s: %v", conn.Name, err)
+				continue
+			}
+			queued++
+			continue
+		}
+
+		// Hold the follow-up until the send window opens
+		window, err := m.sendWindow(conn.ProfileURL)
+		if err != nil {
+			return queued, err
+		}
+		notBefore := m.run.Now()
+		if window != nil {
+			notBefore = window.Next(notBefore)
+		}
+
+		if err := m.queueFollowUp(conn, messageTemplate, notBefore); err != nil {
+			m.logger.Error("Failed to queue follow-up for %s: %v", conn.Name, err)
+			continue
+		}
+		queued++
+	}
+
+	if len(m.sequence) > 0 {
+		m.logger.Info("Started %d follow-up sequences", queued)
+	} else {
+		m.logger.Info("Queued %d follow-up messages", queued)
+	}
+	return queued, nil
+}
 
-		// Send message
-		if err := m.sendMessage(conn.ProfileURL, conn.Name, messageTemplate); err != nil {
-			m.logger.Error("Failed to send message: %v", err)
+// QueueBroadcast stages a message to existing connections for review; nothing
+// is sent until the campaign is approved with the broadcast command
+func (m *Messenger) QueueBroadcast(conns []network.Connection, messageTemplate, campaign string) (int, error) {
+	queued := 0
+	for _, conn := range conns {
+		if m.doNotContact(conn.URL) {
+			m.logger.Debug("%s is on the do-not-contact list, skipping broadcast", conn.Name)
 			continue
 		}
 
-		// Update database
-		m.store.MarkConnectionAccepted(conn.ProfileURL)
-		m.store.SaveMessage(conn.ProfileURL, messageTemplate)
+		if err := m.store.Enqueue(storage.QueueItem{
+			Campaign:   campaign,
+			Action:     storage.QueueActionBroadcast,
+			ProfileURL: conn.URL,
+			Name:       conn.Name,
+			Payload:    personalize.Prefill(messageTemplate, personalize.NewData(conn.Name)),
+			Source:     "connections",
+			Status:     storage.QueueStatusReview,
+		}); err != nil {
+			return queued, err
+		}
+		queued++
+	}
+
+	m.logger.Info("Staged %d broadcast messages for review in campaign %q", queued, campaign)
+	return queued, nil
+}
+
+// ProcessQueueItem sends a single queued follow-up message
+func (m *Messenger) ProcessQueueItem(ctx context.Context, item storage.QueueItem) error {
+	return m.process(ctx, item, storage.MessageKindFollowUp)
+}
+
+// ProcessBroadcastItem sends a single approved broadcast message. Broadcasts
+// have their own, stricter daily cap on top of the overall message limit.
+func (m *Messenger) ProcessBroadcastItem(ctx context.Context, item storage.QueueItem) error {
+	count, err := m.store.GetMessagesCountTodayByKind(storage.MessageKindBroadcast)
+	if err != nil {
+		return err
+	}
+
+	if count >= m.cfg.Limits.MaxBroadcastsPerDay {
+		m.logger.Warn("Daily broadcast limit reached")
+		return fmt.Errorf("%w: daily broadcast limit reached", apperr.ErrRateLimited)
+	}
+
+	return m.process(ctx, item, storage.MessageKindBroadcast)
+}
+
+// ProcessCongratsItem sends a single queued job change message
+func (m *Messenger) ProcessCongratsItem(ctx context.Context, item storage.QueueItem) error {
+	return m.process(ctx, item, storage.MessageKindCongrats)
+}
+
+func (m *Messenger) process(ctx context.Context, item storage.QueueItem, kind string) error {
+	window, err := m.itemWindow(item)
+	if err != nil {
+		return err
+	}
+
+	// The window may have closed since the item became due
+	if now := m.run.Now(); window != nil && !window.Contains(now) {
+		next := window.Next(now)
+		if err := m.store.RescheduleQueueItem(item.ID, next); err != nil {
+			return err
+		}
+		m.logger.Debug("Send window closed, follow-up to %s deferred until %s", item.Name, next.Format(time.RFC1123))
+		return queue.ErrDeferred
+	}
+
+	// Answering a reply within minutes, at any hour, gives automation away
+	if due, err := m.replyDue(item, window); err != nil {
+		return err
+	} else if m.run.Now().Before(due) {
+		if err := m.store.RescheduleQueueItem(item.ID, due); err != nil {
+			return err
+		}
+		m.logger.Debug("%s replied recently, message deferred until %s", item.Name, due.Format(time.RFC1123))
+		return queue.ErrDeferred
+	}
+
+	// Check message limit
+	todayCount, err := m.store.GetMessagesCountToday()
+	if err != nil {
+		return err
+	}
+
+	if todayCount >= m.cfg.Limits.MaxMessagesPerDay {
+		m.logger.Warn("Daily message limit reached")
+		return fmt.Errorf("%w: daily message limit reached", apperr.ErrRateLimited)
+	}
+
+	if m.doNotContact(item.ProfileURL) {
+		m.logger.Info("%s is on the do-not-contact list, skipping follow-up", item.Name)
+		return nil
+	}
+
+	text := item.Payload
+	if personalize.Dynamic(text) {
+		d := personalize.NewData(item.Name)
+		if personalize.NeedsProfile(text) {
+			d = d.WithProfile(m.profileOf(ctx, item.ProfileURL))
+		}
+		if text, err = personalize.Render(text, d); err != nil {
+			return err
+		}
+	}
+	body := m.compliance.DecorateMessage(text)
+
+	// A previous run may have sent this and stopped before the item was done
+	if sent, err := m.alreadySent(item.ProfileURL, body); err != nil {
+		return err
+	} else if sent {
+		m.logger.Info("Identical message to %s already sent, skipping", item.Name)
+		return nil
+	}
 
-		sent++
-		m.logger.LogAction("MESSAGE_SENT", map[string]interface{}{
-			"name": conn.Name,
-			"url":  conn.ProfileURL,
-		})
+	m.logger.Info("Sending %s message to %s", strings.ReplaceAll(kind, "_", "-"), item.Name)
 
-		// Delay between messages
-		stealth.HumanDelay(
-			m.cfg.Delays.MinActionDelayMs*3,
-			m.cfg.Delays.MaxActionDelayMs*3,
-		)
+	// Replace links with per-contact tracking redirects
+	text, err = m.links.Wrap(body, item.ProfileURL, item.ID)
+	if err != nil {
+		return fmt.Errorf("failed to wrap links: %w", err)
 	}
 
-	m.logger.Info("Sent %d follow-up messages", sent)
+	// Send message
+	err = m.sendMessage(ctx, item.ProfileURL, item.Name, text)
+	if errors.Is(err, errAlreadySent) {
+		if m.cfg.DryRun {
+			return queue.Planned("record the message already in the thread")
+		}
+		// Sent before a crash but never recorded: record it now so the
+		// daily counts and later checks see it
+		m.logger.Warn("Message to %s is already in the thread, recording it without sending again", item.Name)
+		m.store.SaveMessage(item.ProfileURL, stealth.FinalText(text), kind)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+
+	// Update database with the wording that was actually sent
+	text = stealth.FinalText(text)
+	m.store.SaveMessage(item.ProfileURL, text, kind)
+
+	m.logger.LogAction(logger.Action{
+		Type:       "MESSAGE_SENT",
+		Action:     item.Action,
+		ProfileURL: item.ProfileURL,
+		Campaign:   item.Campaign,
+		Duration:   queue.Elapsed(ctx),
+		Outcome:    logger.OutcomeSuccess,
+		Details: risk.Annotate(m.store, m.cfg, item.Action, map[string]interface{}{
+			"name": item.Name,
+			"kind": kind,
+		}),
+	})
+
 	return nil
 }
 
-func (m *Messenger) checkConnectionAccepted(profileURL string) (bool, error) {
-	// Navigate to profile
-	if err := m.page.Navigate(profileURL); err != nil {
+// doNotContact reports whether the active compliance profile forbids contacting the profile
+func (m *Messenger) doNotContact(profileURL string) bool {
+	if !m.compliance.HonorDoNotContact {
+		return false
+	}
+
+	dnc, err := m.store.IsDoNotContact(profileURL)
+	if err != nil {
+		// Fail closed: never contact someone we cannot verify
+		m.logger.Error("%v", err)
+		return true
+	}
+	return dnc
+}
+
+// sendWindow returns messaging.send_window for the prospect at profileURL;
+// nil when it is disabled
+func (m *Messenger) sendWindow(profileURL string) (*stealth.SendWindow, error) {
+	window, err := m.window(m.cfg.Messaging.SendWindow, profileURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid messaging.send_window: %w", err)
+	}
+	return window, nil
+}
+
+// itemWindow returns the send window item goes out in: its own, set by a
+// campaign step, or else messaging.send_window
+func (m *Messenger) itemWindow(item storage.QueueItem) (*stealth.SendWindow, error) {
+	if item.SendWindow == "" {
+		return m.sendWindow(item.ProfileURL)
+	}
+
+	var w config.SendWindowConfig
+	if err := json.Unmarshal([]byte(item.SendWindow), &w); err != nil {
+		return nil, fmt.Errorf("failed to read the send window of queue item %d: %w", item.ID, err)
+	}
+	window, err := m.window(w, item.ProfileURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid send window on queue item %d: %w", item.ID, err)
+	}
+	return window, nil
+}
+
+// window builds w, in the prospect's own timezone when it is recipient-local
+// and their stored profile location gives one away
+func (m *Messenger) window(w config.SendWindowConfig, profileURL string) (*stealth.SendWindow, error) {
+	if !w.Enabled {
+		return nil, nil
+	}
+
+	timezone := w.Timezone
+	if w.RecipientLocal && profileURL != "" {
+		p, err := profile.Load(m.store, profileURL)
+		if err != nil {
+			m.logger.Warn("Failed to read the location of %s, using %q: %v", profileURL, timezone, err)
+		} else if p != nil {
+			if tz := stealth.TimezoneFor(p.Location); tz != "" {
+				timezone = tz
+			}
+		}
+	}
+	return stealth.NewSendWindow(w.Days, w.StartHour, w.EndHour, timezone)
+}
+
+// replyDue returns when item may go out as an answer to the prospect's
+// latest reply, within the send window or else working hours; the zero time
+// when they never replied
+func (m *Messenger) replyDue(item storage.QueueItem, window *stealth.SendWindow) (time.Time, error) {
+	repliedAt, err := m.store.GetLastReplyAt(item.ProfileURL)
+	if err != nil || repliedAt.IsZero() {
+		return time.Time{}, err
+	}
+
+	if window == nil && m.cfg.Stealth.BusinessHoursOnly {
+		s := m.cfg.Stealth
+		if window, err = stealth.NewSendWindow(nil, s.WorkStartHour, s.WorkEndHour, s.Timezone); err != nil {
+			return time.Time{}, err
+		}
+	}
+	return stealth.ReplyDue(repliedAt, item.ID^repliedAt.Unix(), window), nil
+}
+
+func (m *Messenger) queueFollowUp(conn storage.ConnectionRequest, messageTemplate string, notBefore time.Time) error {
+	if err := m.store.Enqueue(storage.QueueItem{
+		Campaign:   conn.Campaign,
+		Action:     storage.QueueActionMessage,
+		ProfileURL: conn.ProfileURL,
+		Name:       conn.Name,
+		Payload:    messageTemplate,
+		Source:     conn.Source,
+		NotBefore:  notBefore,
+	}); err != nil {
+		return err
+	}
+
+	// Accepted connections are no longer re-checked; the queue now owns the follow-up
+	m.store.MarkConnectionAccepted(conn.ProfileURL)
+
+	if notBefore.After(m.run.Now()) {
+		m.logger.Info("Connection accepted: %s. Follow-up queued until %s", conn.Name, notBefore.Format(time.RFC1123))
+	} else {
+		m.logger.Info("Connection accepted: %s. Follow-up queued", conn.Name)
+	}
+	return nil
+}
+
+// SetSequence makes QueueFollowUps start accepted connections on the
+// messaging.sequence steps instead of queueing the single follow-up; the
+// campaign runner sends the steps
+func (m *Messenger) SetSequence(steps []config.SequenceStep) {
+	m.sequence = steps
+}
+
+// startSequence adds an accepted connection to storage.SequenceCampaign with
+// its first step due
+func (m *Messenger) startSequence(conn storage.ConnectionRequest) error {
+	due := m.run.Now().AddDate(0, 0, m.sequence[0].AfterDays)
+	started, err := m.store.AddAcceptedProspect(storage.SequenceCampaign, conn.ProfileURL, conn.Name, due)
+	if err != nil {
+		return err
+	}
+
+	// Accepted connections are no longer re-checked; the sequence now owns the follow-ups
+	m.store.MarkConnectionAccepted(conn.ProfileURL)
+
+	if started {
+		m.logger.Info("Connection accepted: %s. Sequence started, first step due %s", conn.Name, due.Format(time.RFC1123))
+	}
+	return nil
+}
+
+// profileOf returns the stored profile, reading it from LinkedIn when the
+// connection request did not; nil when it cannot be read
+func (m *Messenger) profileOf(ctx context.Context, profileURL string) *profile.Profile {
+	p, err := profile.Load(m.store, profileURL)
+	if err != nil {
+		m.logger.Warn("%v", err)
+	}
+	if p != nil {
+		return p
+	}
+
+	if err := m.limiter.AcquireContext(ctx, stealth.ActionProfileView); err != nil {
+		m.logger.Warn("Not reading profile details: %v", err)
+		return nil
+	}
+	if p, err = profile.Fetch(m.page, m.logger, profileURL); err != nil {
+		m.logger.Warn("Failed to read profile details: %v", err)
+		return nil
+	}
+	if err := profile.Save(m.store, profileURL, p); err != nil {
+		m.logger.Warn("%v", err)
+	}
+	return p
+}
+
+// CheckConnectionAccepted visits the profile and reports whether it can be messaged
+func (m *Messenger) CheckConnectionAccepted(ctx context.Context, profileURL string) (bool, error) {
+	if err := m.limiter.AcquireContext(ctx, stealth.ActionProfileView); err != nil {
 		return false, err
 	}
 
+	// Navigate to profile
+	navigated := timing.Start(timing.Navigation)
+	if err := m.page.Context(ctx).Navigate(profileURL); err != nil {
+		return false, apperr.Navigation(profileURL, err)
+	}
+
 	m.page.WaitLoad()
-	stealth.RandomDelay(1000, 2000)
+	navigated()
+	if err := stealth.RandomDelayContext(ctx, 1000, 2000); err != nil {
+		return false, err
+	}
 
 	// Check if "Message" button exists (indicates connected)
-	_, err := m.page.Element("button[aria-label*='Message']")
+	_, err := dom.WaitVisibleEnabled(m.page, "button[aria-label*='Message']", dom.ControlWait)
 	return err == nil, nil
 }
 
-func (m *Messenger) sendMessage(profileURL, name, template string) error {
+func (m *Messenger) sendMessage(ctx context.Context, profileURL, name, template string) error {
+	if err := m.throttle.Acquire(ctx); err != nil {
+		return fmt.Errorf("failed to acquire throttle: %w", err)
+	}
+	defer m.throttle.Release()
+
+	// Another instance may have used up today's budget while we waited
+	if count, err := m.store.GetMessagesCountToday(); err == nil && count >= m.cfg.Limits.MaxMessagesPerDay {
+		return fmt.Errorf("%w: daily message limit reached", apperr.ErrRateLimited)
+	}
+
 	// Navigate to messaging
-	messagingURL := fmt.Sprintf("https://www.linkedin.com/messaging/thread/new/?recipient=%s", extractProfileID(profileURL))
+	messagingURL := threadURL(profileURL)
 
-	if err := m.page.Navigate(messagingURL); err != nil {
-		return err
+	navigated := timing.Start(timing.Navigation)
+	if err := m.page.Context(ctx).Navigate(messagingURL); err != nil {
+		return apperr.Navigation(messagingURL, err)
 	}
 
 	m.page.WaitLoad()
-	stealth.RandomDelay(2000, 3000)
+	navigated()
+	if err := stealth.RandomDelayContext(ctx, 2000, 3000); err != nil {
+		return err
+	}
 
 	// Find message compose box
 	composeBox, err := m.findComposeBox()
@@ -130,32 +553,56 @@ func (m *Messenger) sendMessage(profileURL, name, template string) error {
 		return err
 	}
 
+	// Never send the same message twice into one thread
+	if m.inThread(template) {
+		return errAlreadySent
+	}
+
+	// Typing would leave a draft in the thread
+	if m.cfg.DryRun {
+		return queue.Planned("send the message %q", stealth.FinalText(template))
+	}
+
 	// Click to focus
-	stealth.HumanClick(m.page, composeBox)
-	stealth.SimulateThinking()
-
-	// Type message
-	m.logger.Debug("Typing message")
-	if err := stealth.HumanType(
-		m.page,
-		composeBox,
-		template,
-		m.cfg.Delays.MinTypingDelayMs,
-		m.cfg.Delays.MaxTypingDelayMs,
-		m.cfg.Stealth.TypoProbability,
-	); err != nil {
+	page := m.page.Context(ctx)
+	stealth.HumanClick(page, composeBox)
+	if err := stealth.SimulateThinking(ctx); err != nil {
 		return err
 	}
 
-	stealth.RandomDelay(1000, 2000)
+	// Long messages are prepared elsewhere and pasted; short ones are typed
+	final := stealth.FinalText(template)
+	if limit := m.cfg.Messaging.PasteOverChars; limit > 0 && utf8.RuneCountInString(final) > limit {
+		m.logger.Debug("Pasting message")
+		if err := stealth.PasteText(page, composeBox, final); err != nil {
+			return err
+		}
+	} else if m.cfg.Messaging.DraftAndRevise {
+		m.logger.Debug("Composing message with revisions")
+		if err := m.typist.ComposeWithRevisions(page, composeBox, template); err != nil {
+			return err
+		}
+	} else {
+		m.logger.Debug("Typing message")
+		if err := m.typist.Type(page, composeBox, final); err != nil {
+			return err
+		}
+	}
+
+	if err := stealth.RandomDelayContext(ctx, 1000, 2000); err != nil {
+		return err
+	}
 
 	// Find and click send button
-	sendBtn, err := m.page.Element("button[type='submit']")
+	sendBtn, err := dom.Find(m.page, m.logger, dom.MessageSendButton)
 	if err != nil {
-		return errors.New("send button not found")
+		return err
 	}
 
-	return stealth.HumanClick(m.page, sendBtn)
+	shot := capture.Before(m.run, m.page, "message", profileURL)
+	err = stealth.HumanClick(page, sendBtn)
+	shot.After(ctx, err)
+	return err
 }
 
 func (m *Messenger) findComposeBox() (*rod.Element, error) {
@@ -165,13 +612,27 @@ func (m *Messenger) findComposeBox() (*rod.Element, error) {
 		".msg-form__msg-content-container",
 	}
 
+	// Give the page time to render once, then probe the rest without waiting
+	wait := dom.ControlWait
 	for _, sel := range selectors {
-		if el, err := m.page.Element(sel); err == nil {
+		if el, err := dom.WaitVisibleEnabled(m.page, sel, wait); err == nil {
 			return el, nil
 		}
+		wait = 0
+	}
+
+	// Without a compose box LinkedIn is usually offering InMail instead,
+	// because the recipient is not a 1st-degree connection
+	if has, _, _ := m.page.Has("button[aria-label*='Connect']"); has {
+		return nil, fmt.Errorf("%w: compose box unavailable", apperr.ErrNotConnected)
 	}
 
-	return nil, errors.New("compose box not found")
+	return nil, apperr.Selector("compose box")
+}
+
+// threadURL opens the conversation with a profile, starting one if needed
+func threadURL(profileURL string) string {
+	return fmt.Sprintf("https://www.linkedin.com/messaging/thread/new/?recipient=%s", extractProfileID(profileURL))
 }
 
 func extractProfileID(profileURL string) string {
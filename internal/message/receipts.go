@@ -0,0 +1,194 @@
+package message
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-rod/rod"
+
+	"linkedin-automation/internal/apperr"
+	"linkedin-automation/internal/stealth"
+	"linkedin-automation/internal/storage"
+	"linkedin-automation/internal/timing"
+)
+
+const (
+	// receiptWindow is how long after sending a message's thread is still checked
+	receiptWindow = 30 * 24 * time.Hour
+	// receiptRecheck spaces out checks of the same thread
+	receiptRecheck = 20 * time.Hour
+)
+
+// seenJS finds LinkedIn's "seen" indicator: the recipient's avatar placed
+// under the last message they have read
+const seenJS = `() => !!document.querySelector(
+	'.msg-s-event-listitem__seen-receipts img, .msg-seen-receipt, img[title^="Seen by"], img[alt^="Seen by"]')`
+
+// SeenShown reports whether the open thread shows a "seen" indicator
+func SeenShown(page *rod.Page) bool {
+	res, err := page.Eval(seenJS)
+	return err == nil && res.Value.Bool()
+}
+
+// CheckReceipts opens the threads of recently sent messages that have no
+// reply yet and records whether they were seen or answered. Replies keep the
+// time shown in the thread; otherwise times are those of the first check
+// that saw them. When ctx is done the rest are checked next run
+func (m *Messenger) CheckReceipts(ctx context.Context) (int, error) {
+	limit := m.cfg.Messaging.ReceiptChecksPerRun
+	if limit <= 0 {
+		return 0, nil
+	}
+
+	checks, err := m.store.GetReceiptChecks(m.run.Now().Add(-receiptWindow), receiptRecheck, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	checked := 0
+	for _, c := range checks {
+		if ctx.Err() != nil {
+			break
+		}
+		seen, repliedAt, err := m.readReceipt(ctx, c)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			m.logger.Warn("Failed to check read receipt for %s: %v", c.ProfileURL, err)
+			// Stamp the check anyway, so a thread that keeps failing waits
+			// out the recheck interval instead of heading every run's batch
+			if err := m.store.UpdateReceipt(c.ID, time.Time{}, time.Time{}); err != nil {
+				return checked, err
+			}
+			continue
+		}
+
+		// Replying means it was seen by then at the latest
+		replied := !repliedAt.IsZero()
+		var seenAt time.Time
+		if replied {
+			seenAt = repliedAt
+		} else if seen {
+			seenAt = time.Now()
+		}
+		if replied {
+			m.logger.Info("%s replied", nameOr(c.Name, c.ProfileURL))
+		} else if seen && !c.Seen {
+			m.logger.Info("%s has seen your message", nameOr(c.Name, c.ProfileURL))
+		}
+
+		if err := m.store.UpdateReceipt(c.ID, seenAt, repliedAt); err != nil {
+			return checked, err
+		}
+		checked++
+		stealth.RandomDelayContext(ctx, 2000, 5000)
+	}
+
+	m.logger.Info("Checked read receipts for %d messages", checked)
+	return checked, nil
+}
+
+// readReceipt reports whether the recipient has seen the message and when
+// anyone else wrote in the thread since (zero if nobody has)
+func (m *Messenger) readReceipt(ctx context.Context, c storage.ReceiptCheck) (seen bool, repliedAt time.Time, err error) {
+	url := threadURL(c.ProfileURL)
+
+	navigated := timing.Start(timing.Navigation)
+	if err := m.page.Context(ctx).Navigate(url); err != nil {
+		return false, time.Time{}, apperr.Navigation(url, err)
+	}
+	m.page.WaitLoad()
+	navigated()
+	// The thread has loaded; it is read even if ctx ends the wait
+	stealth.RandomDelayContext(ctx, 1500, 3000)
+
+	extracted := timing.Start(timing.Extraction)
+	defer extracted()
+
+	msgs, err := ReadThread(m.page)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+
+	want := normalize(c.Content)
+	mine := -1
+	for i, msg := range msgs {
+		if normalize(msg.Text) == want {
+			mine = i
+		}
+	}
+	if mine < 0 {
+		return false, time.Time{}, fmt.Errorf("sent message not found in thread")
+	}
+
+	for _, msg := range msgs[mine+1:] {
+		if msg.Sender != "" && msg.Sender != msgs[mine].Sender {
+			// A day without a clock time reads as midnight, before the message
+			now := time.Now()
+			if at, ok := msg.SentAt(now); ok && !at.After(now) && at.After(c.SentAt) {
+				return true, at, nil
+			}
+			return true, now, nil
+		}
+	}
+	return SeenShown(m.page), time.Time{}, nil
+}
+
+func nameOr(name, fallback string) string {
+	if name != "" {
+		return name
+	}
+	return fallback
+}
+
+// QueueBumps queues one nudge for every contact who saw a follow-up at least
+// messaging.bump_after_days ago and has not replied
+func (m *Messenger) QueueBumps(messageTemplate string) (int, error) {
+	days := m.cfg.Messaging.BumpAfterDays
+	if days <= 0 || messageTemplate == "" {
+		return 0, nil
+	}
+
+	candidates, err := m.store.GetBumpCandidates(m.run.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	for _, c := range candidates {
+		if m.doNotContact(c.ProfileURL) {
+			continue
+		}
+
+		window, err := m.sendWindow(c.ProfileURL)
+		if err != nil {
+			return queued, err
+		}
+		notBefore := m.run.Now()
+		if window != nil {
+			notBefore = window.Next(notBefore)
+		}
+		if err := m.store.Enqueue(storage.QueueItem{
+			Campaign:   c.Campaign,
+			Action:     storage.QueueActionBump,
+			ProfileURL: c.ProfileURL,
+			Name:       c.Name,
+			Payload:    messageTemplate,
+			Source:     c.Source,
+			NotBefore:  notBefore,
+		}); err != nil {
+			return queued, err
+		}
+		queued++
+	}
+
+	m.logger.Info("Queued %d bumps for follow-ups seen %d+ days ago without a reply", queued, days)
+	return queued, nil
+}
+
+// ProcessBumpItem sends a single queued bump
+func (m *Messenger) ProcessBumpItem(ctx context.Context, item storage.QueueItem) error {
+	return m.process(ctx, item, storage.MessageKindBump)
+}
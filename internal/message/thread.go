@@ -0,0 +1,64 @@
+package message
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+
+	"linkedin-automation/internal/dates"
+)
+
+// ThreadMessage is one message of an open conversation thread
+type ThreadMessage struct {
+	Sender string `json:"sender"`
+	// Day is the heading of the day the message is under ("Yesterday",
+	// "Mar 5"); Time is the clock time of its group
+	Day  string `json:"day,omitempty"`
+	Time string `json:"time"`
+	Text string `json:"text"`
+}
+
+// SentAt reads when the message was sent from its day heading and time;
+// ok is false when LinkedIn showed nothing dates.Parse understands
+func (m ThreadMessage) SentAt(now time.Time) (time.Time, bool) {
+	return dates.Parse(strings.TrimSpace(m.Day+" "+m.Time), now)
+}
+
+// readThreadJS walks the thread in order; consecutive messages from one
+// sender share the name and timestamp of their group, and groups share the
+// day heading above them
+const readThreadJS = `() => {
+	const out = [];
+	let sender = '', day = '', time = '';
+	for (const ev of document.querySelectorAll('.msg-s-message-list__time-heading, .msg-s-message-list__event')) {
+		if (ev.matches('.msg-s-message-list__time-heading')) {
+			day = ev.innerText.trim();
+			continue;
+		}
+		const name = ev.querySelector('.msg-s-message-group__name');
+		if (name) sender = name.innerText.trim();
+		const ts = ev.querySelector('time.msg-s-message-group__timestamp');
+		if (ts) time = ts.innerText.trim();
+		for (const body of ev.querySelectorAll('.msg-s-event-listitem__body')) {
+			const text = body.innerText.trim();
+			if (text) out.push({sender, day, time, text});
+		}
+	}
+	return out;
+}`
+
+// ReadThread reads the messages of the conversation thread currently loaded
+func ReadThread(page *rod.Page) ([]ThreadMessage, error) {
+	res, err := page.Eval(readThreadJS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read thread: %w", err)
+	}
+
+	var msgs []ThreadMessage
+	if err := res.Value.Unmarshal(&msgs); err != nil {
+		return nil, fmt.Errorf("failed to read thread: %w", err)
+	}
+	return msgs, nil
+}
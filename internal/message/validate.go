@@ -0,0 +1,120 @@
+package message
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"linkedin-automation/internal/stealth"
+	"linkedin-automation/internal/timing"
+)
+
+// Sample is a message exercising one kind of international content
+type Sample struct {
+	Name     string
+	Template string
+}
+
+// Samples cover the content most likely to be mangled between template and
+// thread: combining accents, emoji with modifiers and joiners, right-to-left
+// scripts mixed with Latin, and CJK. They are short enough to be typed, so
+// the runes no key can produce go through text insertion mid-word
+var Samples = []Sample{
+	{"mixed_keys", "Hi {name},\nFrançois, Zoë and Łukasz say hi: naïve → ok ✓"},
+	{"accents", "Bonjour {name}, ça va ? Grüße aus München, señor Ødegård – naïve café déjà vu."},
+	{"emoji", "Thanks {name} 🙏 talk soon 🚀 👍🏽 👩‍💻 🇩🇪"},
+	{"rtl_arabic", "مرحبا {name}، سعدت بالتواصل معك! See you at 10:30."},
+	{"rtl_hebrew", "שלום {name}, נשמח לדבר בשבוע הבא (Tuesday)."},
+	{"cjk", "{name}さん、はじめまして。你好，很高兴认识你。반갑습니다!"},
+}
+
+// ValidationResult compares what was sent with what the thread rendered
+type ValidationResult struct {
+	Sample   string
+	Sent     string
+	Rendered string
+	Err      error
+}
+
+func (r ValidationResult) Passed() bool {
+	return r.Err == nil && collapse(r.Sent) == collapse(r.Rendered)
+}
+
+// Validate sends every sample to a test account through the normal compose
+// path (typing, pasting or revising as configured) and reads each back from
+// the thread. Nothing is recorded as outreach
+func (m *Messenger) Validate(ctx context.Context, profileURL, name string) []ValidationResult {
+	// A per-run stamp keeps the duplicate guard from skipping a rerun
+	stamp := time.Now().Format("0102-150405")
+
+	var results []ValidationResult
+	for _, s := range Samples {
+		if ctx.Err() != nil {
+			break
+		}
+		text := strings.ReplaceAll(s.Template, "{name}", name) + " [" + s.Name + " " + stamp + "]"
+		res := ValidationResult{Sample: s.Name, Sent: text}
+
+		m.logger.Info("Validation: sending %s sample", s.Name)
+		if err := m.sendMessage(ctx, profileURL, name, text); err != nil {
+			res.Err = err
+			results = append(results, res)
+			continue
+		}
+
+		res.Rendered, res.Err = m.lastRendered(ctx, text)
+		results = append(results, res)
+		stealth.RandomDelayContext(ctx, 3000, 6000)
+	}
+	return results
+}
+
+// lastRendered waits for the sent message to appear and returns the thread's
+// rendering of it, matched by the trailing stamp
+func (m *Messenger) lastRendered(ctx context.Context, sent string) (string, error) {
+	tag := sent[strings.LastIndex(sent, " ["):]
+
+	deadline := time.Now().Add(15 * time.Second)
+	for time.Now().Before(deadline) {
+		if err := stealth.Wait(ctx, time.Second); err != nil {
+			return "", err
+		}
+
+		extracted := timing.Start(timing.Extraction)
+		msgs, err := ReadThread(m.page)
+		extracted()
+		if err != nil {
+			return "", err
+		}
+		for i := len(msgs) - 1; i >= 0; i-- {
+			if strings.Contains(msgs[i].Text, strings.TrimSpace(tag)) {
+				return msgs[i].Text, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("sent message did not appear in the thread")
+}
+
+// Diff describes where the rendering first departs from what was sent
+func (r ValidationResult) Diff() string {
+	sent, got := []rune(collapse(r.Sent)), []rune(collapse(r.Rendered))
+	i := 0
+	for i < len(sent) && i < len(got) && sent[i] == got[i] {
+		i++
+	}
+	return fmt.Sprintf("differs at character %d: sent %q, rendered %q", i, excerpt(sent, i), excerpt(got, i))
+}
+
+func excerpt(r []rune, at int) string {
+	end := min(at+12, len(r))
+	if at > len(r) {
+		return ""
+	}
+	return string(r[at:end])
+}
+
+// collapse evens out whitespace, which LinkedIn normalises when rendering
+func collapse(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,225 @@
+package network
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+
+	"linkedin-automation/config"
+	"linkedin-automation/internal/apperr"
+	"linkedin-automation/internal/dates"
+	"linkedin-automation/internal/logger"
+	"linkedin-automation/internal/stealth"
+	"linkedin-automation/internal/timing"
+)
+
+// Connection is a 1st-degree connection as listed on the My Network page
+type Connection struct {
+	URL      string
+	Name     string
+	Headline string
+	// Connected is the card's badge, e.g. "Connected 2 weeks ago"
+	Connected string `json:",omitempty"`
+}
+
+// ConnectedAt reads when the connection was made from the card's badge; ok
+// is false when the card shows none dates.Parse understands
+func (c Connection) ConnectedAt(now time.Time) (time.Time, bool) {
+	return dates.Parse(c.Connected, now)
+}
+
+// Filter selects connections by headline; empty fields match everything
+type Filter struct {
+	Title   string
+	Company string
+}
+
+// Matches reports whether the connection's headline ("Title at Company")
+// contains the filter's title and company, case-insensitively
+func (f Filter) Matches(c Connection) bool {
+	headline := strings.ToLower(c.Headline)
+	if f.Title != "" && !strings.Contains(headline, strings.ToLower(f.Title)) {
+		return false
+	}
+	if f.Company != "" && !strings.Contains(headline, strings.ToLower(f.Company)) {
+		return false
+	}
+	return true
+}
+
+type Lister struct {
+	page   *rod.Page
+	cfg    *config.Config
+	logger *logger.Logger
+}
+
+func New(page *rod.Page, cfg *config.Config, log *logger.Logger) *Lister {
+	return &Lister{
+		page:   page,
+		cfg:    cfg,
+		logger: log,
+	}
+}
+
+// ListConnections scrolls the connections list until maxResults connections
+// match the filter or the list stops growing
+func (l *Lister) ListConnections(filter Filter, maxResults int) ([]Connection, error) {
+	l.logger.Info("Listing 1st-degree connections (title=%q, company=%q)", filter.Title, filter.Company)
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+
+	var matched []Connection
+	seen := make(map[string]bool)
+
+	for stale := 0; len(matched) < maxResults && stale < 3; {
+		extracted := timing.Start(timing.Extraction)
+		conns, err := ParseConnections(l.page)
+		extracted()
+		if err != nil {
+			return nil, err
+		}
+
+		before := len(seen)
+		for _, c := range conns {
+			if seen[c.URL] {
+				continue
+			}
+			seen[c.URL] = true
+
+			if filter.Matches(c) && len(matched) < maxResults {
+				matched = append(matched, c)
+			}
+		}
+
+		// Three scrolls without new cards means the list is exhausted
+		if len(seen) == before {
+			stale++
+		} else {
+			stale = 0
+		}
+
+		stealth.HumanScroll(l.page, "down", 800+rand.Intn(600))
+		stealth.RandomDelay(1500, 3000)
+	}
+
+	l.logger.Info("Scanned %d connections, %d match the filter", len(seen), len(matched))
+	return matched, nil
+}
+
+// RecentConnections reads the connections list, which LinkedIn sorts by
+// recently added, back to the first connection made before since; it stops
+// early after maxResults connections or when the list stops growing
+func (l *Lister) RecentConnections(since time.Time, maxResults int) ([]Connection, error) {
+	l.logger.Info("Listing connections added since %s", since.Local().Format("Jan 2"))
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+
+	var recent []Connection
+	seen := make(map[string]bool)
+	reached := false
+
+	for stale := 0; !reached && len(recent) < maxResults && stale < 3; {
+		extracted := timing.Start(timing.Extraction)
+		conns, err := ParseConnections(l.page)
+		extracted()
+		if err != nil {
+			return nil, err
+		}
+
+		before := len(seen)
+		for _, c := range conns {
+			if seen[c.URL] {
+				continue
+			}
+			seen[c.URL] = true
+
+			if at, ok := c.ConnectedAt(time.Now()); ok && at.Before(since) {
+				reached = true
+				continue
+			}
+			if len(recent) < maxResults {
+				recent = append(recent, c)
+			}
+		}
+
+		if len(seen) == before {
+			stale++
+		} else {
+			stale = 0
+		}
+
+		if !reached {
+			stealth.HumanScroll(l.page, "down", 800+rand.Intn(600))
+			stealth.RandomDelay(1500, 3000)
+		}
+	}
+
+	l.logger.Info("Found %d connections added since %s", len(recent), since.Local().Format("Jan 2"))
+	return recent, nil
+}
+
+// open loads the connections page
+func (l *Lister) open() error {
+	url := strings.TrimSuffix(l.cfg.LinkedIn.BaseURL, "/") + "/mynetwork/invite-connect/connections/"
+
+	navigated := timing.Start(timing.Navigation)
+	if err := l.page.Navigate(url); err != nil {
+		return apperr.Navigation(url, err)
+	}
+	if err := l.page.WaitLoad(); err != nil {
+		return apperr.Navigation(url, err)
+	}
+	navigated()
+	stealth.RandomDelay(2000, 4000)
+	return nil
+}
+
+// ParseConnections reads the connection cards rendered so far on the connections page
+func ParseConnections(page *rod.Page) ([]Connection, error) {
+	cards, err := page.Elements("li.mn-connection-card")
+	if err != nil {
+		return nil, fmt.Errorf("%w: connection cards: %v", apperr.ErrSelectorMissing, err)
+	}
+
+	var conns []Connection
+	for _, card := range cards {
+		if c, ok := extractConnection(card); ok {
+			conns = append(conns, c)
+		}
+	}
+	return conns, nil
+}
+
+func extractConnection(card *rod.Element) (Connection, bool) {
+	var c Connection
+
+	has, link, _ := card.Has("a.mn-connection-card__link")
+	if !has {
+		return c, false
+	}
+	href, err := link.Property("href")
+	if err != nil {
+		return c, false
+	}
+	c.URL = strings.Split(href.String(), "?")[0]
+
+	if has, el, _ := card.Has(".mn-connection-card__name"); has {
+		text, _ := el.Text()
+		c.Name = strings.TrimSpace(text)
+	}
+	if has, el, _ := card.Has(".mn-connection-card__occupation"); has {
+		text, _ := el.Text()
+		c.Headline = strings.TrimSpace(text)
+	}
+	if has, el, _ := card.Has("time"); has {
+		text, _ := el.Text()
+		c.Connected = strings.TrimSpace(text)
+	}
+
+	return c, c.URL != ""
+}
@@ -0,0 +1,366 @@
+// Package personalize renders connection notes and messages. Templates are Go
+// text/template, e.g. "Hi {{.FirstName | default "there"}}{{if .Company}},
+// how is {{.Company}}?{{end}}"; the older {name} style placeholders and the
+// {{draft|final}} revision markers keep working alongside them.
+package personalize
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	tparse "text/template/parse"
+
+	"linkedin-automation/internal/profile"
+)
+
+// Data is what a template can use. Name, Title and Location come from the
+// search result or the queue item; the rest from the stored profile, and are
+// empty until it has been read
+type Data struct {
+	Name      string
+	FirstName string
+	LastName  string
+	Title     string
+	Location  string
+
+	Headline string
+	Company  string
+	School   string
+	Skill    string
+	Mutuals  int
+}
+
+// NewData derives the name fields from a full name
+func NewData(name string) Data {
+	d := Data{Name: strings.TrimSpace(name)}
+	if names := strings.Fields(d.Name); len(names) > 0 {
+		d.FirstName = names[0]
+		if len(names) > 1 {
+			d.LastName = names[len(names)-1]
+		}
+	}
+	return d
+}
+
+// WithProfile adds the details of a read profile; p may be nil
+func (d Data) WithProfile(p *profile.Profile) Data {
+	if p == nil {
+		return d
+	}
+	if d.Name == "" {
+		name := NewData(p.Name)
+		d.Name, d.FirstName, d.LastName = name.Name, name.FirstName, name.LastName
+	}
+	d.Headline = p.Headline
+	d.Company = p.Company
+	if len(p.Education) > 0 {
+		d.School = p.Education[0].School
+	}
+	if len(p.Skills) > 0 {
+		d.Skill = p.Skills[0]
+	}
+	d.Mutuals = p.Mutuals
+	if d.Title == "" {
+		d.Title = p.Headline
+	}
+	if d.Location == "" {
+		d.Location = p.Location
+	}
+	return d
+}
+
+// sample exercises every field when a template is validated
+var sample = Data{
+	Name: "Ada Lovelace", FirstName: "Ada", LastName: "Lovelace",
+	Title: "Engineer", Location: "London", Headline: "Engineer at Analytical Engines",
+	Company: "Analytical Engines", School: "University of London", Skill: "Mathematics", Mutuals: 3,
+}
+
+var funcs = template.FuncMap{
+	// {{.FirstName | default "there"}}
+	"default": func(fallback string, v interface{}) string {
+		if v == nil {
+			return fallback
+		}
+		if s := strings.TrimSpace(fmt.Sprint(v)); s != "" && s != "0" {
+			return s
+		}
+		return fallback
+	},
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"title": capitalize,
+	"trim":  strings.TrimSpace,
+	// first returns the first word, e.g. the company before "Inc."
+	"first": func(s string) string {
+		if f := strings.Fields(s); len(f) > 0 {
+			return f[0]
+		}
+		return ""
+	},
+	// {{.Headline | truncate 40}}
+	"truncate": func(n int, s string) string {
+		if r := []rune(s); n > 0 && len(r) > n {
+			return strings.TrimSpace(string(r[:n]))
+		}
+		return s
+	},
+}
+
+// capitalize upper-cases the first letter of each word
+func capitalize(s string) string {
+	words := strings.Fields(strings.ToLower(s))
+	for i, w := range words {
+		r := []rune(w)
+		r[0] = []rune(strings.ToUpper(string(r[0])))[0]
+		words[i] = string(r)
+	}
+	return strings.Join(words, " ")
+}
+
+var (
+	// legacy maps the {name} style placeholders to template actions
+	legacy = map[string]string{
+		"name":       "{{.Name}}",
+		"first_name": "{{.FirstName}}",
+		"title":      "{{.Title}}",
+		"location":   "{{.Location}}",
+		"headline":   "{{.Headline}}",
+		"company":    "{{.Company}}",
+		"school":     "{{.School}}",
+		"skill":      "{{.Skill}}",
+		"mutuals":    "{{with .Mutuals}}{{.}}{{end}}",
+	}
+	legacyPattern = regexp.MustCompile(`\{(name|first_name|title|location|headline|company|school|skill|mutuals)\}`)
+
+	// revisionPattern matches the stealth package's {{draft|final}} markers;
+	// markers are told apart from actions like {{.Name | lower}} by isAction
+	revisionPattern = regexp.MustCompile(`\{\{([^{}|]*)\|([^{}]*)\}\}`)
+
+	// profileFields are the fields only a read profile provides
+	profileFields = regexp.MustCompile(`\.(Headline|Company|School|Skill|Mutuals)\b`)
+
+	keywords = map[string]bool{
+		"if": true, "else": true, "end": true, "range": true, "with": true, "define": true,
+		"template": true, "block": true, "break": true, "continue": true, "nil": true,
+		"not": true, "and": true, "or": true, "eq": true, "ne": true, "lt": true, "le": true,
+		"gt": true, "ge": true, "len": true, "index": true, "print": true, "printf": true,
+		"println": true, "slice": true, "html": true, "js": true, "urlquery": true, "call": true,
+	}
+)
+
+// isAction reports whether the inside of {{ }} is template code rather than
+// the draft wording of a revision marker
+func isAction(inner string) bool {
+	inner = strings.TrimSpace(inner)
+	// {{|inserted words}} drafts nothing
+	if inner == "" {
+		return false
+	}
+	if strings.ContainsAny(inner[:1], `.$-"/(`) {
+		return true
+	}
+	word := strings.FieldsFunc(inner, func(r rune) bool { return r == ' ' || r == '|' || r == '(' })[0]
+	_, fn := funcs[word]
+	return keywords[word] || fn
+}
+
+// source rewrites text into plain template syntax: revision markers become
+// literals that render back unchanged, old placeholders become actions
+func source(text string) string {
+	text = revisionPattern.ReplaceAllStringFunc(text, func(m string) string {
+		if isAction(revisionPattern.FindStringSubmatch(m)[1]) {
+			return m
+		}
+		return "{{" + strconv.Quote(m) + "}}"
+	})
+	return legacyPattern.ReplaceAllStringFunc(text, func(m string) string {
+		return legacy[m[1:len(m)-1]]
+	})
+}
+
+func parse(text string) (*template.Template, error) {
+	t, err := template.New("text").Funcs(funcs).Option("missingkey=error").Parse(source(text))
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return t, nil
+}
+
+// Render fills text in with d
+func Render(text string, d Data) (string, error) {
+	t, err := parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, d); err != nil {
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+	return b.String(), nil
+}
+
+// Validate parses text and renders it once with sample data, so unknown
+// fields and functions are reported before anything is queued
+func Validate(text string) error {
+	_, err := Render(text, sample)
+	return err
+}
+
+// Dynamic reports whether text has anything to fill in
+func Dynamic(text string) bool {
+	stripped := revisionPattern.ReplaceAllStringFunc(text, func(m string) string {
+		if isAction(revisionPattern.FindStringSubmatch(m)[1]) {
+			return m
+		}
+		return ""
+	})
+	return strings.Contains(stripped, "{{") || legacyPattern.MatchString(stripped)
+}
+
+// NeedsProfile reports whether text uses details only a read profile has
+func NeedsProfile(text string) bool {
+	return profileFields.MatchString(source(text))
+}
+
+// Prefill renders text when it needs nothing from the profile, so reviewers
+// and the queue see the final wording; otherwise text is left for Render at
+// send time
+func Prefill(text string, d Data) string {
+	if !Dynamic(text) || NeedsProfile(text) {
+		return text
+	}
+	out, err := Render(text, d)
+	if err != nil {
+		return text
+	}
+	return out
+}
+
+// Missing returns the fields text prints that are empty in d, so "Hi ,
+// saw your work at " is not sent. Fields given a default, or printed inside
+// an {{if}} or {{with}} on the same field, do not count
+func Missing(text string, d Data) ([]string, error) {
+	t, err := parse(text)
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	seen := make(map[string]bool)
+	var walk func(n tparse.Node, guarded map[string]bool)
+	walk = func(n tparse.Node, guarded map[string]bool) {
+		switch n := n.(type) {
+		case *tparse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, c := range n.Nodes {
+				walk(c, guarded)
+			}
+		case *tparse.ActionNode:
+			if n.Pipe == nil || len(n.Pipe.Decl) > 0 {
+				return
+			}
+			for _, cmd := range n.Pipe.Cmds {
+				if id, ok := cmd.Args[0].(*tparse.IdentifierNode); ok && id.Ident == "default" {
+					return
+				}
+			}
+			for _, name := range fieldsOf(n.Pipe) {
+				if !guarded[name] && !seen[name] && empty(d, name) {
+					seen[name] = true
+					missing = append(missing, name)
+				}
+			}
+		case *tparse.IfNode:
+			walkBranch(n.BranchNode, guarded, walk)
+		case *tparse.WithNode:
+			walkBranch(n.BranchNode, guarded, walk)
+		case *tparse.RangeNode:
+			walkBranch(n.BranchNode, guarded, walk)
+		}
+	}
+	walk(t.Tree.Root, nil)
+	return missing, nil
+}
+
+// walkBranch walks an {{if}}, {{with}} or {{range}} body with the fields its
+// condition tests counted as guarded
+func walkBranch(b tparse.BranchNode, guarded map[string]bool, walk func(tparse.Node, map[string]bool)) {
+	inner := make(map[string]bool, len(guarded))
+	for name := range guarded {
+		inner[name] = true
+	}
+	for _, name := range fieldsOf(b.Pipe) {
+		inner[name] = true
+	}
+	walk(b.List, inner)
+	walk(b.ElseList, guarded)
+}
+
+// fieldsOf returns the Data fields a pipeline reads
+func fieldsOf(pipe *tparse.PipeNode) []string {
+	if pipe == nil {
+		return nil
+	}
+	var names []string
+	for _, cmd := range pipe.Cmds {
+		for _, arg := range cmd.Args {
+			switch arg := arg.(type) {
+			case *tparse.FieldNode:
+				names = append(names, arg.Ident[0])
+			case *tparse.PipeNode:
+				names = append(names, fieldsOf(arg)...)
+			}
+		}
+	}
+	return names
+}
+
+// empty reports whether the field name of d has nothing to print
+func empty(d Data, name string) bool {
+	switch name {
+	case "Name":
+		return strings.TrimSpace(d.Name) == ""
+	case "FirstName":
+		return strings.TrimSpace(d.FirstName) == ""
+	case "LastName":
+		return strings.TrimSpace(d.LastName) == ""
+	case "Title":
+		return strings.TrimSpace(d.Title) == ""
+	case "Location":
+		return strings.TrimSpace(d.Location) == ""
+	case "Headline":
+		return strings.TrimSpace(d.Headline) == ""
+	case "Company":
+		return strings.TrimSpace(d.Company) == ""
+	case "School":
+		return strings.TrimSpace(d.School) == ""
+	case "Skill":
+		return strings.TrimSpace(d.Skill) == ""
+	case "Mutuals":
+		return d.Mutuals == 0
+	}
+	return false
+}
+
+// Load resolves a template setting: "file:note.tmpl" reads the file, relative
+// to dir unless absolute; anything else is the template itself
+func Load(value, dir string) (string, error) {
+	path, ok := strings.CutPrefix(value, "file:")
+	if !ok {
+		return value, nil
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read template: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
@@ -0,0 +1,221 @@
+// Package profile reads the details of a LinkedIn profile (headline, about,
+// experience, education, skills and mutual connections) and keeps them in
+// the profiles table, where notes and messages find them for personalization.
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+
+	"linkedin-automation/internal/apperr"
+	"linkedin-automation/internal/logger"
+	"linkedin-automation/internal/stealth"
+	"linkedin-automation/internal/storage"
+	"linkedin-automation/internal/timing"
+)
+
+type Experience struct {
+	Title   string `json:"title"`
+	Company string `json:"company"`
+	Dates   string `json:"dates,omitempty"`
+}
+
+type Education struct {
+	School string `json:"school"`
+	Degree string `json:"degree,omitempty"`
+	Dates  string `json:"dates,omitempty"`
+}
+
+// Profile is what a profile page shows; Experience lists the current
+// position first, as the page does
+type Profile struct {
+	URL        string       `json:"url"`
+	Name       string       `json:"name"`
+	Headline   string       `json:"headline"`
+	Location   string       `json:"location,omitempty"`
+	About      string       `json:"about,omitempty"`
+	Company    string       `json:"company,omitempty"`
+	Experience []Experience `json:"experience,omitempty"`
+	Education  []Education  `json:"education,omitempty"`
+	Skills     []string     `json:"skills,omitempty"`
+	Mutuals    int          `json:"mutuals"`
+	ScrapedAt  time.Time    `json:"scraped_at"`
+}
+
+// scrapeJS reads the profile in one pass. Sections are found by their anchor
+// ids; in each list item the aria-hidden spans hold the visible text lines
+const scrapeJS = `() => {
+	const text = (el, sel) => ((sel ? el?.querySelector(sel) : el)?.innerText || '').trim();
+	const section = (id) => document.getElementById(id)?.closest('section');
+	const items = (id) => Array.from(section(id)?.querySelectorAll('li.artdeco-list__item') || [])
+		.map(li => Array.from(li.querySelectorAll('span[aria-hidden="true"]'))
+			.map(s => s.innerText.trim()).filter(Boolean));
+	const about = section('about');
+	return {
+		url: location.href.split('?')[0],
+		name: text(document, 'main h1'),
+		headline: text(document, 'main .text-body-medium.break-words'),
+		location: text(document, 'main .text-body-small.inline.t-black--light.break-words'),
+		about: text(about, '.inline-show-more-text span[aria-hidden="true"]') || text(about, '.inline-show-more-text'),
+		current_company: (document.querySelector('main button[aria-label^="Current company"]')
+			?.getAttribute('aria-label') || '').replace(/^Current company:\s*/i, '').replace(/\.\s*Click.*$/i, '').trim(),
+		experience: items('experience'),
+		education: items('education'),
+		skills: items('skills').map(lines => lines[0]),
+		main: text(document, 'main').slice(0, 5000),
+	};
+}`
+
+var (
+	// "Jane, John and 12 other mutual connections"
+	othersRe  = regexp.MustCompile(`(?i)and (\d+) other mutual connections?`)
+	mutualsRe = regexp.MustCompile(`(?i)(\d+) mutual connections?`)
+)
+
+// Fetch opens the profile and reads it; lazy sections are scrolled into view
+func Fetch(page *rod.Page, log *logger.Logger, url string) (*Profile, error) {
+	log.Debug("Reading profile %s", url)
+	navigated := timing.Start(timing.Navigation)
+	if err := page.Navigate(url); err != nil {
+		return nil, apperr.Navigation(url, err)
+	}
+	if err := page.WaitLoad(); err != nil {
+		return nil, apperr.Navigation(url, err)
+	}
+	navigated()
+	stealth.RandomDelay(2000, 4000)
+	stealth.PageThroughContent(page, 3)
+
+	p, err := Scrape(page)
+	if err != nil {
+		return nil, err
+	}
+	p.ScrapedAt = time.Now()
+	return p, nil
+}
+
+// Scrape reads the profile page currently loaded; sections that have not
+// rendered yet are left empty and ScrapedAt is left unset
+func Scrape(page *rod.Page) (*Profile, error) {
+	extracted := timing.Start(timing.Extraction)
+	res, err := page.Eval(scrapeJS)
+	extracted()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile: %w", err)
+	}
+
+	var raw struct {
+		URL            string     `json:"url"`
+		Name           string     `json:"name"`
+		Headline       string     `json:"headline"`
+		Location       string     `json:"location"`
+		About          string     `json:"about"`
+		CurrentCompany string     `json:"current_company"`
+		Experience     [][]string `json:"experience"`
+		Education      [][]string `json:"education"`
+		Skills         []string   `json:"skills"`
+		Main           string     `json:"main"`
+	}
+	if err := res.Value.Unmarshal(&raw); err != nil {
+		return nil, fmt.Errorf("failed to read profile: %w", err)
+	}
+	if raw.Name == "" {
+		return nil, apperr.Selector("profile name")
+	}
+
+	p := &Profile{
+		URL:      raw.URL,
+		Name:     raw.Name,
+		Headline: raw.Headline,
+		Location: raw.Location,
+		About:    raw.About,
+		Skills:   raw.Skills,
+		Mutuals:  mutuals(raw.Main),
+	}
+	for _, lines := range raw.Experience {
+		if len(lines) < 2 {
+			continue
+		}
+		// "Acme · Full-time"
+		p.Experience = append(p.Experience, Experience{
+			Title:   lines[0],
+			Company: strings.TrimSpace(strings.Split(lines[1], " · ")[0]),
+			Dates:   line(lines, 2),
+		})
+	}
+	for _, lines := range raw.Education {
+		if len(lines) == 0 {
+			continue
+		}
+		p.Education = append(p.Education, Education{School: lines[0], Degree: line(lines, 1), Dates: line(lines, 2)})
+	}
+
+	p.Company = raw.CurrentCompany
+	if p.Company == "" && len(p.Experience) > 0 {
+		p.Company = p.Experience[0].Company
+	}
+	return p, nil
+}
+
+func line(lines []string, i int) string {
+	if i < len(lines) {
+		return lines[i]
+	}
+	return ""
+}
+
+// mutuals reads the mutual connection count from the top card's insight
+func mutuals(main string) int {
+	if m := othersRe.FindStringSubmatch(main); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		// The named connections come before "and N other"
+		return n + 2
+	}
+	if m := mutualsRe.FindStringSubmatch(main); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return n
+	}
+	switch {
+	case strings.Contains(main, "are mutual connections"):
+		return 2
+	case strings.Contains(main, "is a mutual connection"):
+		return 1
+	}
+	return 0
+}
+
+// Save stores the profile under url, which is the URL the rest of the
+// database knows the prospect by
+func Save(store *storage.Store, url string, p *Profile) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return store.SaveProfile(storage.ProfileRecord{
+		ProfileURL: url,
+		Name:       p.Name,
+		Headline:   p.Headline,
+		Company:    p.Company,
+		Data:       string(data),
+	})
+}
+
+// Load returns the stored profile, or nil when it was never scraped
+func Load(store *storage.Store, url string) (*Profile, error) {
+	rec, err := store.GetProfile(url)
+	if err != nil || rec == nil {
+		return nil, err
+	}
+	var p Profile
+	if err := json.Unmarshal([]byte(rec.Data), &p); err != nil {
+		return nil, fmt.Errorf("failed to read stored profile %s: %w", url, err)
+	}
+	p.ScrapedAt = rec.ScrapedAt
+	return &p, nil
+}
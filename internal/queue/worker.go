@@ -0,0 +1,385 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"linkedin-automation/internal/apperr"
+	"linkedin-automation/internal/logger"
+	"linkedin-automation/internal/stealth"
+	"linkedin-automation/internal/storage"
+	"linkedin-automation/internal/timing"
+)
+
+var (
+	// ErrStop tells the worker to stop draining the queue (e.g. daily budget spent)
+	ErrStop = errors.New("queue worker stopped")
+	// ErrDeferred tells the worker the item was rescheduled and is not a failure
+	ErrDeferred = errors.New("queue item deferred")
+	// ErrSkipped tells the worker the handler chose not to act, e.g. on a
+	// do-not-contact entry; the item is done but is not counted as an action
+	ErrSkipped = errors.New("queue item skipped")
+	// ErrDryRun tells the worker the handler stopped short of acting because
+	// of a dry run; the item stays queued
+	ErrDryRun = errors.New("dry run")
+)
+
+// Planned is returned by a handler in a dry run instead of acting; the text
+// says what would have been done, e.g. "send a connection request", and is
+// recorded in pending_actions. It matches ErrDryRun however it is wrapped
+func Planned(format string, args ...interface{}) error {
+	return &plannedError{what: fmt.Sprintf(format, args...)}
+}
+
+type plannedError struct {
+	what string
+}
+
+func (e *plannedError) Error() string        { return "dry run: would " + e.what }
+func (e *plannedError) Is(target error) bool { return target == ErrDryRun }
+
+// Handler performs the action for a single queued item. ctx ends at
+// itemTimeout; Stop and the deadline let the action finish instead
+type Handler func(ctx context.Context, item storage.QueueItem) error
+
+type route struct {
+	actionType stealth.ActionType
+	handle     Handler
+	// bias scales pacing by time of day: 1 keeps the normal pace, lower values slow it down
+	bias func(t time.Time) float64
+}
+
+// Worker pulls queued items only when both the RateLimiter and the
+// ActivityScheduler approve, so all pacing decisions live in one place
+type Worker struct {
+	store     *storage.Store
+	limiter   *stealth.RateLimiter
+	scheduler *stealth.ActivityScheduler
+	logger    *logger.Logger
+	routes    map[string]route
+	deadline  time.Time
+	stop      chan struct{}
+	stopOnce  sync.Once
+	// maxAttempts moves items that keep failing to the dead-letter state
+	maxAttempts int
+	beforeItem  func() error
+	admit       func(item storage.QueueItem) error
+	afterItem   func(item storage.QueueItem, err error)
+	recover     func() (bool, error)
+	onChallenge func(err error)
+}
+
+// maxRecoveries is how many times one Run may recover from a broken
+// environment before giving up
+const maxRecoveries = 3
+
+// itemTimeout bounds a single action, so one stuck on a page that never
+// settles fails instead of holding up the run
+const itemTimeout = 10 * time.Minute
+
+// startKey holds when the worker handed an item to its handler
+type startKey struct{}
+
+// Elapsed returns how long the item being handled under ctx has taken, for
+// the handler's action log; zero outside the worker
+func Elapsed(ctx context.Context) time.Duration {
+	if start, ok := ctx.Value(startKey{}).(time.Time); ok {
+		return time.Since(start)
+	}
+	return 0
+}
+
+func NewWorker(store *storage.Store, limiter *stealth.RateLimiter, scheduler *stealth.ActivityScheduler, log *logger.Logger) *Worker {
+	return &Worker{
+		store:     store,
+		limiter:   limiter,
+		scheduler: scheduler,
+		logger:    log,
+		routes:    make(map[string]route),
+		stop:      make(chan struct{}),
+	}
+}
+
+// Handle registers the handler for a queue action and the rate limit it consumes
+func (w *Worker) Handle(action string, actionType stealth.ActionType, h Handler) {
+	w.routes[action] = route{actionType: actionType, handle: h}
+}
+
+// SetDeadline makes Run wrap up at t: the current action finishes and
+// everything not started stays queued for the next run
+func (w *Worker) SetDeadline(t time.Time) {
+	w.deadline = t
+}
+
+// Stop makes Run wrap up as if the deadline had passed; unlike SetDeadline
+// it is safe to call from another goroutine, e.g. a signal handler
+func (w *Worker) Stop() {
+	w.stopOnce.Do(func() { close(w.stop) })
+}
+
+// Expired reports whether the deadline set with SetDeadline has passed or
+// Stop was called
+func (w *Worker) Expired() bool {
+	select {
+	case <-w.stop:
+		return true
+	default:
+	}
+	return !w.deadline.IsZero() && !time.Now().Before(w.deadline)
+}
+
+// BeforeItem registers a hook that runs before each item is handled, e.g. to
+// recycle the browser between actions; an error stops the worker
+func (w *Worker) BeforeItem(fn func() error) {
+	w.beforeItem = fn
+}
+
+// Admit registers a hook that can hold an item back just before it is
+// handled, e.g. while someone else is working the prospect. An error leaves
+// the item for a later run; ErrDeferred says the hook rescheduled it
+func (w *Worker) Admit(fn func(item storage.QueueItem) error) {
+	w.admit = fn
+}
+
+// AfterItem registers a hook that runs after each item is handled, with the
+// handler's result; nil removes it
+func (w *Worker) AfterItem(fn func(item storage.QueueItem, err error)) {
+	w.afterItem = fn
+}
+
+// Recover registers a hook consulted when an item fails. It returns true when
+// the failure came from the environment (e.g. a browser crash) and was
+// repaired; the item is then retried instead of counted as failed
+func (w *Worker) Recover(fn func() (bool, error)) {
+	w.recover = fn
+}
+
+// OnChallenge registers a hook that runs when an item meets a security
+// challenge, before the worker stops
+func (w *Worker) OnChallenge(fn func(err error)) {
+	w.onChallenge = fn
+}
+
+// SetMaxAttempts sets how many failures across runs an item gets before it
+// is dead-lettered (0 = retry forever)
+func (w *Worker) SetMaxAttempts(n int) {
+	w.maxAttempts = n
+}
+
+// BiasPacing slows the pace for action at times bias scores below 1, so more
+// of the budget is spent in the hours that perform best
+func (w *Worker) BiasPacing(action string, bias func(t time.Time) float64) {
+	rt := w.routes[action]
+	rt.bias = bias
+	w.routes[action] = rt
+}
+
+// Run drains due items for action until the queue is empty or a budget is exhausted
+func (w *Worker) Run(action string) (int, error) {
+	rt, ok := w.routes[action]
+	if !ok {
+		return 0, fmt.Errorf("no handler registered for queue action: %s", action)
+	}
+
+	processed, recoveries := 0, 0
+	skipped := make(map[int64]bool)
+
+	for {
+		if w.Expired() {
+			w.logger.Info("Maximum runtime reached; leaving remaining %s items queued (%d processed)", action, processed)
+			return processed, nil
+		}
+
+		item, err := w.nextItem(action, skipped)
+		if err != nil {
+			return processed, err
+		}
+		if item == nil {
+			w.logger.Info("Queue %s drained (%d processed)", action, processed)
+			return processed, nil
+		}
+
+		// Scheduler approval: take breaks like a human would
+		if w.scheduler.IsBreakTime() || w.scheduler.ShouldTakeBreak() {
+			d := w.scheduler.GetBreakDuration()
+			w.logger.Info("Taking a %v break", d.Round(time.Second))
+			w.sleep(d)
+			continue
+		}
+
+		// Rate limiter approval: sleep on cooldowns/intervals, stop on spent budgets
+		if allowed, reason := w.limiter.CanPerformAction(rt.actionType); !allowed {
+			wait := w.limiter.GetWaitTime(rt.actionType)
+			if wait <= 0 {
+				w.logger.Warn("Budget for %s exhausted: %s. Leaving remaining items queued", rt.actionType, reason)
+				return processed, nil
+			}
+			w.logger.Debug("Rate limiter: %s", reason)
+			w.sleep(wait)
+			continue
+		}
+
+		if w.beforeItem != nil {
+			if err := w.beforeItem(); err != nil {
+				return processed, err
+			}
+		}
+		if w.admit != nil {
+			if err := w.admit(*item); err != nil {
+				if !errors.Is(err, ErrDeferred) {
+					w.logger.Warn("Holding back queue item %d (%s): %v", item.ID, item.ProfileURL, err)
+				}
+				skipped[item.ID] = true
+				continue
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), startKey{}, time.Now()), itemTimeout)
+		err = rt.handle(ctx, *item)
+		cancel()
+		if w.afterItem != nil {
+			w.afterItem(*item, err)
+		}
+		switch {
+		case err == nil:
+			w.store.MarkQueueItemDone(item.ID)
+			w.limiter.RecordAction(rt.actionType)
+			w.scheduler.RecordActivity()
+			w.record(rt.actionType, item, "success", "")
+			processed++
+
+			w.sleep(w.thinkTime(rt))
+
+		case errors.Is(err, ErrDeferred):
+			skipped[item.ID] = true
+
+		case errors.Is(err, ErrSkipped):
+			w.store.MarkQueueItemDone(item.ID)
+			w.record(rt.actionType, item, "skipped", err.Error())
+
+		case errors.Is(err, ErrDryRun):
+			// Paced like a real action so the run shows what a real one would reach
+			what := err.Error()
+			var planned *plannedError
+			if errors.As(err, &planned) {
+				what = "would " + planned.what
+			}
+			w.logger.Info("[dry run] %s: %s", item.Name, what)
+			if serr := w.store.SavePendingAction(storage.PendingAction{
+				Action:     item.Action,
+				ProfileURL: item.ProfileURL,
+				Name:       item.Name,
+				Campaign:   item.Campaign,
+				Payload:    item.Payload,
+				Detail:     what,
+			}); serr != nil {
+				w.logger.Warn("%v", serr)
+			}
+			w.limiter.RecordAction(rt.actionType)
+			w.scheduler.RecordActivity()
+			skipped[item.ID] = true
+			processed++
+
+			w.sleep(w.thinkTime(rt))
+
+		case errors.Is(err, ErrStop), errors.Is(err, apperr.ErrRateLimited):
+			w.logger.Info("Stopping queue %s: %v", action, err)
+			return processed, nil
+
+		case errors.Is(err, apperr.ErrChallenge):
+			w.record(rt.actionType, item, "challenge", err.Error())
+			if w.onChallenge != nil {
+				w.onChallenge(err)
+			}
+			return processed, err
+
+		default:
+			if w.recover != nil {
+				recovered, rerr := w.recover()
+				if rerr != nil {
+					return processed, rerr
+				}
+				if recovered {
+					if recoveries++; recoveries > maxRecoveries {
+						return processed, fmt.Errorf("gave up after %d recoveries: %w", maxRecoveries, err)
+					}
+					w.logger.Info("Recovered; retrying queue item %d (%s)", item.ID, item.ProfileURL)
+					continue
+				}
+			}
+
+			w.logger.Error("Queue item %d (%s) failed: %v", item.ID, item.ProfileURL, err)
+			w.record(rt.actionType, item, "failure", err.Error())
+			if dead, derr := w.store.RecordQueueFailure(item.ID, err.Error(), w.maxAttempts); derr != nil {
+				w.logger.Warn("Failed to record queue failure: %v", derr)
+			} else if dead {
+				w.logger.Warn("%s failed %d times; moved to the dead-letter list", item.ProfileURL, item.Attempts+1)
+			}
+			skipped[item.ID] = true
+		}
+	}
+}
+
+// sleep waits for d, cut short by the deadline or Stop
+func (w *Worker) sleep(d time.Duration) {
+	if !w.deadline.IsZero() {
+		if left := time.Until(w.deadline); left < d {
+			d = left
+		}
+	}
+	if d <= 0 {
+		return
+	}
+
+	start := time.Now()
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-w.stop:
+	}
+	timing.Record(timing.Pacing, time.Since(start))
+}
+
+func (w *Worker) thinkTime(rt route) time.Duration {
+	d := w.scheduler.GetThinkTime()
+	if rt.bias == nil {
+		return d
+	}
+
+	if weight := rt.bias(time.Now()); weight > 0 && weight < 1 {
+		d = time.Duration(float64(d) / weight)
+	}
+	return d
+}
+
+// nextItem returns the highest-priority due item not already attempted this run
+func (w *Worker) nextItem(action string, skipped map[int64]bool) (*storage.QueueItem, error) {
+	items, err := w.store.GetDueQueueItems(action, time.Now(), len(skipped)+1)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range items {
+		if !skipped[items[i].ID] {
+			return &items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (w *Worker) record(actionType stealth.ActionType, item *storage.QueueItem, outcome, detail string) {
+	err := w.store.AppendActionLog(storage.ActionLogEntry{
+		Action:     string(actionType),
+		ProfileURL: item.ProfileURL,
+		Campaign:   item.Campaign,
+		Outcome:    outcome,
+		Detail:     detail,
+	})
+	if err != nil {
+		w.logger.Warn("Failed to write action log: %v", err)
+	}
+}
@@ -0,0 +1,247 @@
+// Package relay lets an operator solve a security challenge on a machine with
+// no display: the live page is streamed to a temporary, token-protected local
+// web endpoint and clicks and keys from the operator's browser are replayed
+// into it over CDP. Reach it through an SSH tunnel, e.g.
+// ssh -L 8765:127.0.0.1:8765 server
+package relay
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/input"
+	"github.com/go-rod/rod/lib/proto"
+
+	"linkedin-automation/config"
+	"linkedin-automation/internal/logger"
+)
+
+// ErrTimeout means nobody solved the challenge before the relay closed
+var ErrTimeout = errors.New("challenge relay timed out")
+
+// pollInterval is how often Solve checks whether the challenge is gone
+const pollInterval = 2 * time.Second
+
+// relay holds the latest screencast frame and replays operator input
+type relay struct {
+	page  *rod.Page
+	token string
+
+	mu sync.Mutex
+	// frame is the latest JPEG; the viewport's device scale factor is 1, so
+	// frame pixels are the page's CSS pixels
+	frame []byte
+}
+
+// Solve serves the page until solved reports true or the configured timeout
+// passes; the endpoint is shut down either way
+func Solve(page *rod.Page, cfg *config.Config, log *logger.Logger, solved func() bool) error {
+	token, err := newToken()
+	if err != nil {
+		return err
+	}
+	r := &relay{page: page, token: token}
+
+	ln, err := net.Listen("tcp", cfg.Challenge.RelayListen)
+	if err != nil {
+		return fmt.Errorf("failed to start challenge relay: %w", err)
+	}
+	srv := &http.Server{Handler: r.handler()}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	stop, err := r.screencast()
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	// The token stays out of the log file: it goes to the operator's terminal only
+	log.Warn("Security challenge: solve it through the relay at http://%s/ (the access link is on the terminal)", ln.Addr())
+	fmt.Fprintf(os.Stderr, "Challenge relay access link: http://%s/?token=%s\n", ln.Addr(), token)
+	log.Warn("From another machine, tunnel first: ssh -L %d:127.0.0.1:%d <this host>",
+		ln.Addr().(*net.TCPAddr).Port, ln.Addr().(*net.TCPAddr).Port)
+
+	deadline := time.Now().Add(time.Duration(cfg.Challenge.RelayTimeoutMinutes) * time.Minute)
+	for time.Now().Before(deadline) {
+		time.Sleep(pollInterval)
+		if solved() {
+			log.Info("Challenge solved through the relay")
+			return nil
+		}
+	}
+	return ErrTimeout
+}
+
+// screencast streams JPEG frames from the page into r.frame
+func (r *relay) screencast() (func(), error) {
+	quality := 70
+	if err := (proto.PageStartScreencast{Format: proto.PageStartScreencastFormatJpeg, Quality: &quality}).Call(r.page); err != nil {
+		return nil, fmt.Errorf("failed to start screencast: %w", err)
+	}
+
+	page, cancel := r.page.WithCancel()
+	go page.EachEvent(func(e *proto.PageScreencastFrame) {
+		r.mu.Lock()
+		r.frame = e.Data
+		r.mu.Unlock()
+		_ = proto.PageScreencastFrameAck{SessionID: e.SessionID}.Call(page)
+	})()
+
+	return func() {
+		cancel()
+		_ = proto.PageStopScreencast{}.Call(r.page)
+	}, nil
+}
+
+func (r *relay) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", r.authed(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, viewerPage, r.token)
+	}))
+	mux.HandleFunc("/frame", r.authed(func(w http.ResponseWriter, req *http.Request) {
+		r.mu.Lock()
+		frame := r.frame
+		r.mu.Unlock()
+		if frame == nil {
+			http.Error(w, "no frame yet", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write(frame)
+	}))
+	mux.HandleFunc("/input", r.authed(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			http.Error(w, "POST only", http.StatusMethodNotAllowed)
+			return
+		}
+		var ev event
+		if err := json.NewDecoder(req.Body).Decode(&ev); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := r.replay(ev); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+		}
+	}))
+	return mux
+}
+
+// authed requires the session token, from the query string or header
+func (r *relay) authed(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		got := req.URL.Query().Get("token")
+		if got == "" {
+			got = req.Header.Get("X-Relay-Token")
+		}
+		if subtle.ConstantTimeCompare([]byte(got), []byte(r.token)) != 1 {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		next(w, req)
+	}
+}
+
+// event is one operator action from the viewer
+type event struct {
+	Type string  `json:"type"` // click, text, key or scroll
+	X    float64 `json:"x"`
+	Y    float64 `json:"y"`
+	Text string  `json:"text"`
+	Key  string  `json:"key"`
+	DY   float64 `json:"dy"`
+}
+
+// specialKeys maps viewer key names to rod keys
+var specialKeys = map[string]input.Key{
+	"Enter":      input.Enter,
+	"Backspace":  input.Backspace,
+	"Tab":        input.Tab,
+	"Escape":     input.Escape,
+	"ArrowLeft":  input.ArrowLeft,
+	"ArrowRight": input.ArrowRight,
+	"ArrowUp":    input.ArrowUp,
+	"ArrowDown":  input.ArrowDown,
+	"Delete":     input.Delete,
+}
+
+func (r *relay) replay(ev event) error {
+	switch ev.Type {
+	case "click":
+		for _, t := range []proto.InputDispatchMouseEventType{
+			proto.InputDispatchMouseEventTypeMouseMoved,
+			proto.InputDispatchMouseEventTypeMousePressed,
+			proto.InputDispatchMouseEventTypeMouseReleased,
+		} {
+			if err := (proto.InputDispatchMouseEvent{
+				Type: t, X: ev.X, Y: ev.Y, Button: proto.InputMouseButtonLeft, ClickCount: 1,
+			}).Call(r.page); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "text":
+		return proto.InputInsertText{Text: ev.Text}.Call(r.page)
+	case "key":
+		key, ok := specialKeys[ev.Key]
+		if !ok {
+			return fmt.Errorf("unsupported key %q", ev.Key)
+		}
+		return r.page.Keyboard.Type(key)
+	case "scroll":
+		return r.page.Mouse.Scroll(0, ev.DY, 1)
+	default:
+		return fmt.Errorf("unknown event type %q", ev.Type)
+	}
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate relay token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// viewerPage polls frames and posts clicks, typed text and special keys
+const viewerPage = `<!DOCTYPE html>
+<html><head><title>Challenge relay</title>
+<style>body{margin:0;background:#222;color:#eee;font:14px sans-serif} img{display:block;max-width:100%%;cursor:crosshair} p{margin:8px}</style>
+</head><body>
+<p>Solve the challenge below. Clicks and typing are sent to the remote browser; the run continues once it is solved.</p>
+<img id="screen" alt="waiting for the first frame">
+<script>
+const token = %q;
+const img = document.getElementById('screen');
+const send = (ev) => fetch('/input?token=' + token, {method: 'POST', body: JSON.stringify(ev)});
+const refresh = () => {
+	const next = new Image();
+	next.onload = () => { img.src = next.src; setTimeout(refresh, 300); };
+	next.onerror = () => setTimeout(refresh, 1000);
+	next.src = '/frame?token=' + token + '&t=' + Date.now();
+};
+refresh();
+img.addEventListener('click', (e) => {
+	const r = img.getBoundingClientRect();
+	send({type: 'click', x: (e.clientX - r.left) * img.naturalWidth / r.width, y: (e.clientY - r.top) * img.naturalHeight / r.height});
+});
+img.addEventListener('wheel', (e) => { e.preventDefault(); send({type: 'scroll', dy: e.deltaY}); });
+document.addEventListener('keydown', (e) => {
+	if (e.key.length === 1) send({type: 'text', text: e.key});
+	else send({type: 'key', key: e.key});
+	e.preventDefault();
+});
+</script>
+</body></html>`
@@ -0,0 +1,92 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"linkedin-automation/config"
+	"linkedin-automation/internal/storage"
+)
+
+// Digest lists what waits on the operator rather than on the tool
+type Digest struct {
+	Account       string
+	CreatedAt     time.Time
+	AwaitingReply []storage.AwaitingReply
+	Approvals     []storage.ReviewCount
+	Stalled       []storage.StalledCount
+	StalledDays   int
+}
+
+// BuildDigest collects the digest from the account's database
+func BuildDigest(cfg *config.Config, store *storage.Store, now time.Time) (*Digest, error) {
+	d := &Digest{Account: cfg.Account, CreatedAt: now, StalledDays: cfg.Digest.StalledDays}
+
+	var err error
+	if d.AwaitingReply, err = store.GetAwaitingReply(); err != nil {
+		return nil, err
+	}
+	if d.Approvals, err = store.GetReviewCounts(); err != nil {
+		return nil, err
+	}
+	if d.StalledDays > 0 {
+		if d.Stalled, err = store.GetStalledProspects(now.AddDate(0, 0, -d.StalledDays)); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// Empty reports whether nothing waits on the operator
+func (d *Digest) Empty() bool {
+	return len(d.AwaitingReply) == 0 && len(d.Approvals) == 0 && len(d.Stalled) == 0
+}
+
+// String renders the digest as plain text, which chat webhooks show as is
+func (d *Digest) String() string {
+	var b strings.Builder
+	title := "LinkedIn outreach digest"
+	if d.Account != "" {
+		title += " for " + d.Account
+	}
+	fmt.Fprintf(&b, "%s, %s\n", title, d.CreatedAt.Format("Mon Jan 2"))
+	if d.Empty() {
+		b.WriteString("\nNothing is waiting on you.\n")
+		return b.String()
+	}
+
+	if len(d.AwaitingReply) > 0 {
+		fmt.Fprintf(&b, "\nAwaiting your reply (%d):\n", len(d.AwaitingReply))
+		for _, a := range d.AwaitingReply {
+			name := a.Name
+			if name == "" {
+				name = a.ProfileURL
+			}
+			fmt.Fprintf(&b, "• %s replied %s ago: %s\n", name, ago(d.CreatedAt.Sub(a.RepliedAt)), a.ProfileURL)
+		}
+	}
+
+	if len(d.Approvals) > 0 {
+		b.WriteString("\nWaiting for approval:\n")
+		for _, r := range d.Approvals {
+			fmt.Fprintf(&b, "• %d %s items in campaign %q (go run . broadcast review %s)\n", r.Count, r.Action, r.Campaign, r.Campaign)
+		}
+	}
+
+	if len(d.Stalled) > 0 {
+		fmt.Fprintf(&b, "\nStalled for %d+ days:\n", d.StalledDays)
+		for _, c := range d.Stalled {
+			fmt.Fprintf(&b, "• %d %s in campaign %q, the oldest since %s\n", c.Count, c.Status, c.Campaign, c.Since.Local().Format("Jan 2"))
+		}
+	}
+	return b.String()
+}
+
+// ago rounds d to days, or hours under a day
+func ago(d time.Duration) string {
+	if d < 24*time.Hour {
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
@@ -0,0 +1,134 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"linkedin-automation/config"
+	"linkedin-automation/internal/analytics"
+	"linkedin-automation/internal/storage"
+)
+
+const week = 7 * 24 * time.Hour
+
+// recommend turns stored analytics into concrete next steps
+func recommend(cfg *config.Config, store *storage.Store, now time.Time) []string {
+	var recs []string
+
+	if rec := weeklyBudgetRecommendation(cfg, store, now); rec != "" {
+		recs = append(recs, rec)
+	}
+	if rec := acceptanceTrendRecommendation(store, now); rec != "" {
+		recs = append(recs, rec)
+	}
+	if rec := sendTimeRecommendation(cfg, store, now); rec != "" {
+		recs = append(recs, rec)
+	}
+
+	if contacts, clicked, err := store.GetLinkStats(); err == nil && contacts > 0 {
+		recs = append(recs, fmt.Sprintf("message link CTR %.0f%% (%d of %d contacts clicked)",
+			float64(clicked)*100/float64(contacts), clicked, contacts))
+	}
+
+	if pending, err := store.CountQueueItems(storage.QueueStatusPending); err == nil && pending > 0 {
+		recs = append(recs, fmt.Sprintf("%d actions are still queued; the next run will continue them", pending))
+	}
+
+	if dead, err := store.CountQueueItems(storage.QueueStatusDead); err == nil && dead > 0 {
+		recs = append(recs, fmt.Sprintf("%d prospects are dead-lettered after repeated failures; inspect them with the dead-letter command", dead))
+	}
+
+	if sent, err := store.GetMessagesCountToday(); err == nil && cfg.Limits.MaxMessagesPerDay > 0 &&
+		sent >= cfg.Limits.MaxMessagesPerDay {
+		recs = append(recs, "daily message budget fully used; next message run recommended tomorrow")
+	}
+
+	return recs
+}
+
+func weeklyBudgetRecommendation(cfg *config.Config, store *storage.Store, now time.Time) string {
+	budget := cfg.Limits.MaxConnectionsPerWeek
+	if budget <= 0 {
+		return ""
+	}
+
+	since := now.Add(-week)
+	used, err := store.GetConnectionsCountSince(since)
+	if err != nil {
+		return ""
+	}
+
+	pct := used * 100 / budget
+	if pct < 80 {
+		return fmt.Sprintf("weekly invite budget %d%% consumed (%d/%d); %d invites left this week", pct, used, budget, budget-used)
+	}
+
+	// Budget frees up as the oldest invite in the rolling window ages out
+	oldest, err := store.GetOldestConnectionSince(since)
+	if err != nil || oldest.IsZero() {
+		return fmt.Sprintf("weekly invite budget %d%% consumed (%d/%d)", pct, used, budget)
+	}
+
+	next := oldest.Add(week).Local()
+	if cfg.Stealth.BusinessHoursOnly && next.Hour() < cfg.Stealth.WorkStartHour {
+		next = time.Date(next.Year(), next.Month(), next.Day(), cfg.Stealth.WorkStartHour, 0, 0, 0, next.Location())
+	} else {
+		next = next.Truncate(time.Hour).Add(time.Hour)
+	}
+
+	return fmt.Sprintf("weekly invite budget %d%% consumed (%d/%d); next connect run recommended %s",
+		pct, used, budget, next.Format("Monday 15:04"))
+}
+
+func acceptanceTrendRecommendation(store *storage.Store, now time.Time) string {
+	// Invites need time to be accepted: compare last week's cohort with the four before it
+	recentSent, recentAccepted, err := store.GetAcceptanceStats(now.Add(-2*week), now.Add(-week))
+	if err != nil || recentSent < 10 {
+		return ""
+	}
+
+	baseSent, baseAccepted, err := store.GetAcceptanceStats(now.Add(-6*week), now.Add(-2*week))
+	if err != nil || baseSent < 10 {
+		return ""
+	}
+
+	recentRate := float64(recentAccepted) * 100 / float64(recentSent)
+	baseRate := float64(baseAccepted) * 100 / float64(baseSent)
+
+	if drop := baseRate - recentRate; drop >= 10 {
+		return fmt.Sprintf("acceptance rate dropped %.0f%% (%.0f%% → %.0f%%) — consider revising the connection note or targeting",
+			drop, baseRate, recentRate)
+	}
+	return fmt.Sprintf("acceptance rate %.0f%% for last week's invites (baseline %.0f%%)", recentRate, baseRate)
+}
+
+func sendTimeRecommendation(cfg *config.Config, store *storage.Store, now time.Time) string {
+	st, err := analytics.LoadSendTimes(store, storage.QueueActionConnect, now.AddDate(0, 0, -90), analytics.Location(cfg.Stealth.Timezone))
+	if err != nil {
+		return ""
+	}
+
+	hours := st.BestHours(3)
+	if len(hours) < 2 {
+		return ""
+	}
+
+	var parts []string
+	for _, h := range hours {
+		parts = append(parts, fmt.Sprintf("%02d:00 (%.0f%%)", h, st.ByHour[h].Rate()*100))
+	}
+
+	rec := fmt.Sprintf("best send hours for acceptance: %s vs %.0f%% overall", strings.Join(parts, ", "), st.Total.Rate()*100)
+	if days := st.BestWeekdays(2); len(days) > 0 {
+		var names []string
+		for _, d := range days {
+			names = append(names, d.String()[:3])
+		}
+		rec += "; best days: " + strings.Join(names, ", ")
+	}
+	if !cfg.Stealth.BiasToBestHours {
+		rec += " — set stealth.bias_to_best_hours to favor them"
+	}
+	return rec
+}
@@ -0,0 +1,303 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"linkedin-automation/config"
+	"linkedin-automation/internal/scoring"
+	"linkedin-automation/internal/storage"
+	"linkedin-automation/internal/timing"
+)
+
+// Report summarizes a single run and is persisted as JSON in the report directory
+type Report struct {
+	Operator        string    `json:"operator,omitempty"`
+	StartedAt       time.Time `json:"started_at"`
+	FinishedAt      time.Time `json:"finished_at"`
+	ConnectionsSent int       `json:"connections_sent"`
+	MessagesSent    int       `json:"messages_sent"`
+	// EventInvitesSent is omitted from runs that did not invite to an event
+	EventInvitesSent int      `json:"event_invites_sent,omitempty"`
+	Errors           []string `json:"errors,omitempty"`
+	Alerts           []string `json:"alerts,omitempty"`
+	Stopped          string   `json:"stopped,omitempty"`
+	Recommendations  []string `json:"recommendations"`
+
+	// Planned counts what a dry run recorded in pending_actions instead of sending
+	Planned int `json:"dry_run_planned,omitempty"`
+
+	// AnonymizedSkipped counts "LinkedIn Member" search results left out
+	AnonymizedSkipped int `json:"anonymized_skipped,omitempty"`
+	// FilteredOut counts search results dropped by the search rules
+	FilteredOut int `json:"filtered_out,omitempty"`
+	// SearchDropped counts by reason the search result cards that could not
+	// be read; any at all usually means the results markup changed
+	SearchDropped map[string]int `json:"search_dropped,omitempty"`
+	// JobChanges counts new titles and companies found on tracked profiles
+	JobChanges int `json:"job_changes,omitempty"`
+	// AcceptanceCheck is what a -check-accepted run found
+	AcceptanceCheck *AcceptanceCheck `json:"acceptance_check,omitempty"`
+
+	// SafeMode names the new build a reduced-volume run verified
+	SafeMode string `json:"safe_mode,omitempty"`
+
+	// ReadReceipts counts sent messages seen and replied to, all time
+	ReadReceipts *storage.ReadStats `json:"read_receipts,omitempty"`
+
+	// Sources rolls all-time outcomes up by prospect source
+	Sources []storage.SourceStats `json:"sources,omitempty"`
+
+	// Timings break the run's wall time down by stage
+	Timings []timing.Stat `json:"timings,omitempty"`
+
+	// Model describes the acceptance model, once there is enough history to train it
+	Model *ModelSummary `json:"acceptance_model,omitempty"`
+}
+
+// AcceptanceCheck counts the pending connection requests a run looked at
+type AcceptanceCheck struct {
+	Checked  int `json:"checked"`
+	Accepted int `json:"accepted"`
+	// Unchecked were not reached before the run stopped or the profile
+	// views ran out
+	Unchecked int `json:"unchecked,omitempty"`
+	// File lists every request with what was found
+	File string `json:"file,omitempty"`
+}
+
+// ModelSummary is what the acceptance model learned and predicts for the queue
+type ModelSummary struct {
+	Samples  int                `json:"samples"`
+	BaseRate float64            `json:"base_rate"`
+	Weights  map[string]float64 `json:"weights"`
+	// QueuedPredicted is the mean predicted acceptance of pending connection requests
+	QueuedPredicted float64 `json:"queued_predicted,omitempty"`
+	Queued          int     `json:"queued,omitempty"`
+	strongest       []string
+}
+
+func New() *Report {
+	return &Report{StartedAt: time.Now()}
+}
+
+// AddError records a run-level error for the report
+func (r *Report) AddError(format string, v ...interface{}) {
+	r.Errors = append(r.Errors, fmt.Sprintf(format, v...))
+}
+
+// AddSearchDropped adds a searcher's counts of unreadable result cards
+func (r *Report) AddSearchDropped(dropped map[string]int) {
+	for reason, n := range dropped {
+		if r.SearchDropped == nil {
+			r.SearchDropped = make(map[string]int)
+		}
+		r.SearchDropped[reason] += n
+	}
+}
+
+// Finish stamps the report and derives next-step recommendations from analytics
+func (r *Report) Finish(cfg *config.Config, store *storage.Store) {
+	r.FinishedAt = time.Now()
+	r.Timings, _ = timing.Snapshot()
+	r.Recommendations = recommend(cfg, store, r.FinishedAt)
+
+	if len(r.SearchDropped) > 0 {
+		r.Alerts = append(r.Alerts, "search result cards could not be read; check the extractor against the current results page"+
+			" (set search.dump_dropped to save them as fixtures)")
+	}
+
+	// Nothing is sent in a dry run; what the queue got through was only planned
+	if cfg.DryRun {
+		r.ConnectionsSent, r.MessagesSent, r.EventInvitesSent = 0, 0, 0
+		if planned, err := store.GetPendingActions(r.StartedAt); err == nil {
+			r.Planned = len(planned)
+		}
+	}
+
+	if !cfg.Features.Analytics {
+		return
+	}
+
+	if stats, err := store.GetReadStats(); err == nil && stats.Sent > 0 {
+		r.ReadReceipts = &stats
+	}
+
+	if sources, err := store.GetSourceStats(); err == nil {
+		r.Sources = sources
+	}
+
+	if model, err := scoring.Load(store); err == nil && model != nil {
+		r.Model = summarize(model, store)
+	}
+}
+
+func summarize(model *scoring.Model, store *storage.Store) *ModelSummary {
+	s := &ModelSummary{
+		Samples:   model.Samples,
+		BaseRate:  model.BaseRate(),
+		Weights:   make(map[string]float64),
+		strongest: model.Strongest(),
+	}
+	for _, name := range scoring.FeatureNames {
+		s.Weights[name] = model.Weight(name)
+	}
+
+	// Items queued before the model existed carry no prediction
+	if priorities, err := store.GetPendingPriorities(storage.QueueActionConnect); err == nil {
+		total := 0
+		for _, p := range priorities {
+			if p > 0 {
+				total += p
+				s.Queued++
+			}
+		}
+		if s.Queued > 0 {
+			s.QueuedPredicted = float64(total) / float64(s.Queued) / 100
+		}
+	}
+	return s
+}
+
+// Save writes the report to dir and returns the file path
+func (r *Report) Save(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create report directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, "run-"+r.StartedAt.Format("20060102-150405")+".json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write report: %w", err)
+	}
+	return path, nil
+}
+
+// Print writes a human-readable summary to stdout
+func (r *Report) Print() {
+	fmt.Println("\n── Run summary ──────────────────────────────")
+	if r.Operator != "" {
+		fmt.Printf("  Operator:          %s\n", r.Operator)
+	}
+	fmt.Printf("  Duration:          %v\n", r.FinishedAt.Sub(r.StartedAt).Round(time.Second))
+	fmt.Printf("  Connections sent:  %d\n", r.ConnectionsSent)
+	fmt.Printf("  Messages sent:     %d\n", r.MessagesSent)
+	if r.EventInvitesSent > 0 {
+		fmt.Printf("  Event invites:     %d\n", r.EventInvitesSent)
+	}
+	if r.Planned > 0 {
+		fmt.Printf("  Dry run planned:   %d (see: go run . pending)\n", r.Planned)
+	}
+	if r.AnonymizedSkipped > 0 {
+		fmt.Printf("  Anonymous skipped: %d\n", r.AnonymizedSkipped)
+	}
+	if r.FilteredOut > 0 {
+		fmt.Printf("  Filtered out:      %d\n", r.FilteredOut)
+	}
+	if len(r.SearchDropped) > 0 {
+		reasons := make([]string, 0, len(r.SearchDropped))
+		total := 0
+		for reason, n := range r.SearchDropped {
+			reasons = append(reasons, fmt.Sprintf("%s %d", reason, n))
+			total += n
+		}
+		sort.Strings(reasons)
+		fmt.Printf("  Unreadable cards:  %d (%s)\n", total, strings.Join(reasons, ", "))
+	}
+	if r.JobChanges > 0 {
+		fmt.Printf("  Job changes:       %d\n", r.JobChanges)
+	}
+	if a := r.AcceptanceCheck; a != nil {
+		fmt.Printf("  Accepted:          %d of %d checked", a.Accepted, a.Checked)
+		if a.Unchecked > 0 {
+			fmt.Printf(", %d not reached", a.Unchecked)
+		}
+		fmt.Println()
+		if a.File != "" {
+			fmt.Printf("  Acceptance list:   %s\n", a.File)
+		}
+	}
+	if r.SafeMode != "" {
+		fmt.Printf("  Safe mode:         build %s\n", r.SafeMode)
+	}
+	if len(r.Errors) > 0 {
+		fmt.Printf("  Errors:            %d\n", len(r.Errors))
+	}
+	if len(r.Alerts) > 0 {
+		fmt.Println("\n  ⚠ Alerts:")
+		for _, a := range r.Alerts {
+			fmt.Printf("  • %s\n", a)
+		}
+	}
+	if r.Stopped != "" {
+		fmt.Printf("  Stopped early:     %s\n", r.Stopped)
+	}
+
+	if rr := r.ReadReceipts; rr != nil {
+		fmt.Printf("\n  Messages seen:     %d of %d (%.0f%%), replied %d, seen without reply %d\n",
+			rr.Seen, rr.Sent, pct(rr.Seen, rr.Sent), rr.Replied, rr.SeenNoReply)
+	}
+
+	if len(r.Sources) > 0 {
+		fmt.Println("\n  By source (sent / accepted / messaged / replied):")
+		for _, s := range r.Sources {
+			fmt.Printf("  %-28s %4d / %4d / %4d / %4d  (%.0f%% accepted)\n",
+				s.Source, s.Sent, s.Accepted, s.Messaged, s.Replied, pct(s.Accepted, s.Sent))
+		}
+	}
+
+	if len(r.Timings) > 0 {
+		fmt.Println("\n  Where the time went:")
+		PrintTimings(r.Timings, r.FinishedAt.Sub(r.StartedAt))
+	}
+
+	if m := r.Model; m != nil {
+		fmt.Printf("\n  Acceptance model: %d decided requests, %.0f%% accepted\n", m.Samples, m.BaseRate*100)
+		for _, name := range m.strongest[:min(3, len(m.strongest))] {
+			fmt.Printf("  %-28s %+.2f\n", name, m.Weights[name])
+		}
+		if m.Queued > 0 {
+			fmt.Printf("  Queued requests:   %d, predicted %.0f%% acceptance\n", m.Queued, m.QueuedPredicted*100)
+		}
+	}
+
+	if len(r.Recommendations) > 0 {
+		fmt.Println("\n  Next steps:")
+		for _, rec := range r.Recommendations {
+			fmt.Printf("  • %s\n", rec)
+		}
+	}
+	fmt.Println()
+}
+
+// PrintTimings lists stages by total time with their share of the wall time
+func PrintTimings(stages []timing.Stat, wall time.Duration) {
+	if wall <= 0 {
+		return
+	}
+
+	var attributed time.Duration
+	for _, s := range stages {
+		attributed += s.Total
+		fmt.Printf("  %-16s %10v  %5.1f%%  (%d × %v)\n", s.Stage, s.Total.Round(time.Millisecond),
+			float64(s.Total)*100/float64(wall), s.Count, s.Mean().Round(time.Millisecond))
+	}
+	other := wall - attributed
+	fmt.Printf("  %-16s %10v  %5.1f%%\n", "other", other.Round(time.Millisecond), float64(other)*100/float64(wall))
+}
+
+func pct(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) * 100 / float64(total)
+}
@@ -0,0 +1,106 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"linkedin-automation/config"
+	"linkedin-automation/internal/storage"
+)
+
+// summaryFailures is how many failed actions the summary lists one by one
+const summaryFailures = 10
+
+// Summary is what the account did over the last day, for the daily email
+type Summary struct {
+	Account     string
+	Since       time.Time
+	CreatedAt   time.Time
+	Connections int
+	Accepted    int
+	Messages    int
+	// Failures are the actions that failed, oldest first
+	Failures  []storage.ActionLogEntry
+	Incidents map[string]int
+}
+
+// BuildSummary collects the 24 hours before now from the account's database
+func BuildSummary(cfg *config.Config, store *storage.Store, now time.Time) (*Summary, error) {
+	s := &Summary{Account: cfg.Account, Since: now.Add(-24 * time.Hour), CreatedAt: now}
+
+	var err error
+	if s.Connections, err = store.GetConnectionsCountSince(s.Since); err != nil {
+		return nil, err
+	}
+	if s.Accepted, err = store.GetAcceptedCountSince(s.Since); err != nil {
+		return nil, err
+	}
+	if s.Messages, err = store.GetMessagesCountSince(s.Since); err != nil {
+		return nil, err
+	}
+	entries, err := store.GetActionLogSince(s.Since)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.Outcome == "failure" {
+			s.Failures = append(s.Failures, e)
+		}
+	}
+	if s.Incidents, err = store.CountIncidentsSince(s.Since); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Subject is the summary's email subject line
+func (s *Summary) Subject() string {
+	subject := "LinkedIn outreach summary"
+	if s.Account != "" {
+		subject += " for " + s.Account
+	}
+	subject += fmt.Sprintf(": %d sent, %d accepted, %d messages", s.Connections, s.Accepted, s.Messages)
+	if n := len(s.Failures); n > 0 {
+		subject += fmt.Sprintf(", %d errors", n)
+	}
+	return subject
+}
+
+// String renders the summary as plain text
+func (s *Summary) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Activity from %s to %s\n\n",
+		s.Since.Local().Format("Mon Jan 2 15:04"), s.CreatedAt.Local().Format("Mon Jan 2 15:04"))
+	fmt.Fprintf(&b, "Connection requests sent: %d\n", s.Connections)
+	fmt.Fprintf(&b, "Connections accepted:     %d\n", s.Accepted)
+	fmt.Fprintf(&b, "Messages delivered:       %d\n", s.Messages)
+	fmt.Fprintf(&b, "Errors:                   %d\n", len(s.Failures))
+
+	if len(s.Incidents) > 0 {
+		kinds := make([]string, 0, len(s.Incidents))
+		for k := range s.Incidents {
+			kinds = append(kinds, k)
+		}
+		sort.Strings(kinds)
+		b.WriteString("\nIncidents:\n")
+		for _, k := range kinds {
+			fmt.Fprintf(&b, "• %s: %d\n", k, s.Incidents[k])
+		}
+	}
+
+	if len(s.Failures) > 0 {
+		failures := s.Failures
+		if len(failures) > summaryFailures {
+			failures = failures[len(failures)-summaryFailures:]
+			fmt.Fprintf(&b, "\nThe last %d errors:\n", summaryFailures)
+		} else {
+			b.WriteString("\nErrors:\n")
+		}
+		for _, e := range failures {
+			fmt.Fprintf(&b, "• %s %s %s: %s\n", e.CreatedAt.Local().Format("15:04"), e.Action, e.ProfileURL, e.Detail)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,148 @@
+// Package risk describes what an action costs: the limits it counts toward,
+// how much of each is left and the account's current health, so every action
+// log line carries the context a post-mortem needs
+package risk
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"linkedin-automation/config"
+	"linkedin-automation/internal/storage"
+)
+
+// Budget is one limit an action counts toward
+type Budget struct {
+	Name  string
+	Used  int
+	Limit int
+}
+
+func (b Budget) Remaining() int {
+	return max(b.Limit-b.Used, 0)
+}
+
+func (b Budget) String() string {
+	return fmt.Sprintf("%s %d/%d", b.Name, b.Used, b.Limit)
+}
+
+// Budgets returns the limits a queue action counts toward, with usage so far
+// (including the action just taken when called after it was recorded)
+func Budgets(store *storage.Store, cfg *config.Config, action string) ([]Budget, error) {
+	l := cfg.Limits
+	switch action {
+	case storage.QueueActionConnect:
+		day, err := store.GetConnectionsCountToday()
+		if err != nil {
+			return nil, err
+		}
+		week, err := store.GetConnectionsCountSince(time.Now().Add(-7 * 24 * time.Hour))
+		if err != nil {
+			return nil, err
+		}
+		return []Budget{{"connections/day", day, l.MaxConnectionsPerDay}, {"connections/week", week, l.MaxConnectionsPerWeek}}, nil
+
+	case storage.QueueActionMessage, storage.QueueActionBump, storage.QueueActionCongrats:
+		day, err := store.GetMessagesCountToday()
+		if err != nil {
+			return nil, err
+		}
+		return []Budget{{"messages/day", day, l.MaxMessagesPerDay}}, nil
+
+	case storage.QueueActionBroadcast:
+		day, err := store.GetMessagesCountToday()
+		if err != nil {
+			return nil, err
+		}
+		broadcasts, err := store.GetMessagesCountTodayByKind(storage.MessageKindBroadcast)
+		if err != nil {
+			return nil, err
+		}
+		return []Budget{{"messages/day", day, l.MaxMessagesPerDay}, {"broadcasts/day", broadcasts, l.MaxBroadcastsPerDay}}, nil
+
+	case storage.QueueActionEventInvite:
+		day, err := store.GetEventInvitesCountToday()
+		if err != nil {
+			return nil, err
+		}
+		return []Budget{{"event_invites/day", day, l.MaxEventInvitesPerDay}}, nil
+
+	case storage.QueueActionFollow, storage.QueueActionUnfollow:
+		follows, unfollows, err := store.GetFollowCountsToday()
+		if err != nil {
+			return nil, err
+		}
+		if action == storage.QueueActionFollow {
+			return []Budget{{"follows/day", follows, l.MaxFollowsPerDay}}, nil
+		}
+		return []Budget{{"unfollows/day", unfollows, l.MaxUnfollowsPerDay}}, nil
+	}
+	return nil, nil
+}
+
+// incidentPenalty is what one incident of each kind in the last week costs
+// the health score
+var incidentPenalty = map[string]int{
+	storage.IncidentInviteLimit:  30,
+	storage.IncidentSearchLimit:  10,
+	storage.IncidentSelfAudit:    15,
+	storage.IncidentBrowserCrash: 5,
+	storage.IncidentThrottled:    10,
+}
+
+// Health scores the account from 0 to 100: it starts at 100 and loses points
+// for recent incidents and for a poor acceptance rate over the last 30 days,
+// the two signals LinkedIn restrictions tend to follow
+func Health(store *storage.Store) (int, error) {
+	score := 100
+
+	incidents, err := store.CountIncidentsSince(time.Now().Add(-7 * 24 * time.Hour))
+	if err != nil {
+		return 0, err
+	}
+	for kind, n := range incidents {
+		score -= incidentPenalty[kind] * n
+	}
+
+	sent, accepted, err := store.GetAcceptanceStats(time.Now().AddDate(0, 0, -30), time.Now())
+	if err != nil {
+		return 0, err
+	}
+	if sent >= 20 {
+		switch rate := float64(accepted) / float64(sent); {
+		case rate < 0.15:
+			score -= 25
+		case rate < 0.25:
+			score -= 10
+		}
+	}
+
+	return max(score, 0), nil
+}
+
+// Annotate adds the limits the action counts toward, the smallest remaining
+// budget and the health score to an action's log details. Failures to read
+// them are noted in the details rather than dropping the log line
+func Annotate(store *storage.Store, cfg *config.Config, action string, details map[string]interface{}) map[string]interface{} {
+	budgets, err := Budgets(store, cfg, action)
+	if err != nil {
+		details["limits"] = "unavailable: " + err.Error()
+	} else if len(budgets) == 0 {
+		details["limits"] = "none"
+	} else {
+		names := make([]string, len(budgets))
+		remaining := budgets[0].Remaining()
+		for i, b := range budgets {
+			names[i] = b.String()
+			remaining = min(remaining, b.Remaining())
+		}
+		details["limits"] = strings.Join(names, ", ")
+		details["remaining"] = remaining
+	}
+
+	if health, err := Health(store); err == nil {
+		details["health"] = health
+	}
+	return details
+}
@@ -0,0 +1,126 @@
+// Package runctx carries what the components of a run share. Auth, search,
+// connect and message take a Context in place of separate config, logger and
+// store arguments, so every log line names the run, account and campaign it
+// belongs to, and a simulation can swap the clock and the random source
+package runctx
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"linkedin-automation/config"
+	"linkedin-automation/internal/logger"
+	"linkedin-automation/internal/storage"
+)
+
+// Context is shared by the components of one run
+type Context struct {
+	// Context is cancelled when the operator asks the run to stop; long
+	// loops end after their current step when it is
+	context.Context
+
+	// RunID tells apart the log lines of successive runs, and of the jobs
+	// of a daemon
+	RunID    string
+	Account  string
+	Campaign string
+	DryRun   bool
+
+	Cfg   *config.Config
+	Store *storage.Store
+	// Logger adds the fields above to every line
+	Logger *logger.Logger
+
+	// Clock and Rand are the wall clock and a time-seeded source unless a
+	// simulation sets its own
+	Clock func() time.Time
+	Rand  *rand.Rand
+
+	// OnChallenge, when set, is told of every security challenge met while
+	// logging in, whether or not it was solved
+	OnChallenge func(detail string)
+
+	// base is the logger without the fields, for copies with other ones
+	base *logger.Logger
+}
+
+// New returns the context of a new run for cfg's account; store may be nil
+// for commands that run without the database
+func New(cfg *config.Config, lgr *logger.Logger, store *storage.Store) *Context {
+	c := &Context{
+		Context: context.Background(),
+		RunID:   newRunID(),
+		Account: cfg.Account,
+		DryRun:  cfg.DryRun,
+		Cfg:     cfg,
+		Store:   store,
+		Clock:   time.Now,
+		Rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		base:    lgr,
+	}
+	c.Logger = lgr.With(c.attrs()...)
+	return c
+}
+
+// Stopped reports whether a stop was asked for
+func (c *Context) Stopped() bool {
+	return c.Err() != nil
+}
+
+// Now reads the context's clock
+func (c *Context) Now() time.Time {
+	return c.Clock()
+}
+
+// WithCampaign returns a copy for the actions of one campaign
+func (c *Context) WithCampaign(name string) *Context {
+	cc := *c
+	cc.Campaign = name
+	cc.Logger = c.base.With(cc.attrs()...)
+	return &cc
+}
+
+// WithConfig returns a copy that runs under cfg, e.g. with a campaign's own
+// compliance profile
+func (c *Context) WithConfig(cfg *config.Config) *Context {
+	cc := *c
+	cc.Cfg = cfg
+	return &cc
+}
+
+// NextRun returns a copy with a new run ID and no campaign, for the next job
+// of a long-lived session
+func (c *Context) NextRun() *Context {
+	cc := *c
+	cc.RunID = newRunID()
+	cc.Campaign = ""
+	cc.Logger = c.base.With(cc.attrs()...)
+	return &cc
+}
+
+// attrs are the fields every log line of the context carries
+func (c *Context) attrs() []any {
+	attrs := []any{"run", c.RunID}
+	if c.Account != "" {
+		attrs = append(attrs, "account", c.Account)
+	}
+	if c.Campaign != "" {
+		attrs = append(attrs, "campaign", c.Campaign)
+	}
+	if c.DryRun {
+		attrs = append(attrs, "dry_run", true)
+	}
+	return attrs
+}
+
+func newRunID() string {
+	b := make([]byte, 4)
+	if _, err := crand.Read(b); err != nil {
+		return fmt.Sprintf("%08x", time.Now().UnixNano()&0xffffffff)
+	}
+	return hex.EncodeToString(b)
+}
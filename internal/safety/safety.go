@@ -0,0 +1,187 @@
+// Package safety looks at the page after navigations for signs that LinkedIn
+// has noticed the account: warning banners, the weekly invitation limit
+// dialog, temporary restriction pages and redirects to a checkpoint. The
+// session stops sending as soon as one shows, records it and cools down.
+// Responses LinkedIn throttles, with HTTP 429 or a "too many requests"
+// toast, only slow the session down
+package safety
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+
+	"linkedin-automation/internal/connect"
+	"linkedin-automation/internal/storage"
+)
+
+// Kinds of finding, recorded as incidents of the same kind
+const (
+	KindWarning     = storage.IncidentWarning
+	KindInviteLimit = storage.IncidentInviteLimit
+	KindRestriction = storage.IncidentRestriction
+	KindCheckpoint  = storage.IncidentCheckpoint
+	KindThrottled   = storage.IncidentThrottled
+)
+
+// checkpointPaths are where LinkedIn sends an account it wants to verify
+var checkpointPaths = []string{"/checkpoint/challenge", "/checkpoint/lg/", "/checkpoint/rp/"}
+
+// restrictionText matches the temporary and permanent restriction pages
+const restrictionText = `/account (has been|is) (temporarily )?restricted|restricted your account|temporarily limited your account/i`
+
+// warningText matches the banners LinkedIn shows before it restricts an account
+const warningText = `/unusual activity|automat(ed|ion) (tool|software)|third.party (tool|software)|violat\w* (our|the) user agreement/i`
+
+var warningSelectors = []string{".artdeco-global-alert", "[role='alert']", ".global-alert-banner"}
+
+// throttleText matches the toasts LinkedIn shows when it rejects requests
+// for coming too fast
+const throttleText = `/too many requests|doing that too (fast|often|much)|slow down|you've reached the limit for now/i`
+
+// throttleStatus are the response codes LinkedIn throttles with: 429, and
+// the non-standard 999 it sends to clients it takes for scrapers
+var throttleStatus = map[int]bool{429: true, 999: true}
+
+// Finding is one sign of trouble on the page
+type Finding struct {
+	Kind   string
+	URL    string
+	Detail string
+}
+
+func (f *Finding) String() string {
+	return fmt.Sprintf("%s at %s: %s", f.Kind, f.URL, f.Detail)
+}
+
+// Monitor counts the page's top-level navigations and inspects it when there
+// were any since the last check
+type Monitor struct {
+	mu          sync.Mutex
+	page        *rod.Page
+	navigations atomic.Int64
+	checked     int64
+	// checkpoint is the last checkpoint URL navigated through, which a
+	// redirect may already have left by the time the page is inspected
+	checkpoint atomic.Value
+	// throttled counts throttled responses, which come from background
+	// requests as often as from navigations, and throttledURL is the last
+	throttled        atomic.Int64
+	throttledChecked int64
+	throttledURL     atomic.Value
+}
+
+func New(page *rod.Page) *Monitor {
+	m := &Monitor{}
+	m.SetPage(page)
+	return m
+}
+
+// SetPage watches a new page after the browser is recycled or relaunched
+func (m *Monitor) SetPage(page *rod.Page) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.page = page
+	// The new page is inspected at the next check
+	m.checked = m.navigations.Load() - 1
+	m.throttledChecked = m.throttled.Load()
+	// Response events only flow once the network domain is enabled
+	_ = proto.NetworkEnable{}.Call(page)
+	go page.EachEvent(func(e *proto.PageFrameNavigated) {
+		if e.Frame.ParentID != "" {
+			return
+		}
+		if isCheckpoint(e.Frame.URL) {
+			m.checkpoint.Store(e.Frame.URL)
+		}
+		m.navigations.Add(1)
+	}, func(e *proto.NetworkResponseReceived) {
+		if throttleStatus[e.Response.Status] {
+			m.throttledURL.Store(fmt.Sprintf("HTTP %d from %s", e.Response.Status, e.Response.URL))
+			m.throttled.Add(1)
+		}
+	})()
+}
+
+// Check inspects the page if it has navigated since the last check, and
+// returns what it found or nil. Throttled responses since the last check
+// are reported when the page shows nothing more serious
+func (m *Monitor) Check() *Finding {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var f *Finding
+	if n := m.navigations.Load(); n != m.checked {
+		m.checked = n
+		if url, _ := m.checkpoint.Swap("").(string); url != "" {
+			return &Finding{Kind: KindCheckpoint, URL: url, Detail: "redirected to a checkpoint"}
+		}
+		f = Inspect(m.page)
+	}
+
+	if n := m.throttled.Load(); n != m.throttledChecked && (f == nil || f.Kind == KindThrottled) {
+		detail, _ := m.throttledURL.Load().(string)
+		f = &Finding{Kind: KindThrottled, Detail: fmt.Sprintf("%d throttled responses, last %s", n-m.throttledChecked, detail)}
+		if info, err := m.page.Info(); err == nil {
+			f.URL = info.URL
+		}
+		m.throttledChecked = n
+	}
+	return f
+}
+
+// Inspect looks at the page as it is now and returns the most serious sign
+// of trouble on it, or nil
+func Inspect(page *rod.Page) *Finding {
+	info, err := page.Info()
+	if err != nil {
+		return nil
+	}
+	url := info.URL
+
+	if has, el, _ := page.HasR("h1, h2", restrictionText); has {
+		return &Finding{Kind: KindRestriction, URL: url, Detail: excerpt(el)}
+	}
+	if isCheckpoint(url) {
+		return &Finding{Kind: KindCheckpoint, URL: url, Detail: "checkpoint page"}
+	}
+	if connect.InviteLimitShown(page) {
+		return &Finding{Kind: KindInviteLimit, URL: url, Detail: "weekly invitation limit dialog"}
+	}
+	for _, sel := range warningSelectors {
+		if has, el, _ := page.HasR(sel, warningText); has {
+			return &Finding{Kind: KindWarning, URL: url, Detail: excerpt(el)}
+		}
+	}
+	if has, el, _ := page.HasR(".artdeco-toast-item, [role='alert']", throttleText); has {
+		return &Finding{Kind: KindThrottled, URL: url, Detail: excerpt(el)}
+	}
+	return nil
+}
+
+func isCheckpoint(url string) bool {
+	for _, p := range checkpointPaths {
+		if strings.Contains(url, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// excerpt is the start of an element's text, for the incident log
+func excerpt(el *rod.Element) string {
+	text, err := el.Text()
+	if err != nil {
+		return ""
+	}
+	text = strings.Join(strings.Fields(text), " ")
+	if r := []rune(text); len(r) > 160 {
+		text = string(r[:160]) + "…"
+	}
+	return text
+}
@@ -0,0 +1,296 @@
+// Package salesnav bridges campaigns with Sales Navigator lead lists: leads
+// in an existing list can be imported as prospects, and accepted or replied
+// prospects can be saved into a list. Everything goes through the Sales
+// Navigator UI
+package salesnav
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/input"
+
+	"linkedin-automation/config"
+	"linkedin-automation/internal/apperr"
+	"linkedin-automation/internal/logger"
+	"linkedin-automation/internal/queue"
+	"linkedin-automation/internal/risk"
+	"linkedin-automation/internal/search"
+	"linkedin-automation/internal/stealth"
+	"linkedin-automation/internal/storage"
+	"linkedin-automation/internal/throttle"
+	"linkedin-automation/internal/timing"
+)
+
+type Syncer struct {
+	page     *rod.Page
+	cfg      *config.Config
+	logger   *logger.Logger
+	store    *storage.Store
+	throttle *throttle.Coordinator
+}
+
+func New(page *rod.Page, cfg *config.Config, log *logger.Logger, store *storage.Store) *Syncer {
+	return &Syncer{
+		page:     page,
+		cfg:      cfg,
+		logger:   log,
+		store:    store,
+		throttle: throttle.New(cfg, log, store),
+	}
+}
+
+// SetPage points the syncer at a new page after the browser is relaunched
+func (s *Syncer) SetPage(page *rod.Page) {
+	s.page = page
+}
+
+// SourceLabel is the attribution of prospects imported from a lead list
+func SourceLabel(list string) string {
+	return "salesnav:" + list
+}
+
+// ImportList reads up to max leads from the named lead list. Sales Navigator
+// links to its own lead pages, so each lead is opened once to find the
+// LinkedIn profile URL the rest of the tool works with
+func (s *Syncer) ImportList(list string, max int) ([]search.Profile, error) {
+	if err := s.openList(list); err != nil {
+		return nil, err
+	}
+
+	extracted := timing.Start(timing.Extraction)
+	leads, err := readLeads(s.page)
+	extracted()
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("Lead list %q shows %d leads", list, len(leads))
+
+	var profiles []search.Profile
+	for _, l := range leads {
+		if len(profiles) >= max {
+			break
+		}
+		url, err := s.profileURL(l.URL)
+		if err != nil {
+			s.logger.Warn("Skipping lead %s: %v", l.Name, err)
+			continue
+		}
+		profiles = append(profiles, search.Profile{
+			URL:    url,
+			Name:   l.Name,
+			Title:  l.Title,
+			Source: SourceLabel(list),
+		})
+		stealth.RandomDelay(2000, 4000)
+	}
+	return profiles, nil
+}
+
+// EnqueueSaves queues every accepted or replied prospect not yet saved to the list
+func (s *Syncer) EnqueueSaves(list string) (int, error) {
+	candidates, err := s.store.GetLeadListCandidates(list)
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	for _, c := range candidates {
+		if err := s.store.Enqueue(storage.QueueItem{
+			Campaign:   c.Campaign,
+			Action:     storage.QueueActionSaveLead,
+			ProfileURL: c.ProfileURL,
+			Name:       c.Name,
+			Payload:    list,
+			Source:     c.Source,
+		}); err != nil {
+			return queued, err
+		}
+		queued++
+	}
+
+	s.logger.Info("Queued %d prospects to save to lead list %q", queued, list)
+	return queued, nil
+}
+
+// ProcessQueueItem saves one prospect to the lead list named in the payload
+func (s *Syncer) ProcessQueueItem(ctx context.Context, item storage.QueueItem) error {
+	list := item.Payload
+
+	if err := s.throttle.Acquire(ctx); err != nil {
+		return fmt.Errorf("failed to acquire throttle: %w", err)
+	}
+	defer s.throttle.Release()
+
+	if err := s.openLead(item.ProfileURL); err != nil {
+		return err
+	}
+
+	saved, err := s.saveToList(list)
+	if err != nil {
+		return err
+	}
+	if !saved && s.cfg.DryRun {
+		return queue.Planned("record that the lead is already in list %q", list)
+	}
+	if err := s.store.SaveLeadListEntry(item.ProfileURL, list); err != nil {
+		s.logger.Error("Failed to record lead list save: %v", err)
+	}
+	if !saved {
+		s.logger.Info("%s is already in lead list %q", item.Name, list)
+		return nil
+	}
+
+	s.logger.LogAction(logger.Action{
+		Type:       "LEAD_SAVED",
+		Action:     item.Action,
+		ProfileURL: item.ProfileURL,
+		Campaign:   item.Campaign,
+		Duration:   queue.Elapsed(ctx),
+		Outcome:    logger.OutcomeSuccess,
+		Details: risk.Annotate(s.store, s.cfg, item.Action, map[string]interface{}{
+			"name": item.Name,
+			"list": list,
+		}),
+	})
+	return nil
+}
+
+func (s *Syncer) navigate(url string) error {
+	navigated := timing.Start(timing.Navigation)
+	defer navigated()
+	if err := s.page.Navigate(url); err != nil {
+		return apperr.Navigation(url, err)
+	}
+	s.page.WaitLoad()
+	stealth.RandomDelay(2000, 4000)
+	return nil
+}
+
+// openList opens the people list whose name matches exactly
+func (s *Syncer) openList(list string) error {
+	if err := s.navigate(s.cfg.LinkedIn.BaseURL + "/sales/lists/people"); err != nil {
+		return err
+	}
+
+	links, err := s.page.Elements("a[href*='/sales/lists/people/']")
+	if err != nil {
+		return apperr.Selector("lead lists")
+	}
+	for _, a := range links {
+		text, _ := a.Text()
+		if strings.EqualFold(strings.TrimSpace(text), list) {
+			stealth.HumanClick(s.page, a)
+			s.page.WaitLoad()
+			stealth.RandomDelay(2000, 4000)
+			return nil
+		}
+	}
+	return fmt.Errorf("lead list %q not found", list)
+}
+
+// lead is one row of a lead list
+type lead struct {
+	Name  string `json:"name"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+const readLeadsJS = `() => {
+	const out = [];
+	for (const row of document.querySelectorAll('tr, [data-x--people-list--row]')) {
+		const a = row.querySelector('a[href*="/sales/lead/"], a[href*="/sales/people/"]');
+		if (!a) continue;
+		const title = row.querySelector('[data-anonymize="title"]');
+		out.push({name: a.innerText.trim(), title: title ? title.innerText.trim() : '', url: a.href});
+	}
+	return out;
+}`
+
+func readLeads(page *rod.Page) ([]lead, error) {
+	res, err := page.Eval(readLeadsJS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lead list: %w", err)
+	}
+	var leads []lead
+	if err := res.Value.Unmarshal(&leads); err != nil {
+		return nil, fmt.Errorf("failed to read lead list: %w", err)
+	}
+	return leads, nil
+}
+
+// profileURL opens a Sales Navigator lead page and returns the member's
+// LinkedIn profile URL from its "View LinkedIn profile" link
+func (s *Syncer) profileURL(leadURL string) (string, error) {
+	if err := s.navigate(leadURL); err != nil {
+		return "", err
+	}
+
+	// The link lives in the overflow menu of the lead's top card
+	if has, more, _ := s.page.Has("button[aria-label*='Open actions overflow menu'], button[aria-label^='More actions']"); has {
+		stealth.HumanClick(s.page, more)
+		stealth.RandomDelay(600, 1200)
+	}
+	has, a, _ := s.page.Has("a[href*='linkedin.com/in/']")
+	if !has {
+		return "", apperr.Selector("view LinkedIn profile link")
+	}
+	href, err := a.Property("href")
+	if err != nil {
+		return "", err
+	}
+	return href.String(), nil
+}
+
+// openLead goes from a LinkedIn profile to its Sales Navigator lead page
+func (s *Syncer) openLead(profileURL string) error {
+	if err := s.navigate(profileURL); err != nil {
+		return err
+	}
+	has, a, _ := s.page.Has("a[href*='/sales/people/'], a[href*='/sales/lead/']")
+	if !has {
+		return apperr.Selector("view in Sales Navigator link")
+	}
+	href, err := a.Property("href")
+	if err != nil {
+		return err
+	}
+	return s.navigate(href.String())
+}
+
+// saveToList opens the lead's Save menu and ticks the list; it reports false
+// when the lead was already in it
+func (s *Syncer) saveToList(list string) (bool, error) {
+	has, btn, _ := s.page.Has("button[aria-label^='Save'], button[aria-label^='Saved']")
+	if !has {
+		return false, apperr.Selector("lead save button")
+	}
+	stealth.HumanClick(s.page, btn)
+	stealth.RandomDelay(800, 1600)
+
+	items, err := s.page.Elements("[role='menu'] li, .artdeco-dropdown__content li")
+	if err != nil {
+		return false, apperr.Selector("lead list menu")
+	}
+	for _, it := range items {
+		text, _ := it.Text()
+		if !strings.Contains(strings.ToLower(text), strings.ToLower(list)) {
+			continue
+		}
+		// Lists the lead is already in are shown checked
+		if checked, _, _ := it.Has("[aria-checked='true'], input:checked"); checked {
+			s.page.Keyboard.Type(input.Escape)
+			return false, nil
+		}
+		if s.cfg.DryRun {
+			s.page.Keyboard.Type(input.Escape)
+			return false, queue.Planned("save the lead to list %q", list)
+		}
+		stealth.HumanClick(s.page, it)
+		stealth.RandomDelay(1000, 2000)
+		return true, nil
+	}
+	return false, fmt.Errorf("lead list %q not offered in the save menu", list)
+}
@@ -0,0 +1,157 @@
+package scoring
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"linkedin-automation/internal/storage"
+)
+
+// MinSamples is how many decided requests the model needs, with both
+// outcomes represented, before its predictions are used
+const MinSamples = 30
+
+// decideAfter is how long an unanswered request waits before it counts as declined
+const decideAfter = 21 * 24 * time.Hour
+
+// FeatureNames label the model's weights, after the intercept
+var FeatureNames = []string{"title_match", "location_match", "mutuals", "open_to_work", "note"}
+
+// Model is a logistic regression of acceptance on prospect features
+type Model struct {
+	// Weights holds the intercept followed by one weight per FeatureNames entry
+	Weights  []float64
+	Samples  int
+	Accepted int
+}
+
+// Load trains a model on the account's decided connection requests; it
+// returns nil when there is not enough history yet
+func Load(store *storage.Store) (*Model, error) {
+	rows, err := store.GetTrainingRows(time.Now().Add(-decideAfter))
+	if err != nil {
+		return nil, err
+	}
+	return Train(rows), nil
+}
+
+// Train fits the model with batch gradient descent and light L2
+// regularisation, which keeps weights sane on the small samples one account
+// produces
+func Train(rows []storage.TrainingRow) *Model {
+	accepted := 0
+	for _, r := range rows {
+		if r.Accepted {
+			accepted++
+		}
+	}
+	if len(rows) < MinSamples || accepted == 0 || accepted == len(rows) {
+		return nil
+	}
+
+	const (
+		iterations = 2000
+		rate       = 0.5
+		lambda     = 0.01
+	)
+
+	w := make([]float64, len(FeatureNames)+1)
+	grad := make([]float64, len(w))
+	n := float64(len(rows))
+
+	for it := 0; it < iterations; it++ {
+		for i := range grad {
+			grad[i] = 0
+		}
+		for _, r := range rows {
+			x := vector(r.ProspectFeatures)
+			y := 0.0
+			if r.Accepted {
+				y = 1
+			}
+			diff := sigmoid(dot(w, x)) - y
+			for i := range x {
+				grad[i] += diff * x[i]
+			}
+		}
+		for i := range w {
+			reg := lambda * w[i]
+			if i == 0 {
+				reg = 0
+			}
+			w[i] -= rate * (grad[i]/n + reg)
+		}
+	}
+
+	return &Model{Weights: w, Samples: len(rows), Accepted: accepted}
+}
+
+// Predict returns the probability the prospect accepts
+func (m *Model) Predict(f storage.ProspectFeatures) float64 {
+	return sigmoid(dot(m.Weights, vector(f)))
+}
+
+// Priority maps a prediction onto the queue's priority scale (0-100)
+func (m *Model) Priority(f storage.ProspectFeatures) int {
+	return int(math.Round(m.Predict(f) * 100))
+}
+
+// BaseRate is the share of training requests that were accepted
+func (m *Model) BaseRate() float64 {
+	return float64(m.Accepted) / float64(m.Samples)
+}
+
+// Strongest returns feature names ordered by the size of their effect
+func (m *Model) Strongest() []string {
+	names := append([]string(nil), FeatureNames...)
+	weight := make(map[string]float64)
+	for i, name := range FeatureNames {
+		weight[name] = m.Weights[i+1]
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		return math.Abs(weight[names[i]]) > math.Abs(weight[names[j]])
+	})
+	return names
+}
+
+// Weight returns the learned weight for a feature name
+func (m *Model) Weight(name string) float64 {
+	for i, n := range FeatureNames {
+		if n == name {
+			return m.Weights[i+1]
+		}
+	}
+	return 0
+}
+
+// vector scales features to comparable ranges; mutual counts are heavy-tailed
+func vector(f storage.ProspectFeatures) []float64 {
+	return []float64{
+		1,
+		f.TitleMatch,
+		f.LocationMatch,
+		math.Log1p(float64(f.Mutuals)) / math.Log1p(50),
+		boolFloat(f.OpenToWork),
+		boolFloat(f.Note),
+	}
+}
+
+func boolFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func dot(a, b []float64) float64 {
+	s := 0.0
+	for i := range a {
+		s += a[i] * b[i]
+	}
+	return s
+}
+
+func sigmoid(z float64) float64 {
+	return 1 / (1 + math.Exp(-z))
+}
@@ -0,0 +1,66 @@
+package scoring
+
+import (
+	"testing"
+
+	"linkedin-automation/internal/storage"
+)
+
+// rows returns n requests where open-to-work prospects mostly accept and the
+// others mostly do not, with notes sent at random to both
+func rows(n int) []storage.TrainingRow {
+	out := make([]storage.TrainingRow, n)
+	for i := range out {
+		open := i%2 == 0
+		accepted := open
+		// Every fifth outcome goes the other way, so the weights stay finite
+		if i%5 == 0 {
+			accepted = !accepted
+		}
+		out[i] = storage.TrainingRow{
+			ProspectFeatures: storage.ProspectFeatures{OpenToWork: open, Note: i%3 == 0},
+			Accepted:         accepted,
+		}
+	}
+	return out
+}
+
+func TestTrain(t *testing.T) {
+	m := Train(rows(60))
+	if m == nil {
+		t.Fatal("no model from 60 samples")
+	}
+	if m.Samples != 60 || m.Accepted != 30 {
+		t.Errorf("samples %d, accepted %d; want 60, 30", m.Samples, m.Accepted)
+	}
+	if got := m.Strongest()[0]; got != "open_to_work" {
+		t.Errorf("strongest feature %q, want open_to_work (weights %v)", got, m.Weights)
+	}
+	if w := m.Weight("open_to_work"); w <= 0 {
+		t.Errorf("open_to_work weight %.3f, want positive", w)
+	}
+
+	open := m.Predict(storage.ProspectFeatures{OpenToWork: true})
+	closed := m.Predict(storage.ProspectFeatures{})
+	if !(open > m.BaseRate() && m.BaseRate() > closed) {
+		t.Errorf("predicted %.2f open to work and %.2f not, around a base rate of %.2f", open, closed, m.BaseRate())
+	}
+	// Trained on 80% accuracy, predictions should land near it
+	if open < 0.7 || open > 0.9 || closed < 0.1 || closed > 0.3 {
+		t.Errorf("predicted %.2f open to work and %.2f not; want about 0.8 and 0.2", open, closed)
+	}
+}
+
+func TestTrainNeedsHistory(t *testing.T) {
+	if m := Train(rows(MinSamples - 1)); m != nil {
+		t.Errorf("trained on %d samples, below MinSamples", MinSamples-1)
+	}
+
+	all := rows(60)
+	for i := range all {
+		all[i].Accepted = true
+	}
+	if m := Train(all); m != nil {
+		t.Error("trained with only accepted requests")
+	}
+}
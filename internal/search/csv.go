@@ -0,0 +1,122 @@
+package search
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LoadCSV reads prospects from a CSV file with a header row naming its
+// columns; url is required, and name, title and location are used when
+// present. It is the sourcing fallback while search is blocked
+func LoadCSV(path string, max int) ([]Profile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open prospect CSV: %w", err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prospect CSV header: %w", err)
+	}
+	col := make(map[string]int)
+	for i, name := range header {
+		col[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := col["url"]; !ok {
+		return nil, fmt.Errorf("prospect CSV %s has no url column", path)
+	}
+
+	field := func(rec []string, name string) string {
+		if i, ok := col[name]; ok && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+
+	source := "csv:" + filepath.Base(path)
+	seen := make(map[string]bool)
+	var profiles []Profile
+	for len(profiles) < max {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read prospect CSV: %w", err)
+		}
+
+		url := field(rec, "url")
+		if !strings.Contains(url, "/in/") || seen[url] {
+			continue
+		}
+		seen[url] = true
+		profiles = append(profiles, Profile{
+			URL:      url,
+			Name:     field(rec, "name"),
+			Title:    field(rec, "title"),
+			Location: field(rec, "location"),
+			Source:   source,
+		})
+	}
+	return profiles, nil
+}
+
+// LoadList reads prospects from a CSV with a url column (see LoadCSV) or from
+// a plain list of profile URLs, one per line, as other tools export them.
+// Blank lines, # comments and lines without a profile URL are skipped
+func LoadList(path string, max int) ([]Profile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open profile list: %w", err)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	var lines []string
+	for sc.Scan() {
+		if line := strings.TrimSpace(sc.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read profile list: %w", err)
+	}
+
+	// A header row naming a url column makes it a CSV
+	if len(lines) > 0 && !strings.Contains(lines[0], "/in/") && strings.Contains(strings.ToLower(lines[0]), "url") {
+		return LoadCSV(path, max)
+	}
+
+	source := "list:" + filepath.Base(path)
+	seen := make(map[string]bool)
+	var profiles []Profile
+	for _, line := range lines {
+		if len(profiles) >= max {
+			break
+		}
+		// The URL may be one column of an export without a header
+		var url string
+		for _, field := range strings.Split(line, ",") {
+			if field = strings.Trim(strings.TrimSpace(field), `"`); strings.Contains(field, "/in/") {
+				url = strings.Split(field, "?")[0]
+				break
+			}
+		}
+		if url == "" || seen[url] {
+			continue
+		}
+		seen[url] = true
+		profiles = append(profiles, Profile{URL: url, Source: source})
+	}
+	return profiles, nil
+}
@@ -0,0 +1,173 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"linkedin-automation/config"
+)
+
+// seniorityWords map title words to seniority levels, most senior first so
+// "Senior Engineering Manager" is a manager
+var seniorityWords = []struct {
+	level string
+	words []string
+}{
+	{"founder", []string{"founder", "co-founder", "owner"}},
+	{"cxo", []string{"chief", "ceo", "cto", "cfo", "coo", "cio", "cmo", "cpo"}},
+	{"vp", []string{"vp", "vice president", "svp", "evp"}},
+	{"director", []string{"director", "head of"}},
+	{"manager", []string{"manager", "mgr"}},
+	{"lead", []string{"lead", "principal", "staff", "architect"}},
+	{"senior", []string{"senior", "sr", "sr."}},
+	{"entry", []string{"intern", "junior", "jr", "jr.", "graduate", "trainee", "apprentice", "entry"}},
+}
+
+var wordRe = regexp.MustCompile(`[\pL\pN.-]+`)
+
+// Seniority reads the level of a title: entry, mid, senior, lead, manager,
+// director, vp, cxo or founder. Titles naming none are mid
+func Seniority(title string) string {
+	title = strings.ToLower(title)
+	// Only the role before "at Company" counts
+	if i := strings.Index(title, " at "); i >= 0 {
+		title = title[:i]
+	}
+	words := make(map[string]bool)
+	for _, w := range wordRe.FindAllString(title, -1) {
+		words[w] = true
+	}
+	for _, s := range seniorityWords {
+		for _, w := range s.words {
+			if words[w] || (strings.Contains(w, " ") && strings.Contains(title, w)) {
+				return s.level
+			}
+		}
+	}
+	return "mid"
+}
+
+// Company reads the company from a title like "Platform Lead at Acme"
+func Company(title string) string {
+	if i := strings.LastIndex(strings.ToLower(title), " at "); i >= 0 {
+		return strings.TrimSpace(title[i+4:])
+	}
+	return ""
+}
+
+// Filter applies the search include and exclude rules
+type Filter struct {
+	include, exclude rules
+}
+
+type rules struct {
+	titles, locations, companies, seniority []string
+	patterns                                []*regexp.Regexp
+}
+
+// NewFilter compiles the configured rules
+func NewFilter(cfg config.SearchConfig) (*Filter, error) {
+	include, err := compileRules(cfg.Include)
+	if err != nil {
+		return nil, fmt.Errorf("search.include: %w", err)
+	}
+	exclude, err := compileRules(cfg.Exclude)
+	if err != nil {
+		return nil, fmt.Errorf("search.exclude: %w", err)
+	}
+	return &Filter{include: include, exclude: exclude}, nil
+}
+
+func compileRules(r config.SearchRules) (rules, error) {
+	out := rules{
+		titles:    lower(r.Titles),
+		locations: lower(r.Locations),
+		companies: lower(r.Companies),
+		seniority: lower(r.Seniority),
+	}
+	for _, s := range out.seniority {
+		if s != "mid" && !knownLevel(s) {
+			return out, fmt.Errorf("unknown seniority %q", s)
+		}
+	}
+	for _, p := range r.Patterns {
+		re, err := regexp.Compile("(?i)" + p)
+		if err != nil {
+			return out, fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+		out.patterns = append(out.patterns, re)
+	}
+	return out, nil
+}
+
+func knownLevel(level string) bool {
+	for _, s := range seniorityWords {
+		if s.level == level {
+			return true
+		}
+	}
+	return false
+}
+
+func lower(values []string) []string {
+	var out []string
+	for _, v := range values {
+		if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Keep reports whether p passes the rules, and if not, which rule dropped it
+func (f *Filter) Keep(p Profile) (bool, string) {
+	title, location := strings.ToLower(p.Title), strings.ToLower(p.Location)
+	company, level := strings.ToLower(Company(p.Title)), Seniority(p.Title)
+
+	checks := []struct {
+		name             string
+		include, exclude []string
+		value            string
+		exact            bool
+	}{
+		{"title", f.include.titles, f.exclude.titles, title, false},
+		{"location", f.include.locations, f.exclude.locations, location, false},
+		{"company", f.include.companies, f.exclude.companies, company, false},
+		{"seniority", f.include.seniority, f.exclude.seniority, level, true},
+	}
+	for _, c := range checks {
+		if len(c.include) > 0 && !matchAny(c.include, c.value, c.exact) {
+			return false, "no included " + c.name
+		}
+		if matchAny(c.exclude, c.value, c.exact) {
+			return false, "excluded " + c.name
+		}
+	}
+
+	if len(f.include.patterns) > 0 && !matchPattern(f.include.patterns, p.Title) {
+		return false, "title matches no included pattern"
+	}
+	if matchPattern(f.exclude.patterns, p.Title) {
+		return false, "title matches an excluded pattern"
+	}
+	return true, ""
+}
+
+func matchAny(keywords []string, value string, exact bool) bool {
+	for _, k := range keywords {
+		if (exact && value == k) || (!exact && strings.Contains(value, k)) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchPattern(patterns []*regexp.Regexp, value string) bool {
+	for _, re := range patterns {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
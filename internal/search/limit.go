@@ -0,0 +1,42 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-rod/rod"
+
+	"linkedin-automation/internal/apperr"
+)
+
+// ErrSearchLimit means LinkedIn's commercial use limit blocks people search
+// on this (free) account until the start of next month
+var ErrSearchLimit = fmt.Errorf("%w: monthly search limit reached", apperr.ErrRateLimited)
+
+// SearchLimitShown detects the commercial use limit banner or modal that
+// replaces results once a free account has searched too much this month
+func SearchLimitShown(page *rod.Page) bool {
+	for _, selector := range []string{
+		".search-paywall__info",
+		"[data-test-search-paywall]",
+	} {
+		if has, _, _ := page.Has(selector); has {
+			return true
+		}
+	}
+
+	has, _, _ := page.HasR("main, [role='dialog']", "/reached the monthly limit for (profile )?searches|commercial use limit/i")
+	return has
+}
+
+// SearchLimitReset is when the limit lifts: LinkedIn resets it at the start
+// of each calendar month
+func SearchLimitReset(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, now.Location())
+}
+
+// IsSearchLimit reports whether err came from the search limit
+func IsSearchLimit(err error) bool {
+	return errors.Is(err, ErrSearchLimit)
+}
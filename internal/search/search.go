@@ -1,20 +1,44 @@
 package search
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"linkedin-automation/config"
+	"linkedin-automation/internal/apperr"
+	"linkedin-automation/internal/dom"
 	"linkedin-automation/internal/logger"
+	"linkedin-automation/internal/runctx"
 	"linkedin-automation/internal/stealth"
+	"linkedin-automation/internal/storage"
+	"linkedin-automation/internal/timing"
 	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-rod/rod"
 )
 
 type Searcher struct {
 	page   *rod.Page
+	run    *runctx.Context
 	cfg    *config.Config
 	logger *logger.Logger
+	// limiter paces result pages; nil leaves them unpaced
+	limiter *stealth.RateLimiter
+	// anonymized counts the "LinkedIn Member" cards skipped so far, and
+	// filtered the results the search rules dropped
+	anonymized int
+	filtered   int
+	// dropped counts by reason the cards that could not be read, which
+	// climbs when LinkedIn changes the results markup; dumped names the
+	// cards saved for fixtures
+	dropped map[string]int
+	dumped  int
 }
 
 type Profile struct {
@@ -22,74 +46,161 @@ type Profile struct {
 	Name     string
 	Title    string
 	Location string
+	// Source records how the prospect entered the system, e.g. "search:golang engineer"
+	Source string
+
+	// Signals shown on the result card
+	Mutuals    int
+	OpenToWork bool
+
+	// TitleMatch and LocationMatch are the share of the search's query and
+	// location words found in the profile's title and location (0-1)
+	TitleMatch    float64
+	LocationMatch float64
 }
 
-func New(page *rod.Page, cfg *config.Config, log *logger.Logger) *Searcher {
+func New(page *rod.Page, rc *runctx.Context) *Searcher {
 	return &Searcher{
-		page:   page,
-		cfg:    cfg,
-		logger: log,
+		page:    page,
+		run:     rc,
+		cfg:     rc.Cfg,
+		logger:  rc.Logger,
+		dropped: make(map[string]int),
 	}
 }
 
-func (s *Searcher) SearchPeople(query, location, company string, maxResults int) ([]Profile, error) {
+// SetLimiter paces each results page with the session's rate limiter
+func (s *Searcher) SetLimiter(rl *stealth.RateLimiter) {
+	s.limiter = rl
+}
+
+// SourceLabel builds the attribution label for prospects found by a search
+func SourceLabel(query, location, company string) string {
+	label := "search:" + query
+	if location != "" {
+		label += " @" + location
+	}
+	if company != "" {
+		label += " #" + company
+	}
+	return label
+}
+
+// SearchPeople collects up to maxResults profiles from LinkedIn's people
+// search. When ctx is done it stops between pages and saves where it got to,
+// so the next run of the same search picks up there
+func (s *Searcher) SearchPeople(ctx context.Context, query, location, company string, maxResults int) ([]Profile, error) {
 	s.logger.Info("Starting people search: query=%s, location=%s, company=%s", query, location, company)
 
-	// Build search URL
+	filter, err := NewFilter(s.cfg.Search)
+	if err != nil {
+		return nil, err
+	}
+
+	source := SourceLabel(query, location, company)
+
+	// Build search URL, resuming an interrupted run of the same search
 	searchURL := s.buildSearchURL(query, location, company)
+	page := s.resumePage(source)
+	if page > 1 {
+		s.logger.Info("Resuming the interrupted search at page %d", page)
+		searchURL += "&page=" + strconv.Itoa(page)
+	}
 	s.logger.Debug("Search URL: %s", searchURL)
 
+	if err := s.limiter.AcquireContext(ctx, stealth.ActionSearch); err != nil {
+		return nil, err
+	}
+
 	// Navigate to search
-	if err := s.page.Navigate(searchURL); err != nil {
-		return nil, fmt.Errorf("failed to navigate to search: %w", err)
+	navigated := timing.Start(timing.Navigation)
+	if err := s.page.Context(ctx).Navigate(searchURL); err != nil {
+		return nil, apperr.Navigation(searchURL, err)
+	}
+
+	if err := s.page.Context(ctx).WaitLoad(); err != nil {
+		return nil, apperr.Navigation(searchURL, err)
 	}
+	navigated()
 
-	if err := s.page.WaitLoad(); err != nil {
+	if err := stealth.RandomDelayContext(ctx, 2000, 4000); err != nil {
 		return nil, err
 	}
 
-	stealth.RandomDelay(2000, 4000)
+	if SearchLimitShown(s.page) {
+		return nil, ErrSearchLimit
+	}
 
 	// Scroll to load results
 	if s.cfg.Stealth.EnableRandomScrolling {
-		stealth.HumanScroll(s.page, "down", 300)
-		stealth.RandomDelay(1000, 2000)
+		stealth.HumanScroll(s.page.Context(ctx), "down", 300)
+		stealth.RandomDelayContext(ctx, 1000, 2000)
 	}
 
 	var profiles []Profile
 	seenURLs := make(map[string]bool)
-	page := 1
 
 	for len(profiles) < maxResults {
 		s.logger.Info("Processing page %d (collected %d/%d profiles)", page, len(profiles), maxResults)
 
 		// Extract profiles from current page
-		pageProfiles, err := s.extractProfiles()
+		pageProfiles, err := s.extractProfiles(ctx)
 		if err != nil {
 			s.logger.Error("Failed to extract profiles: %v", err)
 			break
 		}
 
-		// Filter duplicates
+		// Filter duplicates and results the search rules drop
 		for _, p := range pageProfiles {
 			if !seenURLs[p.URL] && len(profiles) < maxResults {
-				profiles = append(profiles, p)
 				seenURLs[p.URL] = true
+				if keep, why := filter.Keep(p); !keep {
+					s.logger.Debug("Filtered out %s (%s): %s", p.Name, p.Title, why)
+					s.filtered++
+					continue
+				}
+				p.Source = source
+				p.TitleMatch = overlap(query, p.Title)
+				p.LocationMatch = overlap(location, p.Location)
+				profiles = append(profiles, p)
 			}
 		}
 
 		// Try to go to next page
 		if len(profiles) < maxResults {
+			if ctx.Err() != nil {
+				s.stopAt(source, page+1)
+				break
+			}
 			if !s.hasNextPage() {
 				s.logger.Info("No more pages available")
 				break
 			}
 
-			if err := s.goToNextPage(); err != nil {
-				s.logger.Warn("Failed to go to next page: %v", err)
+			if err := s.limiter.AcquireContext(ctx, stealth.ActionSearch); err != nil {
+				if ctx.Err() != nil {
+					s.stopAt(source, page+1)
+				} else {
+					s.logger.Info("Stopping at page %d: %v", page, err)
+				}
+				break
+			}
+
+			if err := s.goToNextPage(ctx); err != nil {
+				if ctx.Err() != nil {
+					s.stopAt(source, page+1)
+				} else {
+					s.logger.Warn("Failed to go to next page: %v", err)
+				}
 				break
 			}
 
+			// The limit can also cut in part way through the pages
+			if SearchLimitShown(s.page) {
+				s.logger.Info("Search completed: found %d profiles before the search limit", len(profiles))
+				return profiles, ErrSearchLimit
+			}
+
 			page++
 		} else {
 			break
@@ -97,9 +208,82 @@ func (s *Searcher) SearchPeople(query, location, company string, maxResults int)
 	}
 
 	s.logger.Info("Search completed: found %d profiles", len(profiles))
+	if s.anonymized > 0 {
+		s.logger.Info("Skipped %d anonymized LinkedIn Member results", s.anonymized)
+	}
+	if s.filtered > 0 {
+		s.logger.Info("Filtered out %d results by the search rules", s.filtered)
+	}
 	return profiles, nil
 }
 
+// searchCheckpointTTL is how long an interrupted search is resumed where it
+// stopped; after that the results have moved on
+const searchCheckpointTTL = 7 * 24 * time.Hour
+
+// searchCheckpoint is where an interrupted search stopped
+type searchCheckpoint struct {
+	Search  string    `json:"search"`
+	Page    int       `json:"page"`
+	SavedAt time.Time `json:"saved_at"`
+}
+
+// resumePage returns the page an interrupted run of the search stopped at,
+// or 1, and clears the checkpoint; a search stopped again saves a new one
+func (s *Searcher) resumePage(source string) int {
+	if s.run.Store == nil {
+		return 1
+	}
+	value, err := s.run.Store.GetState(storage.StateSearchCheckpoint)
+	if err != nil || value == "" {
+		return 1
+	}
+	s.run.Store.SetState(storage.StateSearchCheckpoint, "")
+
+	var cp searchCheckpoint
+	if err := json.Unmarshal([]byte(value), &cp); err != nil || cp.Search != source ||
+		time.Since(cp.SavedAt) > searchCheckpointTTL || cp.Page < 2 {
+		return 1
+	}
+	return cp.Page
+}
+
+// stopAt saves page as where the next run of the search starts
+func (s *Searcher) stopAt(source string, page int) {
+	s.logger.Info("Stop requested; the next run resumes this search at page %d", page)
+	s.saveCheckpoint(source, page)
+}
+
+func (s *Searcher) saveCheckpoint(source string, page int) {
+	if s.run.Store == nil {
+		return
+	}
+	data, err := json.Marshal(searchCheckpoint{Search: source, Page: page, SavedAt: time.Now()})
+	if err == nil {
+		err = s.run.Store.SetState(storage.StateSearchCheckpoint, string(data))
+	}
+	if err != nil {
+		s.logger.Warn("Failed to save search checkpoint: %v", err)
+	}
+}
+
+// Filtered returns how many results the search include and exclude rules dropped
+func (s *Searcher) Filtered() int {
+	return s.filtered
+}
+
+// Anonymized returns how many out-of-network "LinkedIn Member" results the
+// searches skipped
+func (s *Searcher) Anonymized() int {
+	return s.anonymized
+}
+
+// Dropped returns how many result cards the searches could not read, by
+// reason
+func (s *Searcher) Dropped() map[string]int {
+	return s.dropped
+}
+
 func (s *Searcher) buildSearchURL(query, location, company string) string {
 	baseURL := s.cfg.LinkedIn.SearchURL
 	params := url.Values{}
@@ -126,17 +310,114 @@ func (s *Searcher) buildSearchURL(query, location, company string) string {
 	return baseURL + "?" + params.Encode()
 }
 
-func (s *Searcher) extractProfiles() ([]Profile, error) {
-	// Wait for search results to load
-	stealth.RandomDelay(1000, 2000)
+func (s *Searcher) extractProfiles(ctx context.Context) ([]Profile, error) {
+	// Wait for search results to load; when ctx ends early what has loaded
+	// is still read, and the search stops after this page
+	stealth.RandomDelayContext(ctx, 1000, 2000)
+
+	extracted := timing.Start(timing.Extraction)
+	profiles, drops, err := ParseResults(s.page)
+	extracted()
+	if err != nil {
+		return nil, err
+	}
+
+	anonymized, unread := 0, make(map[string]int)
+	for _, d := range drops {
+		if d.Reason == DropAnonymized {
+			anonymized++
+			continue
+		}
+		unread[d.Reason]++
+		s.dump(d)
+	}
+	s.anonymized += anonymized
+	for reason, n := range unread {
+		s.dropped[reason] += n
+	}
+	if n := len(drops) - anonymized; n > 0 {
+		s.logger.Warn("Could not read %d of %d result cards (%s); the results page may have changed",
+			n, len(profiles)+len(drops), formatDrops(unread))
+	}
+
+	s.logger.Debug("Extracted %d profiles from page (%d anonymized skipped)", len(profiles), anonymized)
+	return profiles, nil
+}
+
+// dump saves a card that could not be read under search.dump_dropped, in
+// the fixtures layout, so it can be replayed once the extractor is fixed
+func (s *Searcher) dump(d Drop) {
+	dir := s.cfg.Search.DumpDropped
+	if dir == "" {
+		return
+	}
+	html, err := d.Card.HTML()
+	if err != nil {
+		s.logger.Debug("Failed to read dropped card: %v", err)
+		return
+	}
+	dir = filepath.Join(dir, "search_results")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		s.logger.Warn("Failed to create %s: %v", dir, err)
+		return
+	}
+	s.dumped++
+	path := filepath.Join(dir, fmt.Sprintf("dropped-%s-%s-%d.html", s.run.Now().Format("20060102-150405"), d.Reason, s.dumped))
+	if err := os.WriteFile(path, []byte(html), 0644); err != nil {
+		s.logger.Warn("Failed to save dropped card: %v", err)
+		return
+	}
+	s.logger.Debug("Saved the dropped card to %s", path)
+}
+
+// formatDrops renders drop counts as "no_name 2, no_link 1"
+func formatDrops(counts map[string]int) string {
+	parts := make([]string, 0, len(counts))
+	for _, reason := range dropReasons {
+		if n := counts[reason]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", reason, n))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// anonymousNames are the names LinkedIn shows, per UI language, for members
+// outside the searcher's network
+var anonymousNames = []string{
+	"linkedin member", "membre de linkedin", "linkedin mitglied", "miembro de linkedin",
+	"membro di linkedin", "linkedin-lid", "membro do linkedin",
+}
 
+// Reasons a result card is left out
+const (
+	// DropAnonymized is an out-of-network "LinkedIn Member", which has no
+	// name to personalize with and no profile to visit
+	DropAnonymized = "anonymized"
+	// The others are cards that could not be read
+	DropNoLink     = "no_link"
+	DropNoName     = "no_name"
+	DropNotProfile = "not_profile"
+)
+
+var dropReasons = []string{DropAnonymized, DropNoLink, DropNoName, DropNotProfile}
+
+// Drop is a result card ParseResults left out, and why
+type Drop struct {
+	Reason string
+	Card   *rod.Element
+}
+
+// ParseResults reads the profile cards of the search results page currently
+// loaded, and returns the cards it left out with the reason for each
+func ParseResults(page *rod.Page) ([]Profile, []Drop, error) {
 	// Find all profile cards
-	elements, err := s.page.Elements(".reusable-search__result-container")
+	elements, err := page.Elements(".reusable-search__result-container")
 	if err != nil {
-		return nil, fmt.Errorf("failed to find profile elements: %w", err)
+		return nil, nil, fmt.Errorf("%w: search result cards: %v", apperr.ErrSelectorMissing, err)
 	}
 
 	var profiles []Profile
+	var drops []Drop
 
 	for _, el := range elements {
 		profile := Profile{}
@@ -144,11 +425,17 @@ func (s *Searcher) extractProfiles() ([]Profile, error) {
 		// Extract profile URL
 		linkEl, err := el.Element("a.app-aware-link")
 		if err != nil {
+			reason := DropNoLink
+			if anonymousCard(el) {
+				reason = DropAnonymized
+			}
+			drops = append(drops, Drop{Reason: reason, Card: el})
 			continue
 		}
 
 		href, err := linkEl.Property("href")
-		if err != nil {
+		if err != nil || href.String() == "" {
+			drops = append(drops, Drop{Reason: DropNoLink, Card: el})
 			continue
 		}
 		profile.URL = href.String()
@@ -160,6 +447,19 @@ func (s *Searcher) extractProfiles() ([]Profile, error) {
 			profile.Name = strings.TrimSpace(name)
 		}
 
+		// Anonymized members link to a headless search rather than a profile
+		switch {
+		case isAnonymous(profile.Name):
+			drops = append(drops, Drop{Reason: DropAnonymized, Card: el})
+			continue
+		case !strings.Contains(profile.URL, "/in/"):
+			drops = append(drops, Drop{Reason: DropNotProfile, Card: el})
+			continue
+		case profile.Name == "":
+			drops = append(drops, Drop{Reason: DropNoName, Card: el})
+			continue
+		}
+
 		// Extract title
 		titleEl, err := el.Element(".entity-result__primary-subtitle")
 		if err == nil {
@@ -174,48 +474,140 @@ func (s *Searcher) extractProfiles() ([]Profile, error) {
 			profile.Location = strings.TrimSpace(location)
 		}
 
-		if profile.URL != "" {
-			profiles = append(profiles, profile)
+		// Extract signals
+		if has, insight, _ := el.Has(".entity-result__insights, .reusable-search-simple-insight__text"); has {
+			text, _ := insight.Text()
+			profile.Mutuals = parseMutuals(text)
 		}
+		profile.OpenToWork, _, _ = el.Has("img[alt*='open to work' i], img[alt*='OPEN_TO_WORK']")
+
+		profiles = append(profiles, profile)
 	}
 
-	s.logger.Debug("Extracted %d profiles from page", len(profiles))
-	return profiles, nil
+	return profiles, drops, nil
 }
 
-func (s *Searcher) hasNextPage() bool {
-	nextButton, err := s.page.Element("button[aria-label='Next']")
+// anonymousCard reports whether a card without a profile link is an
+// anonymized member
+func anonymousCard(el *rod.Element) bool {
+	nameEl, err := el.Element(".entity-result__title-text span[aria-hidden='true']")
 	if err != nil {
 		return false
 	}
+	name, _ := nameEl.Text()
+	return isAnonymous(name)
+}
+
+func isAnonymous(name string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, n := range anonymousNames {
+		if name == n {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	otherMutuals = regexp.MustCompile(`(\d+)\s+other\s+mutual`)
+	countMutuals = regexp.MustCompile(`(\d+)\s+mutual`)
+)
 
-	disabled, _ := nextButton.Property("disabled")
-	return disabled.Nil()
+// parseMutuals reads insights like "Jane Doe and 12 other mutual connections",
+// "Jane Doe and John Roe are mutual connections" or "8 mutual connections"
+func parseMutuals(text string) int {
+	if !strings.Contains(strings.ToLower(text), "mutual") {
+		return 0
+	}
+	if m := otherMutuals.FindStringSubmatch(text); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		// Plus the names listed before "and"
+		named := strings.Split(text, " and ")[0]
+		return n + strings.Count(named, ",") + 1
+	}
+	if m := countMutuals.FindStringSubmatch(text); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return n
+	}
+	if strings.Contains(text, " and ") {
+		return 2
+	}
+	return 1
+}
+
+// overlap is the share of want's words that appear in text
+func overlap(want, text string) float64 {
+	words := strings.Fields(strings.ToLower(want))
+	if len(words) == 0 {
+		return 0
+	}
+	text = strings.ToLower(text)
+	found := 0
+	for _, w := range words {
+		if strings.Contains(text, strings.Trim(w, `",()`)) {
+			found++
+		}
+	}
+	return float64(found) / float64(len(words))
+}
+
+func (s *Searcher) hasNextPage() bool {
+	_, ok := s.nextPageButton()
+	return ok
 }
 
-func (s *Searcher) goToNextPage() error {
+// nextPageButton returns the enabled next page button. The last page has
+// none, or a disabled one, so the page is probed without waiting and without
+// reporting a selector fallback
+func (s *Searcher) nextPageButton() (*rod.Element, bool) {
+	var el *rod.Element
+	for _, sel := range dom.NextPageButton.Selectors {
+		if has, found, _ := s.page.Has(sel); has {
+			el = found
+			break
+		}
+	}
+	if el == nil {
+		found, _, err := dom.FindByText(s.page, dom.NextPageButton)
+		if err != nil {
+			return nil, false
+		}
+		el = found
+	}
+
+	if disabled, err := el.Property("disabled"); err == nil && disabled.Bool() {
+		return nil, false
+	}
+	return el, true
+}
+
+func (s *Searcher) goToNextPage(ctx context.Context) error {
 	s.logger.Debug("Going to next page")
 
-	nextButton, err := s.page.Element("button[aria-label='Next']")
-	if err != nil {
-		return err
+	nextButton, ok := s.nextPageButton()
+	if !ok {
+		return apperr.Selector(dom.NextPageButton.Name)
 	}
 
 	// Scroll to button
 	stealth.ScrollToElement(s.page, nextButton)
-	stealth.RandomDelay(500, 1000)
+	if err := stealth.RandomDelayContext(ctx, 500, 1000); err != nil {
+		return err
+	}
 
 	// Click next
-	if err := stealth.HumanClick(s.page, nextButton); err != nil {
+	if err := stealth.HumanClick(s.page.Context(ctx), nextButton); err != nil {
 		return err
 	}
 
 	// Wait for page to load
-	stealth.RandomDelay(2000, 4000)
+	if err := stealth.RandomDelayContext(ctx, 2000, 4000); err != nil {
+		return err
+	}
 
 	// Random scrolling on new page
 	if s.cfg.Stealth.EnableRandomScrolling {
-		stealth.RandomScroll(s.page)
+		stealth.RandomScroll(s.page.Context(ctx))
 	}
 
 	return nil
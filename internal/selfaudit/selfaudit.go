@@ -0,0 +1,197 @@
+package selfaudit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+
+	"linkedin-automation/config"
+	"linkedin-automation/internal/apperr"
+	"linkedin-automation/internal/logger"
+	"linkedin-automation/internal/stealth"
+	"linkedin-automation/internal/storage"
+)
+
+// Snapshot is what the account's own profile looked like at a point in time
+type Snapshot struct {
+	TakenAt     time.Time `json:"taken_at"`
+	URL         string    `json:"url"`
+	Name        string    `json:"name"`
+	Headline    string    `json:"headline"`
+	Location    string    `json:"location"`
+	Connections string    `json:"connections"`
+	Sections    []string  `json:"sections"`
+	Restricted  bool      `json:"restricted"`
+}
+
+// Change is a difference between two snapshots; alerts point at account problems
+type Change struct {
+	Field string
+	From  string
+	To    string
+	Alert bool
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %q → %q", c.Field, c.From, c.To)
+}
+
+// scrapeJS reads the profile in one pass; section anchors are ids like "about" or "experience"
+const scrapeJS = `() => {
+	const text = (sel) => (document.querySelector(sel)?.innerText || '').trim();
+	const main = document.querySelector('main')?.innerText || '';
+	const conns = main.match(/([\d,.+]+)\s+connections/i);
+	return {
+		url: location.href,
+		name: text('main h1'),
+		headline: text('main .text-body-medium.break-words'),
+		location: text('main .text-body-small.inline.t-black--light.break-words'),
+		connections: conns ? conns[1] : '',
+		sections: Array.from(document.querySelectorAll('main section > div[id]'))
+			.map(d => d.id).filter(id => /^[a-z_]+$/.test(id)),
+		restricted: /account (has been|is|was) (temporarily )?restricted|restricted from (sending|connecting|messaging)/i
+			.test(document.body.innerText),
+	};
+}`
+
+// Ensure takes a snapshot when the last one is older than the configured
+// interval (or force is set) and returns what changed since then
+func Ensure(page *rod.Page, cfg *config.Config, log *logger.Logger, store *storage.Store, force bool) ([]Change, error) {
+	prevData, takenAt, err := store.GetLatestProfileSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	interval := time.Duration(cfg.SelfAudit.IntervalHours) * time.Hour
+	if !force && prevData != "" && time.Since(takenAt) < interval {
+		return nil, nil
+	}
+
+	cur, err := Take(page, cfg, log)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(cur)
+	if err != nil {
+		return nil, err
+	}
+	if err := store.SaveProfileSnapshot(string(data)); err != nil {
+		return nil, err
+	}
+
+	if prevData == "" {
+		log.Info("Self-audit: first profile snapshot stored")
+		return nil, nil
+	}
+
+	var prev Snapshot
+	if err := json.Unmarshal([]byte(prevData), &prev); err != nil {
+		return nil, fmt.Errorf("failed to read previous snapshot: %w", err)
+	}
+
+	changes := Diff(prev, cur)
+	for _, c := range changes {
+		if c.Alert {
+			log.Warn("Self-audit alert: %s", c)
+			store.RecordIncident(storage.IncidentSelfAudit, c.String())
+		} else {
+			log.Info("Self-audit: %s", c)
+		}
+	}
+	if len(changes) == 0 {
+		log.Info("Self-audit: profile unchanged since %s", prev.TakenAt.Local().Format("2006-01-02 15:04"))
+	}
+	return changes, nil
+}
+
+// Take scrapes the logged-in account's own profile
+func Take(page *rod.Page, cfg *config.Config, log *logger.Logger) (Snapshot, error) {
+	log.Info("Self-audit: snapshotting own profile")
+	snap := Snapshot{TakenAt: time.Now()}
+
+	url := strings.TrimSuffix(cfg.LinkedIn.BaseURL, "/") + "/in/me/"
+	if err := page.Navigate(url); err != nil {
+		return snap, apperr.Navigation(url, err)
+	}
+	if err := page.WaitLoad(); err != nil {
+		return snap, apperr.Navigation(url, err)
+	}
+	stealth.RandomDelay(2000, 4000)
+
+	// Lazy sections only render once scrolled into view
+	stealth.PageThroughContent(page, 3)
+
+	snap, err := Scrape(page)
+	if err != nil {
+		return snap, err
+	}
+	snap.TakenAt = time.Now()
+	return snap, nil
+}
+
+// Scrape reads the profile page currently loaded; TakenAt is left unset
+func Scrape(page *rod.Page) (Snapshot, error) {
+	var snap Snapshot
+	res, err := page.Eval(scrapeJS)
+	if err != nil {
+		return snap, fmt.Errorf("failed to read own profile: %w", err)
+	}
+	if err := res.Value.Unmarshal(&snap); err != nil {
+		return snap, fmt.Errorf("failed to read own profile: %w", err)
+	}
+
+	if snap.Name == "" {
+		return snap, apperr.Selector("own profile name")
+	}
+	return snap, nil
+}
+
+// Diff lists what changed between two snapshots
+func Diff(prev, cur Snapshot) []Change {
+	var changes []Change
+
+	if cur.Restricted && !prev.Restricted {
+		changes = append(changes, Change{Field: "restriction", From: "none", To: "restricted notice shown", Alert: true})
+	}
+
+	if prev.Name != cur.Name {
+		changes = append(changes, Change{Field: "name", From: prev.Name, To: cur.Name, Alert: true})
+	}
+	if prev.Headline != cur.Headline {
+		changes = append(changes, Change{Field: "headline", From: prev.Headline, To: cur.Headline})
+	}
+	if prev.Location != cur.Location {
+		changes = append(changes, Change{Field: "location", From: prev.Location, To: cur.Location})
+	}
+
+	// Fewer connections can mean removals or reports
+	if before, after := parseCount(prev.Connections), parseCount(cur.Connections); before > 0 && after > 0 && after < before {
+		changes = append(changes, Change{Field: "connections", From: prev.Connections, To: cur.Connections, Alert: true})
+	}
+
+	present := make(map[string]bool)
+	for _, s := range cur.Sections {
+		present[s] = true
+	}
+	for _, s := range prev.Sections {
+		if !present[s] {
+			changes = append(changes, Change{Field: "section " + s, From: "present", To: "missing", Alert: true})
+		}
+	}
+
+	return changes
+}
+
+// parseCount reads counts like "1,234"; "500+" is not exact and reads as 0
+func parseCount(s string) int {
+	if strings.HasSuffix(s, "+") {
+		return 0
+	}
+	n, _ := strconv.Atoi(strings.NewReplacer(",", "", ".", "").Replace(s))
+	return n
+}
@@ -0,0 +1,293 @@
+// Package simulate plans what the configured schedule would do over a
+// virtual stretch of days: the activity scheduler and rate limiter run on a
+// fake clock, sessions start when their schedule is due and drain queues
+// that never run dry, and every action, break and wait is recorded instead
+// of done. Nothing touches the browser or the database, so the timeline can
+// be checked for a believable rhythm before the schedule goes live
+package simulate
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"linkedin-automation/config"
+	"linkedin-automation/internal/cron"
+	"linkedin-automation/internal/stealth"
+)
+
+// Session is a run of the tool: a daemon job, or the daily run otherwise
+type Session struct {
+	Name     string
+	Schedule *cron.Schedule
+	// Actions are drained in order, each until a limit stops it
+	Actions    []Action
+	MaxRuntime time.Duration
+}
+
+// Action is a queue a session drains and the caps the tool keeps on it on
+// top of the rate limiter's (0 = none)
+type Action struct {
+	Name    string
+	Type    stealth.ActionType
+	PerDay  int
+	PerWeek int
+}
+
+// Kinds of event
+const (
+	EventStart  = "start"
+	EventAction = "action"
+	EventBreak  = "break"
+	EventWait   = "wait"
+	EventStop   = "stop"
+)
+
+// Event is one step of the plan
+type Event struct {
+	At       time.Time
+	Kind     string
+	Session  string
+	Action   string
+	Duration time.Duration
+	Detail   string
+}
+
+// Day totals the plan for one calendar day
+type Day struct {
+	Date        time.Time
+	Actions     map[string]int
+	First, Last time.Time
+	Breaks      int
+	BreakTime   time.Duration
+}
+
+// Plan is the timeline of a simulation and its daily totals
+type Plan struct {
+	Events []Event
+	Days   []*Day
+}
+
+// sim holds the state of a simulation as the fake clock advances
+type sim struct {
+	cfg       *config.Config
+	clock     time.Time
+	scheduler *stealth.ActivityScheduler
+	limiter   *stealth.RateLimiter
+	rand      *rand.Rand
+	plan      *Plan
+	days      map[string]*Day
+	// done is when each action was taken, for the daily and weekly caps
+	done       map[string][]time.Time
+	lastAction time.Time
+}
+
+// Run plans sessions from start for days days. limiter carries the
+// configured rate limits and should have no history; it is left on the fake
+// clock. seed makes the plan repeatable
+func Run(cfg *config.Config, limiter *stealth.RateLimiter, sessions []Session, start time.Time, days int, seed int64) (*Plan, error) {
+	if len(sessions) == 0 {
+		return nil, fmt.Errorf("no sessions to simulate")
+	}
+	scheduler, err := stealth.NewActivityScheduler(cfg.Stealth.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &sim{
+		cfg:       cfg,
+		clock:     start,
+		scheduler: scheduler,
+		limiter:   limiter,
+		rand:      rand.New(rand.NewSource(seed)),
+		plan:      &Plan{},
+		days:      make(map[string]*Day),
+		done:      make(map[string][]time.Time),
+	}
+	now := func() time.Time { return s.clock }
+	scheduler.SetClock(now)
+	scheduler.SetSeed(seed)
+	scheduler.SetThinkTimeFactor(cfg.Stealth.ThinkTimeFactor)
+	limiter.SetClock(now)
+
+	// Days without activity are listed too
+	for i := 0; i < days; i++ {
+		s.day(start.AddDate(0, 0, i))
+	}
+
+	end := start.AddDate(0, 0, days)
+	for {
+		var next *Session
+		var at time.Time
+		for i := range sessions {
+			t := sessions[i].Schedule.Next(s.clock)
+			if !t.IsZero() && (at.IsZero() || t.Before(at)) {
+				next, at = &sessions[i], t
+			}
+		}
+		if next == nil || !at.Before(end) {
+			return s.plan, nil
+		}
+		s.clock = at
+		s.session(next)
+	}
+}
+
+// session runs one session from the current time, as the queue worker would
+func (s *sim) session(sess *Session) {
+	if s.cfg.Stealth.BusinessHoursOnly && !s.businessHours() {
+		// Occurrences due outside business hours wait for them, as jobs do
+		start := s.nextBusinessHours()
+		s.event(Event{Kind: EventWait, Session: sess.Name, Duration: start.Sub(s.clock), Detail: "outside business hours"})
+		s.clock = start
+	}
+	s.event(Event{Kind: EventStart, Session: sess.Name})
+
+	var deadline time.Time
+	if sess.MaxRuntime > 0 {
+		deadline = s.clock.Add(sess.MaxRuntime)
+	}
+	// sleep advances the clock by d, cut short by the deadline
+	sleep := func(d time.Duration) {
+		if !deadline.IsZero() && s.clock.Add(d).After(deadline) {
+			d = deadline.Sub(s.clock)
+		}
+		if d > 0 {
+			s.clock = s.clock.Add(d)
+		}
+	}
+
+	for _, a := range sess.Actions {
+		for {
+			if !deadline.IsZero() && !s.clock.Before(deadline) {
+				s.event(Event{Kind: EventStop, Session: sess.Name, Action: a.Name, Detail: "maximum runtime reached"})
+				return
+			}
+			if reason := s.capped(a); reason != "" {
+				s.event(Event{Kind: EventStop, Session: sess.Name, Action: a.Name, Detail: reason})
+				break
+			}
+
+			if s.scheduler.IsBreakTime() || s.scheduler.ShouldTakeBreak() {
+				d := s.scheduler.GetBreakDuration()
+				s.event(Event{Kind: EventBreak, Session: sess.Name, Action: a.Name, Duration: d})
+				sleep(d)
+				continue
+			}
+
+			if allowed, reason := s.limiter.CanPerformAction(a.Type); !allowed {
+				wait := s.limiter.GetWaitTime(a.Type)
+				if wait <= 0 {
+					s.event(Event{Kind: EventStop, Session: sess.Name, Action: a.Name, Detail: reason})
+					break
+				}
+				s.event(Event{Kind: EventWait, Session: sess.Name, Action: a.Name, Duration: wait, Detail: reason})
+				sleep(wait)
+				continue
+			}
+
+			if s.cfg.Throttle.Enabled && !s.lastAction.IsZero() {
+				gap := time.Duration(s.cfg.Throttle.MinGapSeconds)*time.Second - s.clock.Sub(s.lastAction)
+				if gap > 0 {
+					s.event(Event{Kind: EventWait, Session: sess.Name, Action: a.Name, Duration: gap, Detail: "throttle gap"})
+					sleep(gap)
+					continue
+				}
+			}
+
+			d := s.actionTime()
+			s.event(Event{Kind: EventAction, Session: sess.Name, Action: a.Name, Duration: d})
+			s.clock = s.clock.Add(d)
+			s.limiter.RecordAction(a.Type)
+			s.scheduler.RecordActivity()
+			s.done[a.Name] = append(s.done[a.Name], s.clock)
+			s.lastAction = s.clock
+
+			sleep(s.scheduler.GetThinkTime())
+		}
+	}
+}
+
+// capped returns why the tool's own daily or weekly cap stops a, or ""
+func (s *sim) capped(a Action) string {
+	today := s.clock.Format("2006-01-02")
+	weekAgo := s.clock.AddDate(0, 0, -7)
+	day, week := 0, 0
+	for _, t := range s.done[a.Name] {
+		if t.Format("2006-01-02") == today {
+			day++
+		}
+		if t.After(weekAgo) {
+			week++
+		}
+	}
+	switch {
+	case a.PerDay > 0 && day >= a.PerDay:
+		return fmt.Sprintf("daily limit reached (%d/%d)", day, a.PerDay)
+	case a.PerWeek > 0 && week >= a.PerWeek:
+		return fmt.Sprintf("weekly limit reached (%d/%d)", week, a.PerWeek)
+	}
+	return ""
+}
+
+// actionTime is how long one action takes in the browser: a page load and
+// the configured action delay between each of its steps (open the profile,
+// click, send)
+func (s *sim) actionTime() time.Duration {
+	d := s.scheduler.GetPageLoadWait()
+	lo, hi := s.cfg.Delays.MinActionDelayMs, s.cfg.Delays.MaxActionDelayMs
+	for i := 0; i < 3; i++ {
+		ms := lo
+		if hi > lo {
+			ms += s.rand.Intn(hi - lo)
+		}
+		d += time.Duration(ms) * time.Millisecond
+	}
+	return d
+}
+
+func (s *sim) businessHours() bool {
+	wd, h := s.clock.Weekday(), s.clock.Hour()
+	return wd != time.Saturday && wd != time.Sunday && h >= s.cfg.Stealth.WorkStartHour && h < s.cfg.Stealth.WorkEndHour
+}
+
+// nextBusinessHours is when business hours next begin
+func (s *sim) nextBusinessHours() time.Time {
+	t := time.Date(s.clock.Year(), s.clock.Month(), s.clock.Day(), s.cfg.Stealth.WorkStartHour, 0, 0, 0, s.clock.Location())
+	for !t.After(s.clock) || t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t
+}
+
+// event records e at the current time and adds it to its day's totals
+func (s *sim) event(e Event) {
+	e.At = s.clock
+	s.plan.Events = append(s.plan.Events, e)
+
+	day := s.day(e.At)
+	switch e.Kind {
+	case EventAction:
+		day.Actions[e.Action]++
+		if day.First.IsZero() {
+			day.First = e.At
+		}
+		day.Last = e.At
+	case EventBreak:
+		day.Breaks++
+		day.BreakTime += e.Duration
+	}
+}
+
+// day returns the totals of t's calendar day, adding them to the plan first
+func (s *sim) day(t time.Time) *Day {
+	key := t.Format("2006-01-02")
+	if day, ok := s.days[key]; ok {
+		return day
+	}
+	y, m, d := t.Date()
+	day := &Day{Date: time.Date(y, m, d, 0, 0, 0, 0, t.Location()), Actions: make(map[string]int)}
+	s.days[key] = day
+	s.plan.Days = append(s.plan.Days, day)
+	return day
+}
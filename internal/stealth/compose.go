@@ -0,0 +1,129 @@
+package stealth
+
+import (
+	"math/rand"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/input"
+
+	"linkedin-automation/internal/timing"
+)
+
+// revisionPattern marks a spot to draft and revise in a template:
+// "{{first wording|final wording}}" types the first, deletes it, then types the final
+var revisionPattern = regexp.MustCompile(`\{\{([^{}|]*)\|([^{}]*)\}\}`)
+
+// FinalText resolves revision markers to the wording that is actually sent
+func FinalText(text string) string {
+	return revisionPattern.ReplaceAllString(text, "$2")
+}
+
+type composePart struct {
+	text  string
+	draft string
+}
+
+// ComposeWithRevisions types text the way people write important messages:
+// a sentence goes down, the writer pauses, deletes a few words and retypes
+// them. Revision markers choose the draft wording; without markers one
+// sentence is picked and its ending retyped as written.
+func (p TypingProfile) ComposeWithRevisions(page *rod.Page, el *rod.Element, text string) error {
+	defer timing.Start(timing.Typing)()
+	parts := splitRevisions(text)
+	if len(parts) == 1 {
+		parts = pickRevision(parts[0].text)
+	}
+
+	if err := el.Focus(); err != nil {
+		return err
+	}
+	Wait(page.GetContext(), time.Duration(100+rand.Intn(200))*time.Millisecond)
+
+	for _, part := range parts {
+		if part.draft == "" {
+			if err := p.typeKeys(page, part.text); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Draft, reread, delete
+		if err := p.typeKeys(page, part.draft); err != nil {
+			return err
+		}
+		if err := Wait(page.GetContext(), time.Duration(800+rand.Intn(2200))*time.Millisecond); err != nil {
+			return err
+		}
+		for range []rune(part.draft) {
+			page.Keyboard.Press(input.Backspace)
+			Wait(page.GetContext(), time.Duration(40+rand.Intn(80))*time.Millisecond)
+		}
+		Wait(page.GetContext(), time.Duration(300+rand.Intn(900))*time.Millisecond)
+
+		// The final wording comes out with the odd correction
+		if err := TypeWithBackspace(page, el, part.text); err != nil {
+			return err
+		}
+	}
+
+	Wait(page.GetContext(), time.Duration(200+rand.Intn(300))*time.Millisecond)
+	return nil
+}
+
+func splitRevisions(text string) []composePart {
+	var parts []composePart
+	last := 0
+	for _, m := range revisionPattern.FindAllStringSubmatchIndex(text, -1) {
+		if m[0] > last {
+			parts = append(parts, composePart{text: text[last:m[0]]})
+		}
+		parts = append(parts, composePart{draft: text[m[2]:m[3]], text: text[m[4]:m[5]]})
+		last = m[1]
+	}
+	if last < len(text) || len(parts) == 0 {
+		parts = append(parts, composePart{text: text[last:]})
+	}
+	return parts
+}
+
+// pickRevision chooses one sentence of at least four words and marks its last
+// one to three words for a delete-and-retype
+func pickRevision(text string) []composePart {
+	var candidates []int
+	for i, r := range text {
+		if (r == '.' || r == '!' || r == '?') && len(strings.Fields(sentenceBefore(text, i))) >= 4 {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		return []composePart{{text: text}}
+	}
+
+	end := candidates[rand.Intn(len(candidates))]
+	words := strings.Fields(sentenceBefore(text, end))
+	n := 1 + rand.Intn(3)
+	if n > len(words)-1 {
+		n = len(words) - 1
+	}
+
+	tail := " " + strings.Join(words[len(words)-n:], " ")
+	start := strings.LastIndex(text[:end], tail)
+	if start < 0 {
+		return []composePart{{text: text}}
+	}
+
+	return []composePart{
+		{text: text[:start]},
+		{draft: tail, text: tail},
+		{text: text[end:]},
+	}
+}
+
+// sentenceBefore returns the sentence that ends at index end
+func sentenceBefore(text string, end int) string {
+	start := strings.LastIndexAny(text[:end], ".!?\n") + 1
+	return text[start:end]
+}
@@ -8,6 +8,8 @@ import (
 
 	"github.com/go-rod/rod"
 	"github.com/go-rod/rod/lib/proto"
+
+	"linkedin-automation/internal/timing"
 )
 
 // Point represents a 2D coordinate
@@ -90,7 +92,7 @@ func HumanMouseMove(page *rod.Page, targetX, targetY float64) error {
 		if err != nil {
 			return err
 		}
-		time.Sleep(delay)
+		Wait(page.GetContext(), delay)
 
 		// Add occasional micro-corrections
 		if rand.Float64() < 0.1 {
@@ -106,7 +108,7 @@ func HumanMouseMove(page *rod.Page, targetX, targetY float64) error {
 			if err != nil {
 				return err
 			}
-			time.Sleep(time.Duration(5+rand.Intn(5)) * time.Millisecond)
+			Wait(page.GetContext(), time.Duration(5+rand.Intn(5))*time.Millisecond)
 		}
 	}
 
@@ -123,7 +125,7 @@ func HumanMouseMove(page *rod.Page, targetX, targetY float64) error {
 	if err != nil {
 		return err
 	}
-	time.Sleep(time.Duration(10+rand.Intn(10)) * time.Millisecond)
+	Wait(page.GetContext(), time.Duration(10+rand.Intn(10))*time.Millisecond)
 
 	err = proto.InputDispatchMouseEvent{
 		Type: proto.InputDispatchMouseEventTypeMouseMoved,
@@ -134,19 +136,20 @@ func HumanMouseMove(page *rod.Page, targetX, targetY float64) error {
 	if err != nil {
 		return err
 	}
-	time.Sleep(time.Duration(20+rand.Intn(20)) * time.Millisecond)
+	Wait(page.GetContext(), time.Duration(20+rand.Intn(20))*time.Millisecond)
 
 	return nil
 }
 
 // HumanClick performs a human-like click with natural timing
 func HumanClick(page *rod.Page, el *rod.Element) error {
+	defer timing.Start(timing.Mouse)()
 	// Scroll element into view first
 	el.MustScrollIntoView()
-	time.Sleep(time.Duration(100+rand.Intn(200)) * time.Millisecond)
+	Wait(page.GetContext(), time.Duration(100+rand.Intn(200))*time.Millisecond)
 
 	// Brief pause before clicking
-	time.Sleep(time.Duration(50+rand.Intn(100)) * time.Millisecond)
+	Wait(page.GetContext(), time.Duration(50+rand.Intn(100))*time.Millisecond)
 
 	// Click the element
 	return el.Click(proto.InputMouseButtonLeft, 1)
@@ -171,13 +174,14 @@ func RandomMouseWander(page *rod.Page) {
 			document.dispatchEvent(event);
 		}
 	`, x, y))
-	time.Sleep(time.Duration(100+rand.Intn(200)) * time.Millisecond)
+	Wait(page.GetContext(), time.Duration(100+rand.Intn(200))*time.Millisecond)
 }
 
 // HoverElement simulates hovering over an element
 func HoverElement(page *rod.Page, el *rod.Element) error {
+	defer timing.Start(timing.Mouse)()
 	// Scroll into view and hover
 	el.MustScrollIntoView()
-	time.Sleep(time.Duration(100+rand.Intn(200)) * time.Millisecond)
+	Wait(page.GetContext(), time.Duration(100+rand.Intn(200))*time.Millisecond)
 	return el.Hover()
 }
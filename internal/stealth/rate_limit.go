@@ -1,9 +1,12 @@
 package stealth
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
+
+	"linkedin-automation/internal/apperr"
 )
 
 // ActionType represents different types of actions
@@ -18,6 +21,8 @@ const (
 	ActionLike          ActionType = "like"
 	ActionComment       ActionType = "comment"
 	ActionPageView      ActionType = "page_view"
+	ActionEventInvite   ActionType = "event_invite"
+	ActionFollow        ActionType = "follow"
 )
 
 // RateLimiter manages action quotas and cooldowns
@@ -29,6 +34,13 @@ type RateLimiter struct {
 	hourlyResetTime    time.Time
 	cooldownUntil      time.Time
 	consecutiveActions int
+	// onAcquire persists actions taken through Acquire; see OnAcquire
+	onAcquire func(ActionType)
+	// onRecord and onCooldown persist the limiter's own state; see OnRecord
+	onRecord   func(ActionType, time.Time)
+	onCooldown func(time.Time)
+	// clock replaces the system clock; see SetClock
+	clock func() time.Time
 }
 
 // ActionLimit defines limits for a specific action
@@ -112,18 +124,76 @@ func NewRateLimiter() *RateLimiter {
 		CooldownDuration: 3 * time.Minute,
 	}
 
+	rl.limits[ActionEventInvite] = &ActionLimit{
+		HourlyMax:        20,
+		DailyMax:         60,
+		MinInterval:      30 * time.Second,
+		CooldownAfter:    10,
+		CooldownDuration: 5 * time.Minute,
+	}
+
+	// Follows and unfollows share one budget
+	rl.limits[ActionFollow] = &ActionLimit{
+		HourlyMax:        15,
+		DailyMax:         80,
+		MinInterval:      45 * time.Second,
+		CooldownAfter:    8,
+		CooldownDuration: 6 * time.Minute,
+	}
+
 	rl.resetTimers()
 	return rl
 }
 
+// SetClock makes the limiter read the time from now instead of the system
+// clock, e.g. to plan a virtual week
+func (rl *RateLimiter) SetClock(now func() time.Time) {
+	rl.clock = now
+}
+
+func (rl *RateLimiter) now() time.Time {
+	if rl.clock != nil {
+		return rl.clock()
+	}
+	return time.Now()
+}
+
+// SetLimit overrides actionType's limits, e.g. with configured ones; zero
+// fields keep the current value
+func (rl *RateLimiter) SetLimit(actionType ActionType, l ActionLimit) error {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	cur, exists := rl.limits[actionType]
+	if !exists {
+		return fmt.Errorf("no limit defined for action type: %s", actionType)
+	}
+	if l.HourlyMax > 0 {
+		cur.HourlyMax = l.HourlyMax
+	}
+	if l.DailyMax > 0 {
+		cur.DailyMax = l.DailyMax
+	}
+	if l.MinInterval > 0 {
+		cur.MinInterval = l.MinInterval
+	}
+	if l.CooldownAfter > 0 {
+		cur.CooldownAfter = l.CooldownAfter
+	}
+	if l.CooldownDuration > 0 {
+		cur.CooldownDuration = l.CooldownDuration
+	}
+	return nil
+}
+
 // CanPerformAction checks if an action is allowed
 func (rl *RateLimiter) CanPerformAction(actionType ActionType) (bool, string) {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
 	// Check if in cooldown period
-	if time.Now().Before(rl.cooldownUntil) {
-		remaining := time.Until(rl.cooldownUntil)
+	if rl.now().Before(rl.cooldownUntil) {
+		remaining := rl.cooldownUntil.Sub(rl.now())
 		return false, fmt.Sprintf("In cooldown period. Wait %v", remaining.Round(time.Second))
 	}
 
@@ -136,7 +206,7 @@ func (rl *RateLimiter) CanPerformAction(actionType ActionType) (bool, string) {
 	rl.cleanHistory(actionType)
 
 	history := rl.actionHistory[actionType]
-	now := time.Now()
+	now := rl.now()
 
 	// Check hourly limit
 	hourlyCount := rl.countActionsInWindow(history, time.Hour)
@@ -164,15 +234,33 @@ func (rl *RateLimiter) CanPerformAction(actionType ActionType) (bool, string) {
 
 // RecordAction records an action and updates counters
 func (rl *RateLimiter) RecordAction(actionType ActionType) error {
+	now, cooldown, err := rl.record(actionType)
+	if err != nil {
+		return err
+	}
+
+	// Persisted outside the lock so a slow write does not hold up readers
+	if rl.onRecord != nil {
+		rl.onRecord(actionType, now)
+	}
+	if !cooldown.IsZero() && rl.onCooldown != nil {
+		rl.onCooldown(cooldown)
+	}
+	return nil
+}
+
+// record adds the action to the history and returns when it was taken, and
+// when the cooldown it triggered ends if it triggered one
+func (rl *RateLimiter) record(actionType ActionType) (now, cooldown time.Time, err error) {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
 	limit, exists := rl.limits[actionType]
 	if !exists {
-		return fmt.Errorf("no limit defined for action type: %s", actionType)
+		return now, cooldown, fmt.Errorf("no limit defined for action type: %s", actionType)
 	}
 
-	now := time.Now()
+	now = rl.now()
 
 	// Add to history
 	if rl.actionHistory[actionType] == nil {
@@ -185,6 +273,7 @@ func (rl *RateLimiter) RecordAction(actionType ActionType) error {
 	if rl.consecutiveActions >= limit.CooldownAfter {
 		rl.cooldownUntil = now.Add(limit.CooldownDuration)
 		rl.consecutiveActions = 0
+		cooldown = rl.cooldownUntil
 	}
 
 	// Reset timers if needed
@@ -195,17 +284,115 @@ func (rl *RateLimiter) RecordAction(actionType ActionType) error {
 		rl.dailyResetTime = now.Add(24 * time.Hour)
 	}
 
+	return now, cooldown, nil
+}
+
+// SlowDown stretches every minimum interval and cooldown by factor, and keeps
+// only keep (0-1) of what is left of each hourly and daily budget
+func (rl *RateLimiter) SlowDown(factor, keep float64) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	cut := func(limit, used int) int {
+		if limit <= used {
+			return limit
+		}
+		return used + int(float64(limit-used)*keep)
+	}
+	for actionType, l := range rl.limits {
+		if factor > 1 {
+			l.MinInterval = time.Duration(float64(l.MinInterval) * factor)
+			l.CooldownDuration = time.Duration(float64(l.CooldownDuration) * factor)
+		}
+		history := rl.actionHistory[actionType]
+		l.HourlyMax = cut(l.HourlyMax, rl.countActionsInWindow(history, time.Hour))
+		l.DailyMax = cut(l.DailyMax, rl.countActionsInWindow(history, 24*time.Hour))
+	}
+}
+
+// Acquire waits out cooldowns and minimum intervals until actionType is
+// allowed, then records it. It fails with apperr.ErrRateLimited when the
+// hourly or daily budget is spent, since waiting would hold up the run; a
+// nil limiter allows everything
+func (rl *RateLimiter) Acquire(actionType ActionType) error {
+	return rl.AcquireContext(context.Background(), actionType)
+}
+
+// AcquireContext is Acquire that stops waiting when ctx is done
+func (rl *RateLimiter) AcquireContext(ctx context.Context, actionType ActionType) error {
+	if rl == nil {
+		return nil
+	}
+
+	for {
+		allowed, reason := rl.CanPerformAction(actionType)
+		if allowed {
+			break
+		}
+		wait := rl.GetWaitTime(actionType)
+		if wait <= 0 {
+			return fmt.Errorf("%w: %s: %s", apperr.ErrRateLimited, actionType, reason)
+		}
+		if err := Wait(ctx, wait); err != nil {
+			return err
+		}
+	}
+
+	if err := rl.RecordAction(actionType); err != nil {
+		return err
+	}
+	if rl.onAcquire != nil {
+		rl.onAcquire(actionType)
+	}
 	return nil
 }
 
+// OnAcquire registers fn to run after Acquire records an action, e.g. to
+// persist it so Restore can replay it after a restart
+func (rl *RateLimiter) OnAcquire(fn func(ActionType)) {
+	rl.onAcquire = fn
+}
+
+// OnRecord registers fn to run after every recorded action, and onCooldown
+// to run when one starts a cooldown, so the history and cooldown can be saved
+// and brought back with Restore and RestoreCooldown after a restart
+func (rl *RateLimiter) OnRecord(fn func(ActionType, time.Time), onCooldown func(time.Time)) {
+	rl.onRecord = fn
+	rl.onCooldown = onCooldown
+}
+
+// Restore adds an action taken before this process started to the history,
+// so restarts do not reset the hourly and daily budgets. Call it oldest first,
+// before any new action is recorded
+func (rl *RateLimiter) Restore(actionType ActionType, at time.Time) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if _, exists := rl.limits[actionType]; !exists || rl.now().Sub(at) > 24*time.Hour {
+		return
+	}
+	rl.actionHistory[actionType] = append(rl.actionHistory[actionType], at)
+}
+
+// RestoreCooldown resumes a cooldown an earlier process started, so a
+// restart does not cut it short
+func (rl *RateLimiter) RestoreCooldown(until time.Time) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if until.After(rl.cooldownUntil) {
+		rl.cooldownUntil = until
+	}
+}
+
 // GetWaitTime returns recommended wait time before next action
 func (rl *RateLimiter) GetWaitTime(actionType ActionType) time.Duration {
 	rl.mu.RLock()
 	defer rl.mu.RUnlock()
 
 	// If in cooldown, return remaining cooldown time
-	if time.Now().Before(rl.cooldownUntil) {
-		return time.Until(rl.cooldownUntil)
+	if rl.now().Before(rl.cooldownUntil) {
+		return rl.cooldownUntil.Sub(rl.now())
 	}
 
 	limit, exists := rl.limits[actionType]
@@ -219,7 +406,7 @@ func (rl *RateLimiter) GetWaitTime(actionType ActionType) time.Duration {
 	}
 
 	lastAction := history[len(history)-1]
-	elapsed := time.Since(lastAction)
+	elapsed := rl.now().Sub(lastAction)
 
 	if elapsed < limit.MinInterval {
 		return limit.MinInterval - elapsed
@@ -247,10 +434,10 @@ func (rl *RateLimiter) GetActionStats(actionType ActionType) map[string]interfac
 		"daily_count":      dailyCount,
 		"daily_limit":      limit.DailyMax,
 		"daily_remaining":  limit.DailyMax - dailyCount,
-		"in_cooldown":      time.Now().Before(rl.cooldownUntil),
+		"in_cooldown":      rl.now().Before(rl.cooldownUntil),
 		"cooldown_remaining": func() time.Duration {
-			if time.Now().Before(rl.cooldownUntil) {
-				return time.Until(rl.cooldownUntil)
+			if rl.now().Before(rl.cooldownUntil) {
+				return rl.cooldownUntil.Sub(rl.now())
 			}
 			return 0
 		}(),
@@ -287,7 +474,7 @@ func (rl *RateLimiter) ResetDaily() {
 		rl.cleanHistory(actionType)
 	}
 
-	rl.dailyResetTime = time.Now().Add(24 * time.Hour)
+	rl.dailyResetTime = rl.now().Add(24 * time.Hour)
 }
 
 // countActionsInWindow counts actions within a time window
@@ -296,7 +483,7 @@ func (rl *RateLimiter) countActionsInWindow(history []time.Time, window time.Dur
 		return 0
 	}
 
-	cutoff := time.Now().Add(-window)
+	cutoff := rl.now().Add(-window)
 	count := 0
 
 	for i := len(history) - 1; i >= 0; i-- {
@@ -318,7 +505,7 @@ func (rl *RateLimiter) cleanHistory(actionType ActionType) {
 	}
 
 	// Keep only last 24 hours of history
-	cutoff := time.Now().Add(-24 * time.Hour)
+	cutoff := rl.now().Add(-24 * time.Hour)
 	newHistory := []time.Time{}
 
 	for _, t := range history {
@@ -332,7 +519,7 @@ func (rl *RateLimiter) cleanHistory(actionType ActionType) {
 
 // resetTimers initializes reset timers
 func (rl *RateLimiter) resetTimers() {
-	now := time.Now()
+	now := rl.now()
 	rl.hourlyResetTime = now.Add(time.Hour)
 	rl.dailyResetTime = now.Add(24 * time.Hour)
 }
@@ -342,7 +529,7 @@ func (rl *RateLimiter) IsInCooldown() bool {
 	rl.mu.RLock()
 	defer rl.mu.RUnlock()
 
-	return time.Now().Before(rl.cooldownUntil)
+	return rl.now().Before(rl.cooldownUntil)
 }
 
 // GetCooldownRemaining returns remaining cooldown duration
@@ -350,8 +537,8 @@ func (rl *RateLimiter) GetCooldownRemaining() time.Duration {
 	rl.mu.RLock()
 	defer rl.mu.RUnlock()
 
-	if time.Now().Before(rl.cooldownUntil) {
-		return time.Until(rl.cooldownUntil)
+	if rl.now().Before(rl.cooldownUntil) {
+		return rl.cooldownUntil.Sub(rl.now())
 	}
 	return 0
 }
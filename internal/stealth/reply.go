@@ -0,0 +1,35 @@
+package stealth
+
+import (
+	"math/rand"
+	"time"
+)
+
+// MinReplyLatency is the least time between a prospect's reply and a message
+// answering it; people read, get interrupted and come back
+const MinReplyLatency = 2 * time.Minute
+
+// ReplyDue returns when a message answering a reply received at repliedAt
+// may be sent: usually one to six hours later, sometimes sooner or the next
+// day, and inside window when one is given. The same seed gives the same
+// time, so a deferred message keeps its slot when it is retried
+func ReplyDue(repliedAt time.Time, seed int64, window *SendWindow) time.Time {
+	r := rand.New(rand.NewSource(seed))
+
+	var latency time.Duration
+	switch p := r.Float64(); {
+	case p < 0.15:
+		latency = MinReplyLatency + time.Duration(r.Int63n(int64(time.Hour-MinReplyLatency)))
+	case p < 0.85:
+		latency = time.Hour + time.Duration(r.Int63n(int64(5*time.Hour)))
+	default:
+		latency = 6*time.Hour + time.Duration(r.Int63n(int64(14*time.Hour)))
+	}
+
+	due := repliedAt.Add(latency)
+	if window != nil && !window.Contains(due) {
+		// Not on the dot of the window opening
+		due = window.Next(due).Add(time.Duration(r.Int63n(int64(90 * time.Minute))))
+	}
+	return due
+}
@@ -13,7 +13,10 @@ type ActivityScheduler struct {
 	breakTimes       []TimeWindow
 	lastActivityTime time.Time
 	dailyActionCount int
+	thinkFactor      float64 // scales GetThinkTime
 	rand             *rand.Rand
+	// clock replaces the system clock; see SetClock
+	clock func() time.Time
 }
 
 // TimeWindow represents a time range
@@ -37,13 +40,40 @@ func NewActivityScheduler(timezone string) (*ActivityScheduler, error) {
 			// Lunch break: 12-1 PM with slight variation
 			{},
 		},
-		rand: rand.New(rand.NewSource(time.Now().UnixNano())),
+		thinkFactor: 1,
+		rand:        rand.New(rand.NewSource(time.Now().UnixNano())),
 	}, nil
 }
 
+// SetThinkTimeFactor stretches (>1) or shortens think time; non-positive
+// factors are ignored
+func (as *ActivityScheduler) SetThinkTimeFactor(f float64) {
+	if f > 0 {
+		as.thinkFactor = f
+	}
+}
+
+// SetClock makes the scheduler read the time from now instead of the system
+// clock, e.g. to plan a virtual week
+func (as *ActivityScheduler) SetClock(now func() time.Time) {
+	as.clock = now
+}
+
+// SetSeed makes the scheduler's random choices repeat for the same seed
+func (as *ActivityScheduler) SetSeed(seed int64) {
+	as.rand = rand.New(rand.NewSource(seed))
+}
+
+func (as *ActivityScheduler) now() time.Time {
+	if as.clock != nil {
+		return as.clock()
+	}
+	return time.Now()
+}
+
 // IsWorkingHours checks if current time is within working hours
 func (as *ActivityScheduler) IsWorkingHours() bool {
-	now := time.Now().In(as.timezone)
+	now := as.now().In(as.timezone)
 	hour := now.Hour()
 
 	// Weekend check
@@ -61,7 +91,7 @@ func (as *ActivityScheduler) IsWorkingHours() bool {
 
 // IsBreakTime checks if it's a typical break period
 func (as *ActivityScheduler) IsBreakTime() bool {
-	now := time.Now().In(as.timezone)
+	now := as.now().In(as.timezone)
 	hour := now.Hour()
 	minute := now.Minute()
 
@@ -85,7 +115,7 @@ func (as *ActivityScheduler) IsBreakTime() bool {
 
 // ShouldTakeBreak determines if a break should be taken based on activity
 func (as *ActivityScheduler) ShouldTakeBreak() bool {
-	now := time.Now()
+	now := as.now()
 
 	// If last activity was recent, occasionally take a break
 	if !as.lastActivityTime.IsZero() {
@@ -130,7 +160,7 @@ func (as *ActivityScheduler) GetThinkTime() time.Duration {
 		base += 3000 + as.rand.Intn(7000) // Additional 3-10 seconds
 	}
 
-	return time.Duration(base) * time.Millisecond
+	return time.Duration(float64(base)*as.thinkFactor) * time.Millisecond
 }
 
 // GetActionInterval returns time between major actions (profile views, messages)
@@ -139,7 +169,7 @@ func (as *ActivityScheduler) GetActionInterval() time.Duration {
 	baseSeconds := 15 + as.rand.Intn(76)
 
 	// Vary based on time of day
-	now := time.Now().In(as.timezone)
+	now := as.now().In(as.timezone)
 	hour := now.Hour()
 
 	// Slower during early morning and late evening
@@ -189,7 +219,7 @@ func (as *ActivityScheduler) GetPageLoadWait() time.Duration {
 
 // RecordActivity updates the last activity timestamp
 func (as *ActivityScheduler) RecordActivity() {
-	as.lastActivityTime = time.Now()
+	as.lastActivityTime = as.now()
 	as.dailyActionCount++
 }
 
@@ -205,7 +235,7 @@ func (as *ActivityScheduler) GetDailyActionCount() int {
 
 // SimulateHumanRhythm adds natural rhythm variations
 func (as *ActivityScheduler) SimulateHumanRhythm() time.Duration {
-	hour := time.Now().In(as.timezone).Hour()
+	hour := as.now().In(as.timezone).Hour()
 
 	// Morning: slower (just starting)
 	if hour >= 9 && hour < 11 {
@@ -39,12 +39,14 @@ func HumanScroll(page *rod.Page, direction string, distance int) error {
 
 		// Variable delay between scroll steps
 		delay := time.Duration(30+rand.Intn(50)) * time.Millisecond
-		time.Sleep(delay)
+		if err := Wait(page.GetContext(), delay); err != nil {
+			return err
+		}
 	}
 
 	// Occasional scroll back
 	if rand.Float64() < 0.15 {
-		time.Sleep(time.Duration(200+rand.Intn(300)) * time.Millisecond)
+		Wait(page.GetContext(), time.Duration(200+rand.Intn(300))*time.Millisecond)
 		smallScrollBack := float64(distance) * 0.1
 		if direction == "down" {
 			page.MustEval(fmt.Sprintf(`window.scrollBy(0, %f)`, -smallScrollBack))
@@ -92,12 +94,14 @@ func ScrollToBottom(page *rod.Page) error {
 		currentScroll += scrollAmount
 
 		// Random pause while "reading"
-		time.Sleep(time.Duration(500+rand.Intn(1500)) * time.Millisecond)
+		if err := Wait(page.GetContext(), time.Duration(500+rand.Intn(1500))*time.Millisecond); err != nil {
+			return err
+		}
 
 		// Occasionally scroll back up slightly
 		if rand.Float64() < 0.2 {
 			HumanScroll(page, "up", 50+rand.Intn(100))
-			time.Sleep(time.Duration(300+rand.Intn(500)) * time.Millisecond)
+			Wait(page.GetContext(), time.Duration(300+rand.Intn(500))*time.Millisecond)
 		}
 	}
 
@@ -114,12 +118,14 @@ func PageThroughContent(page *rod.Page, sections int) error {
 
 		// Pause to "read"
 		readTime := time.Duration(1000+rand.Intn(3000)) * time.Millisecond
-		time.Sleep(readTime)
+		if err := Wait(page.GetContext(), readTime); err != nil {
+			return err
+		}
 
 		// Occasionally scroll back to reread
 		if rand.Float64() < 0.25 {
 			HumanScroll(page, "up", 100+rand.Intn(200))
-			time.Sleep(time.Duration(500+rand.Intn(1000)) * time.Millisecond)
+			Wait(page.GetContext(), time.Duration(500+rand.Intn(1000))*time.Millisecond)
 			HumanScroll(page, "down", 100+rand.Intn(200))
 		}
 	}
@@ -0,0 +1,180 @@
+package stealth
+
+import "strings"
+
+// Regions whose name alone pins a timezone. Countries spanning several
+// zones are resolved through regionZones first and fall back to their
+// most populous zone here
+var countryZones = map[string]string{
+	"united states":        "America/New_York",
+	"usa":                  "America/New_York",
+	"canada":               "America/Toronto",
+	"mexico":               "America/Mexico_City",
+	"brazil":               "America/Sao_Paulo",
+	"argentina":            "America/Argentina/Buenos_Aires",
+	"chile":                "America/Santiago",
+	"colombia":             "America/Bogota",
+	"peru":                 "America/Lima",
+	"united kingdom":       "Europe/London",
+	"ireland":              "Europe/Dublin",
+	"portugal":             "Europe/Lisbon",
+	"spain":                "Europe/Madrid",
+	"france":               "Europe/Paris",
+	"belgium":              "Europe/Brussels",
+	"netherlands":          "Europe/Amsterdam",
+	"germany":              "Europe/Berlin",
+	"switzerland":          "Europe/Zurich",
+	"austria":              "Europe/Vienna",
+	"italy":                "Europe/Rome",
+	"denmark":              "Europe/Copenhagen",
+	"norway":               "Europe/Oslo",
+	"sweden":               "Europe/Stockholm",
+	"finland":              "Europe/Helsinki",
+	"poland":               "Europe/Warsaw",
+	"czechia":              "Europe/Prague",
+	"greece":               "Europe/Athens",
+	"romania":              "Europe/Bucharest",
+	"ukraine":              "Europe/Kyiv",
+	"turkey":               "Europe/Istanbul",
+	"türkiye":              "Europe/Istanbul",
+	"israel":               "Asia/Jerusalem",
+	"united arab emirates": "Asia/Dubai",
+	"saudi arabia":         "Asia/Riyadh",
+	"egypt":                "Africa/Cairo",
+	"nigeria":              "Africa/Lagos",
+	"kenya":                "Africa/Nairobi",
+	"south africa":         "Africa/Johannesburg",
+	"india":                "Asia/Kolkata",
+	"pakistan":             "Asia/Karachi",
+	"bangladesh":           "Asia/Dhaka",
+	"singapore":            "Asia/Singapore",
+	"malaysia":             "Asia/Kuala_Lumpur",
+	"indonesia":            "Asia/Jakarta",
+	"philippines":          "Asia/Manila",
+	"vietnam":              "Asia/Ho_Chi_Minh",
+	"thailand":             "Asia/Bangkok",
+	"china":                "Asia/Shanghai",
+	"hong kong":            "Asia/Hong_Kong",
+	"taiwan":               "Asia/Taipei",
+	"south korea":          "Asia/Seoul",
+	"japan":                "Asia/Tokyo",
+	"australia":            "Australia/Sydney",
+	"new zealand":          "Pacific/Auckland",
+}
+
+// States, provinces and metro areas of multi-zone countries, matched as
+// whole comma-separated parts of the location
+var regionZones = map[string]string{
+	// United States
+	"new york":             "America/New_York",
+	"massachusetts":        "America/New_York",
+	"boston":               "America/New_York",
+	"pennsylvania":         "America/New_York",
+	"new jersey":           "America/New_York",
+	"washington dc":        "America/New_York",
+	"district of columbia": "America/New_York",
+	"virginia":             "America/New_York",
+	"north carolina":       "America/New_York",
+	"georgia":              "America/New_York",
+	"atlanta":              "America/New_York",
+	"florida":              "America/New_York",
+	"miami":                "America/New_York",
+	"ohio":                 "America/New_York",
+	"michigan":             "America/Detroit",
+	"detroit":              "America/Detroit",
+	"illinois":             "America/Chicago",
+	"chicago":              "America/Chicago",
+	"texas":                "America/Chicago",
+	"dallas":               "America/Chicago",
+	"houston":              "America/Chicago",
+	"austin":               "America/Chicago",
+	"minnesota":            "America/Chicago",
+	"minneapolis":          "America/Chicago",
+	"missouri":             "America/Chicago",
+	"tennessee":            "America/Chicago",
+	"wisconsin":            "America/Chicago",
+	"colorado":             "America/Denver",
+	"denver":               "America/Denver",
+	"utah":                 "America/Denver",
+	"salt lake city":       "America/Denver",
+	"arizona":              "America/Phoenix",
+	"phoenix":              "America/Phoenix",
+	"california":           "America/Los_Angeles",
+	"san francisco":        "America/Los_Angeles",
+	"san francisco bay":    "America/Los_Angeles",
+	"los angeles":          "America/Los_Angeles",
+	"san diego":            "America/Los_Angeles",
+	"washington":           "America/Los_Angeles",
+	"seattle":              "America/Los_Angeles",
+	"oregon":               "America/Los_Angeles",
+	"portland":             "America/Los_Angeles",
+	"nevada":               "America/Los_Angeles",
+	"las vegas":            "America/Los_Angeles",
+	"alaska":               "America/Anchorage",
+	"hawaii":               "Pacific/Honolulu",
+	// Canada
+	"ontario":          "America/Toronto",
+	"toronto":          "America/Toronto",
+	"quebec":           "America/Toronto",
+	"montreal":         "America/Toronto",
+	"manitoba":         "America/Winnipeg",
+	"alberta":          "America/Edmonton",
+	"calgary":          "America/Edmonton",
+	"british columbia": "America/Vancouver",
+	"vancouver":        "America/Vancouver",
+	"nova scotia":      "America/Halifax",
+	// Australia
+	"new south wales":   "Australia/Sydney",
+	"sydney":            "Australia/Sydney",
+	"melbourne":         "Australia/Melbourne",
+	"queensland":        "Australia/Brisbane",
+	"brisbane":          "Australia/Brisbane",
+	"western australia": "Australia/Perth",
+	"perth":             "Australia/Perth",
+	"south australia":   "Australia/Adelaide",
+	"adelaide":          "Australia/Adelaide",
+	// Brazil, Mexico
+	"manaus":  "America/Manaus",
+	"tijuana": "America/Tijuana",
+	// Elsewhere, for locations that name only the city
+	"london":    "Europe/London",
+	"paris":     "Europe/Paris",
+	"berlin":    "Europe/Berlin",
+	"amsterdam": "Europe/Amsterdam",
+	"dubai":     "Asia/Dubai",
+	"bengaluru": "Asia/Kolkata",
+	"bangalore": "Asia/Kolkata",
+	"mumbai":    "Asia/Kolkata",
+	"tokyo":     "Asia/Tokyo",
+}
+
+// Suffixes LinkedIn appends to metro-area locations
+var regionSuffixes = []string{" metropolitan area", " metroplex", " area", " county"}
+
+// TimezoneFor guesses the IANA timezone of a LinkedIn profile location such
+// as "Austin, Texas, United States" or "Greater Chicago Area"; "" when the
+// location names no known region
+func TimezoneFor(location string) string {
+	parts := strings.Split(strings.ToLower(location), ",")
+	for i := range parts {
+		p := strings.TrimSpace(parts[i])
+		p = strings.TrimPrefix(p, "greater ")
+		for _, s := range regionSuffixes {
+			p = strings.TrimSuffix(p, s)
+		}
+		parts[i] = p
+	}
+
+	// The most specific part wins: city or state before country
+	for _, p := range parts {
+		if tz, ok := regionZones[p]; ok {
+			return tz
+		}
+	}
+	for i := len(parts) - 1; i >= 0; i-- {
+		if tz, ok := countryZones[parts[i]]; ok {
+			return tz
+		}
+	}
+	return ""
+}
@@ -1,18 +1,77 @@
 package stealth
 
 import (
+	"context"
+	"math"
 	"math/rand"
+	"sync/atomic"
 	"time"
+
+	"linkedin-automation/internal/timing"
 )
 
+// Wait pauses for d, or until ctx is done, in which case it returns ctx's
+// error so the caller can give up on what it was doing. The helpers that take
+// a page wait on its context, so a page from page.Context(ctx) stops them too
+func Wait(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// delayFactor holds the bits of the factor set with SetDelayFactor; zero
+// means 1
+var delayFactor atomic.Uint64
+
+// SetDelayFactor stretches every later RandomDelay, HumanDelay and
+// SimulateThinking by f, e.g. for the rest of a session LinkedIn throttled;
+// non-positive factors are ignored
+func SetDelayFactor(f float64) {
+	if f > 0 {
+		delayFactor.Store(math.Float64bits(f))
+	}
+}
+
+// DelayFactor returns the factor set with SetDelayFactor
+func DelayFactor() float64 {
+	if bits := delayFactor.Load(); bits != 0 {
+		return math.Float64frombits(bits)
+	}
+	return 1
+}
+
+func scaled(d time.Duration) time.Duration {
+	return time.Duration(float64(d) * DelayFactor())
+}
+
 // RandomDelay adds a random delay between min and max milliseconds
 func RandomDelay(minMs, maxMs int) {
+	RandomDelayContext(context.Background(), minMs, maxMs)
+}
+
+// RandomDelayContext is RandomDelay that ends early when ctx is done
+func RandomDelayContext(ctx context.Context, minMs, maxMs int) error {
+	defer timing.Start(timing.StealthDelay)()
 	delay := time.Duration(minMs+rand.Intn(maxMs-minMs)) * time.Millisecond
-	time.Sleep(delay)
+	return Wait(ctx, scaled(delay))
 }
 
 // HumanDelay simulates human-like delay with occasional longer pauses
 func HumanDelay(baseMinMs, baseMaxMs int) {
+	HumanDelayContext(context.Background(), baseMinMs, baseMaxMs)
+}
+
+// HumanDelayContext is HumanDelay that ends early when ctx is done
+func HumanDelayContext(ctx context.Context, baseMinMs, baseMaxMs int) error {
+	defer timing.Start(timing.StealthDelay)()
 	delay := baseMinMs + rand.Intn(baseMaxMs-baseMinMs)
 
 	// 10% chance of longer delay (distraction/thinking)
@@ -20,7 +79,7 @@ func HumanDelay(baseMinMs, baseMaxMs int) {
 		delay += 1000 + rand.Intn(2000)
 	}
 
-	time.Sleep(time.Duration(delay) * time.Millisecond)
+	return Wait(ctx, scaled(time.Duration(delay)*time.Millisecond))
 }
 
 // IsBusinessHours checks if current time is within business hours
@@ -37,8 +96,8 @@ func IsBusinessHours(startHour, endHour int) bool {
 	return hour >= startHour && hour < endHour
 }
 
-// WaitForBusinessHours blocks until business hours
-func WaitForBusinessHours(startHour, endHour int) {
+// WaitForBusinessHours blocks until business hours, or until ctx is done
+func WaitForBusinessHours(ctx context.Context, startHour, endHour int) error {
 	for !IsBusinessHours(startHour, endHour) {
 		now := time.Now()
 
@@ -57,26 +116,26 @@ func WaitForBusinessHours(startHour, endHour int) {
 			nextStart = nextStart.Add(24 * time.Hour)
 		}
 
-		waitDuration := time.Until(nextStart)
-		time.Sleep(waitDuration)
+		if err := Wait(ctx, time.Until(nextStart)); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 // RandomBreak simulates taking a random break
-func RandomBreak() {
+func RandomBreak(ctx context.Context) error {
 	// 5% chance of taking a break
 	if rand.Float64() < 0.05 {
 		breakDuration := time.Duration(2+rand.Intn(5)) * time.Minute
-		time.Sleep(breakDuration)
+		return Wait(ctx, breakDuration)
 	}
+	return nil
 }
 
 // ThrottleAction ensures minimum time between actions
-func ThrottleAction(lastActionTime time.Time, minInterval time.Duration) {
-	elapsed := time.Since(lastActionTime)
-	if elapsed < minInterval {
-		time.Sleep(minInterval - elapsed)
-	}
+func ThrottleAction(ctx context.Context, lastActionTime time.Time, minInterval time.Duration) error {
+	return Wait(ctx, minInterval-time.Since(lastActionTime))
 }
 
 // ExponentialBackoff implements retry delay with exponential increase
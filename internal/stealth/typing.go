@@ -1,11 +1,14 @@
 package stealth
 
 import (
+	"context"
 	"math/rand"
 	"time"
 
 	"github.com/go-rod/rod"
 	"github.com/go-rod/rod/lib/input"
+
+	"linkedin-automation/internal/timing"
 )
 
 // HumanType simulates human typing with realistic patterns
@@ -15,7 +18,7 @@ func HumanType(page *rod.Page, el *rod.Element, text string, minDelay, maxDelay
 		return err
 	}
 
-	time.Sleep(time.Duration(100+rand.Intn(200)) * time.Millisecond)
+	Wait(page.GetContext(), time.Duration(100+rand.Intn(200))*time.Millisecond)
 
 	for i, char := range text {
 		// Occasionally make a typo
@@ -23,18 +26,20 @@ func HumanType(page *rod.Page, el *rod.Element, text string, minDelay, maxDelay
 			// Type wrong character
 			wrongChar := rune('a' + rand.Intn(26))
 			page.Keyboard.Type(input.Key(wrongChar))
-			time.Sleep(time.Duration(minDelay+rand.Intn(maxDelay-minDelay)) * time.Millisecond)
+			Wait(page.GetContext(), time.Duration(minDelay+rand.Intn(maxDelay-minDelay))*time.Millisecond)
 
 			// Pause (realize mistake)
-			time.Sleep(time.Duration(200+rand.Intn(300)) * time.Millisecond)
+			Wait(page.GetContext(), time.Duration(200+rand.Intn(300))*time.Millisecond)
 
 			// Backspace
 			page.Keyboard.Press(input.Backspace)
-			time.Sleep(time.Duration(50+rand.Intn(100)) * time.Millisecond)
+			Wait(page.GetContext(), time.Duration(50+rand.Intn(100))*time.Millisecond)
 		}
 
 		// Type the correct character
-		page.Keyboard.Type(input.Key(char))
+		if err := typeRune(page, char); err != nil {
+			return err
+		}
 
 		// Variable typing speed
 		var delay time.Duration
@@ -50,11 +55,13 @@ func HumanType(page *rod.Page, el *rod.Element, text string, minDelay, maxDelay
 			delay += time.Duration(300+rand.Intn(500)) * time.Millisecond
 		}
 
-		time.Sleep(delay)
+		if err := Wait(page.GetContext(), delay); err != nil {
+			return err
+		}
 	}
 
 	// Brief pause after typing
-	time.Sleep(time.Duration(200+rand.Intn(300)) * time.Millisecond)
+	Wait(page.GetContext(), time.Duration(200+rand.Intn(300))*time.Millisecond)
 
 	return nil
 }
@@ -62,43 +69,55 @@ func HumanType(page *rod.Page, el *rod.Element, text string, minDelay, maxDelay
 // TypeWithBackspace simulates typing with occasional backspacing
 func TypeWithBackspace(page *rod.Page, el *rod.Element, text string) error {
 	el.Focus()
-	time.Sleep(time.Duration(100+rand.Intn(200)) * time.Millisecond)
+	Wait(page.GetContext(), time.Duration(100+rand.Intn(200))*time.Millisecond)
 
 	words := splitIntoWords(text)
 
 	for i, word := range words {
 		// Type word
 		for _, char := range word {
-			page.Keyboard.Type(input.Key(char))
-			time.Sleep(time.Duration(50+rand.Intn(150)) * time.Millisecond)
+			typeRune(page, char)
+			Wait(page.GetContext(), time.Duration(50+rand.Intn(150))*time.Millisecond)
 		}
 
 		// Sometimes backspace and retype
-		if rand.Float64() < 0.1 && len(word) > 3 {
+		if rand.Float64() < 0.1 && len([]rune(word)) > 3 {
 			backspaceCount := 1 + rand.Intn(3)
 			for j := 0; j < backspaceCount; j++ {
 				page.Keyboard.Press(input.Backspace)
-				time.Sleep(time.Duration(50+rand.Intn(100)) * time.Millisecond)
+				Wait(page.GetContext(), time.Duration(50+rand.Intn(100))*time.Millisecond)
 			}
 
 			// Retype
-			retyped := word[len(word)-backspaceCount:]
+			retyped := []rune(word)[len([]rune(word))-backspaceCount:]
 			for _, char := range retyped {
-				page.Keyboard.Type(input.Key(char))
-				time.Sleep(time.Duration(50+rand.Intn(150)) * time.Millisecond)
+				typeRune(page, char)
+				Wait(page.GetContext(), time.Duration(50+rand.Intn(150))*time.Millisecond)
 			}
 		}
 
 		// Add space between words
 		if i < len(words)-1 {
 			page.Keyboard.Type(input.Key(' '))
-			time.Sleep(time.Duration(100+rand.Intn(200)) * time.Millisecond)
+			if err := Wait(page.GetContext(), time.Duration(100+rand.Intn(200))*time.Millisecond); err != nil {
+				return err
+			}
 		}
 	}
 
 	return nil
 }
 
+// typeRune presses the key for r when go-rod's US key map has one, which is
+// printable ASCII only; accents, emoji, CJK and newlines are inserted as
+// text, since pressing them would panic and Enter would send the message
+func typeRune(page *rod.Page, r rune) error {
+	if r >= ' ' && r <= '~' {
+		return page.Keyboard.Type(input.Key(r))
+	}
+	return page.InsertText(string(r))
+}
+
 func splitIntoWords(text string) []string {
 	var words []string
 	var current string
@@ -122,7 +141,28 @@ func splitIntoWords(text string) []string {
 }
 
 // SimulateThinking adds a pause to simulate thinking before typing
-func SimulateThinking() {
+func SimulateThinking(ctx context.Context) error {
+	defer timing.Start(timing.StealthDelay)()
 	thinkTime := time.Duration(500+rand.Intn(1500)) * time.Millisecond
-	time.Sleep(thinkTime)
+	return Wait(ctx, scaled(thinkTime))
+}
+
+// PasteText inserts text in one shot, the way a prepared message is pasted:
+// the field is focused, there is a moment to switch to and copy the draft,
+// then the whole text lands at once
+func PasteText(page *rod.Page, el *rod.Element, text string) error {
+	defer timing.Start(timing.Typing)()
+	if err := el.Focus(); err != nil {
+		return err
+	}
+
+	// Copying the draft from elsewhere takes a few seconds
+	Wait(page.GetContext(), time.Duration(1500+rand.Intn(3000))*time.Millisecond)
+
+	if err := page.InsertText(text); err != nil {
+		return err
+	}
+
+	// Re-read what was pasted before sending
+	return Wait(page.GetContext(), time.Duration(len([]rune(text))*(15+rand.Intn(15)))*time.Millisecond)
 }
@@ -0,0 +1,169 @@
+package stealth
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/input"
+
+	"linkedin-automation/internal/timing"
+)
+
+// TypingProfile describes one typist: how fast keys come, how often they slip,
+// and whether words come out in bursts or with pauses between them
+type TypingProfile struct {
+	Name            string
+	MinDelayMs      int
+	MaxDelayMs      int
+	TypoProbability float64
+	// BurstProbability is the chance a word is typed as one fast burst,
+	// with delays scaled by BurstFactor
+	BurstProbability float64
+	BurstFactor      float64
+	// PauseProbability is the chance of a short hesitation after a word
+	PauseProbability float64
+	PauseMinMs       int
+	PauseMaxMs       int
+	PunctuationMs    int
+}
+
+// TypingProfiles are the named typists an account can be assigned
+var TypingProfiles = map[string]TypingProfile{
+	// Looks at the keyboard: slow, steady, few typos, frequent pauses
+	"hunt_and_peck": {
+		MinDelayMs: 120, MaxDelayMs: 380, TypoProbability: 0.02,
+		BurstProbability: 0.05, BurstFactor: 0.7,
+		PauseProbability: 0.15, PauseMinMs: 400, PauseMaxMs: 1200,
+		PunctuationMs: 150,
+	},
+	// Fast and rhythmic: common words fly out in bursts, occasional slips
+	"touch_typist": {
+		MinDelayMs: 40, MaxDelayMs: 120, TypoProbability: 0.04,
+		BurstProbability: 0.35, BurstFactor: 0.5,
+		PauseProbability: 0.04, PauseMinMs: 200, PauseMaxMs: 600,
+		PunctuationMs: 60,
+	},
+	// Thumb typing: medium speed, more typos, uneven rhythm
+	"mobile": {
+		MinDelayMs: 90, MaxDelayMs: 260, TypoProbability: 0.06,
+		BurstProbability: 0.15, BurstFactor: 0.6,
+		PauseProbability: 0.08, PauseMinMs: 300, PauseMaxMs: 900,
+		PunctuationMs: 120,
+	},
+}
+
+func init() {
+	for name, p := range TypingProfiles {
+		p.Name = name
+		TypingProfiles[name] = p
+	}
+}
+
+// TypingProfileFor resolves the configured profile for an account. "auto"
+// assigns a profile from a hash of the account, so the same account always
+// types the same way; "" keeps the plain delays from the delays config.
+func TypingProfileFor(name, account string, minDelay, maxDelay int, typoProb float64) TypingProfile {
+	switch name {
+	case "":
+		return TypingProfile{
+			Name:             "custom",
+			MinDelayMs:       minDelay,
+			MaxDelayMs:       maxDelay,
+			TypoProbability:  typoProb,
+			PauseProbability: 0.05,
+			PauseMinMs:       300,
+			PauseMaxMs:       800,
+			PunctuationMs:    50,
+		}
+	case "auto":
+		names := make([]string, 0, len(TypingProfiles))
+		for n := range TypingProfiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+
+		h := fnv.New32a()
+		h.Write([]byte(strings.ToLower(account)))
+		return TypingProfiles[names[h.Sum32()%uint32(len(names))]]
+	}
+
+	if p, ok := TypingProfiles[name]; ok {
+		return p
+	}
+	return TypingProfileFor("auto", account, minDelay, maxDelay, typoProb)
+}
+
+// WithoutTypos returns the profile with typos disabled, for fields like
+// credentials and search boxes where a correction would look odd
+func (p TypingProfile) WithoutTypos() TypingProfile {
+	p.TypoProbability = 0
+	return p
+}
+
+// Type enters text into el key by key with this profile's rhythm
+func (p TypingProfile) Type(page *rod.Page, el *rod.Element, text string) error {
+	defer timing.Start(timing.Typing)()
+	if err := el.Focus(); err != nil {
+		return err
+	}
+	Wait(page.GetContext(), time.Duration(100+rand.Intn(200))*time.Millisecond)
+
+	if err := p.typeKeys(page, text); err != nil {
+		return err
+	}
+
+	// Brief pause after typing
+	Wait(page.GetContext(), time.Duration(200+rand.Intn(300))*time.Millisecond)
+	return nil
+}
+
+// typeKeys types into whatever has focus
+func (p TypingProfile) typeKeys(page *rod.Page, text string) error {
+	burst := false
+	runes := []rune(text)
+
+	for i, char := range runes {
+		// Decide per word whether it comes out in a burst
+		if i == 0 || unicode.IsSpace(runes[i-1]) {
+			burst = rand.Float64() < p.BurstProbability
+		}
+
+		// Occasionally hit a wrong key and correct it
+		if p.TypoProbability > 0 && unicode.IsLetter(char) && rand.Float64() < p.TypoProbability {
+			page.Keyboard.Type(input.Key(rune('a' + rand.Intn(26))))
+			Wait(page.GetContext(), p.keyDelay(false))
+			Wait(page.GetContext(), time.Duration(200+rand.Intn(300))*time.Millisecond)
+			page.Keyboard.Press(input.Backspace)
+			Wait(page.GetContext(), time.Duration(50+rand.Intn(100))*time.Millisecond)
+		}
+
+		if err := typeRune(page, char); err != nil {
+			return err
+		}
+
+		delay := p.keyDelay(burst)
+		if unicode.IsPunct(char) {
+			delay += time.Duration(p.PunctuationMs) * time.Millisecond
+		}
+		if unicode.IsSpace(char) && p.PauseProbability > 0 && rand.Float64() < p.PauseProbability {
+			delay += time.Duration(p.PauseMinMs+rand.Intn(p.PauseMaxMs-p.PauseMinMs+1)) * time.Millisecond
+		}
+		if err := Wait(page.GetContext(), delay); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p TypingProfile) keyDelay(burst bool) time.Duration {
+	ms := float64(p.MinDelayMs + rand.Intn(p.MaxDelayMs-p.MinDelayMs+1))
+	if burst && p.BurstFactor > 0 {
+		ms *= p.BurstFactor
+	}
+	return time.Duration(ms) * time.Millisecond
+}
@@ -0,0 +1,58 @@
+package stealth
+
+import (
+	"math/rand"
+	"strings"
+
+	"github.com/go-rod/rod"
+)
+
+// WarmUp browses like a person opening LinkedIn before the first outreach
+// action: the feed loads, gets a short read, and notifications are sometimes
+// glanced at. Steps and their timing vary on every run; the warm-up ends
+// early when the page's context is done
+func WarmUp(page *rod.Page, baseURL string) error {
+	base := strings.TrimSuffix(baseURL, "/")
+	ctx := page.GetContext()
+
+	// Land on the feed
+	if err := page.Navigate(base + "/feed/"); err != nil {
+		return err
+	}
+	page.WaitLoad()
+	if err := RandomDelayContext(ctx, 2000, 5000); err != nil {
+		return err
+	}
+
+	// Skim a few posts
+	posts := 1 + rand.Intn(3)
+	for i := 0; i < posts; i++ {
+		HumanScroll(page, "down", 300+rand.Intn(600))
+		RandomMouseWander(page)
+		if err := RandomDelayContext(ctx, 1500, 6000); err != nil {
+			return err
+		}
+	}
+
+	// Sometimes scroll back up to something that caught the eye
+	if rand.Float64() < 0.3 {
+		HumanScroll(page, "up", 200+rand.Intn(300))
+		RandomDelayContext(ctx, 1000, 3000)
+	}
+
+	// Glance at notifications about half the time
+	if rand.Float64() < 0.5 {
+		if err := page.Navigate(base + "/notifications/"); err != nil {
+			return err
+		}
+		page.WaitLoad()
+		RandomDelayContext(ctx, 2000, 5000)
+
+		if rand.Float64() < 0.5 {
+			HumanScroll(page, "down", 200+rand.Intn(400))
+			RandomDelayContext(ctx, 1000, 3000)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package stealth
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SendWindow restricts actions to specific weekdays and hours in a given timezone
+type SendWindow struct {
+	days      map[time.Weekday]bool
+	startHour int
+	endHour   int
+	location  *time.Location
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// NewSendWindow creates a send window; an empty day list allows every day
+func NewSendWindow(days []string, startHour, endHour int, timezone string) (*SendWindow, error) {
+	if startHour < 0 || endHour > 24 || startHour >= endHour {
+		return nil, fmt.Errorf("invalid send window hours: %d-%d", startHour, endHour)
+	}
+
+	loc := time.Local
+	if timezone != "" {
+		l, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid send window timezone %q: %w", timezone, err)
+		}
+		loc = l
+	}
+
+	w := &SendWindow{
+		days:      make(map[time.Weekday]bool),
+		startHour: startHour,
+		endHour:   endHour,
+		location:  loc,
+	}
+
+	for _, d := range days {
+		name := strings.ToLower(strings.TrimSpace(d))
+		if len(name) > 3 {
+			name = name[:3]
+		}
+		wd, ok := weekdayNames[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid send window day: %q", d)
+		}
+		w.days[wd] = true
+	}
+
+	return w, nil
+}
+
+// Contains checks whether t falls inside the window
+func (w *SendWindow) Contains(t time.Time) bool {
+	local := t.In(w.location)
+	if len(w.days) > 0 && !w.days[local.Weekday()] {
+		return false
+	}
+	return local.Hour() >= w.startHour && local.Hour() < w.endHour
+}
+
+// Next returns t if it is inside the window, otherwise the next time the window opens
+func (w *SendWindow) Next(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+
+	local := t.In(w.location)
+	start := time.Date(local.Year(), local.Month(), local.Day(), w.startHour, 0, 0, 0, w.location)
+	if !local.Before(start) {
+		start = start.AddDate(0, 0, 1)
+	}
+
+	for i := 0; i < 8; i++ {
+		if len(w.days) == 0 || w.days[start.Weekday()] {
+			return start
+		}
+		start = start.AddDate(0, 0, 1)
+	}
+
+	return start
+}
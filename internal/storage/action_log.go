@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type ActionLogEntry struct {
+	ID         int64
+	Action     string
+	ProfileURL string
+	Campaign   string
+	Outcome    string
+	Detail     string
+	CreatedAt  time.Time
+}
+
+func (s *Store) AppendActionLog(e ActionLogEntry) error {
+	query := `INSERT INTO action_log (action, profile_url, campaign, outcome, detail) VALUES (?, ?, ?, ?, ?)`
+	_, err := s.db.Exec(query, e.Action, e.ProfileURL, e.Campaign, e.Outcome, e.Detail)
+	if err != nil {
+		return fmt.Errorf("failed to append action log: %w", err)
+	}
+	return nil
+}
+
+// GetActionFailures returns the failure history for a profile, oldest first
+func (s *Store) GetActionFailures(profileURL string) ([]ActionLogEntry, error) {
+	query := `SELECT id, action, COALESCE(profile_url, ''), COALESCE(campaign, ''), outcome, COALESCE(detail, ''), created_at
+	          FROM action_log WHERE profile_url = ? AND outcome = 'failure' ORDER BY created_at`
+
+	rows, err := s.db.Query(query, profileURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get action failures: %w", err)
+	}
+	return scanActionLog(rows)
+}
+
+// GetActionLogSince returns the actions attempted since the given time, oldest first
+func (s *Store) GetActionLogSince(since time.Time) ([]ActionLogEntry, error) {
+	query := `SELECT id, action, COALESCE(profile_url, ''), COALESCE(campaign, ''), outcome, COALESCE(detail, ''), created_at
+	          FROM action_log WHERE created_at >= ? ORDER BY created_at`
+
+	rows, err := s.db.Query(query, sqlTime(since))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get action log: %w", err)
+	}
+	return scanActionLog(rows)
+}
+
+func scanActionLog(rows *sql.Rows) ([]ActionLogEntry, error) {
+	defer rows.Close()
+
+	var entries []ActionLogEntry
+	for rows.Next() {
+		var e ActionLogEntry
+		if err := rows.Scan(&e.ID, &e.Action, &e.ProfileURL, &e.Campaign, &e.Outcome, &e.Detail, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+func (s *Store) GetConnectionsCountSince(since time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM connection_requests WHERE sent_at >= ? AND external = 0`
+	var count int
+	if err := s.db.QueryRow(query, sqlTime(since)).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to get connection count: %w", err)
+	}
+	return count, nil
+}
+
+// GetAcceptedCountSince counts the requests seen accepted since since
+func (s *Store) GetAcceptedCountSince(since time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM connection_requests WHERE accepted_at >= ? AND external = 0`
+	var count int
+	if err := s.db.QueryRow(query, sqlTime(since)).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to get accepted count: %w", err)
+	}
+	return count, nil
+}
+
+// GetMessagesCountSince counts the messages sent since since
+func (s *Store) GetMessagesCountSince(since time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM messages WHERE sent_at >= ?`
+	var count int
+	if err := s.db.QueryRow(query, sqlTime(since)).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to get message count: %w", err)
+	}
+	return count, nil
+}
+
+// GetOldestConnectionSince returns the earliest send time at or after since
+func (s *Store) GetOldestConnectionSince(since time.Time) (time.Time, error) {
+	query := `SELECT MIN(sent_at) FROM connection_requests WHERE sent_at >= ? AND external = 0`
+	// Aggregates lose the column's DATETIME type
+	var oldest sql.NullString
+	if err := s.db.QueryRow(query, sqlTime(since)).Scan(&oldest); err != nil {
+		return time.Time{}, fmt.Errorf("failed to get oldest connection: %w", err)
+	}
+	if !oldest.Valid {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(sqlTimeLayout, oldest.String)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse oldest connection time: %w", err)
+	}
+	return t, nil
+}
+
+// GetAcceptanceStats counts requests sent in [from, to) and how many were accepted
+func (s *Store) GetAcceptanceStats(from, to time.Time) (sent, accepted int, err error) {
+	query := `SELECT COUNT(*), COALESCE(SUM(accepted), 0) FROM connection_requests
+	          WHERE sent_at >= ? AND sent_at < ? AND external = 0 AND ` + notArchived("")
+	if err := s.db.QueryRow(query, sqlTime(from), sqlTime(to)).Scan(&sent, &accepted); err != nil {
+		return 0, 0, fmt.Errorf("failed to get acceptance stats: %w", err)
+	}
+	return sent, accepted, nil
+}
+
+func (s *Store) CountQueueItems(status string) (int, error) {
+	query := `SELECT COUNT(*) FROM queue WHERE status = ? AND ` + notArchived("")
+	var count int
+	if err := s.db.QueryRow(query, status).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count queue items: %w", err)
+	}
+	return count, nil
+}
+
+// SourceStats rolls outcomes up by how prospects entered the system
+type SourceStats struct {
+	Source   string `json:"source"`
+	Sent     int    `json:"sent"`
+	Accepted int    `json:"accepted"`
+	Messaged int    `json:"messaged"`
+	Replied  int    `json:"replied"`
+}
+
+func (s *Store) GetSourceStats() ([]SourceStats, error) {
+	// Messages are rolled up per profile first, so a prospect messaged more
+	// than once still counts as one request
+	query := `SELECT COALESCE(NULLIF(c.source, ''), 'unknown'), COUNT(*), COALESCE(SUM(c.accepted), 0),
+	                 COUNT(m.profile_url), COALESCE(SUM(m.replied), 0)
+	          FROM connection_requests c
+	          LEFT JOIN (SELECT profile_url, MAX(replied_at IS NOT NULL) AS replied
+	                     FROM messages GROUP BY profile_url) m ON m.profile_url = c.profile_url
+	          WHERE ` + notArchived("c.") + `
+	          GROUP BY 1 ORDER BY 2 DESC`
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []SourceStats
+	for rows.Next() {
+		var st SourceStats
+		if err := rows.Scan(&st.Source, &st.Sent, &st.Accepted, &st.Messaged, &st.Replied); err != nil {
+			return nil, err
+		}
+		stats = append(stats, st)
+	}
+	return stats, rows.Err()
+}
+
+// SendOutcome is a single outreach action and whether it converted: an
+// accepted invite, or a follow-up that got a reply. Clicked is kept apart
+// for follow-ups whose tracked link was clicked
+type SendOutcome struct {
+	SentAt    time.Time
+	Converted bool
+	Clicked   bool
+}
+
+// GetSendOutcomes returns outcomes for action (QueueActionConnect or
+// QueueActionMessage) sent at or after since
+func (s *Store) GetSendOutcomes(action string, since time.Time) ([]SendOutcome, error) {
+	var query string
+	switch action {
+	case QueueActionConnect:
+		query = `SELECT sent_at, accepted, 0 FROM connection_requests WHERE sent_at >= ? AND external = 0 AND ` + notArchived("")
+	case QueueActionMessage:
+		query = `SELECT m.sent_at, m.replied_at IS NOT NULL,
+		                EXISTS (SELECT 1 FROM tracked_links t JOIN link_clicks k ON k.token = t.token
+		                        WHERE t.profile_url = m.profile_url)
+		         FROM messages m WHERE m.sent_at >= ? AND COALESCE(m.kind, '') = '` + MessageKindFollowUp + `'
+		         AND m.profile_url NOT IN (SELECT key FROM archive WHERE kind = '` + ArchiveProspect + `')`
+	default:
+		return nil, fmt.Errorf("unknown outreach action: %s", action)
+	}
+
+	rows, err := s.db.Query(query, sqlTime(since))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get send outcomes: %w", err)
+	}
+	defer rows.Close()
+
+	var outcomes []SendOutcome
+	for rows.Next() {
+		var o SendOutcome
+		if err := rows.Scan(&o.SentAt, &o.Converted, &o.Clicked); err != nil {
+			return nil, err
+		}
+		outcomes = append(outcomes, o)
+	}
+	return outcomes, rows.Err()
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// Archive kinds
+const (
+	ArchiveCampaign = "campaign"
+	ArchiveProspect = "prospect"
+)
+
+type ArchiveEntry struct {
+	Kind       string
+	Key        string
+	ArchivedAt time.Time
+}
+
+// notArchived returns a WHERE clause fragment that excludes rows belonging to
+// an archived campaign or prospect. The table must have campaign and
+// profile_url columns; prefix qualifies them, e.g. "c."
+func notArchived(prefix string) string {
+	return fmt.Sprintf(`COALESCE(%[1]scampaign, 'default') NOT IN (SELECT key FROM archive WHERE kind = '%[2]s')
+	          AND %[1]sprofile_url NOT IN (SELECT key FROM archive WHERE kind = '%[3]s')`,
+		prefix, ArchiveCampaign, ArchiveProspect)
+}
+
+// Archive hides a campaign or prospect from queues and stats; history is kept
+// and Unarchive restores it
+func (s *Store) Archive(kind, key string) error {
+	query := `INSERT INTO archive (kind, key) VALUES (?, ?) ON CONFLICT(kind, key) DO NOTHING`
+	if _, err := s.db.Exec(query, kind, key); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", kind, err)
+	}
+	return nil
+}
+
+func (s *Store) Unarchive(kind, key string) error {
+	_, err := s.db.Exec(`DELETE FROM archive WHERE kind = ? AND key = ?`, kind, key)
+	return err
+}
+
+func (s *Store) ListArchived() ([]ArchiveEntry, error) {
+	rows, err := s.db.Query(`SELECT kind, key, archived_at FROM archive ORDER BY kind, archived_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []ArchiveEntry
+	for rows.Next() {
+		var e ArchiveEntry
+		if err := rows.Scan(&e.Kind, &e.Key, &e.ArchivedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// Audit actions
+const (
+	AuditRunStarted        = "run_started"
+	AuditBroadcastApproved = "broadcast_approved"
+	AuditBroadcastRejected = "broadcast_rejected"
+	AuditDeadLetterRetried = "dead_letter_retried"
+	AuditQueueRemoved      = "queue_removed"
+	AuditQueueBumped       = "queue_bumped"
+	AuditQueueCleared      = "queue_cleared"
+)
+
+// AuditEntry records who did what in a shared workspace
+type AuditEntry struct {
+	ID        int64
+	Operator  string
+	Action    string
+	Detail    string
+	CreatedAt time.Time
+}
+
+func (s *Store) RecordAudit(operator, action, detail string) error {
+	query := `INSERT INTO audit_log (operator, action, detail) VALUES (?, ?, ?)`
+	if _, err := s.db.Exec(query, operator, action, detail); err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+	return nil
+}
+
+// GetAuditLog returns the most recent entries, newest first; operator
+// filters to one person when set
+func (s *Store) GetAuditLog(operator string, limit int) ([]AuditEntry, error) {
+	query := `SELECT id, operator, action, detail, created_at FROM audit_log
+	          WHERE ? = '' OR operator = ? ORDER BY id DESC LIMIT ?`
+
+	rows, err := s.db.Query(query, operator, operator, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit log: %w", err)
+	}
+	defer rows.Close()
+
+	var out []AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		if err := rows.Scan(&e.ID, &e.Operator, &e.Action, &e.Detail, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
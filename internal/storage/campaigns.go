@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Campaign prospect statuses, in the order a prospect moves through them
+const (
+	// CampaignQueued prospects have a connection request queued
+	CampaignQueued = "queued"
+	// CampaignInvited prospects were sent the request and are checked for acceptance
+	CampaignInvited = "invited"
+	// CampaignAccepted prospects are connected; the next follow-up is due at NextAt
+	CampaignAccepted = "accepted"
+	// CampaignMessaging prospects have a follow-up queued
+	CampaignMessaging = "messaging"
+	// CampaignReplied prospects answered; the sequence stops for them
+	CampaignReplied = "replied"
+	// CampaignDone prospects were sent every follow-up
+	CampaignDone = "done"
+)
+
+// SequenceCampaign is the campaign the messaging.sequence follow-ups run
+// under, for connections made outside campaigns
+const SequenceCampaign = "sequence"
+
+// CampaignStatuses lists the statuses in pipeline order, for reports
+var CampaignStatuses = []string{
+	CampaignQueued, CampaignInvited, CampaignAccepted, CampaignMessaging, CampaignReplied, CampaignDone,
+}
+
+// CampaignProspect is one prospect's progress through a campaign
+type CampaignProspect struct {
+	Campaign   string
+	ProfileURL string
+	Name       string
+	Status     string
+	// Step counts the follow-ups sent so far
+	Step      int
+	NextAt    time.Time
+	UpdatedAt time.Time
+}
+
+// CampaignRun records when a campaign was first and last run
+type CampaignRun struct {
+	Name      string
+	StartedAt time.Time
+	LastRunAt time.Time
+}
+
+// TouchCampaign records a run of the campaign, starting it on the first one
+func (s *Store) TouchCampaign(name string) error {
+	query := `INSERT INTO campaigns (name) VALUES (?)
+	          ON CONFLICT(name) DO UPDATE SET last_run_at = CURRENT_TIMESTAMP`
+	if _, err := s.db.Exec(query, name); err != nil {
+		return fmt.Errorf("failed to record campaign run: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) GetCampaignRuns() ([]CampaignRun, error) {
+	rows, err := s.db.Query(`SELECT name, started_at, last_run_at FROM campaigns ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get campaigns: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []CampaignRun
+	for rows.Next() {
+		var r CampaignRun
+		if err := rows.Scan(&r.Name, &r.StartedAt, &r.LastRunAt); err != nil {
+			return nil, err
+		}
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
+
+// AddCampaignProspect adds a queued prospect; it reports false when the
+// prospect is already part of the campaign
+func (s *Store) AddCampaignProspect(campaign, profileURL, name string) (bool, error) {
+	query := `INSERT INTO campaign_prospects (campaign, profile_url, name, status) VALUES (?, ?, ?, ?)
+	          ON CONFLICT(campaign, profile_url) DO NOTHING`
+	res, err := s.db.Exec(query, campaign, profileURL, name, CampaignQueued)
+	if err != nil {
+		return false, fmt.Errorf("failed to add campaign prospect: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// AddAcceptedProspect adds a connection that is already accepted, with its
+// first follow-up due at nextAt; it reports false when the connection is
+// already part of the campaign
+func (s *Store) AddAcceptedProspect(campaign, profileURL, name string, nextAt time.Time) (bool, error) {
+	query := `INSERT INTO campaign_prospects (campaign, profile_url, name, status, next_at) VALUES (?, ?, ?, ?, ?)
+	          ON CONFLICT(campaign, profile_url) DO NOTHING`
+	res, err := s.db.Exec(query, campaign, profileURL, name, CampaignAccepted, nullTime(nextAt))
+	if err != nil {
+		return false, fmt.Errorf("failed to add campaign prospect: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetCampaignProspects returns the campaign's prospects, all of them when
+// status is empty
+func (s *Store) GetCampaignProspects(campaign, status string) ([]CampaignProspect, error) {
+	query := `SELECT campaign, profile_url, COALESCE(name, ''), status, step, next_at, updated_at
+	          FROM campaign_prospects WHERE campaign = ? AND (? = '' OR status = ?) ORDER BY updated_at`
+	rows, err := s.db.Query(query, campaign, status, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get campaign prospects: %w", err)
+	}
+	defer rows.Close()
+
+	var prospects []CampaignProspect
+	for rows.Next() {
+		var p CampaignProspect
+		var next sql.NullTime
+		if err := rows.Scan(&p.Campaign, &p.ProfileURL, &p.Name, &p.Status, &p.Step, &next, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		p.NextAt = next.Time
+		prospects = append(prospects, p)
+	}
+	return prospects, rows.Err()
+}
+
+// UpdateCampaignProspect saves a prospect's status, step and next due time
+func (s *Store) UpdateCampaignProspect(p CampaignProspect) error {
+	query := `UPDATE campaign_prospects SET status = ?, step = ?, next_at = ?, updated_at = CURRENT_TIMESTAMP
+	          WHERE campaign = ? AND profile_url = ?`
+	if _, err := s.db.Exec(query, p.Status, p.Step, nullTime(p.NextAt), p.Campaign, p.ProfileURL); err != nil {
+		return fmt.Errorf("failed to update campaign prospect: %w", err)
+	}
+	return nil
+}
+
+// CountCampaignProspects returns the number of the campaign's prospects per status
+func (s *Store) CountCampaignProspects(campaign string) (map[string]int, error) {
+	rows, err := s.db.Query(`SELECT status, COUNT(*) FROM campaign_prospects WHERE campaign = ? GROUP BY status`, campaign)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count campaign prospects: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		counts[status] = n
+	}
+	return counts, rows.Err()
+}
+
+// HasReplied reports whether a reply was seen in any thread with the profile
+func (s *Store) HasReplied(profileURL string) (bool, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM messages WHERE profile_url = ? AND replied_at IS NOT NULL`
+	if err := s.db.QueryRow(query, profileURL).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check replies: %w", err)
+	}
+	return count > 0, nil
+}
+
+// EnqueueStep queues an action that a campaign repeats for the same
+// prospect, like its follow-ups: a done item for the same campaign, action
+// and profile is re-armed with the new payload instead of blocking it
+func (s *Store) EnqueueStep(item QueueItem) error {
+	if item.NotBefore.IsZero() {
+		item.NotBefore = time.Now()
+	}
+
+	query := `INSERT INTO queue (campaign, action, profile_url, name, payload, source, send_window, not_before, priority, status)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	          ON CONFLICT(campaign, action, profile_url) DO UPDATE SET
+	          payload = excluded.payload, send_window = excluded.send_window, not_before = excluded.not_before, status = excluded.status,
+	          attempts = 0, last_error = NULL, done_at = NULL
+	          WHERE queue.status = '` + QueueStatusDone + `'`
+	_, err := s.db.Exec(query, item.Campaign, item.Action, item.ProfileURL, item.Name, item.Payload,
+		item.Source, item.SendWindow, sqlTime(item.NotBefore), item.Priority, QueueStatusPending)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue step: %w", err)
+	}
+	return nil
+}
+
+// GetStepOutcome returns the status of the campaign's queue item for action
+// and profileURL and when it was done; status is "" when there is no item
+func (s *Store) GetStepOutcome(campaign, action, profileURL string) (status string, doneAt time.Time, err error) {
+	query := `SELECT status, done_at FROM queue WHERE campaign = ? AND action = ? AND profile_url = ?`
+	var done sql.NullTime
+	err = s.db.QueryRow(query, campaign, action, profileURL).Scan(&status, &done)
+	if err == sql.ErrNoRows {
+		return "", time.Time{}, nil
+	}
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to get step outcome: %w", err)
+	}
+	return status, done.Time, nil
+}
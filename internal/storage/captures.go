@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// Outcomes of a captured send
+const (
+	CaptureSent   = "sent"
+	CaptureFailed = "failed"
+)
+
+// SendCapture is the page before and after a connection request or message
+// was sent: its URL and a screenshot, kept to review disputed or failed sends
+type SendCapture struct {
+	ID         int64
+	Action     string
+	ProfileURL string
+	Campaign   string
+	Dir        string
+	BeforeURL  string
+	BeforeShot string
+	AfterURL   string
+	AfterShot  string
+	Outcome    string
+	Detail     string
+	CreatedAt  time.Time
+}
+
+func (s *Store) SaveSendCapture(c SendCapture) error {
+	query := `INSERT INTO send_captures (action, profile_url, campaign, dir, before_url, before_shot,
+	                                     after_url, after_shot, outcome, detail)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := s.db.Exec(query, c.Action, c.ProfileURL, c.Campaign, c.Dir, c.BeforeURL, c.BeforeShot,
+		c.AfterURL, c.AfterShot, c.Outcome, c.Detail)
+	if err != nil {
+		return fmt.Errorf("failed to save send capture: %w", err)
+	}
+	return nil
+}
+
+// GetSendCaptures returns the captures taken since t, newest first; profileURL
+// and outcome filter them when set
+func (s *Store) GetSendCaptures(since time.Time, profileURL, outcome string) ([]SendCapture, error) {
+	query := `SELECT id, action, profile_url, COALESCE(campaign, ''), dir, COALESCE(before_url, ''),
+	                 COALESCE(before_shot, ''), COALESCE(after_url, ''), COALESCE(after_shot, ''),
+	                 outcome, COALESCE(detail, ''), created_at
+	          FROM send_captures
+	          WHERE created_at >= ? AND (? = '' OR profile_url = ?) AND (? = '' OR outcome = ?)
+	          ORDER BY id DESC`
+
+	rows, err := s.db.Query(query, sqlTime(since), profileURL, profileURL, outcome, outcome)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get send captures: %w", err)
+	}
+	defer rows.Close()
+
+	var out []SendCapture
+	for rows.Next() {
+		var c SendCapture
+		if err := rows.Scan(&c.ID, &c.Action, &c.ProfileURL, &c.Campaign, &c.Dir, &c.BeforeURL, &c.BeforeShot,
+			&c.AfterURL, &c.AfterShot, &c.Outcome, &c.Detail, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan send capture: %w", err)
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
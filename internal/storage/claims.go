@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// Claim is a prospect one account and operator is working; see team.db_url
+type Claim struct {
+	ProfileURL string
+	Account    string
+	Operator   string
+	ClaimedAt  time.Time
+	ExpiresAt  time.Time
+}
+
+// Holder names who holds the claim
+func (c Claim) Holder() string {
+	account := c.Account
+	if account == "" {
+		account = "default"
+	}
+	return fmt.Sprintf("account %s (%s)", account, c.Operator)
+}
+
+// ClaimStore is the team's shared record of claims, and holds nothing else:
+// a PostgreSQL database for accounts on several hosts, or a SQLite file for
+// accounts on one host
+type ClaimStore struct {
+	db       *sql.DB
+	postgres bool
+}
+
+// OpenClaims opens the claims in the PostgreSQL database at url, or else in
+// the SQLite file at path
+func OpenClaims(url, path string) (*ClaimStore, error) {
+	c := &ClaimStore{postgres: url != ""}
+	timeType := "TIMESTAMPTZ"
+
+	var err error
+	if c.postgres {
+		c.db, err = sql.Open("pgx", url)
+	} else {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create team db directory: %w", err)
+		}
+		// busy_timeout lets the accounts' processes share the file without SQLITE_BUSY errors
+		c.db, err = sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)")
+		timeType = "DATETIME"
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open team database: %w", err)
+	}
+
+	query := `CREATE TABLE IF NOT EXISTS claims (
+		profile_url TEXT PRIMARY KEY,
+		account TEXT NOT NULL,
+		operator TEXT NOT NULL,
+		claimed_at ` + timeType + ` NOT NULL,
+		expires_at ` + timeType + ` NOT NULL
+	)`
+	if _, err := c.db.Exec(query); err != nil {
+		c.db.Close()
+		return nil, fmt.Errorf("failed to create claims table: %w", err)
+	}
+	return c, nil
+}
+
+func (c *ClaimStore) Close() error {
+	return c.db.Close()
+}
+
+// rebind numbers the ? placeholders for PostgreSQL
+func (c *ClaimStore) rebind(query string) string {
+	if !c.postgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// at is t as the database compares it: a timestamp in PostgreSQL, text in SQLite
+func (c *ClaimStore) at(t time.Time) interface{} {
+	if c.postgres {
+		return t.UTC()
+	}
+	return sqlTime(t)
+}
+
+// ClaimProspect claims profileURL for account and operator until ttl from
+// now, extending their own claim. When someone else's unexpired claim is in
+// the way it is returned and nothing changes; otherwise the result is nil
+func (c *ClaimStore) ClaimProspect(profileURL, account, operator string, ttl time.Duration) (*Claim, error) {
+	now := time.Now()
+	query := `INSERT INTO claims (profile_url, account, operator, claimed_at, expires_at) VALUES (?, ?, ?, ?, ?)
+	          ON CONFLICT(profile_url) DO UPDATE SET
+	              claimed_at = CASE WHEN claims.expires_at < ? THEN excluded.claimed_at ELSE claims.claimed_at END,
+	              account = excluded.account, operator = excluded.operator, expires_at = excluded.expires_at
+	          WHERE (claims.account = excluded.account AND claims.operator = excluded.operator) OR claims.expires_at < ?`
+
+	res, err := c.db.Exec(c.rebind(query), profileURL, account, operator, c.at(now), c.at(now.Add(ttl)), c.at(now), c.at(now))
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim prospect: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 1 {
+		return nil, nil
+	}
+
+	cl := &Claim{ProfileURL: profileURL}
+	err = c.db.QueryRow(c.rebind(`SELECT account, operator, claimed_at, expires_at FROM claims WHERE profile_url = ?`), profileURL).
+		Scan(&cl.Account, &cl.Operator, &cl.ClaimedAt, &cl.ExpiresAt)
+	if err == sql.ErrNoRows {
+		// Released in between; the next attempt takes it
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read claim: %w", err)
+	}
+	return cl, nil
+}
+
+// ReleaseClaim frees a prospect whoever holds it, and reports whether it was claimed
+func (c *ClaimStore) ReleaseClaim(profileURL string) (bool, error) {
+	res, err := c.db.Exec(c.rebind(`DELETE FROM claims WHERE profile_url = ?`), profileURL)
+	if err != nil {
+		return false, fmt.Errorf("failed to release claim: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetClaims returns the claims that have not expired, the longest-held first
+func (c *ClaimStore) GetClaims() ([]Claim, error) {
+	query := `SELECT profile_url, account, operator, claimed_at, expires_at FROM claims
+	          WHERE expires_at >= ? ORDER BY claimed_at`
+
+	rows, err := c.db.Query(c.rebind(query), c.at(time.Now()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get claims: %w", err)
+	}
+	defer rows.Close()
+
+	var claims []Claim
+	for rows.Next() {
+		var cl Claim
+		if err := rows.Scan(&cl.ProfileURL, &cl.Account, &cl.Operator, &cl.ClaimedAt, &cl.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan claim: %w", err)
+		}
+		claims = append(claims, cl)
+	}
+	return claims, rows.Err()
+}
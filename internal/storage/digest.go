@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// AwaitingReply is a contact whose reply is the last word in the conversation
+type AwaitingReply struct {
+	ProfileURL string
+	Name       string
+	RepliedAt  time.Time
+}
+
+// ReviewCount counts the queue items of a campaign waiting for approval
+type ReviewCount struct {
+	Campaign string
+	Action   string
+	Count    int
+}
+
+// StalledCount counts a campaign's prospects stuck in one status
+type StalledCount struct {
+	Campaign string
+	Status   string
+	Count    int
+	// Since is when the longest-stuck of them last moved
+	Since time.Time
+}
+
+// GetAwaitingReply returns contacts who replied with nothing sent to them
+// since, oldest reply first. Only what the tool sent is known, so answers
+// written by hand on LinkedIn do not take a contact off the list; archiving
+// the prospect does
+func (s *Store) GetAwaitingReply() ([]AwaitingReply, error) {
+	query := `SELECT m.profile_url,
+	                 COALESCE((SELECT name FROM connection_requests c WHERE c.profile_url = m.profile_url LIMIT 1),
+	                          (SELECT name FROM queue q WHERE q.profile_url = m.profile_url AND q.name != '' LIMIT 1), ''),
+	                 m.replied_at
+	          FROM messages m
+	          WHERE m.id = (SELECT id FROM messages r WHERE r.profile_url = m.profile_url AND r.replied_at IS NOT NULL
+	                        ORDER BY r.replied_at DESC LIMIT 1)
+	            AND m.profile_url NOT IN (SELECT key FROM archive WHERE kind = ?)
+	            AND NOT EXISTS (SELECT 1 FROM messages later WHERE later.profile_url = m.profile_url AND later.sent_at > m.replied_at)
+	          ORDER BY m.replied_at`
+
+	rows, err := s.db.Query(query, ArchiveProspect)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversations awaiting reply: %w", err)
+	}
+	defer rows.Close()
+
+	var out []AwaitingReply
+	for rows.Next() {
+		var a AwaitingReply
+		if err := rows.Scan(&a.ProfileURL, &a.Name, &a.RepliedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// GetReviewCounts counts queue items waiting for approval by campaign and action
+func (s *Store) GetReviewCounts() ([]ReviewCount, error) {
+	query := `SELECT campaign, action, COUNT(*) FROM queue
+	          WHERE status = ? AND ` + notArchived("") + `
+	          GROUP BY campaign, action ORDER BY campaign, action`
+
+	rows, err := s.db.Query(query, QueueStatusReview)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count queue items in review: %w", err)
+	}
+	defer rows.Close()
+
+	var out []ReviewCount
+	for rows.Next() {
+		var r ReviewCount
+		if err := rows.Scan(&r.Campaign, &r.Action, &r.Count); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// GetStalledProspects counts campaign prospects that have not moved since
+// before, by campaign and status; replied and finished prospects are done
+// moving and not counted
+func (s *Store) GetStalledProspects(before time.Time) ([]StalledCount, error) {
+	query := `SELECT campaign, status, COUNT(*), MIN(updated_at) FROM campaign_prospects
+	          WHERE status IN (?, ?, ?, ?) AND updated_at < ? AND ` + notArchived("") + `
+	          GROUP BY campaign, status ORDER BY campaign, status`
+
+	rows, err := s.db.Query(query, CampaignQueued, CampaignInvited, CampaignAccepted, CampaignMessaging, sqlTime(before))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stalled prospects: %w", err)
+	}
+	defer rows.Close()
+
+	var out []StalledCount
+	for rows.Next() {
+		// Aggregates lose the column's DATETIME type
+		var c StalledCount
+		var since string
+		if err := rows.Scan(&c.Campaign, &c.Status, &c.Count, &since); err != nil {
+			return nil, err
+		}
+		c.Since, _ = time.Parse(sqlTimeLayout, since)
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+type DoNotContact struct {
+	ProfileURL string
+	Reason     string
+	AddedAt    time.Time
+}
+
+func (s *Store) AddDoNotContact(profileURL, reason string) error {
+	query := `INSERT INTO do_not_contact (profile_url, reason) VALUES (?, ?)
+	          ON CONFLICT(profile_url) DO UPDATE SET reason = excluded.reason`
+	if _, err := s.db.Exec(query, profileURL, reason); err != nil {
+		return fmt.Errorf("failed to add do-not-contact entry: %w", err)
+	}
+
+	// Drop anything still queued for the contact, and the details scraped
+	// from their profile
+	if _, err := s.db.Exec(`DELETE FROM queue WHERE profile_url = ? AND status = ?`, profileURL, QueueStatusPending); err != nil {
+		return err
+	}
+	_, err := s.db.Exec(`DELETE FROM profiles WHERE profile_url = ?`, profileURL)
+	return err
+}
+
+func (s *Store) RemoveDoNotContact(profileURL string) error {
+	_, err := s.db.Exec(`DELETE FROM do_not_contact WHERE profile_url = ?`, profileURL)
+	return err
+}
+
+func (s *Store) IsDoNotContact(profileURL string) (bool, error) {
+	var count int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM do_not_contact WHERE profile_url = ?`, profileURL).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check do-not-contact list: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (s *Store) ListDoNotContact() ([]DoNotContact, error) {
+	rows, err := s.db.Query(`SELECT profile_url, COALESCE(reason, ''), added_at FROM do_not_contact ORDER BY added_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []DoNotContact
+	for rows.Next() {
+		var e DoNotContact
+		if err := rows.Scan(&e.ProfileURL, &e.Reason, &e.AddedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
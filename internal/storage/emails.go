@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// Email match statuses. A searched email with no candidates is kept as one
+// row with no profile, so it is not searched again
+const (
+	EmailMatchReview    = "review"
+	EmailMatchConfirmed = "confirmed"
+	EmailMatchRejected  = "rejected"
+	EmailMatchNone      = "none"
+)
+
+// EmailMatch is a LinkedIn profile found for an address-book email, waiting
+// for the operator to confirm it is the same person
+type EmailMatch struct {
+	ID         int64
+	Email      string
+	Name       string
+	Company    string
+	ProfileURL string
+	Candidate  string
+	Headline   string
+	Confidence float64
+	Status     string
+	CreatedAt  time.Time
+}
+
+func (s *Store) SaveEmailMatch(m EmailMatch) error {
+	query := `INSERT OR IGNORE INTO email_matches (email, name, company, profile_url, candidate, headline, confidence, status)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	if _, err := s.db.Exec(query, m.Email, m.Name, m.Company, m.ProfileURL, m.Candidate, m.Headline, m.Confidence, m.Status); err != nil {
+		return fmt.Errorf("failed to save email match: %w", err)
+	}
+	return nil
+}
+
+// EmailSearched reports whether email has been looked up before
+func (s *Store) EmailSearched(email string) (bool, error) {
+	var count int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM email_matches WHERE email = ?`, email).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check email matches: %w", err)
+	}
+	return count > 0, nil
+}
+
+// GetEmailMatches lists matches in a status, each email's best candidate
+// first
+func (s *Store) GetEmailMatches(status string) ([]EmailMatch, error) {
+	query := `SELECT id, email, COALESCE(name, ''), COALESCE(company, ''), profile_url, COALESCE(candidate, ''),
+	          COALESCE(headline, ''), confidence, status, created_at
+	          FROM email_matches WHERE status = ? ORDER BY email, confidence DESC`
+	rows, err := s.db.Query(query, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email matches: %w", err)
+	}
+	defer rows.Close()
+
+	var matches []EmailMatch
+	for rows.Next() {
+		var m EmailMatch
+		if err := rows.Scan(&m.ID, &m.Email, &m.Name, &m.Company, &m.ProfileURL, &m.Candidate,
+			&m.Headline, &m.Confidence, &m.Status, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan email match: %w", err)
+		}
+		matches = append(matches, m)
+	}
+	return matches, rows.Err()
+}
+
+// ConfirmEmailMatch confirms a candidate and rejects the email's others
+func (s *Store) ConfirmEmailMatch(id int64) error {
+	var email string
+	if err := s.db.QueryRow(`SELECT email FROM email_matches WHERE id = ? AND profile_url != ''`, id).Scan(&email); err != nil {
+		return fmt.Errorf("no email match #%d: %w", id, err)
+	}
+	query := `UPDATE email_matches SET status = CASE WHEN id = ? THEN ? ELSE ? END WHERE email = ? AND profile_url != ''`
+	if _, err := s.db.Exec(query, id, EmailMatchConfirmed, EmailMatchRejected, email); err != nil {
+		return fmt.Errorf("failed to confirm email match: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) RejectEmailMatch(id int64) error {
+	res, err := s.db.Exec(`UPDATE email_matches SET status = ? WHERE id = ? AND profile_url != ''`, EmailMatchRejected, id)
+	if err != nil {
+		return fmt.Errorf("failed to reject email match: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("no email match #%d", id)
+	}
+	return nil
+}
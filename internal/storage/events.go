@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"fmt"
+)
+
+// SaveEventInvite records an invitation; re-recording an existing one is a no-op
+func (s *Store) SaveEventInvite(eventURL, profileURL, name string) error {
+	query := `INSERT INTO event_invites (event_url, profile_url, name) VALUES (?, ?, ?)
+	          ON CONFLICT(event_url, profile_url) DO NOTHING`
+	if _, err := s.db.Exec(query, eventURL, profileURL, name); err != nil {
+		return fmt.Errorf("failed to save event invite: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) IsEventInvited(eventURL, profileURL string) (bool, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM event_invites WHERE event_url = ? AND profile_url = ?`
+	if err := s.db.QueryRow(query, eventURL, profileURL).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check event invite: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (s *Store) GetEventInvitesCountToday() (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM event_invites WHERE DATE(invited_at) = DATE('now')`
+	if err := s.db.QueryRow(query).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to get event invite count: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// exportTables are the tables the export command dumps: the query, the time
+// column a date range applies to, and how a campaign filter applies
+var exportTables = map[string]struct {
+	query, at, campaign string
+}{
+	"connections": {
+		query: `SELECT id, profile_url, COALESCE(name, ''), COALESCE(campaign, ''), COALESCE(source, ''), sent_at,
+		               accepted, COALESCE(note, ''), COALESCE(note_mode, ''), external, withdrawn_at
+		        FROM connection_requests`,
+		at:       "sent_at",
+		campaign: "campaign = ?",
+	},
+	"messages": {
+		query: `SELECT id, profile_url, COALESCE(kind, ''), content, sent_at, seen_at, replied_at FROM messages`,
+		at:    "sent_at",
+		// Messages belong to the campaign that connected with the prospect
+		campaign: "profile_url IN (SELECT profile_url FROM connection_requests WHERE campaign = ?)",
+	},
+	"profiles": {
+		query:    `SELECT profile_url, name, headline, company, scraped_at, data FROM profiles`,
+		at:       "scraped_at",
+		campaign: "profile_url IN (SELECT profile_url FROM connection_requests WHERE campaign = ?)",
+	},
+}
+
+// ExportTables lists the tables ExportRows accepts
+func ExportTables() []string {
+	return []string{"connections", "messages", "profiles"}
+}
+
+// ExportRows returns the column names and rows of an export table, limited to
+// [since, until) when they are set and to a campaign's prospects when one is
+// given. Values are text; times are UTC in sqlTimeLayout
+func (s *Store) ExportRows(table string, since, until time.Time, campaign string) ([]string, [][]string, error) {
+	t, ok := exportTables[table]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown export table %q (want %s)", table, strings.Join(ExportTables(), ", "))
+	}
+
+	var where []string
+	var args []interface{}
+	if !since.IsZero() {
+		where = append(where, t.at+" >= ?")
+		args = append(args, sqlTime(since))
+	}
+	if !until.IsZero() {
+		where = append(where, t.at+" < ?")
+		args = append(args, sqlTime(until))
+	}
+	if campaign != "" {
+		where = append(where, t.campaign)
+		args = append(args, campaign)
+	}
+	query := t.query
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY " + t.at
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to export %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to export %s: %w", table, err)
+	}
+	// COALESCE(x, '') reads back under its expression; name it by the column
+	for i, c := range columns {
+		if name, ok := strings.CutPrefix(c, "COALESCE("); ok {
+			columns[i], _, _ = strings.Cut(name, ",")
+		}
+	}
+
+	var out [][]string
+	values := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, nil, fmt.Errorf("failed to export %s: %w", table, err)
+		}
+		row := make([]string, len(values))
+		for i, v := range values {
+			switch v := v.(type) {
+			case nil:
+			case time.Time:
+				row[i] = v.UTC().Format(sqlTimeLayout)
+			case []byte:
+				row[i] = string(v)
+			default:
+				row[i] = fmt.Sprint(v)
+			}
+		}
+		out = append(out, row)
+	}
+	return columns, out, rows.Err()
+}
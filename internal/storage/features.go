@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// ProspectFeatures are the signals the acceptance model is trained on,
+// captured when a prospect is queued
+type ProspectFeatures struct {
+	TitleMatch    float64
+	LocationMatch float64
+	Mutuals       int
+	OpenToWork    bool
+	Note          bool
+}
+
+// TrainingRow is a decided connection request with its features
+type TrainingRow struct {
+	ProspectFeatures
+	Accepted bool
+}
+
+func (s *Store) SaveProspectFeatures(profileURL string, f ProspectFeatures) error {
+	query := `INSERT OR REPLACE INTO prospect_features (profile_url, title_match, location_match, mutuals, open_to_work, has_note)
+	          VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := s.db.Exec(query, profileURL, f.TitleMatch, f.LocationMatch, f.Mutuals, f.OpenToWork, f.Note)
+	if err != nil {
+		return fmt.Errorf("failed to save prospect features: %w", err)
+	}
+	return nil
+}
+
+// GetTrainingRows returns requests whose outcome is known: accepted ones, and
+// unaccepted ones sent before decidedBefore. Recent unanswered requests are
+// left out rather than counted as declines
+func (s *Store) GetTrainingRows(decidedBefore time.Time) ([]TrainingRow, error) {
+	query := `SELECT f.title_match, f.location_match, f.mutuals, f.open_to_work, COALESCE(c.note, '') != '', c.accepted
+	          FROM connection_requests c JOIN prospect_features f ON f.profile_url = c.profile_url
+	          WHERE c.external = 0 AND (c.accepted = 1 OR c.sent_at < ?)`
+
+	rows, err := s.db.Query(query, sqlTime(decidedBefore))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get training rows: %w", err)
+	}
+	defer rows.Close()
+
+	var out []TrainingRow
+	for rows.Next() {
+		var r TrainingRow
+		if err := rows.Scan(&r.TitleMatch, &r.LocationMatch, &r.Mutuals, &r.OpenToWork, &r.Note, &r.Accepted); err != nil {
+			return nil, fmt.Errorf("failed to scan training row: %w", err)
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// GetPendingPriorities returns the priorities of pending queue items for an action
+func (s *Store) GetPendingPriorities(action string) ([]int, error) {
+	query := `SELECT priority FROM queue WHERE action = ? AND status = ? AND ` + notArchived("")
+	rows, err := s.db.Query(query, action, QueueStatusPending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queue priorities: %w", err)
+	}
+	defer rows.Close()
+
+	var out []int
+	for rows.Next() {
+		var p int
+		if err := rows.Scan(&p); err != nil {
+			return nil, fmt.Errorf("failed to scan queue priority: %w", err)
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// Follow kinds
+const (
+	FollowKindProfile = "profile"
+	FollowKindPage    = "page"
+)
+
+// Follow is an account or page followed by the tool
+type Follow struct {
+	TargetURL    string
+	Name         string
+	Kind         string
+	Campaign     string
+	FollowedAt   time.Time
+	UnfollowedAt time.Time
+}
+
+// SaveFollow records a follow; following again after an unfollow reopens it
+func (s *Store) SaveFollow(f Follow) error {
+	if f.Campaign == "" {
+		f.Campaign = "default"
+	}
+	query := `INSERT INTO follows (target_url, name, kind, campaign) VALUES (?, ?, ?, ?)
+	          ON CONFLICT(target_url) DO UPDATE SET followed_at = CURRENT_TIMESTAMP, unfollowed_at = NULL,
+	          campaign = excluded.campaign`
+	if _, err := s.db.Exec(query, f.TargetURL, f.Name, f.Kind, f.Campaign); err != nil {
+		return fmt.Errorf("failed to save follow: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) MarkUnfollowed(targetURL string) error {
+	query := `UPDATE follows SET unfollowed_at = CURRENT_TIMESTAMP WHERE target_url = ?`
+	if _, err := s.db.Exec(query, targetURL); err != nil {
+		return fmt.Errorf("failed to mark unfollowed: %w", err)
+	}
+	return nil
+}
+
+// IsFollowing reports whether the tool followed the target and has not unfollowed it
+func (s *Store) IsFollowing(targetURL string) (bool, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM follows WHERE target_url = ? AND unfollowed_at IS NULL`
+	if err := s.db.QueryRow(query, targetURL).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check follow: %w", err)
+	}
+	return count > 0, nil
+}
+
+// GetFollowCountsToday returns today's follows and unfollows
+func (s *Store) GetFollowCountsToday() (follows, unfollows int, err error) {
+	query := `SELECT
+		(SELECT COUNT(*) FROM follows WHERE DATE(followed_at) = DATE('now')),
+		(SELECT COUNT(*) FROM follows WHERE DATE(unfollowed_at) = DATE('now'))`
+	if err := s.db.QueryRow(query).Scan(&follows, &unfollows); err != nil {
+		return 0, 0, fmt.Errorf("failed to get follow counts: %w", err)
+	}
+	return follows, unfollows, nil
+}
+
+// GetFollowsBefore returns active follows made before cutoff, oldest first
+func (s *Store) GetFollowsBefore(cutoff time.Time) ([]Follow, error) {
+	query := `SELECT target_url, COALESCE(name, ''), kind, campaign, followed_at FROM follows
+	          WHERE unfollowed_at IS NULL AND followed_at < ? ORDER BY followed_at`
+
+	rows, err := s.db.Query(query, sqlTime(cutoff))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get follows: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Follow
+	for rows.Next() {
+		var f Follow
+		if err := rows.Scan(&f.TargetURL, &f.Name, &f.Kind, &f.Campaign, &f.FollowedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan follow: %w", err)
+		}
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,39 @@
+package storage
+
+import "fmt"
+
+// SaveLeadListEntry records that a prospect is in a Sales Navigator lead list
+func (s *Store) SaveLeadListEntry(profileURL, list string) error {
+	query := `INSERT INTO lead_list_saves (profile_url, list_name) VALUES (?, ?)
+	          ON CONFLICT(profile_url, list_name) DO NOTHING`
+	if _, err := s.db.Exec(query, profileURL, list); err != nil {
+		return fmt.Errorf("failed to save lead list entry: %w", err)
+	}
+	return nil
+}
+
+// GetLeadListCandidates returns prospects who accepted or replied and are not
+// yet recorded in the list
+func (s *Store) GetLeadListCandidates(list string) ([]ConnectionRequest, error) {
+	query := `SELECT c.profile_url, COALESCE(c.name, ''), COALESCE(c.source, ''), COALESCE(c.campaign, 'default')
+	          FROM connection_requests c
+	          WHERE (c.accepted = 1 OR EXISTS (SELECT 1 FROM messages m WHERE m.profile_url = c.profile_url AND m.replied_at IS NOT NULL))
+	            AND NOT EXISTS (SELECT 1 FROM lead_list_saves l WHERE l.profile_url = c.profile_url AND l.list_name = ?)
+	            AND ` + notArchived("c.")
+
+	rows, err := s.db.Query(query, list)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lead list candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var out []ConnectionRequest
+	for rows.Next() {
+		var c ConnectionRequest
+		if err := rows.Scan(&c.ProfileURL, &c.Name, &c.Source, &c.Campaign); err != nil {
+			return nil, fmt.Errorf("failed to scan lead list candidate: %w", err)
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// AcquireLease takes the named lease if it is free, expired, or already held by holder
+func (s *Store) AcquireLease(name, holder string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	query := `INSERT INTO leases (name, holder, expires_at) VALUES (?, ?, ?)
+	          ON CONFLICT(name) DO UPDATE SET holder = excluded.holder, expires_at = excluded.expires_at
+	          WHERE leases.holder = excluded.holder OR leases.expires_at < ?`
+
+	res, err := s.db.Exec(query, name, holder, sqlTime(now.Add(ttl)), sqlTime(now))
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// ReleaseLease frees the lease and stamps the time of the action performed under it
+func (s *Store) ReleaseLease(name, holder string) error {
+	now := sqlTime(time.Now())
+	query := `UPDATE leases SET expires_at = ?, last_action_at = ? WHERE name = ? AND holder = ?`
+	if _, err := s.db.Exec(query, now, now, name, holder); err != nil {
+		return fmt.Errorf("failed to release lease: %w", err)
+	}
+	return nil
+}
+
+// GetLeaseLastAction returns when an action was last performed under the lease
+func (s *Store) GetLeaseLastAction(name string) (time.Time, error) {
+	var last sql.NullTime
+	err := s.db.QueryRow(`SELECT last_action_at FROM leases WHERE name = ?`, name).Scan(&last)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read lease: %w", err)
+	}
+	return last.Time, nil
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// LimiterAction is an action the rate limiter counted against its budgets
+type LimiterAction struct {
+	Action string
+	At     time.Time
+}
+
+// RecordLimiterAction saves an action the rate limiter counted, so the next
+// process starts with the same hourly and daily budgets. Rows older than a
+// day no longer count toward any budget and are dropped
+func (s *Store) RecordLimiterAction(action string, at time.Time) error {
+	query := `INSERT INTO limiter_actions (action, performed_at) VALUES (?, ?)`
+	if _, err := s.db.Exec(query, action, sqlTime(at)); err != nil {
+		return fmt.Errorf("failed to record limiter action: %w", err)
+	}
+
+	cutoff := sqlTime(at.Add(-24 * time.Hour))
+	if _, err := s.db.Exec(`DELETE FROM limiter_actions WHERE performed_at < ?`, cutoff); err != nil {
+		return fmt.Errorf("failed to prune limiter actions: %w", err)
+	}
+	return nil
+}
+
+// GetLimiterActionsSince returns the actions counted since the given time, oldest first
+func (s *Store) GetLimiterActionsSince(since time.Time) ([]LimiterAction, error) {
+	query := `SELECT action, performed_at FROM limiter_actions WHERE performed_at >= ? ORDER BY performed_at, id`
+	rows, err := s.db.Query(query, sqlTime(since))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get limiter actions: %w", err)
+	}
+	defer rows.Close()
+
+	var actions []LimiterAction
+	for rows.Next() {
+		var a LimiterAction
+		if err := rows.Scan(&a.Action, &a.At); err != nil {
+			return nil, err
+		}
+		actions = append(actions, a)
+	}
+	return actions, rows.Err()
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+type TrackedLink struct {
+	Token      string
+	ProfileURL string
+	Target     string
+}
+
+// SaveTrackedLink records token as the redirect to target for the contact;
+// queueID is the queued message it was made for (0 = none)
+func (s *Store) SaveTrackedLink(token, profileURL, target string, queueID int64) error {
+	query := `INSERT INTO tracked_links (token, profile_url, target_url, queue_id) VALUES (?, ?, ?, ?)`
+	if _, err := s.db.Exec(query, token, profileURL, target, sql.NullInt64{Int64: queueID, Valid: queueID != 0}); err != nil {
+		return fmt.Errorf("failed to save tracked link: %w", err)
+	}
+	return nil
+}
+
+// GetTrackedLinkToken returns the token already made for target in the
+// queued message, or "" when there is none
+func (s *Store) GetTrackedLinkToken(queueID int64, target string) (string, error) {
+	var token string
+	query := `SELECT token FROM tracked_links WHERE queue_id = ? AND target_url = ? ORDER BY created_at LIMIT 1`
+	err := s.db.QueryRow(query, queueID, target).Scan(&token)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get tracked link: %w", err)
+	}
+	return token, nil
+}
+
+func (s *Store) GetTrackedLink(token string) (TrackedLink, error) {
+	link := TrackedLink{Token: token}
+	query := `SELECT profile_url, target_url FROM tracked_links WHERE token = ?`
+	err := s.db.QueryRow(query, token).Scan(&link.ProfileURL, &link.Target)
+	if err == sql.ErrNoRows {
+		return link, nil
+	}
+	return link, err
+}
+
+func (s *Store) RecordLinkClick(token, userAgent string) error {
+	query := `INSERT INTO link_clicks (token, user_agent) VALUES (?, ?)`
+	if _, err := s.db.Exec(query, token, userAgent); err != nil {
+		return fmt.Errorf("failed to record link click: %w", err)
+	}
+	return nil
+}
+
+// GetLinkStats counts contacts who received a tracked link and how many clicked one
+func (s *Store) GetLinkStats() (contacts, clicked int, err error) {
+	query := `SELECT COUNT(DISTINCT t.profile_url),
+	                 COUNT(DISTINCT CASE WHEN c.token IS NOT NULL THEN t.profile_url END)
+	          FROM tracked_links t LEFT JOIN link_clicks c ON c.token = t.token`
+	if err := s.db.QueryRow(query).Scan(&contacts, &clicked); err != nil {
+		return 0, 0, fmt.Errorf("failed to get link stats: %w", err)
+	}
+	return contacts, clicked, nil
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaintenanceResult reports what Maintain did
+type MaintenanceResult struct {
+	// Pruned is the number of action_log rows deleted
+	Pruned     int64
+	SizeBefore int64
+	SizeAfter  int64
+}
+
+// Maintain deletes action_log rows from before the cutoff (none when it is
+// zero), then VACUUMs to give the space back and ANALYZEs so the query
+// planner has fresh statistics
+func (s *Store) Maintain(before time.Time) (MaintenanceResult, error) {
+	var res MaintenanceResult
+	var err error
+	if res.SizeBefore, err = s.size(); err != nil {
+		return res, err
+	}
+
+	if !before.IsZero() {
+		r, err := s.db.Exec(`DELETE FROM action_log WHERE created_at < ?`, sqlTime(before))
+		if err != nil {
+			return res, fmt.Errorf("failed to prune action log: %w", err)
+		}
+		res.Pruned, _ = r.RowsAffected()
+	}
+
+	if _, err := s.db.Exec(`VACUUM`); err != nil {
+		return res, fmt.Errorf("failed to vacuum database: %w", err)
+	}
+	if _, err := s.db.Exec(`ANALYZE`); err != nil {
+		return res, fmt.Errorf("failed to analyze database: %w", err)
+	}
+
+	if res.SizeAfter, err = s.size(); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// size returns the database size in bytes
+func (s *Store) size() (int64, error) {
+	var pages, pageSize int64
+	if err := s.db.QueryRow(`PRAGMA page_count`).Scan(&pages); err != nil {
+		return 0, fmt.Errorf("failed to read database size: %w", err)
+	}
+	if err := s.db.QueryRow(`PRAGMA page_size`).Scan(&pageSize); err != nil {
+		return 0, fmt.Errorf("failed to read database size: %w", err)
+	}
+	return pages * pageSize, nil
+}
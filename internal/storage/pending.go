@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// PendingAction is what a dry run would have done to a queued item
+type PendingAction struct {
+	ID         int64
+	Action     string
+	ProfileURL string
+	Name       string
+	Campaign   string
+	Payload    string
+	Detail     string
+	CreatedAt  time.Time
+}
+
+func (s *Store) SavePendingAction(a PendingAction) error {
+	query := `INSERT INTO pending_actions (action, profile_url, name, campaign, payload, detail) VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := s.db.Exec(query, a.Action, a.ProfileURL, a.Name, a.Campaign, a.Payload, a.Detail)
+	if err != nil {
+		return fmt.Errorf("failed to save pending action: %w", err)
+	}
+	return nil
+}
+
+// GetPendingActions returns the planned actions recorded since t, oldest first
+func (s *Store) GetPendingActions(since time.Time) ([]PendingAction, error) {
+	query := `SELECT id, action, COALESCE(profile_url, ''), COALESCE(name, ''), COALESCE(campaign, ''),
+	                 COALESCE(payload, ''), COALESCE(detail, ''), created_at
+	          FROM pending_actions WHERE created_at >= ? ORDER BY id`
+
+	rows, err := s.db.Query(query, sqlTime(since))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending actions: %w", err)
+	}
+	defer rows.Close()
+
+	var actions []PendingAction
+	for rows.Next() {
+		var a PendingAction
+		if err := rows.Scan(&a.ID, &a.Action, &a.ProfileURL, &a.Name, &a.Campaign, &a.Payload, &a.Detail, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		actions = append(actions, a)
+	}
+	return actions, rows.Err()
+}
+
+// ClearPendingActions deletes every recorded plan and returns how many there were
+func (s *Store) ClearPendingActions() (int, error) {
+	res, err := s.db.Exec(`DELETE FROM pending_actions`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear pending actions: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// Snapshot periods
+const (
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// activeWindow is how recently a contact must have been messaged to count as
+// an active conversation
+const activeWindow = 14 * 24 * time.Hour
+
+// PipelineCounts is the state of the outreach pipeline at one point in time
+type PipelineCounts struct {
+	InvitesSent         int `json:"invites_sent"`
+	AwaitingAcceptance  int `json:"awaiting_acceptance"`
+	Accepted            int `json:"accepted"`
+	Messaged            int `json:"messaged"`
+	ActiveConversations int `json:"active_conversations"`
+	Queued              int `json:"queued"`
+	DeadLettered        int `json:"dead_lettered"`
+}
+
+// PipelineSnapshot is the pipeline as last recorded during one week or month
+type PipelineSnapshot struct {
+	Period      string
+	PeriodStart time.Time
+	TakenAt     time.Time
+	PipelineCounts
+}
+
+// PeriodStart returns the start of the week (Monday) or month containing t, in UTC
+func PeriodStart(period string, t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if period == PeriodMonth {
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+func (s *Store) GetPipelineCounts(now time.Time) (PipelineCounts, error) {
+	var c PipelineCounts
+	query := `SELECT
+		(SELECT COUNT(*) FROM connection_requests WHERE ` + notArchived("") + `),
+		(SELECT COUNT(*) FROM connection_requests WHERE accepted = 0 AND withdrawn_at IS NULL AND ` + notArchived("") + `),
+		(SELECT COUNT(*) FROM connection_requests WHERE accepted = 1 AND ` + notArchived("") + `),
+		(SELECT COUNT(DISTINCT profile_url) FROM messages),
+		(SELECT COUNT(DISTINCT profile_url) FROM messages WHERE sent_at >= ?),
+		(SELECT COUNT(*) FROM queue WHERE status = ? AND ` + notArchived("") + `),
+		(SELECT COUNT(*) FROM queue WHERE status = ? AND ` + notArchived("") + `)`
+
+	err := s.db.QueryRow(query, sqlTime(now.Add(-activeWindow)), QueueStatusPending, QueueStatusDead).Scan(
+		&c.InvitesSent, &c.AwaitingAcceptance, &c.Accepted, &c.Messaged, &c.ActiveConversations, &c.Queued, &c.DeadLettered)
+	if err != nil {
+		return c, fmt.Errorf("failed to get pipeline counts: %w", err)
+	}
+	return c, nil
+}
+
+// SnapshotPipeline records the current counts for the week and the month
+// containing now. Later snapshots in the same period overwrite earlier ones,
+// so each row ends up holding the state at the period's last run
+func (s *Store) SnapshotPipeline(now time.Time) error {
+	counts, err := s.GetPipelineCounts(now)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT OR REPLACE INTO pipeline_snapshots (period, period_start, invites_sent, awaiting_acceptance,
+	          accepted, messaged, active_conversations, queued, dead_lettered, taken_at)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	for _, period := range []string{PeriodWeek, PeriodMonth} {
+		if _, err := s.db.Exec(query, period, PeriodStart(period, now).Format("2006-01-02"),
+			counts.InvitesSent, counts.AwaitingAcceptance, counts.Accepted, counts.Messaged,
+			counts.ActiveConversations, counts.Queued, counts.DeadLettered, sqlTime(now)); err != nil {
+			return fmt.Errorf("failed to save pipeline snapshot: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetPipelineSnapshots returns the most recent snapshots for a period, oldest first
+func (s *Store) GetPipelineSnapshots(period string, limit int) ([]PipelineSnapshot, error) {
+	query := `SELECT period, period_start, taken_at, invites_sent, awaiting_acceptance, accepted, messaged,
+	                 active_conversations, queued, dead_lettered
+	          FROM (SELECT * FROM pipeline_snapshots WHERE period = ? ORDER BY period_start DESC LIMIT ?)
+	          ORDER BY period_start`
+
+	rows, err := s.db.Query(query, period, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pipeline snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var out []PipelineSnapshot
+	for rows.Next() {
+		var p PipelineSnapshot
+		var start string
+		if err := rows.Scan(&p.Period, &start, &p.TakenAt, &p.InvitesSent, &p.AwaitingAcceptance, &p.Accepted,
+			&p.Messaged, &p.ActiveConversations, &p.Queued, &p.DeadLettered); err != nil {
+			return nil, fmt.Errorf("failed to scan pipeline snapshot: %w", err)
+		}
+		p.PeriodStart, _ = time.Parse("2006-01-02", start)
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ProfileRecord is a scraped profile; the searchable fields have columns of
+// their own and Data holds the full details as JSON
+type ProfileRecord struct {
+	ProfileURL string
+	Name       string
+	Headline   string
+	Company    string
+	Data       string
+	ScrapedAt  time.Time
+}
+
+// SaveProfile stores a profile, replacing an earlier scrape
+func (s *Store) SaveProfile(p ProfileRecord) error {
+	query := `INSERT INTO profiles (profile_url, name, headline, company, data, scraped_at) VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	          ON CONFLICT(profile_url) DO UPDATE SET name = excluded.name, headline = excluded.headline,
+	          company = excluded.company, data = excluded.data, scraped_at = CURRENT_TIMESTAMP`
+	if _, err := s.db.Exec(query, p.ProfileURL, p.Name, p.Headline, p.Company, p.Data); err != nil {
+		return fmt.Errorf("failed to save profile: %w", err)
+	}
+	return nil
+}
+
+// GetProfile returns the stored profile, or nil when it was never scraped
+func (s *Store) GetProfile(profileURL string) (*ProfileRecord, error) {
+	query := `SELECT profile_url, name, headline, company, data, scraped_at FROM profiles WHERE profile_url = ?`
+	var p ProfileRecord
+	err := s.db.QueryRow(query, profileURL).Scan(&p.ProfileURL, &p.Name, &p.Headline, &p.Company, &p.Data, &p.ScrapedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get profile: %w", err)
+	}
+	return &p, nil
+}
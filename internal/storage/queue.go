@@ -0,0 +1,279 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// Queue actions
+const (
+	QueueActionConnect = "connect"
+	QueueActionMessage = "message"
+	// QueueActionBroadcast messages existing connections picked by a segment filter
+	QueueActionBroadcast = "broadcast"
+	// QueueActionBump nudges a contact who read a follow-up and did not reply
+	QueueActionBump = "bump"
+	// QueueActionFollow follows a profile or page; QueueActionUnfollow undoes it
+	QueueActionFollow   = "follow"
+	QueueActionUnfollow = "unfollow"
+	// QueueActionSaveLead saves a prospect to the Sales Navigator lead list in the payload
+	QueueActionSaveLead = "save_lead"
+	// QueueActionEventInvite invites a connection to an event; the payload is the event URL
+	QueueActionEventInvite = "event_invite"
+	// QueueActionCongrats congratulates a campaign's connection on a new job
+	QueueActionCongrats = "congrats"
+)
+
+// Queue statuses
+const (
+	QueueStatusPending = "pending"
+	QueueStatusDone    = "done"
+	// QueueStatusReview items wait for explicit approval before they become pending
+	QueueStatusReview = "review"
+	// QueueStatusDead items failed too often; they are kept for inspection but never retried
+	QueueStatusDead = "dead"
+)
+
+// sqlTimeLayout matches the format SQLite uses for CURRENT_TIMESTAMP
+const sqlTimeLayout = "2006-01-02 15:04:05"
+
+type QueueItem struct {
+	ID         int64
+	Campaign   string
+	Action     string
+	ProfileURL string
+	Name       string
+	Payload    string
+	Source     string
+	// SendWindow overrides messaging.send_window for this item, as the JSON
+	// of a config.SendWindowConfig; empty uses the global window
+	SendWindow string
+	NotBefore  time.Time
+	Priority   int
+	Attempts   int
+	Status     string
+	LastError  string
+	CreatedAt  time.Time
+}
+
+func sqlTime(t time.Time) string {
+	return t.UTC().Format(sqlTimeLayout)
+}
+
+func (s *Store) Enqueue(item QueueItem) error {
+	if item.Campaign == "" {
+		item.Campaign = "default"
+	}
+	if item.NotBefore.IsZero() {
+		item.NotBefore = time.Now()
+	}
+	if item.Status == "" {
+		item.Status = QueueStatusPending
+	}
+
+	query := `INSERT INTO queue (campaign, action, profile_url, name, payload, source, send_window, not_before, priority, status)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	          ON CONFLICT(campaign, action, profile_url) DO NOTHING`
+	_, err := s.db.Exec(query, item.Campaign, item.Action, item.ProfileURL, item.Name, item.Payload,
+		item.Source, item.SendWindow, sqlTime(item.NotBefore), item.Priority, item.Status)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue item: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) GetDueQueueItems(action string, now time.Time, limit int) ([]QueueItem, error) {
+	query := `SELECT id, campaign, action, profile_url, name, payload, COALESCE(source, ''), COALESCE(send_window, ''), not_before, priority, attempts, status, COALESCE(last_error, ''), created_at
+	          FROM queue WHERE action = ? AND status = ? AND not_before <= ? AND ` + notArchived("") + `
+	          ORDER BY priority DESC, not_before ASC LIMIT ?`
+
+	rows, err := s.db.Query(query, action, QueueStatusPending, sqlTime(now), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due queue items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []QueueItem
+	for rows.Next() {
+		var it QueueItem
+		if err := rows.Scan(&it.ID, &it.Campaign, &it.Action, &it.ProfileURL, &it.Name, &it.Payload, &it.Source,
+			&it.SendWindow, &it.NotBefore, &it.Priority, &it.Attempts, &it.Status, &it.LastError, &it.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, it)
+	}
+
+	return items, rows.Err()
+}
+
+func (s *Store) MarkQueueItemDone(id int64) error {
+	query := `UPDATE queue SET status = ?, attempts = attempts + 1, done_at = CURRENT_TIMESTAMP WHERE id = ?`
+	_, err := s.db.Exec(query, QueueStatusDone, id)
+	return err
+}
+
+// RecordQueueFailure keeps the item pending so it is retried on the next run,
+// or moves it to the dead-letter state once maxAttempts is reached
+func (s *Store) RecordQueueFailure(id int64, reason string, maxAttempts int) (dead bool, err error) {
+	query := `UPDATE queue SET attempts = attempts + 1, last_error = ?,
+	          status = CASE WHEN ? > 0 AND attempts + 1 >= ? THEN ? ELSE status END
+	          WHERE id = ?`
+	if _, err := s.db.Exec(query, reason, maxAttempts, maxAttempts, QueueStatusDead, id); err != nil {
+		return false, fmt.Errorf("failed to record queue failure: %w", err)
+	}
+
+	var status string
+	if err := s.db.QueryRow(`SELECT status FROM queue WHERE id = ?`, id).Scan(&status); err != nil {
+		return false, err
+	}
+	return status == QueueStatusDead, nil
+}
+
+func (s *Store) GetDeadLetters() ([]QueueItem, error) {
+	query := `SELECT id, campaign, action, profile_url, name, payload, COALESCE(source, ''), not_before, priority, attempts, status, COALESCE(last_error, ''), created_at
+	          FROM queue WHERE status = ? ORDER BY created_at`
+
+	rows, err := s.db.Query(query, QueueStatusDead)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	var items []QueueItem
+	for rows.Next() {
+		var it QueueItem
+		if err := rows.Scan(&it.ID, &it.Campaign, &it.Action, &it.ProfileURL, &it.Name, &it.Payload, &it.Source,
+			&it.NotBefore, &it.Priority, &it.Attempts, &it.Status, &it.LastError, &it.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, it)
+	}
+	return items, rows.Err()
+}
+
+// RequeueDeadLetter gives a dead item a fresh set of attempts
+func (s *Store) RequeueDeadLetter(id int64) error {
+	res, err := s.db.Exec(`UPDATE queue SET status = ?, attempts = 0, not_before = ? WHERE id = ? AND status = ?`,
+		QueueStatusPending, sqlTime(time.Now()), id, QueueStatusDead)
+	if err != nil {
+		return fmt.Errorf("failed to requeue item: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("no dead-letter item with id %d", id)
+	}
+	return nil
+}
+
+func (s *Store) RescheduleQueueItem(id int64, notBefore time.Time) error {
+	query := `UPDATE queue SET not_before = ? WHERE id = ?`
+	_, err := s.db.Exec(query, sqlTime(notBefore), id)
+	return err
+}
+
+func (s *Store) ListQueueItems(campaign, action, status string) ([]QueueItem, error) {
+	query := `SELECT id, campaign, action, profile_url, name, payload, COALESCE(source, ''), not_before, priority, attempts, status, COALESCE(last_error, ''), created_at
+	          FROM queue WHERE campaign = ? AND action = ? AND status = ? ORDER BY id`
+
+	rows, err := s.db.Query(query, campaign, action, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queue items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []QueueItem
+	for rows.Next() {
+		var it QueueItem
+		if err := rows.Scan(&it.ID, &it.Campaign, &it.Action, &it.ProfileURL, &it.Name, &it.Payload, &it.Source,
+			&it.NotBefore, &it.Priority, &it.Attempts, &it.Status, &it.LastError, &it.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, it)
+	}
+	return items, rows.Err()
+}
+
+// SetQueueStatus moves every item of a campaign and action from one status to another
+func (s *Store) SetQueueStatus(campaign, action, from, to string) (int64, error) {
+	query := `UPDATE queue SET status = ? WHERE campaign = ? AND action = ? AND status = ?`
+	res, err := s.db.Exec(query, to, campaign, action, from)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update queue status: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// DeleteQueueItems removes items of a campaign and action in the given status;
+// id 0 removes all of them
+func (s *Store) DeleteQueueItems(campaign, action, status string, id int64) (int64, error) {
+	query := `DELETE FROM queue WHERE campaign = ? AND action = ? AND status = ? AND (? = 0 OR id = ?)`
+	res, err := s.db.Exec(query, campaign, action, status, id, id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete queue items: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// GetOpenQueueItems returns pending and review items in the order the worker
+// takes them, optionally for one campaign and action; limit 0 returns all
+func (s *Store) GetOpenQueueItems(campaign, action string, limit int) ([]QueueItem, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	query := `SELECT id, campaign, action, profile_url, name, payload, COALESCE(source, ''), not_before, priority, attempts, status, COALESCE(last_error, ''), created_at
+	          FROM queue WHERE status IN (?, ?) AND (? = '' OR campaign = ?) AND (? = '' OR action = ?)
+	          ORDER BY status, priority DESC, not_before ASC LIMIT ?`
+
+	rows, err := s.db.Query(query, QueueStatusPending, QueueStatusReview, campaign, campaign, action, action, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queue items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []QueueItem
+	for rows.Next() {
+		var it QueueItem
+		if err := rows.Scan(&it.ID, &it.Campaign, &it.Action, &it.ProfileURL, &it.Name, &it.Payload, &it.Source,
+			&it.NotBefore, &it.Priority, &it.Attempts, &it.Status, &it.LastError, &it.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, it)
+	}
+	return items, rows.Err()
+}
+
+// RemoveQueuedProfile deletes a profile's unfinished items (pending, review
+// and dead), in every campaign when campaign is empty. Done items stay as
+// the record that the action happened
+func (s *Store) RemoveQueuedProfile(profileURL, campaign string) (int64, error) {
+	query := `DELETE FROM queue WHERE profile_url = ? AND status != ? AND (? = '' OR campaign = ?)`
+	res, err := s.db.Exec(query, profileURL, QueueStatusDone, campaign, campaign)
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove queue items: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// BumpQueuedProfile moves a profile's pending items to the front of the
+// queue: above every other item's priority, and due now
+func (s *Store) BumpQueuedProfile(profileURL string) (int64, error) {
+	query := `UPDATE queue SET
+	              priority = (SELECT COALESCE(MAX(priority), 0) + 1 FROM queue WHERE status = ?),
+	              not_before = MIN(not_before, ?)
+	          WHERE profile_url = ? AND status = ?`
+	res, err := s.db.Exec(query, QueueStatusPending, sqlTime(time.Now()), profileURL, QueueStatusPending)
+	if err != nil {
+		return 0, fmt.Errorf("failed to bump queue items: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// ClearQueue deletes a campaign's pending and review items, optionally of
+// one action only
+func (s *Store) ClearQueue(campaign, action string) (int64, error) {
+	query := `DELETE FROM queue WHERE campaign = ? AND status IN (?, ?) AND (? = '' OR action = ?)`
+	res, err := s.db.Exec(query, campaign, QueueStatusPending, QueueStatusReview, action, action)
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear queue: %w", err)
+	}
+	return res.RowsAffected()
+}
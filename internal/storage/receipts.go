@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ReceiptCheck is a sent message whose read and reply status is still open
+type ReceiptCheck struct {
+	Message
+	Name string
+	Seen bool
+}
+
+// ReadStats summarises read receipts across sent messages
+type ReadStats struct {
+	Sent    int `json:"sent"`
+	Seen    int `json:"seen"`
+	Replied int `json:"replied"`
+	// SeenNoReply were read but have had no answer
+	SeenNoReply int `json:"seen_no_reply"`
+}
+
+// GetReceiptChecks returns messages sent since since that have no reply
+// recorded and were not checked within recheck, least recently checked first
+func (s *Store) GetReceiptChecks(since time.Time, recheck time.Duration, limit int) ([]ReceiptCheck, error) {
+	query := `SELECT m.id, m.profile_url, m.content, m.sent_at, m.seen_at IS NOT NULL,
+	                 COALESCE((SELECT name FROM connection_requests c WHERE c.profile_url = m.profile_url LIMIT 1), '')
+	          FROM messages m
+	          WHERE m.sent_at >= ? AND m.replied_at IS NULL AND (m.checked_at IS NULL OR m.checked_at < ?)
+	          ORDER BY COALESCE(m.checked_at, m.sent_at) ASC LIMIT ?`
+
+	rows, err := s.db.Query(query, sqlTime(since), sqlTime(time.Now().Add(-recheck)), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receipt checks: %w", err)
+	}
+	defer rows.Close()
+
+	var out []ReceiptCheck
+	for rows.Next() {
+		var c ReceiptCheck
+		if err := rows.Scan(&c.ID, &c.ProfileURL, &c.Content, &c.SentAt, &c.Seen, &c.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan receipt check: %w", err)
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// UpdateReceipt records what a thread showed for a sent message; seen and
+// replied times are kept from their first sighting
+func (s *Store) UpdateReceipt(id int64, seenAt, repliedAt time.Time) error {
+	query := `UPDATE messages SET checked_at = ?,
+	          seen_at = COALESCE(seen_at, ?), replied_at = COALESCE(replied_at, ?) WHERE id = ?`
+	if _, err := s.db.Exec(query, sqlTime(time.Now()), nullTime(seenAt), nullTime(repliedAt), id); err != nil {
+		return fmt.Errorf("failed to update read receipt: %w", err)
+	}
+	return nil
+}
+
+// GetLastReplyAt returns when the prospect last replied to a sent message,
+// or the zero time when they never did
+func (s *Store) GetLastReplyAt(profileURL string) (time.Time, error) {
+	// Aggregates lose the column's DATETIME type
+	var last sql.NullString
+	query := `SELECT MAX(replied_at) FROM messages WHERE profile_url = ?`
+	if err := s.db.QueryRow(query, profileURL).Scan(&last); err != nil {
+		return time.Time{}, fmt.Errorf("failed to get last reply: %w", err)
+	}
+	if !last.Valid {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(sqlTimeLayout, last.String)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse last reply time: %w", err)
+	}
+	return t, nil
+}
+
+// GetBumpCandidates returns follow-ups seen before seenBefore that got no
+// reply, for contacts not yet bumped
+func (s *Store) GetBumpCandidates(seenBefore time.Time) ([]ConnectionRequest, error) {
+	query := `SELECT DISTINCT m.profile_url, COALESCE(c.name, ''), COALESCE(c.source, ''), COALESCE(c.campaign, 'default')
+	          FROM messages m LEFT JOIN connection_requests c ON c.profile_url = m.profile_url
+	          WHERE m.kind = ? AND m.seen_at IS NOT NULL AND m.seen_at <= ? AND m.replied_at IS NULL
+	            AND NOT EXISTS (SELECT 1 FROM messages b WHERE b.profile_url = m.profile_url AND b.kind = ?)
+	            AND NOT EXISTS (SELECT 1 FROM messages r WHERE r.profile_url = m.profile_url AND r.replied_at IS NOT NULL)`
+
+	rows, err := s.db.Query(query, MessageKindFollowUp, sqlTime(seenBefore), MessageKindBump)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bump candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var out []ConnectionRequest
+	for rows.Next() {
+		var c ConnectionRequest
+		if err := rows.Scan(&c.ProfileURL, &c.Name, &c.Source, &c.Campaign); err != nil {
+			return nil, fmt.Errorf("failed to scan bump candidate: %w", err)
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) GetReadStats() (ReadStats, error) {
+	var st ReadStats
+	query := `SELECT COUNT(*),
+	                 COUNT(seen_at),
+	                 COUNT(replied_at),
+	                 COALESCE(SUM(seen_at IS NOT NULL AND replied_at IS NULL), 0)
+	          FROM messages`
+	if err := s.db.QueryRow(query).Scan(&st.Sent, &st.Seen, &st.Replied, &st.SeenNoReply); err != nil {
+		return st, fmt.Errorf("failed to get read stats: %w", err)
+	}
+	return st, nil
+}
+
+// nullTime stores the zero time as NULL
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return sql.NullString{}
+	}
+	return sqlTime(t)
+}
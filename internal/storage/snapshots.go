@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+func (s *Store) SaveProfileSnapshot(data string) error {
+	if _, err := s.db.Exec(`INSERT INTO profile_snapshots (data) VALUES (?)`, data); err != nil {
+		return fmt.Errorf("failed to save profile snapshot: %w", err)
+	}
+	return nil
+}
+
+// GetLatestProfileSnapshot returns the newest snapshot, or empty data when there is none
+func (s *Store) GetLatestProfileSnapshot() (string, time.Time, error) {
+	var data string
+	var takenAt time.Time
+	err := s.db.QueryRow(`SELECT data, taken_at FROM profile_snapshots ORDER BY id DESC LIMIT 1`).Scan(&data, &takenAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", time.Time{}, nil
+	}
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to get profile snapshot: %w", err)
+	}
+	return data, takenAt, nil
+}
@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Account state keys
+const (
+	StateInviteLimitUntil = "invite_limit_until"
+	StateCapabilities     = "capabilities"
+	StateSearchLimitUntil = "search_limit_until"
+	// StateNoteAllowanceUntil is when LinkedIn lets the account personalize
+	// invitations again after it reported the monthly allowance used up
+	StateNoteAllowanceUntil = "note_allowance_until"
+	// StateBuildVersion is the last build that completed a run
+	StateBuildVersion = "build_version"
+	// StateLoginEmail is the email the last credential rotation logged in with
+	StateLoginEmail = "login_email"
+	// StateLimiterCooldownUntil is when the rate limiter's last cooldown ends
+	StateLimiterCooldownUntil = "limiter_cooldown_until"
+	// StateWarmUpStarted is when the account's warm-up began
+	StateWarmUpStarted = "warmup_started"
+	// StateAccountFirstSeen is when the tool first ran for the account
+	StateAccountFirstSeen = "account_first_seen"
+	// StateCooldownLevel is how far repeated challenges have escalated the
+	// mandatory cooldown, StateCooldownUntil when the current one ends and
+	// StateCooldownSince when the level last changed
+	StateCooldownLevel = "cooldown_level"
+	StateCooldownUntil = "cooldown_until"
+	StateCooldownSince = "cooldown_since"
+	// StateSearchCheckpoint is the search and result page a stopped run
+	// reached, for the next run of the same search to resume at
+	StateSearchCheckpoint = "search_checkpoint"
+)
+
+// Incident kinds
+const (
+	IncidentInviteLimit  = "invite_limit"
+	IncidentSearchLimit  = "search_limit"
+	IncidentNoteLimit    = "note_limit"
+	IncidentSelfAudit    = "self_audit"
+	IncidentBrowserCrash = "browser_crash"
+	IncidentChallenge    = "challenge"
+	// Warning banners, restriction pages, checkpoint redirects and HTTP 429
+	// responses; see internal/safety
+	IncidentWarning     = "warning"
+	IncidentRestriction = "restriction"
+	IncidentCheckpoint  = "checkpoint"
+	IncidentThrottled   = "throttled"
+)
+
+func (s *Store) SetState(key, value string) error {
+	query := `INSERT INTO account_state (key, value, updated_at) VALUES (?, ?, CURRENT_TIMESTAMP)
+	          ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = CURRENT_TIMESTAMP`
+	if _, err := s.db.Exec(query, key, value); err != nil {
+		return fmt.Errorf("failed to set state %s: %w", key, err)
+	}
+	return nil
+}
+
+// GetState returns the stored value, or "" when the key is not set
+func (s *Store) GetState(key string) (string, error) {
+	var value string
+	err := s.db.QueryRow(`SELECT value FROM account_state WHERE key = ?`, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get state %s: %w", key, err)
+	}
+	return value, nil
+}
+
+func (s *Store) SetStateTime(key string, t time.Time) error {
+	return s.SetState(key, t.UTC().Format(time.RFC3339))
+}
+
+// GetStateTime returns the stored time, or the zero time when unset
+func (s *Store) GetStateTime(key string) (time.Time, error) {
+	value, err := s.GetState(key)
+	if err != nil || value == "" {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func (s *Store) RecordIncident(kind, detail string) error {
+	query := `INSERT INTO incidents (kind, detail) VALUES (?, ?)`
+	if _, err := s.db.Exec(query, kind, detail); err != nil {
+		return fmt.Errorf("failed to record incident: %w", err)
+	}
+	return nil
+}
+
+// CountIncidentsSince counts incidents by kind that occurred at or after since
+func (s *Store) CountIncidentsSince(since time.Time) (map[string]int, error) {
+	query := `SELECT kind, COUNT(*) FROM incidents WHERE occurred_at >= ? GROUP BY kind`
+	rows, err := s.db.Query(query, sqlTime(since))
+	if err != nil {
+		return nil, fmt.Errorf("failed to count incidents: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var kind string
+		var n int
+		if err := rows.Scan(&kind, &n); err != nil {
+			return nil, fmt.Errorf("failed to scan incident count: %w", err)
+		}
+		counts[kind] = n
+	}
+	return counts, rows.Err()
+}
+
+// GetLastIncident returns when the latest incident of the given kinds
+// occurred, or the zero time when there was none
+func (s *Store) GetLastIncident(kinds ...string) (time.Time, error) {
+	if len(kinds) == 0 {
+		return time.Time{}, nil
+	}
+	query := `SELECT MAX(occurred_at) FROM incidents WHERE kind IN (?` + strings.Repeat(", ?", len(kinds)-1) + `)`
+	args := make([]any, len(kinds))
+	for i, k := range kinds {
+		args[i] = k
+	}
+	// Aggregates lose the column's DATETIME type
+	var last sql.NullString
+	if err := s.db.QueryRow(query, args...).Scan(&last); err != nil {
+		return time.Time{}, fmt.Errorf("failed to get last incident: %w", err)
+	}
+	if !last.Valid {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(sqlTimeLayout, last.String)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse last incident time: %w", err)
+	}
+	return t, nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	_ "modernc.org/sqlite"
@@ -21,8 +22,34 @@ type ConnectionRequest struct {
 	SentAt     time.Time
 	Accepted   bool
 	Note       string
+	Source     string
+	Campaign   string
+	// External requests were found pending on LinkedIn, sent outside the
+	// tool at an unknown time; they are kept out of budgets and statistics
+	External bool
+	// NoteMode records how the invitation went out, one of the NoteMode constants
+	NoteMode string
 }
 
+// Note modes of a connection request
+const (
+	NoteModeNote = "note"
+	NoteModeNone = "none"
+	// NoteModeFallback went without the note it was queued with, because the
+	// month's allowance of personalized invitations was used up
+	NoteModeFallback = "fallback"
+)
+
+// Message kinds
+const (
+	MessageKindFollowUp  = "follow_up"
+	MessageKindBroadcast = "broadcast"
+	// MessageKindBump nudges after a follow-up was seen but not answered
+	MessageKindBump = "bump"
+	// MessageKindCongrats answers a job change a tracked contact made
+	MessageKindCongrats = "congrats"
+)
+
 type Message struct {
 	ID         int64
 	ProfileURL string
@@ -37,7 +64,8 @@ func New(dbPath string) (*Store, error) {
 		return nil, fmt.Errorf("failed to create db directory: %w", err)
 	}
 
-	db, err := sql.Open("sqlite", dbPath)
+	// busy_timeout lets several processes share the database without SQLITE_BUSY errors
+	db, err := sql.Open("sqlite", dbPath+"?_pragma=busy_timeout(5000)")
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -46,6 +74,9 @@ func New(dbPath string) (*Store, error) {
 	if err := store.createTables(); err != nil {
 		return nil, err
 	}
+	if err := store.migrate(); err != nil {
+		return nil, err
+	}
 
 	return store, nil
 }
@@ -68,6 +99,223 @@ func (s *Store) createTables() error {
 		)`,
 		`CREATE INDEX IF NOT EXISTS idx_profile_url ON connection_requests(profile_url)`,
 		`CREATE INDEX IF NOT EXISTS idx_sent_at ON connection_requests(sent_at)`,
+		`CREATE TABLE IF NOT EXISTS queue (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			campaign TEXT NOT NULL DEFAULT 'default',
+			action TEXT NOT NULL,
+			profile_url TEXT NOT NULL,
+			name TEXT,
+			payload TEXT,
+			not_before DATETIME DEFAULT CURRENT_TIMESTAMP,
+			priority INTEGER DEFAULT 0,
+			attempts INTEGER DEFAULT 0,
+			status TEXT DEFAULT 'pending',
+			last_error TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(campaign, action, profile_url)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_queue_due ON queue(status, not_before)`,
+		`CREATE TABLE IF NOT EXISTS action_log (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			action TEXT NOT NULL,
+			profile_url TEXT,
+			campaign TEXT,
+			outcome TEXT NOT NULL,
+			detail TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_action_log_created ON action_log(created_at)`,
+		`CREATE TABLE IF NOT EXISTS account_state (
+			key TEXT PRIMARY KEY,
+			value TEXT NOT NULL,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS incidents (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			kind TEXT NOT NULL,
+			detail TEXT,
+			occurred_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS tracked_links (
+			token TEXT PRIMARY KEY,
+			profile_url TEXT NOT NULL,
+			target_url TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS link_clicks (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			token TEXT NOT NULL,
+			user_agent TEXT,
+			clicked_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS contact_tags (
+			profile_url TEXT NOT NULL,
+			name TEXT,
+			tag TEXT NOT NULL,
+			added_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (profile_url, tag)
+		)`,
+		`CREATE TABLE IF NOT EXISTS tracked_contacts (
+			profile_url TEXT PRIMARY KEY,
+			name TEXT,
+			added_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			checked_at DATETIME
+		)`,
+		`CREATE TABLE IF NOT EXISTS job_changes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			profile_url TEXT NOT NULL,
+			name TEXT,
+			old_title TEXT,
+			new_title TEXT,
+			old_company TEXT,
+			new_company TEXT,
+			detected_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS job_change_reactions (
+			campaign TEXT NOT NULL,
+			change_id INTEGER NOT NULL,
+			queued_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (campaign, change_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS event_invites (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			event_url TEXT NOT NULL,
+			profile_url TEXT NOT NULL,
+			name TEXT,
+			invited_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(event_url, profile_url)
+		)`,
+		`CREATE TABLE IF NOT EXISTS follows (
+			target_url TEXT PRIMARY KEY,
+			name TEXT,
+			kind TEXT NOT NULL,
+			campaign TEXT DEFAULT 'default',
+			followed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			unfollowed_at DATETIME
+		)`,
+		`CREATE TABLE IF NOT EXISTS lead_list_saves (
+			profile_url TEXT NOT NULL,
+			list_name TEXT NOT NULL,
+			saved_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (profile_url, list_name)
+		)`,
+		`CREATE TABLE IF NOT EXISTS profile_snapshots (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			data TEXT NOT NULL,
+			taken_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS profiles (
+			profile_url TEXT PRIMARY KEY,
+			name TEXT DEFAULT '',
+			headline TEXT DEFAULT '',
+			company TEXT DEFAULT '',
+			data TEXT NOT NULL,
+			scraped_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS prospect_features (
+			profile_url TEXT PRIMARY KEY,
+			title_match REAL DEFAULT 0,
+			location_match REAL DEFAULT 0,
+			mutuals INTEGER DEFAULT 0,
+			open_to_work BOOLEAN DEFAULT 0,
+			has_note BOOLEAN DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS pipeline_snapshots (
+			period TEXT NOT NULL,
+			period_start TEXT NOT NULL,
+			invites_sent INTEGER DEFAULT 0,
+			awaiting_acceptance INTEGER DEFAULT 0,
+			accepted INTEGER DEFAULT 0,
+			messaged INTEGER DEFAULT 0,
+			active_conversations INTEGER DEFAULT 0,
+			queued INTEGER DEFAULT 0,
+			dead_lettered INTEGER DEFAULT 0,
+			taken_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (period, period_start)
+		)`,
+		`CREATE TABLE IF NOT EXISTS audit_log (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			operator TEXT NOT NULL,
+			action TEXT NOT NULL,
+			detail TEXT DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS campaigns (
+			name TEXT PRIMARY KEY,
+			started_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			last_run_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS campaign_prospects (
+			campaign TEXT NOT NULL,
+			profile_url TEXT NOT NULL,
+			name TEXT,
+			status TEXT NOT NULL,
+			step INTEGER DEFAULT 0,
+			next_at DATETIME,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (campaign, profile_url)
+		)`,
+		`CREATE TABLE IF NOT EXISTS archive (
+			kind TEXT NOT NULL,
+			key TEXT NOT NULL,
+			archived_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (kind, key)
+		)`,
+		`CREATE TABLE IF NOT EXISTS do_not_contact (
+			profile_url TEXT PRIMARY KEY,
+			reason TEXT,
+			added_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS leases (
+			name TEXT PRIMARY KEY,
+			holder TEXT NOT NULL,
+			expires_at DATETIME NOT NULL,
+			last_action_at DATETIME
+		)`,
+		`CREATE TABLE IF NOT EXISTS pending_actions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			action TEXT NOT NULL,
+			profile_url TEXT,
+			name TEXT,
+			campaign TEXT,
+			payload TEXT,
+			detail TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS limiter_actions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			action TEXT NOT NULL,
+			performed_at DATETIME NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_limiter_actions_performed ON limiter_actions(performed_at)`,
+		`CREATE TABLE IF NOT EXISTS email_matches (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			email TEXT NOT NULL,
+			name TEXT,
+			company TEXT,
+			profile_url TEXT NOT NULL DEFAULT '',
+			candidate TEXT,
+			headline TEXT,
+			confidence REAL NOT NULL DEFAULT 0,
+			status TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(email, profile_url)
+		)`,
+		`CREATE TABLE IF NOT EXISTS send_captures (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			action TEXT NOT NULL,
+			profile_url TEXT NOT NULL,
+			campaign TEXT,
+			dir TEXT NOT NULL,
+			before_url TEXT,
+			before_shot TEXT,
+			after_url TEXT,
+			after_shot TEXT,
+			outcome TEXT NOT NULL,
+			detail TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
 	}
 
 	for _, q := range queries {
@@ -79,9 +327,42 @@ func (s *Store) createTables() error {
 	return nil
 }
 
-func (s *Store) SaveConnectionRequest(profileURL, name, note string) error {
-	query := `INSERT INTO connection_requests (profile_url, name, note) VALUES (?, ?, ?)`
-	_, err := s.db.Exec(query, profileURL, name, note)
+// migrations add columns to tables created by older versions; they run on
+// every start and "duplicate column" errors mean they were already applied
+var migrations = []string{
+	`ALTER TABLE connection_requests ADD COLUMN source TEXT DEFAULT ''`,
+	`ALTER TABLE queue ADD COLUMN source TEXT DEFAULT ''`,
+	`ALTER TABLE connection_requests ADD COLUMN campaign TEXT DEFAULT 'default'`,
+	`ALTER TABLE messages ADD COLUMN kind TEXT DEFAULT 'follow_up'`,
+	`ALTER TABLE messages ADD COLUMN seen_at DATETIME`,
+	`ALTER TABLE messages ADD COLUMN replied_at DATETIME`,
+	`ALTER TABLE messages ADD COLUMN checked_at DATETIME`,
+	`ALTER TABLE connection_requests ADD COLUMN external BOOLEAN DEFAULT 0`,
+	`ALTER TABLE connection_requests ADD COLUMN withdrawn_at DATETIME`,
+	`ALTER TABLE connection_requests ADD COLUMN note_mode TEXT DEFAULT ''`,
+	`ALTER TABLE connection_requests ADD COLUMN accepted_at DATETIME`,
+	`ALTER TABLE connection_requests ADD COLUMN follow_up_due BOOLEAN DEFAULT 0`,
+	`ALTER TABLE queue ADD COLUMN done_at DATETIME`,
+	`ALTER TABLE queue ADD COLUMN send_window TEXT DEFAULT ''`,
+	`ALTER TABLE tracked_links ADD COLUMN queue_id INTEGER`,
+}
+
+func (s *Store) migrate() error {
+	for _, q := range migrations {
+		if _, err := s.db.Exec(q); err != nil && !strings.Contains(err.Error(), "duplicate column") {
+			return fmt.Errorf("failed to migrate schema: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *Store) SaveConnectionRequest(req ConnectionRequest) error {
+	if req.Campaign == "" {
+		req.Campaign = "default"
+	}
+
+	query := `INSERT INTO connection_requests (profile_url, name, note, source, campaign, external, note_mode) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err := s.db.Exec(query, req.ProfileURL, req.Name, req.Note, req.Source, req.Campaign, req.External, req.NoteMode)
 	if err != nil {
 		return fmt.Errorf("failed to save connection request: %w", err)
 	}
@@ -98,8 +379,22 @@ func (s *Store) IsConnectionSent(profileURL string) (bool, error) {
 	return count > 0, nil
 }
 
+// HasConnectionRequest reports whether a request went to the profile, whatever
+// query string or trailing slash its URL was saved with
+func (s *Store) HasConnectionRequest(profileURL string) (bool, error) {
+	query := `SELECT COUNT(*) FROM connection_requests
+	          WHERE RTRIM(CASE WHEN INSTR(profile_url, '?') > 0 THEN SUBSTR(profile_url, 1, INSTR(profile_url, '?') - 1)
+	                           ELSE profile_url END, '/') = ?`
+	var count int
+	key := strings.TrimRight(strings.Split(profileURL, "?")[0], "/")
+	if err := s.db.QueryRow(query, key).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check connection: %w", err)
+	}
+	return count > 0, nil
+}
+
 func (s *Store) GetConnectionsCountToday() (int, error) {
-	query := `SELECT COUNT(*) FROM connection_requests WHERE DATE(sent_at) = DATE('now')`
+	query := `SELECT COUNT(*) FROM connection_requests WHERE DATE(sent_at) = DATE('now') AND external = 0`
 	var count int
 	err := s.db.QueryRow(query).Scan(&count)
 	if err != nil {
@@ -108,9 +403,9 @@ func (s *Store) GetConnectionsCountToday() (int, error) {
 	return count, nil
 }
 
-func (s *Store) SaveMessage(profileURL, content string) error {
-	query := `INSERT INTO messages (profile_url, content) VALUES (?, ?)`
-	_, err := s.db.Exec(query, profileURL, content)
+func (s *Store) SaveMessage(profileURL, content, kind string) error {
+	query := `INSERT INTO messages (profile_url, content, kind) VALUES (?, ?, ?)`
+	_, err := s.db.Exec(query, profileURL, content, kind)
 	if err != nil {
 		return fmt.Errorf("failed to save message: %w", err)
 	}
@@ -127,15 +422,118 @@ func (s *Store) GetMessagesCountToday() (int, error) {
 	return count, nil
 }
 
+func (s *Store) GetMessagesCountTodayByKind(kind string) (int, error) {
+	query := `SELECT COUNT(*) FROM messages WHERE DATE(sent_at) = DATE('now') AND kind = ?`
+	var count int
+	err := s.db.QueryRow(query, kind).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get message count: %w", err)
+	}
+	return count, nil
+}
+
+// GetMessagesSince returns the messages sent to a profile since a point in time, newest first
+func (s *Store) GetMessagesSince(profileURL string, since time.Time) ([]Message, error) {
+	query := `SELECT id, profile_url, content, sent_at FROM messages
+	          WHERE profile_url = ? AND sent_at >= ? ORDER BY sent_at DESC`
+
+	rows, err := s.db.Query(query, profileURL, sqlTime(since))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+	defer rows.Close()
+
+	var msgs []Message
+	for rows.Next() {
+		var m Message
+		if err := rows.Scan(&m.ID, &m.ProfileURL, &m.Content, &m.SentAt); err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs, rows.Err()
+}
+
+// MarkConnectionAccepted records that a request was accepted and its
+// follow-up is taken care of
 func (s *Store) MarkConnectionAccepted(profileURL string) error {
-	query := `UPDATE connection_requests SET accepted = 1 WHERE profile_url = ?`
+	query := `UPDATE connection_requests SET accepted = 1, accepted_at = COALESCE(accepted_at, CURRENT_TIMESTAMP), follow_up_due = 0
+	          WHERE profile_url = ?`
 	_, err := s.db.Exec(query, profileURL)
 	return err
 }
 
+// RecordAcceptance records that a request was accepted while leaving its
+// follow-up to the next -message run; see GetFollowUpsDue
+func (s *Store) RecordAcceptance(profileURL string) error {
+	query := `UPDATE connection_requests SET accepted = 1, accepted_at = CURRENT_TIMESTAMP, follow_up_due = 1
+	          WHERE profile_url = ? AND accepted = 0`
+	if _, err := s.db.Exec(query, profileURL); err != nil {
+		return fmt.Errorf("failed to record acceptance: %w", err)
+	}
+	return nil
+}
+
+// GetFollowUpsDue returns the requests seen accepted by an acceptance check
+// that have no follow-up yet, oldest acceptance first
+func (s *Store) GetFollowUpsDue() ([]ConnectionRequest, error) {
+	query := `SELECT id, profile_url, name, sent_at, accepted, note, COALESCE(source, ''), COALESCE(campaign, 'default')
+	          FROM connection_requests WHERE follow_up_due = 1 AND withdrawn_at IS NULL AND ` + notArchived("") + `
+	          AND profile_url NOT IN (SELECT profile_url FROM campaign_prospects) ORDER BY accepted_at`
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get follow-ups due: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []ConnectionRequest
+	for rows.Next() {
+		var r ConnectionRequest
+		if err := rows.Scan(&r.ID, &r.ProfileURL, &r.Name, &r.SentAt, &r.Accepted, &r.Note, &r.Source, &r.Campaign); err != nil {
+			return nil, err
+		}
+		requests = append(requests, r)
+	}
+	return requests, rows.Err()
+}
+
+// MarkConnectionWithdrawn records that a pending request was withdrawn
+func (s *Store) MarkConnectionWithdrawn(profileURL string) error {
+	query := `UPDATE connection_requests SET withdrawn_at = CURRENT_TIMESTAMP WHERE profile_url = ? AND accepted = 0`
+	if _, err := s.db.Exec(query, profileURL); err != nil {
+		return fmt.Errorf("failed to mark connection withdrawn: %w", err)
+	}
+	return nil
+}
+
+// GetNotesCountSince counts the invitations sent with a note since since
+func (s *Store) GetNotesCountSince(since time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM connection_requests WHERE note != '' AND external = 0 AND sent_at >= ?`
+	var count int
+	if err := s.db.QueryRow(query, sqlTime(since)).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to get note count: %w", err)
+	}
+	return count, nil
+}
+
+func (s *Store) GetWithdrawalsCountToday() (int, error) {
+	query := `SELECT COUNT(*) FROM connection_requests WHERE DATE(withdrawn_at) = DATE('now')`
+	var count int
+	err := s.db.QueryRow(query).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get withdrawal count: %w", err)
+	}
+	return count, nil
+}
+
+// GetPendingConnections returns requests not yet seen accepted nor withdrawn;
+// prospects of a defined campaign are left to the campaign, which has its own
+// follow-ups
 func (s *Store) GetPendingConnections() ([]ConnectionRequest, error) {
-	query := `SELECT id, profile_url, name, sent_at, accepted, note 
-	          FROM connection_requests WHERE accepted = 0 ORDER BY sent_at DESC`
+	query := `SELECT id, profile_url, name, sent_at, accepted, note, COALESCE(source, ''), COALESCE(campaign, 'default')
+	          FROM connection_requests WHERE accepted = 0 AND withdrawn_at IS NULL AND ` + notArchived("") + `
+	          AND profile_url NOT IN (SELECT profile_url FROM campaign_prospects) ORDER BY sent_at DESC`
 
 	rows, err := s.db.Query(query)
 	if err != nil {
@@ -146,7 +544,7 @@ func (s *Store) GetPendingConnections() ([]ConnectionRequest, error) {
 	var requests []ConnectionRequest
 	for rows.Next() {
 		var r ConnectionRequest
-		if err := rows.Scan(&r.ID, &r.ProfileURL, &r.Name, &r.SentAt, &r.Accepted, &r.Note); err != nil {
+		if err := rows.Scan(&r.ID, &r.ProfileURL, &r.Name, &r.SentAt, &r.Accepted, &r.Note, &r.Source, &r.Campaign); err != nil {
 			return nil, err
 		}
 		requests = append(requests, r)
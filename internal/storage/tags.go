@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"fmt"
+)
+
+type TaggedContact struct {
+	ProfileURL string
+	Name       string
+	Tag        string
+}
+
+func (s *Store) TagContact(profileURL, name, tag string) error {
+	query := `INSERT INTO contact_tags (profile_url, name, tag) VALUES (?, ?, ?)
+	          ON CONFLICT(profile_url, tag) DO UPDATE SET name = COALESCE(NULLIF(excluded.name, ''), name)`
+	if _, err := s.db.Exec(query, profileURL, name, tag); err != nil {
+		return fmt.Errorf("failed to tag contact: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) UntagContact(profileURL, tag string) error {
+	_, err := s.db.Exec(`DELETE FROM contact_tags WHERE profile_url = ? AND tag = ?`, profileURL, tag)
+	return err
+}
+
+func (s *Store) GetTaggedContacts(tag string) ([]TaggedContact, error) {
+	rows, err := s.db.Query(`SELECT profile_url, COALESCE(name, ''), tag FROM contact_tags WHERE tag = ? ORDER BY added_at, profile_url`, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tagged contacts: %w", err)
+	}
+	defer rows.Close()
+
+	var contacts []TaggedContact
+	for rows.Next() {
+		var c TaggedContact
+		if err := rows.Scan(&c.ProfileURL, &c.Name, &c.Tag); err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, c)
+	}
+	return contacts, rows.Err()
+}
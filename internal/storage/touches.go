@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// GetTouches counts the automated touches a profile has had in any campaign
+// or run: connection requests, messages and event invitations. It returns
+// when the last was, or the zero time when there were none
+func (s *Store) GetTouches(profileURL string) (int, time.Time, error) {
+	query := `SELECT COUNT(*), MAX(at) FROM (
+	            SELECT sent_at AS at FROM connection_requests WHERE profile_url = ? AND external = 0
+	            UNION ALL SELECT sent_at FROM messages WHERE profile_url = ?
+	            UNION ALL SELECT invited_at FROM event_invites WHERE profile_url = ?)`
+
+	var count int
+	// Aggregates lose the column's DATETIME type
+	var last sql.NullString
+	if err := s.db.QueryRow(query, profileURL, profileURL, profileURL).Scan(&count, &last); err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to count touches: %w", err)
+	}
+	if !last.Valid {
+		return count, time.Time{}, nil
+	}
+	t, err := time.Parse(sqlTimeLayout, last.String)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to parse last touch time: %w", err)
+	}
+	return count, t, nil
+}
@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// TrackedContact is a contact whose profile is rechecked for job changes
+type TrackedContact struct {
+	ProfileURL string
+	Name       string
+	AddedAt    time.Time
+	// CheckedAt is zero until the profile is first rechecked
+	CheckedAt time.Time
+}
+
+// JobChange is a new title or company seen on a tracked contact's profile
+type JobChange struct {
+	ID         int64
+	ProfileURL string
+	Name       string
+	OldTitle   string
+	NewTitle   string
+	OldCompany string
+	NewCompany string
+	DetectedAt time.Time
+}
+
+func (s *Store) TrackContact(profileURL, name string) error {
+	query := `INSERT INTO tracked_contacts (profile_url, name) VALUES (?, ?)
+	          ON CONFLICT(profile_url) DO UPDATE SET name = COALESCE(NULLIF(excluded.name, ''), name)`
+	if _, err := s.db.Exec(query, profileURL, name); err != nil {
+		return fmt.Errorf("failed to track contact: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) UntrackContact(profileURL string) error {
+	if _, err := s.db.Exec(`DELETE FROM tracked_contacts WHERE profile_url = ?`, profileURL); err != nil {
+		return fmt.Errorf("failed to untrack contact: %w", err)
+	}
+	return nil
+}
+
+// GetTrackedContacts returns every tracked contact, longest tracked first
+func (s *Store) GetTrackedContacts() ([]TrackedContact, error) {
+	return s.queryTracked(`SELECT profile_url, COALESCE(name, ''), added_at, checked_at FROM tracked_contacts ORDER BY added_at`)
+}
+
+// GetTrackedDue returns up to limit contacts not checked since before, the
+// never checked and longest unchecked first
+func (s *Store) GetTrackedDue(before time.Time, limit int) ([]TrackedContact, error) {
+	return s.queryTracked(`SELECT profile_url, COALESCE(name, ''), added_at, checked_at FROM tracked_contacts
+	                       WHERE checked_at IS NULL OR checked_at < ? ORDER BY checked_at IS NOT NULL, checked_at LIMIT ?`,
+		sqlTime(before), limit)
+}
+
+func (s *Store) queryTracked(query string, args ...interface{}) ([]TrackedContact, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tracked contacts: %w", err)
+	}
+	defer rows.Close()
+
+	var contacts []TrackedContact
+	for rows.Next() {
+		var c TrackedContact
+		var checked sql.NullTime
+		if err := rows.Scan(&c.ProfileURL, &c.Name, &c.AddedAt, &checked); err != nil {
+			return nil, err
+		}
+		c.CheckedAt = checked.Time
+		contacts = append(contacts, c)
+	}
+	return contacts, rows.Err()
+}
+
+func (s *Store) MarkTrackedChecked(profileURL string) error {
+	if _, err := s.db.Exec(`UPDATE tracked_contacts SET checked_at = CURRENT_TIMESTAMP WHERE profile_url = ?`, profileURL); err != nil {
+		return fmt.Errorf("failed to mark tracked contact checked: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) RecordJobChange(c JobChange) error {
+	query := `INSERT INTO job_changes (profile_url, name, old_title, new_title, old_company, new_company) VALUES (?, ?, ?, ?, ?, ?)`
+	if _, err := s.db.Exec(query, c.ProfileURL, c.Name, c.OldTitle, c.NewTitle, c.OldCompany, c.NewCompany); err != nil {
+		return fmt.Errorf("failed to record job change: %w", err)
+	}
+	return nil
+}
+
+// GetJobChangesSince returns the job changes detected since the given time,
+// newest first; an empty profileURL returns every contact's
+func (s *Store) GetJobChangesSince(profileURL string, since time.Time) ([]JobChange, error) {
+	query := `SELECT id, profile_url, COALESCE(name, ''), COALESCE(old_title, ''), COALESCE(new_title, ''),
+	                 COALESCE(old_company, ''), COALESCE(new_company, ''), detected_at
+	          FROM job_changes WHERE (? = '' OR profile_url = ?) AND detected_at >= ? ORDER BY detected_at DESC, id DESC`
+	rows, err := s.db.Query(query, profileURL, profileURL, sqlTime(since))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job changes: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []JobChange
+	for rows.Next() {
+		var c JobChange
+		if err := rows.Scan(&c.ID, &c.ProfileURL, &c.Name, &c.OldTitle, &c.NewTitle, &c.OldCompany, &c.NewCompany, &c.DetectedAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// ClaimJobChange records that a campaign reacts to a job change; false when
+// it already did
+func (s *Store) ClaimJobChange(campaign string, changeID int64) (bool, error) {
+	res, err := s.db.Exec(`INSERT INTO job_change_reactions (campaign, change_id) VALUES (?, ?) ON CONFLICT DO NOTHING`, campaign, changeID)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim job change: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim job change: %w", err)
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DayActivity is one UTC day's successful actions and setbacks
+type DayActivity struct {
+	Day string // YYYY-MM-DD
+	// Actions counts successful actions by action type
+	Actions map[string]int
+	// Setbacks counts security challenges and incidents of the asked-for kinds
+	Setbacks int
+}
+
+// GetDailyActivity returns the days since the given time that saw actions or
+// setbacks, oldest first; incidents count as setbacks when their kind is in kinds
+func (s *Store) GetDailyActivity(since time.Time, kinds []string) ([]DayActivity, error) {
+	days := make(map[string]*DayActivity)
+	day := func(d string) *DayActivity {
+		if days[d] == nil {
+			days[d] = &DayActivity{Day: d, Actions: make(map[string]int)}
+		}
+		return days[d]
+	}
+
+	query := `SELECT DATE(created_at), action, outcome, COUNT(*) FROM action_log
+	          WHERE created_at >= ? AND outcome IN ('success', 'challenge') GROUP BY 1, 2, 3`
+	rows, err := s.db.Query(query, sqlTime(since))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily activity: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var d, action, outcome string
+		var n int
+		if err := rows.Scan(&d, &action, &outcome, &n); err != nil {
+			return nil, fmt.Errorf("failed to scan daily activity: %w", err)
+		}
+		if outcome == "challenge" {
+			day(d).Setbacks += n
+		} else {
+			day(d).Actions[action] += n
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(kinds) > 0 {
+		args := []interface{}{sqlTime(since)}
+		for _, k := range kinds {
+			args = append(args, k)
+		}
+		query := `SELECT DATE(occurred_at), COUNT(*) FROM incidents
+		          WHERE occurred_at >= ? AND kind IN (?` + strings.Repeat(", ?", len(kinds)-1) + `) GROUP BY 1`
+		rows, err := s.db.Query(query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get daily incidents: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var d string
+			var n int
+			if err := rows.Scan(&d, &n); err != nil {
+				return nil, fmt.Errorf("failed to scan daily incidents: %w", err)
+			}
+			day(d).Setbacks += n
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	activity := make([]DayActivity, 0, len(days))
+	for _, d := range days {
+		activity = append(activity, *d)
+	}
+	sort.Slice(activity, func(i, j int) bool { return activity[i].Day < activity[j].Day })
+	return activity, nil
+}
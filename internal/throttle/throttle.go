@@ -0,0 +1,97 @@
+package throttle
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"linkedin-automation/config"
+	"linkedin-automation/internal/logger"
+	"linkedin-automation/internal/storage"
+)
+
+// Coordinator serializes actions of every process that shares the same
+// account database, so their combined rate respects account-level limits
+type Coordinator struct {
+	store   *storage.Store
+	logger  *logger.Logger
+	enabled bool
+	name    string
+	holder  string
+	ttl     time.Duration
+	minGap  time.Duration
+}
+
+func New(cfg *config.Config, log *logger.Logger, store *storage.Store) *Coordinator {
+	host, _ := os.Hostname()
+
+	return &Coordinator{
+		store:   store,
+		logger:  log,
+		enabled: cfg.Throttle.Enabled,
+		name:    "account:" + cfg.Creds.Email,
+		holder:  fmt.Sprintf("%s:%d", host, os.Getpid()),
+		ttl:     time.Duration(cfg.Throttle.LeaseTTLSeconds) * time.Second,
+		minGap:  time.Duration(cfg.Throttle.MinGapSeconds) * time.Second,
+	}
+}
+
+// Acquire blocks until this process holds the account lease and the minimum
+// gap since the last action of any process has elapsed, or ctx is done
+func (c *Coordinator) Acquire(ctx context.Context) error {
+	if !c.enabled {
+		return nil
+	}
+
+	for {
+		ok, err := c.store.AcquireLease(c.name, c.holder, c.ttl)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			last, err := c.store.GetLeaseLastAction(c.name)
+			if err != nil {
+				c.Release()
+				return err
+			}
+
+			if wait := c.minGap - time.Since(last); !last.IsZero() && wait > 0 {
+				c.logger.Debug("Throttle: waiting %v since last account action", wait.Round(time.Second))
+				if err := sleep(ctx, wait); err != nil {
+					c.Release()
+					return err
+				}
+			}
+			return nil
+		}
+
+		c.logger.Debug("Throttle: account lease held by another process, waiting")
+		if err := sleep(ctx, 5*time.Second); err != nil {
+			return err
+		}
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release frees the account lease so other processes can act
+func (c *Coordinator) Release() {
+	if !c.enabled {
+		return
+	}
+
+	if err := c.store.ReleaseLease(c.name, c.holder); err != nil {
+		c.logger.Warn("Throttle: %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+// Package timing accounts for where a run's wall time goes, stage by stage
+package timing
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Stages; instrumented code never nests one stage inside another, so the
+// totals add up to at most the wall time
+const (
+	Navigation   = "navigation"    // page loads
+	Extraction   = "extraction"    // reading results off a page
+	StealthDelay = "stealth_delay" // random pauses and thinking time
+	Typing       = "typing"
+	Mouse        = "mouse"  // clicks and hovers
+	Pacing       = "pacing" // the queue worker's gaps between actions
+)
+
+// Stat is the accumulated time of one stage
+type Stat struct {
+	Stage string        `json:"stage"`
+	Count int           `json:"count"`
+	Total time.Duration `json:"total_ns"`
+}
+
+func (s Stat) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Count)
+}
+
+var (
+	mu      sync.Mutex
+	stats   = make(map[string]*Stat)
+	started = time.Now()
+)
+
+// Start begins timing a stage; call the returned function when it ends
+func Start(stage string) func() {
+	begin := time.Now()
+	return func() {
+		Record(stage, time.Since(begin))
+	}
+}
+
+// Record adds an already measured duration to a stage
+func Record(stage string, d time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	s, ok := stats[stage]
+	if !ok {
+		s = &Stat{Stage: stage}
+		stats[stage] = s
+	}
+	s.Count++
+	s.Total += d
+}
+
+// Snapshot returns the stages recorded so far, longest first, and the wall
+// time since the last Reset (or process start)
+func Snapshot() ([]Stat, time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	out := make([]Stat, 0, len(stats))
+	for _, s := range stats {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Total > out[j].Total })
+	return out, time.Since(started)
+}
+
+// Reset clears all stages and restarts the wall clock
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	stats = make(map[string]*Stat)
+	started = time.Now()
+}
@@ -0,0 +1,150 @@
+// Package tracking rechecks the profiles of tracked contacts and records a
+// job change when their current title or company differs from the last read,
+// for campaigns to follow up with congratulations.
+package tracking
+
+import (
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+
+	"linkedin-automation/config"
+	"linkedin-automation/internal/logger"
+	"linkedin-automation/internal/profile"
+	"linkedin-automation/internal/stealth"
+	"linkedin-automation/internal/storage"
+)
+
+type Tracker struct {
+	page   *rod.Page
+	cfg    *config.Config
+	logger *logger.Logger
+	store  *storage.Store
+	// limiter paces the profile views; nil leaves them unpaced
+	limiter *stealth.RateLimiter
+}
+
+func New(page *rod.Page, cfg *config.Config, log *logger.Logger, store *storage.Store) *Tracker {
+	return &Tracker{page: page, cfg: cfg, logger: log, store: store}
+}
+
+// SetPage points the tracker at a new page after the browser is relaunched
+func (t *Tracker) SetPage(page *rod.Page) {
+	t.page = page
+}
+
+// SetLimiter paces the profile views with the session's rate limiter
+func (t *Tracker) SetLimiter(rl *stealth.RateLimiter) {
+	t.limiter = rl
+}
+
+// Check revisits the tracked profiles due a check, at most
+// tracking.max_checks_per_run of them, and returns the job changes found
+func (t *Tracker) Check() ([]storage.JobChange, error) {
+	interval := time.Duration(t.cfg.Tracking.IntervalDays) * 24 * time.Hour
+	due, err := t.store.GetTrackedDue(time.Now().Add(-interval), t.cfg.Tracking.MaxChecksPerRun)
+	if err != nil {
+		return nil, err
+	}
+	if len(due) == 0 {
+		t.logger.Info("No tracked contacts due a check")
+		return nil, nil
+	}
+
+	var changes []storage.JobChange
+	for i, c := range due {
+		if i > 0 {
+			stealth.RandomDelay(t.cfg.Delays.MinActionDelayMs, t.cfg.Delays.MaxActionDelayMs)
+		}
+		change, err := t.check(c)
+		if err != nil {
+			t.logger.Warn("Failed to recheck %s: %v", c.ProfileURL, err)
+			continue
+		}
+		if change != nil {
+			changes = append(changes, *change)
+		}
+	}
+	t.logger.Info("Rechecked %d tracked contacts, %d job changes", len(due), len(changes))
+	return changes, nil
+}
+
+func (t *Tracker) check(c storage.TrackedContact) (*storage.JobChange, error) {
+	prev, err := profile.Load(t.store, c.ProfileURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.limiter.Acquire(stealth.ActionProfileView); err != nil {
+		return nil, err
+	}
+	cur, err := profile.Fetch(t.page, t.logger, c.ProfileURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := profile.Save(t.store, c.ProfileURL, cur); err != nil {
+		return nil, err
+	}
+	if err := t.store.MarkTrackedChecked(c.ProfileURL); err != nil {
+		return nil, err
+	}
+
+	// The first read is the baseline
+	if prev == nil {
+		return nil, nil
+	}
+	change := Diff(prev, cur)
+	if change == nil {
+		return nil, nil
+	}
+	change.ProfileURL = c.ProfileURL
+	if change.Name = cur.Name; change.Name == "" {
+		change.Name = c.Name
+	}
+	if err := t.store.RecordJobChange(*change); err != nil {
+		return nil, err
+	}
+	t.logger.Info("Job change: %s is now %s", change.Name, Describe(*change))
+	return change, nil
+}
+
+// Diff compares the current positions of two reads of a profile. Positions
+// that did not render in either read are not compared, so a partly loaded
+// page is not taken for a job change
+func Diff(prev, cur *profile.Profile) *storage.JobChange {
+	oldTitle, newTitle := title(prev), title(cur)
+	titleChanged := oldTitle != "" && newTitle != "" && !strings.EqualFold(oldTitle, newTitle)
+	companyChanged := prev.Company != "" && cur.Company != "" && !strings.EqualFold(prev.Company, cur.Company)
+	if !titleChanged && !companyChanged {
+		return nil
+	}
+	return &storage.JobChange{
+		OldTitle:   oldTitle,
+		NewTitle:   newTitle,
+		OldCompany: prev.Company,
+		NewCompany: cur.Company,
+	}
+}
+
+// title is the current position's title
+func title(p *profile.Profile) string {
+	if len(p.Experience) > 0 {
+		return strings.TrimSpace(p.Experience[0].Title)
+	}
+	return ""
+}
+
+// Describe words a change, e.g. "Head of Data at Acme (was Data Lead at Initech)"
+func Describe(c storage.JobChange) string {
+	return position(c.NewTitle, c.NewCompany) + " (was " + position(c.OldTitle, c.OldCompany) + ")"
+}
+
+func position(title, company string) string {
+	switch {
+	case title == "":
+		return "at " + company
+	case company == "":
+		return title
+	}
+	return title + " at " + company
+}
@@ -0,0 +1,195 @@
+// Package warmup holds a new or recently restricted account to low daily
+// volumes that rise in stages, or on a steady ramp over some weeks.
+// Progress is worked out from the action log and incidents since the warm-up
+// started, so it survives restarts and stays with the account's database
+package warmup
+
+import (
+	"fmt"
+	"time"
+
+	"linkedin-automation/config"
+	"linkedin-automation/internal/stealth"
+	"linkedin-automation/internal/storage"
+)
+
+// defaultStages take an account from a handful of invitations a day to the
+// configured limits over three working weeks
+var defaultStages = []config.WarmUpStage{
+	{Name: "first week", Days: 5, ConnectionsPerDay: 5, MessagesPerDay: 10},
+	{Name: "second week", Days: 5, ConnectionsPerDay: 10, MessagesPerDay: 15},
+	{Name: "third week", Days: 5, ConnectionsPerDay: 15, MessagesPerDay: 20},
+}
+
+// setbackKinds are the incidents that start a stage's days over or set the
+// ramp back a week; security challenges in the action log count too
+var setbackKinds = []string{storage.IncidentInviteLimit, storage.IncidentSelfAudit}
+
+// Progress is where an account is in its warm-up
+type Progress struct {
+	Started time.Time
+	Stages  []config.WarmUpStage
+	// Stage indexes Stages; it is len(Stages) once the warm-up is over
+	Stage int
+	// DaysInStage counts active days since the stage began or its last setback
+	DaysInStage int
+	// DaysCompleted counts every active day since the warm-up started
+	DaysCompleted int
+	Setbacks      int
+	// PeakConnections and PeakMessages are the most sent on one day
+	PeakConnections int
+	PeakMessages    int
+	// RampDays is the length of the ramp, 0 when the warm-up uses stages.
+	// RampDay is how far along it the account is: days since the start, less
+	// a week for every day with a setback
+	RampDays int
+	RampDay  int
+	// Connections and Messages are today's daily caps
+	Connections int
+	Messages    int
+}
+
+// Stages returns the configured stages, or the defaults when none are set
+func Stages(cfg *config.Config) []config.WarmUpStage {
+	if len(cfg.WarmUp.Stages) > 0 {
+		return cfg.WarmUp.Stages
+	}
+	return defaultStages
+}
+
+// Start begins the warm-up now unless it has already begun, and returns when
+// it began
+func Start(store *storage.Store, now time.Time) (time.Time, error) {
+	started, err := store.GetStateTime(storage.StateWarmUpStarted)
+	if err != nil || !started.IsZero() {
+		return started, err
+	}
+	if err := store.SetStateTime(storage.StateWarmUpStarted, now); err != nil {
+		return time.Time{}, err
+	}
+	return now, nil
+}
+
+// Restart begins the warm-up again now, for an account coming out of a
+// restriction
+func Restart(store *storage.Store, now time.Time) error {
+	return store.SetStateTime(storage.StateWarmUpStarted, now)
+}
+
+// FirstSeen returns when the tool first ran for the account, recording now
+// when it has not run before. An account's age is counted from it
+func FirstSeen(store *storage.Store, now time.Time) (time.Time, error) {
+	seen, err := store.GetStateTime(storage.StateAccountFirstSeen)
+	if err != nil || !seen.IsZero() {
+		return seen, err
+	}
+	if err := store.SetStateTime(storage.StateAccountFirstSeen, now); err != nil {
+		return time.Time{}, err
+	}
+	return now, nil
+}
+
+// Load works out the account's progress and today's caps, ramping up to
+// cfg's limits; it returns nil when the warm-up has not started
+func Load(cfg *config.Config, store *storage.Store, now time.Time) (*Progress, error) {
+	started, err := store.GetStateTime(storage.StateWarmUpStarted)
+	if err != nil || started.IsZero() {
+		return nil, err
+	}
+	days, err := store.GetDailyActivity(started, setbackKinds)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Progress{Started: started, Stages: Stages(cfg), RampDays: 7 * cfg.WarmUp.RampWeeks}
+	setbackDays := 0
+	for _, d := range days {
+		connections := d.Actions[string(stealth.ActionConnectionReq)]
+		messages := d.Actions[string(stealth.ActionMessage)]
+		p.PeakConnections = max(p.PeakConnections, connections)
+		p.PeakMessages = max(p.PeakMessages, messages)
+
+		if d.Setbacks > 0 {
+			p.Setbacks += d.Setbacks
+			p.DaysInStage = 0
+			setbackDays++
+			continue
+		}
+		if connections+messages == 0 {
+			continue
+		}
+		p.DaysCompleted++
+		if p.Stage >= len(p.Stages) {
+			continue
+		}
+		if p.DaysInStage++; p.DaysInStage >= p.Stages[p.Stage].Days {
+			p.Stage++
+			p.DaysInStage = 0
+		}
+	}
+
+	switch {
+	case p.RampDays > 0:
+		p.RampDay = max(0, int(now.Sub(started).Hours()/24)-7*setbackDays)
+		p.Connections = p.ramp(cfg.WarmUp.StartPerDay, cfg.Limits.MaxConnectionsPerDay)
+		p.Messages = p.ramp(cfg.WarmUp.StartPerDay, cfg.Limits.MaxMessagesPerDay)
+	case !p.Graduated():
+		p.Connections = p.Current().ConnectionsPerDay
+		p.Messages = p.Current().MessagesPerDay
+	}
+	return p, nil
+}
+
+// ramp returns the cap for today on a straight line from the start value to
+// the configured limit
+func (p *Progress) ramp(from, to int) int {
+	if to <= from || p.RampDay >= p.RampDays {
+		return to
+	}
+	return from + (to-from)*p.RampDay/p.RampDays
+}
+
+// Graduated reports whether the ramp or every stage is done
+func (p *Progress) Graduated() bool {
+	if p.RampDays > 0 {
+		return p.RampDay >= p.RampDays
+	}
+	return p.Stage >= len(p.Stages)
+}
+
+// Current returns the stage the account is in; call it only before the
+// stages are done
+func (p *Progress) Current() config.WarmUpStage {
+	return p.Stages[p.Stage]
+}
+
+// Apply caps cfg's daily limits to today's. Broadcasts share the message cap,
+// and the weekly connection limit is five days of the daily one
+func (p *Progress) Apply(cfg *config.Config) {
+	if p.Graduated() {
+		return
+	}
+	l := &cfg.Limits
+	l.MaxConnectionsPerDay = min(l.MaxConnectionsPerDay, p.Connections)
+	l.MaxConnectionsPerWeek = min(l.MaxConnectionsPerWeek, 5*p.Connections)
+	l.MaxMessagesPerDay = min(l.MaxMessagesPerDay, p.Messages)
+	l.MaxBroadcastsPerDay = min(l.MaxBroadcastsPerDay, p.Messages)
+}
+
+// String summarizes the stage for logs
+func (p *Progress) String() string {
+	switch {
+	case p.Graduated():
+		return fmt.Sprintf("warm-up complete after %d active days", p.DaysCompleted)
+	case p.RampDays > 0:
+		return fmt.Sprintf("warm-up ramp day %d of %d: %d connections and %d messages a day",
+			p.RampDay+1, p.RampDays, p.Connections, p.Messages)
+	}
+	st := p.Current()
+	name := ""
+	if st.Name != "" {
+		name = " (" + st.Name + ")"
+	}
+	return fmt.Sprintf("warm-up stage %d/%d%s, day %d of %d: %d connections and %d messages a day",
+		p.Stage+1, len(p.Stages), name, p.DaysInStage+1, st.Days, p.Connections, p.Messages)
+}
@@ -1,30 +1,67 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"linkedin-automation/config"
+	"linkedin-automation/internal/analytics"
 	"linkedin-automation/internal/auth"
 	"linkedin-automation/internal/browser"
-	"linkedin-automation/internal/connect"
+	"linkedin-automation/internal/compliance"
 	"linkedin-automation/internal/logger"
-	"linkedin-automation/internal/message"
+	"linkedin-automation/internal/network"
+	"linkedin-automation/internal/queue"
+	"linkedin-automation/internal/report"
+	"linkedin-automation/internal/runctx"
 	"linkedin-automation/internal/search"
 	"linkedin-automation/internal/stealth"
 	"linkedin-automation/internal/storage"
 	"log"
+	"math"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-rod/rod"
 )
 
+// processFlags are the flags of a run besides the action flags
+type processFlags struct {
+	configPath string
+	maxRuntime time.Duration
+	compliance string
+	operator   string
+	account    string
+	daemon     bool
+	dryRun     bool
+}
+
+func (p *processFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&p.configPath, "config", "./config/config.yaml", "Path to config file")
+	fs.DurationVar(&p.maxRuntime, "max-runtime", 0, "Wrap up gracefully after this long, e.g. 45m (0 = no limit)")
+	fs.StringVar(&p.compliance, "compliance", "", "Compliance profile from config (overrides compliance.profile)")
+	fs.StringVar(&p.operator, "operator", "", "Who is running this (overrides operator / LINKEDIN_OPERATOR)")
+	fs.StringVar(&p.account, "account", "", "Account from accounts to run as (overrides account / LINKEDIN_ACCOUNT)")
+	fs.BoolVar(&p.daemon, "daemon", false, "Keep the session open and run the jobs in daemon.jobs on their schedule")
+	fs.BoolVar(&p.dryRun, "dry-run", false, "Go through every action but stop before sending; planned actions go to pending_actions")
+}
+
 func main() {
+	// Subcommands operate on local state without launching a browser
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			os.Exit(runCommand(os.Args[1], cmd, os.Args[2:]))
+		}
+	}
+
 	// Parse command line flags
-	configPath := flag.String("config", "./config/config.yaml", "Path to config file")
-	searchQuery := flag.String("query", "", "Search query (job title)")
-	searchLocation := flag.String("location", "", "Search location")
-	searchCompany := flag.String("company", "", "Company name")
-	maxResults := flag.Int("max", 10, "Maximum number of profiles to process")
-	sendConnections := flag.Bool("connect", false, "Send connection requests")
-	sendMessages := flag.Bool("message", false, "Send follow-up messages")
+	var pf processFlags
+	pf.register(flag.CommandLine)
+	var opts runOptions
+	opts.register(flag.CommandLine)
 	flag.Parse()
 
 	fmt.Println(`
@@ -40,7 +77,7 @@ func main() {
 	`)
 
 	// Load configuration
-	cfg, err := config.Load(*configPath)
+	cfg, err := config.LoadAccount(pf.configPath, pf.account)
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
@@ -52,115 +89,388 @@ func main() {
 	}
 	defer lgr.Close()
 
-	lgr.Info("Starting LinkedIn Automation Tool")
-	lgr.Info("Config loaded from: %s", *configPath)
-
-	// Check business hours if enabled
-	if cfg.Stealth.BusinessHoursOnly {
-		if !stealth.IsBusinessHours(cfg.Stealth.WorkStartHour, cfg.Stealth.WorkEndHour) {
-			lgr.Info("Outside business hours. Waiting...")
-			stealth.WaitForBusinessHours(cfg.Stealth.WorkStartHour, cfg.Stealth.WorkEndHour)
-		}
-	}
-
-	// Initialize storage
-	store, err := storage.New(cfg.Storage.DBPath)
-	if err != nil {
-		lgr.Error("Failed to initialize storage: %v", err)
+	if err := applyOverrides(cfg, lgr, pf); err != nil {
+		lgr.Error("%v", err)
 		os.Exit(1)
 	}
-	defer store.Close()
 
-	// Initialize browser
-	lgr.Info("Initializing browser...")
-	br, err := browser.New(cfg, lgr)
-	if err != nil {
-		lgr.Error("Failed to initialize browser: %v", err)
-		os.Exit(1)
+	lgr.Info("Starting LinkedIn Automation Tool (operator %s)", cfg.Operator)
+	if cfg.Account != "" {
+		lgr.Info("Account %s", cfg.Account)
 	}
-	defer br.Close()
+	if cfg.DryRun {
+		lgr.Info("Dry run: nothing will be sent; planned actions are recorded in pending_actions")
+	}
+	runReport := report.New()
+	runReport.Operator = cfg.Operator
 
-	page := br.Page()
+	// The deadline counts from process start so hard runner time limits hold
+	var deadline time.Time
+	if pf.maxRuntime > 0 {
+		deadline = runReport.StartedAt.Add(pf.maxRuntime)
+		lgr.Info("Maximum runtime %v (wrapping up by %s)", pf.maxRuntime, deadline.Format("15:04:05"))
+	}
+	lgr.Info("Config loaded from: %s", pf.configPath)
 
-	// Authenticate
-	lgr.Info("Authenticating...")
-	authenticator := auth.New(page, cfg, lgr)
-	if err := authenticator.Login(); err != nil {
-		lgr.Error("Authentication failed: %v", err)
+	// Reject runs that cannot work before the browser starts
+	tmpl := loadTemplates()
+	if err := tmpl.resolve(cfg); err != nil {
+		lgr.Error("%v", err)
 		os.Exit(1)
 	}
-
-	lgr.Info("✓ Successfully authenticated")
-
-	// Wait after login
-	stealth.RandomDelay(2000, 4000)
-
-	// Execute actions based on flags
-	if *sendConnections {
-		if *searchQuery == "" {
-			lgr.Error("Search query is required for sending connections")
+	var jobs []daemonJob
+	if pf.daemon {
+		if !opts.none() {
+			lgr.Error("Action flags are given per job in daemon.jobs when running with -daemon")
 			os.Exit(1)
 		}
-
-		// Search for people
-		lgr.Info("Searching for people...")
-		searcher := search.New(page, cfg, lgr)
-		profiles, err := searcher.SearchPeople(*searchQuery, *searchLocation, *searchCompany, *maxResults)
-		if err != nil {
-			lgr.Error("Search failed: %v", err)
+		if jobs, err = loadJobs(cfg, lgr, tmpl); err != nil {
+			lgr.Error("%v", err)
 			os.Exit(1)
 		}
+		lgr.Info("Daemon mode: %d scheduled jobs", len(jobs))
+	} else if err := checkOptions(cfg, lgr, opts, tmpl); err != nil {
+		lgr.Error("%v", err)
+		os.Exit(1)
+	}
 
-		lgr.Info("✓ Found %d profiles", len(profiles))
+	// SIGTERM or Ctrl-C cancels ctx: the current action finishes, searches
+	// and checks stop after their current step, and the rest stays queued
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-		// Send connection requests
-		lgr.Info("Sending connection requests...")
-		connector := connect.New(page, cfg, lgr, store)
+	// Installed before the session opens, so a signal during the wait for
+	// business hours or the login is handled too; a second exits at once
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		signal.Stop(signals)
+		lgr.Info("Received %v; finishing the current action before exiting", sig)
+		cancel()
+	}()
 
-		note := os.Getenv("CONNECTION_NOTE")
-		if note == "" {
-			note = "Hi {name}, I'd love to connect with you!"
+	// Check business hours if enabled; the daemon waits per job instead
+	if cfg.Stealth.BusinessHoursOnly && !pf.daemon {
+		if !stealth.IsBusinessHours(cfg.Stealth.WorkStartHour, cfg.Stealth.WorkEndHour) {
+			lgr.Info("Outside business hours. Waiting...")
+			stealth.WaitForBusinessHours(ctx, cfg.Stealth.WorkStartHour, cfg.Stealth.WorkEndHour)
 		}
+	}
+	if ctx.Err() != nil {
+		lgr.Info("Shutdown requested before the run started")
+		return
+	}
 
-		if err := connector.SendConnectionRequests(profiles, note); err != nil {
-			lgr.Error("Failed to send connections: %v", err)
+	sess, err := openSession(ctx, cfg, lgr, tmpl)
+	if err != nil {
+		if ctx.Err() != nil {
+			lgr.Info("Shutdown requested while opening the session: %v", err)
+			return
 		}
-
-		lgr.Info("✓ Connection requests completed")
+		lgr.Error("%v", err)
+		os.Exit(1)
 	}
+	// The daemon may switch accounts; whichever session is open last is closed
+	defer func() { sess.close() }()
+	worker := sess.worker
+	worker.SetDeadline(deadline)
 
-	if *sendMessages {
-		// Send follow-up messages
-		lgr.Info("Sending follow-up messages...")
-		messenger := message.New(page, cfg, lgr, store)
-
-		msgTemplate := os.Getenv("FOLLOW_UP_MESSAGE")
-		if msgTemplate == "" {
-			msgTemplate = "Thanks for connecting! Looking forward to staying in touch."
-		}
+	if err := sess.store.RecordAudit(cfg.Operator, storage.AuditRunStarted, strings.Join(os.Args[1:], " ")); err != nil {
+		lgr.Warn("Failed to record run in audit log: %v", err)
+	}
 
-		if err := messenger.SendFollowUpMessages(msgTemplate); err != nil {
-			lgr.Error("Failed to send messages: %v", err)
+	if pf.daemon {
+		if !deadline.IsZero() {
+			ctx, cancel = context.WithDeadline(ctx, deadline)
+			defer cancel()
 		}
-
-		lgr.Info("✓ Follow-up messages completed")
+		sess = runDaemon(ctx, sess, jobs, func(account string) (*config.Config, error) {
+			cfg, err := config.LoadAccount(pf.configPath, account)
+			if err != nil {
+				return nil, err
+			}
+			return cfg, applyOverrides(cfg, lgr, pf)
+		})
+		lgr.Info("Daemon stopped")
+		return
 	}
 
-	if !*sendConnections && !*sendMessages {
+	// Execute actions based on flags
+	sess.run(opts, runReport)
+
+	if opts.none() {
 		lgr.Info("No action specified. Use -connect or -message flags")
 		fmt.Println(`
 Usage Examples:
   # Search and send connection requests
-  go run main.go -connect -query "Software Engineer" -location "San Francisco" -max 20
+  go run . -connect -query "Software Engineer" -location "San Francisco" -max 20
+
+  # Search, falling back to a prospect CSV while the monthly search limit is in force
+  go run . -connect -query "Software Engineer" -import prospects.csv -max 20
+
+  # Connect with a list of profile URLs exported from another tool, without searching
+  go run . -connect -input profiles.csv -max 20
+
+  # Recheck tracked contacts (go run . track add <url>) for job changes
+  go run . -track
+
+  # Withdraw invitations still pending after limits.withdraw_after_days
+  go run . -withdraw-stale
 
   # Send follow-up messages to accepted connections
-  go run main.go -message
+  go run . -message
 
   # Combined
-  go run main.go -connect -message -query "Product Manager" -company "Google" -max 10
+  go run . -connect -message -query "Product Manager" -company "Google" -max 10
+
+  # Announce to existing connections (staged for review, sent once approved)
+  BROADCAST_MESSAGE="Hi {name}, ..." go run . -broadcast -campaign jobchange -title "Engineer" -max 50
+  go run . broadcast approve jobchange && go run . -broadcast
+
+  # Connect with the leads of a Sales Navigator list, then save those who accept to another
+  go run . -connect -lead-list "Q3 targets" -max 20
+  go run . -save-leads "Engaged"
+
+  # Follow pages during a warm-up campaign, and unfollow old warm-up follows later
+  go run . -follow "https://www.linkedin.com/company/example/" -campaign warmup-q3
+  go run . -cleanup-follows
+
+  # Invite tagged connections to an event you host
+  go run . -event "https://www.linkedin.com/events/1234567890/" -tag meetup -max 50
+
+  # Run a multi-step campaign from config/campaigns/example.yaml (run it again to advance it)
+  go run . -run-campaign example
+
+  # Rehearse a run without sending anything, then review what it would have done
+  go run . -connect -query "Software Engineer" -max 5 -dry-run
+  go run . pending
+
+  # Stay logged in and run the jobs scheduled in daemon.jobs
+  go run . -daemon
 		`)
+		printCommands()
 	}
 
+	if ctx.Err() != nil {
+		runReport.Stopped = "shutdown requested; unfinished actions stay queued"
+		if n, err := sess.store.CountQueueItems(storage.QueueStatusPending); err == nil && n > 0 {
+			lgr.Info("%d queued actions left; the next run resumes them", n)
+		}
+	} else if worker.Expired() {
+		runReport.Stopped = fmt.Sprintf("maximum runtime %v reached; unfinished actions stay queued", pf.maxRuntime)
+	}
+
+	// Summarize the run and persist next-step recommendations
+	sess.finish(runReport)
+
 	lgr.Info("Automation completed successfully")
 	fmt.Println("\n✓ All tasks completed. Check logs for details.")
 }
+
+// sourceProspects finds prospects by search, or from the -import CSV while
+// LinkedIn's monthly search limit blocks searching on a free account
+func sourceProspects(page *rod.Page, rc *runctx.Context, limiter *stealth.RateLimiter, rep *report.Report,
+	query, location, company, csvPath string, max int) ([]search.Profile, error) {
+	lgr, store := rc.Logger, rc.Store
+
+	if query != "" {
+		until, err := store.GetStateTime(storage.StateSearchLimitUntil)
+		if err == nil && rc.Now().Before(until) {
+			lgr.Warn("Search limit in force until %s; skipping search", until.Local().Format(time.RFC1123))
+		} else {
+			lgr.Info("Searching for people...")
+			searcher := search.New(page, rc)
+			searcher.SetLimiter(limiter)
+			profiles, err := searcher.SearchPeople(rc, query, location, company, max)
+			rep.AnonymizedSkipped += searcher.Anonymized()
+			rep.AddSearchDropped(searcher.Dropped())
+			rep.FilteredOut += searcher.Filtered()
+			if !search.IsSearchLimit(err) {
+				if err != nil {
+					return nil, fmt.Errorf("search failed: %w", err)
+				}
+				return profiles, nil
+			}
+
+			reset := search.SearchLimitReset(rc.Now())
+			lgr.Warn("LinkedIn monthly search limit reached; search blocked until %s", reset.Format("2006-01-02"))
+			if err := store.SetStateTime(storage.StateSearchLimitUntil, reset); err != nil {
+				lgr.Error("Failed to persist search limit: %v", err)
+			}
+			store.RecordIncident(storage.IncidentSearchLimit, "reset "+reset.Format(time.RFC3339))
+
+			if len(profiles) >= max || csvPath == "" {
+				return profiles, nil
+			}
+			max -= len(profiles)
+			more, err := loadCSV(store, lgr, csvPath, max)
+			return append(profiles, more...), err
+		}
+	}
+
+	if csvPath == "" {
+		return nil, fmt.Errorf("search is blocked and no -import CSV was given; only already queued prospects will be contacted")
+	}
+	lgr.Info("Sourcing prospects from %s", csvPath)
+	return loadCSV(store, lgr, csvPath, max)
+}
+
+// applyOverrides applies the command-line overrides and the regional
+// compliance profile, before any limits are used
+func applyOverrides(cfg *config.Config, lgr *logger.Logger, pf processFlags) error {
+	if pf.operator != "" {
+		cfg.Operator = pf.operator
+	}
+	if pf.compliance != "" {
+		cfg.Compliance.Profile = pf.compliance
+	}
+	if pf.dryRun {
+		cfg.DryRun = true
+	}
+	profile, err := compliance.Resolve(cfg, cfg.Compliance.Profile)
+	if err != nil {
+		return err
+	}
+	profile.Apply(cfg)
+	if profile.Name != "" {
+		lgr.Info("Compliance profile %q active (volume x%.2f)", profile.Name, profile.VolumeFactor)
+	}
+	if profile.ResearchCap > 0 {
+		lgr.Info("Research mode: at most %d of each action a day, disclosure %q", profile.ResearchCap, profile.Disclosure)
+	}
+	for _, line := range cfg.PacingSummary() {
+		lgr.Info("%s", line)
+	}
+	return nil
+}
+
+// relaunchBrowser restarts Chrome and restores the session on the new page,
+// logging in again if it has expired
+func relaunchBrowser(br *browser.Browser, rc *runctx.Context) error {
+	// No point starting Chrome again for a run that is stopping
+	if rc.Stopped() {
+		return fmt.Errorf("not relaunching the browser: %w", rc.Err())
+	}
+	// A crashed browser has nothing to save; the last saved session is used
+	if br.Alive() {
+		if err := auth.New(br.Page(), rc).SaveSession(); err != nil {
+			rc.Logger.Warn("Failed to save session before relaunch: %v", err)
+		}
+	}
+	if err := br.Relaunch(); err != nil {
+		return err
+	}
+	if err := auth.New(br.Page(), rc).Login(rc); err != nil {
+		return fmt.Errorf("failed to restore session after relaunch: %w", err)
+	}
+	return nil
+}
+
+// biasPacing slows the worker down in hours that historically convert worse
+func biasPacing(worker *queue.Worker, cfg *config.Config, store *storage.Store, lgr *logger.Logger, action string) {
+	st, err := analytics.LoadSendTimes(store, action, time.Now().AddDate(0, 0, -90), analytics.Location(cfg.Stealth.Timezone))
+	if err != nil {
+		lgr.Warn("Failed to load send-time analytics: %v", err)
+		return
+	}
+
+	worker.BiasPacing(action, st.Weight)
+	if hours := st.BestHours(3); len(hours) > 0 {
+		lgr.Info("Pacing for %s biased toward hours %v", action, hours)
+	}
+}
+
+// loadInput reads the -input list, leaving out the profiles a connection
+// request already went to
+func loadInput(store *storage.Store, lgr *logger.Logger, path string, max int) ([]search.Profile, error) {
+	listed, err := search.LoadList(path, math.MaxInt)
+	if err != nil {
+		return nil, err
+	}
+	profiles, sent, err := unsent(store, listed, max)
+	if err != nil {
+		return nil, err
+	}
+	lgr.Info("Read %d profiles from %s (%d already sent a request)", len(profiles), path, sent)
+	return profiles, nil
+}
+
+// loadCSV reads the -import CSV, leaving out the profiles a connection
+// request already went to
+func loadCSV(store *storage.Store, lgr *logger.Logger, path string, max int) ([]search.Profile, error) {
+	listed, err := search.LoadCSV(path, math.MaxInt)
+	if err != nil {
+		return nil, err
+	}
+	profiles, sent, err := unsent(store, listed, max)
+	if err != nil {
+		return nil, err
+	}
+	lgr.Info("Read %d prospects from %s (%d already sent a request)", len(profiles), path, sent)
+	return profiles, nil
+}
+
+// unsent keeps up to max of the listed profiles that have no connection
+// request yet, and counts the ones passed over
+func unsent(store *storage.Store, listed []search.Profile, max int) ([]search.Profile, int, error) {
+	var profiles []search.Profile
+	sent := 0
+	for _, p := range listed {
+		if len(profiles) >= max {
+			break
+		}
+		done, err := store.HasConnectionRequest(p.URL)
+		if err != nil {
+			return nil, 0, err
+		}
+		if done {
+			sent++
+			continue
+		}
+		profiles = append(profiles, p)
+	}
+	return profiles, sent, nil
+}
+
+// segmentConnections picks existing connections by headline filter and/or local tag
+func segmentConnections(page *rod.Page, cfg *config.Config, store *storage.Store, lgr *logger.Logger, title, company, tag string, max int) ([]network.Connection, error) {
+	// Contacts come in tagging order, so the segment is the same on every run
+	var contacts []storage.TaggedContact
+	var tagged map[string]string
+	if tag != "" {
+		var err error
+		if contacts, err = store.GetTaggedContacts(tag); err != nil {
+			return nil, err
+		}
+		tagged = make(map[string]string)
+		for _, c := range contacts {
+			tagged[c.ProfileURL] = c.Name
+		}
+	}
+
+	// Tag only: no need to scan the connections list
+	if title == "" && company == "" {
+		var segment []network.Connection
+		for _, c := range contacts {
+			if len(segment) >= max {
+				break
+			}
+			segment = append(segment, network.Connection{URL: c.ProfileURL, Name: c.Name})
+		}
+		return segment, nil
+	}
+
+	conns, err := network.New(page, cfg, lgr).ListConnections(network.Filter{Title: title, Company: company}, max)
+	if err != nil || tagged == nil {
+		return conns, err
+	}
+
+	var segment []network.Connection
+	for _, c := range conns {
+		if _, ok := tagged[c.URL]; ok {
+			segment = append(segment, c)
+		}
+	}
+	return segment, nil
+}
@@ -0,0 +1,926 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"linkedin-automation/config"
+	"linkedin-automation/internal/auth"
+	"linkedin-automation/internal/browser"
+	"linkedin-automation/internal/campaign"
+	"linkedin-automation/internal/capability"
+	"linkedin-automation/internal/connect"
+	"linkedin-automation/internal/event"
+	"linkedin-automation/internal/follow"
+	"linkedin-automation/internal/lint"
+	"linkedin-automation/internal/logger"
+	"linkedin-automation/internal/message"
+	"linkedin-automation/internal/network"
+	"linkedin-automation/internal/personalize"
+	"linkedin-automation/internal/queue"
+	"linkedin-automation/internal/report"
+	"linkedin-automation/internal/runctx"
+	"linkedin-automation/internal/safety"
+	"linkedin-automation/internal/salesnav"
+	"linkedin-automation/internal/search"
+	"linkedin-automation/internal/selfaudit"
+	"linkedin-automation/internal/stealth"
+	"linkedin-automation/internal/storage"
+	"linkedin-automation/internal/tracking"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+)
+
+// runOptions are the action flags of one run; daemon jobs parse their args
+// into a set of their own
+type runOptions struct {
+	query          string
+	location       string
+	company        string
+	max            int
+	importCSV      string
+	input          string
+	matchEmails    string
+	matched        bool
+	connect        bool
+	withdrawStale  bool
+	message        bool
+	broadcast      bool
+	title          string
+	tag            string
+	selfAudit      bool
+	track          bool
+	checkAccepted  bool
+	eventURL       string
+	leadList       string
+	saveLeads      string
+	follow         string
+	cleanupFollows bool
+	campaign       string
+	runCampaign    string
+}
+
+func (o *runOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.query, "query", "", "Search query (job title)")
+	fs.StringVar(&o.location, "location", "", "Search location")
+	fs.StringVar(&o.company, "company", "", "Company name")
+	fs.IntVar(&o.max, "max", 10, "Maximum number of profiles to process")
+	fs.StringVar(&o.importCSV, "import", "", "CSV of prospects (url,name,title,location), used while search is blocked or with no -query")
+	fs.StringVar(&o.input, "input", "", "CSV or list of profile URLs to connect with, or to use as the broadcast or event segment, instead of searching")
+	fs.StringVar(&o.matchEmails, "match-emails", "", "CSV or list of email addresses to look up by name and company; matches wait for review (see contacts)")
+	fs.BoolVar(&o.matched, "matched", false, "Source -connect prospects from confirmed email matches instead of searching")
+	fs.BoolVar(&o.connect, "connect", false, "Send connection requests")
+	fs.BoolVar(&o.withdrawStale, "withdraw-stale", false, "Withdraw invitations pending longer than limits.withdraw_after_days")
+	fs.BoolVar(&o.message, "message", false, "Send follow-up messages")
+	fs.BoolVar(&o.broadcast, "broadcast", false, "Message existing connections: stage a filtered segment for review, send approved ones")
+	fs.StringVar(&o.title, "title", "", "Broadcast segment: headline must contain this title")
+	fs.StringVar(&o.tag, "tag", "", "Broadcast segment: only contacts with this local tag")
+	fs.BoolVar(&o.selfAudit, "self-audit", false, "Snapshot my own profile now and report changes since the last snapshot")
+	fs.BoolVar(&o.track, "track", false, "Recheck tracked contacts for job changes (see tracking.*)")
+	fs.BoolVar(&o.checkAccepted, "check-accepted", false, "Check pending connection requests for acceptance and record it, without messaging")
+	fs.StringVar(&o.eventURL, "event", "", "Invite the -title/-company/-tag segment to this event (URL of an event you host)")
+	fs.StringVar(&o.leadList, "lead-list", "", "Sales Navigator lead list to source -connect prospects from")
+	fs.StringVar(&o.saveLeads, "save-leads", "", "Save accepted and replied prospects to this Sales Navigator lead list")
+	fs.StringVar(&o.follow, "follow", "", "Comma-separated profile or company page URLs to follow")
+	fs.BoolVar(&o.cleanupFollows, "cleanup-follows", false, "Unfollow what old warm-up campaigns followed (see follow.cleanup_*)")
+	fs.StringVar(&o.campaign, "campaign", "default", "Campaign the queued prospects belong to")
+	fs.StringVar(&o.runCampaign, "run-campaign", "", "Run the next steps of a campaign defined in campaigns.dir")
+}
+
+// none reports whether no action was asked for
+func (o *runOptions) none() bool {
+	return !o.connect && !o.withdrawStale && !o.message && !o.broadcast && o.eventURL == "" && !o.selfAudit && !o.track &&
+		!o.checkAccepted && o.follow == "" && !o.cleanupFollows && o.saveLeads == "" && o.runCampaign == "" && o.matchEmails == ""
+}
+
+func (o *runOptions) hasSegment() bool {
+	return o.title != "" || o.company != "" || o.tag != "" || o.input != ""
+}
+
+// segment picks the connections a broadcast or event invite goes to: the
+// -input list, or else those matching -title, -company and -tag
+func (o *runOptions) segment(s *session) ([]network.Connection, error) {
+	if o.input == "" {
+		return segmentConnections(s.page, s.cfg, s.store, s.lgr, o.title, o.company, o.tag, o.max)
+	}
+	profiles, err := search.LoadList(o.input, o.max)
+	if err != nil {
+		return nil, err
+	}
+	segment := make([]network.Connection, 0, len(profiles))
+	for _, p := range profiles {
+		segment = append(segment, network.Connection{URL: p.URL, Name: p.Name, Headline: p.Title})
+	}
+	return segment, nil
+}
+
+// collectSegment reports whether a new broadcast segment is staged
+func (o *runOptions) collectSegment() bool {
+	return o.broadcast && o.hasSegment()
+}
+
+// templates are the message texts, read from the environment or from the
+// files it names
+type templates struct {
+	note      string
+	message   string
+	broadcast string
+	bump      string
+	// sequence is messaging.sequence with its messages read
+	sequence []config.SequenceStep
+}
+
+func loadTemplates() templates {
+	t := templates{
+		note:      os.Getenv("CONNECTION_NOTE"),
+		message:   os.Getenv("FOLLOW_UP_MESSAGE"),
+		broadcast: os.Getenv("BROADCAST_MESSAGE"),
+		bump:      os.Getenv("BUMP_MESSAGE"),
+	}
+	if t.note == "" {
+		t.note = "Hi {name}, I'd love to connect with you!"
+	}
+	if t.message == "" {
+		t.message = "Thanks for connecting! Looking forward to staying in touch."
+	}
+	return t
+}
+
+// resolve reads the templates given as "file:path" and checks that every
+// template renders, so a typo fails the run before anything is queued
+func (t *templates) resolve(cfg *config.Config) error {
+	for _, v := range []struct {
+		env  string
+		text *string
+	}{
+		{"CONNECTION_NOTE", &t.note},
+		{"FOLLOW_UP_MESSAGE", &t.message},
+		{"BROADCAST_MESSAGE", &t.broadcast},
+		{"BUMP_MESSAGE", &t.bump},
+	} {
+		text, err := personalize.Load(*v.text, "")
+		if err != nil {
+			return fmt.Errorf("%s: %w", v.env, err)
+		}
+		if err := personalize.Validate(text); err != nil {
+			return fmt.Errorf("%s: %w", v.env, err)
+		}
+		*v.text = text
+	}
+
+	t.sequence = nil
+	for i, step := range cfg.Messaging.Sequence {
+		text, err := personalize.Load(step.Message, "")
+		if err != nil {
+			return fmt.Errorf("messaging.sequence step %d: %w", i+1, err)
+		}
+		if err := personalize.Validate(text); err != nil {
+			return fmt.Errorf("messaging.sequence step %d: %w", i+1, err)
+		}
+		t.sequence = append(t.sequence, config.SequenceStep{AfterDays: step.AfterDays, Message: text})
+	}
+	return nil
+}
+
+// checkOptions rejects runs that cannot work before a browser is started:
+// connecting with nowhere to source prospects from, a broadcast that could
+// not be reviewed, a campaign that does not load, and templates that fail lint
+func checkOptions(cfg *config.Config, lgr *logger.Logger, o runOptions, t templates) error {
+	if o.connect && o.query == "" && o.importCSV == "" && o.leadList == "" && o.input == "" && !o.matched {
+		return errors.New("a search query, -import CSV, -input list, -lead-list or -matched is required for sending connections")
+	}
+	if o.collectSegment() && (t.broadcast == "" || o.campaign == "default") {
+		return errors.New("staging a broadcast needs BROADCAST_MESSAGE and a named -campaign to review it under")
+	}
+	if _, err := search.NewFilter(cfg.Search); err != nil {
+		return err
+	}
+	if personalize.Dynamic(cfg.Limits.ConnectionNoteFallback) {
+		return errors.New("limits.connection_note_fallback is sent when variables are missing, so it cannot use any")
+	}
+	var def *campaign.Definition
+	if o.runCampaign != "" {
+		var err error
+		if def, err = campaign.Load(cfg.Campaigns.Dir, o.runCampaign); err != nil {
+			return err
+		}
+	}
+
+	// Lint templates before anything is sent
+	if !cfg.Lint.Enabled {
+		return nil
+	}
+	linter := lint.New(cfg)
+	var findings []lint.Finding
+	if o.connect {
+		findings = append(findings, linter.Lint(lint.KindNote, t.note)...)
+		if cfg.Limits.ConnectionNoteFallback != "" {
+			findings = append(findings, linter.Lint(lint.KindNote, cfg.Limits.ConnectionNoteFallback)...)
+		}
+	}
+	if o.message {
+		findings = append(findings, linter.Lint(lint.KindMessage, t.message)...)
+		if t.bump != "" && cfg.Messaging.BumpAfterDays > 0 {
+			findings = append(findings, linter.Lint(lint.KindMessage, t.bump)...)
+		}
+		for _, step := range t.sequence {
+			findings = append(findings, linter.Lint(lint.KindMessage, step.Message)...)
+		}
+	}
+	if o.collectSegment() {
+		findings = append(findings, linter.Lint(lint.KindMessage, t.broadcast)...)
+	}
+	if def != nil {
+		findings = append(findings, linter.Lint(lint.KindNote, def.Note)...)
+		for _, f := range def.FollowUps {
+			findings = append(findings, linter.Lint(lint.KindMessage, f.Message)...)
+		}
+	}
+
+	for _, f := range findings {
+		if f.Severity == lint.SeverityError {
+			lgr.Error("Template lint: %s", f)
+		} else {
+			lgr.Warn("Template lint: %s", f)
+		}
+	}
+
+	if lint.HasErrors(findings) {
+		return errors.New("template lint failed; fix the errors above or lower their severity in config")
+	}
+	return nil
+}
+
+// session is the logged-in browser shared by the actions of a run, and by
+// every job while running as a daemon
+type session struct {
+	cfg       *config.Config
+	lgr       *logger.Logger
+	store     *storage.Store
+	rc        *runctx.Context
+	br        *browser.Browser
+	page      *rod.Page
+	worker    *queue.Worker
+	limiter   *stealth.RateLimiter
+	scheduler *stealth.ActivityScheduler
+	safety    *safety.Monitor
+	caps      capability.Capabilities
+	templates templates
+	// pageUsers hold the page and follow it when Chrome is recycled or relaunched
+	pageUsers []interface{ SetPage(*rod.Page) }
+	// safeMode runs a new build at reduced volume; fullLimits are restored
+	// once it passes
+	build      string
+	safeMode   bool
+	fullLimits config.LimitsConfig
+	// watchInbox polls the unread count in daemon mode; see checkInbox
+	watchInbox  bool
+	inboxPolled time.Time
+	unread      int
+	// cooldownUntil stops the worker once a repeated challenge has escalated
+	// the cooldown mid-run; see onChallenge
+	cooldownUntil time.Time
+	// slowdown is how many times slower than configured the session runs
+	// after LinkedIn throttled it; see slowDown
+	slowdown float64
+	// team holds the prospect claims shared with other accounts; see claim
+	team *storage.ClaimStore
+}
+
+// openSession opens cfg's database, launches the browser, logs in and sets
+// up the queue worker; the caller sets the worker's deadline. Cancelling ctx
+// stops the session's work after the current step
+func openSession(ctx context.Context, cfg *config.Config, lgr *logger.Logger, tmpl templates) (*session, error) {
+	store, err := storage.New(cfg.Storage.DBPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	rc := runctx.New(cfg, lgr, store)
+	rc.Context = ctx
+	lgr = rc.Logger
+	if err := checkCooldown(cfg, store, lgr); err != nil {
+		store.Close()
+		return nil, err
+	}
+	rc.OnChallenge = func(detail string) {
+		challenged(cfg, store, lgr, detail)
+	}
+
+	if off := cfg.Features.Off(); len(off) > 0 {
+		lgr.Info("Features switched off: %s", strings.Join(off, ", "))
+	}
+
+	lgr.Info("Initializing browser...")
+	br, err := browser.New(cfg, lgr)
+	if err != nil {
+		store.Close()
+		return nil, fmt.Errorf("failed to initialize browser: %w", err)
+	}
+	page := br.Page()
+
+	lgr.Info("Authenticating...")
+	if err := auth.New(page, rc).Login(rc); err != nil {
+		br.Close()
+		store.Close()
+		return nil, fmt.Errorf("authentication failed: %w", err)
+	}
+	lgr.Info("✓ Successfully authenticated")
+	// A challenge while logging in may have escalated the cooldown
+	if err := checkCooldown(cfg, store, lgr); err != nil {
+		br.Close()
+		store.Close()
+		return nil, err
+	}
+
+	// Gate features and limits on the account's subscription
+	caps, err := capability.Ensure(page, cfg, lgr, store)
+	if err != nil {
+		// Limits stay as configured rather than cut to a free account's
+		lgr.Warn("Failed to detect account capabilities: %v", err)
+	} else {
+		caps.Apply(cfg)
+	}
+
+	// Wait after login
+	stealth.RandomDelay(2000, 4000)
+
+	// The queue worker centralizes pacing: items are pulled only when the
+	// rate limiter and activity scheduler both approve
+	scheduler, err := stealth.NewActivityScheduler(cfg.Stealth.Timezone)
+	if err != nil {
+		br.Close()
+		store.Close()
+		return nil, fmt.Errorf("failed to initialize scheduler: %w", err)
+	}
+	scheduler.SetThinkTimeFactor(cfg.Stealth.ThinkTimeFactor)
+	limiter := restoreLimiter(cfg, store, lgr)
+	worker := queue.NewWorker(store, limiter, scheduler, lgr)
+	worker.SetMaxAttempts(cfg.Queue.MaxAttempts)
+	// Cancelling ctx lets the current action finish and keeps the rest queued
+	context.AfterFunc(ctx, worker.Stop)
+
+	s := &session{
+		cfg:       cfg,
+		lgr:       lgr,
+		store:     store,
+		br:        br,
+		page:      page,
+		rc:        rc,
+		worker:    worker,
+		limiter:   limiter,
+		scheduler: scheduler,
+		caps:      caps,
+		templates: tmpl,
+	}
+
+	// Chrome is recycled between actions and relaunched after a crash; the
+	// components holding the page follow it, and the queue resumes where it was
+	if cfg.Safety.Enabled {
+		s.safety = safety.New(page)
+	}
+
+	if err := s.openTeam(); err != nil {
+		br.Close()
+		store.Close()
+		return nil, err
+	}
+
+	worker.OnChallenge(s.onChallenge)
+	worker.BeforeItem(func() error {
+		if err := s.checkSafety(); err != nil {
+			return err
+		}
+		if time.Now().Before(s.cooldownUntil) {
+			return fmt.Errorf("mandatory cooldown until %s", s.cooldownUntil.Local().Format(time.RFC1123))
+		}
+		// Replies take priority over the next queued action
+		s.checkInbox()
+
+		br.CheckMemory()
+		reason := br.RecycleDue()
+		if reason == "" {
+			return nil
+		}
+
+		s.lgr.Info("Recycling browser: %s", reason)
+		if err := relaunchBrowser(br, s.rc); err != nil {
+			return err
+		}
+		s.followPage()
+		return nil
+	})
+	worker.Recover(func() (bool, error) {
+		if br.Alive() {
+			return false, nil
+		}
+
+		s.lgr.Warn("Browser crashed or disconnected; relaunching with the saved session")
+		store.RecordIncident(storage.IncidentBrowserCrash, "")
+		if err := relaunchBrowser(br, s.rc); err != nil {
+			return false, err
+		}
+		s.followPage()
+		return true, nil
+	})
+
+	if err := s.checkWarmUp(); err != nil {
+		s.close()
+		return nil, err
+	}
+	s.checkBuild()
+	return s, nil
+}
+
+// close saves the cookies and shuts the browser and database
+func (s *session) close() {
+	if s.br.Alive() {
+		if err := auth.New(s.page, s.rc).SaveSession(); err != nil {
+			s.lgr.Warn("Failed to save session: %v", err)
+		}
+	}
+	s.br.Close()
+	s.store.Close()
+	if s.team != nil {
+		s.team.Close()
+	}
+	// Delays are slowed process-wide; the next account starts at full speed
+	if s.slowdown != 0 {
+		stealth.SetDelayFactor(1)
+	}
+}
+
+// newLimiter returns a rate limiter with the rate_limits overrides applied
+func newLimiter(cfg *config.Config, lgr *logger.Logger) *stealth.RateLimiter {
+	rl := stealth.NewRateLimiter()
+	for name, l := range cfg.RateLimits {
+		err := rl.SetLimit(stealth.ActionType(name), stealth.ActionLimit{
+			HourlyMax:        l.HourlyMax,
+			DailyMax:         l.DailyMax,
+			MinInterval:      time.Duration(l.MinIntervalSeconds) * time.Second,
+			CooldownAfter:    l.CooldownAfter,
+			CooldownDuration: time.Duration(l.CooldownSeconds) * time.Second,
+		})
+		if err != nil {
+			lgr.Warn("Ignoring rate_limits.%s: %v", name, err)
+		}
+	}
+	return rl
+}
+
+// restoreLimiter returns a rate limiter that carries on from the history and
+// cooldown saved in limiter_actions, so a restart, or a crash loop, does not
+// reset the hourly and daily budgets. Profile views and searches components
+// take through the limiter also go to the action log, next to the queue's
+func restoreLimiter(cfg *config.Config, store *storage.Store, lgr *logger.Logger) *stealth.RateLimiter {
+	rl := newLimiter(cfg, lgr)
+
+	actions, err := store.GetLimiterActionsSince(time.Now().Add(-24 * time.Hour))
+	if err != nil {
+		lgr.Warn("Failed to restore rate limits: %v", err)
+	}
+	for _, a := range actions {
+		rl.Restore(stealth.ActionType(a.Action), a.At)
+	}
+	if len(actions) > 0 {
+		lgr.Debug("Restored %d actions from the last 24h into the rate limiter", len(actions))
+	}
+	if until, err := store.GetStateTime(storage.StateLimiterCooldownUntil); err == nil && time.Now().Before(until) {
+		lgr.Info("Resuming rate limiter cooldown until %s", until.Local().Format("15:04:05"))
+		rl.RestoreCooldown(until)
+	}
+
+	rl.OnAcquire(func(actionType stealth.ActionType) {
+		if err := store.AppendActionLog(storage.ActionLogEntry{Action: string(actionType), Outcome: "success"}); err != nil {
+			lgr.Warn("Failed to write action log: %v", err)
+		}
+	})
+
+	// A dry run is paced like a real one but leaves the next run's budgets alone
+	if cfg.DryRun {
+		return rl
+	}
+	rl.OnRecord(func(actionType stealth.ActionType, at time.Time) {
+		if err := store.RecordLimiterAction(string(actionType), at); err != nil {
+			lgr.Warn("%v", err)
+		}
+	}, func(until time.Time) {
+		if err := store.SetStateTime(storage.StateLimiterCooldownUntil, until); err != nil {
+			lgr.Warn("Failed to save rate limiter cooldown: %v", err)
+		}
+	})
+	return rl
+}
+
+func (s *session) followPage() {
+	s.page = s.br.Page()
+	// The monitor outlives each run's page users, which are reset per run
+	if s.safety != nil {
+		s.safety.SetPage(s.page)
+	}
+	for _, u := range s.pageUsers {
+		u.SetPage(s.page)
+	}
+}
+
+// run executes the actions o asks for, recording results in rep
+func (s *session) run(o runOptions, rep *report.Report) {
+	rc := s.rc
+	if o.campaign != "default" {
+		rc = rc.WithCampaign(o.campaign)
+	}
+	cfg, lgr, store, worker := s.cfg, rc.Logger, s.store, s.worker
+	s.pageUsers = nil
+
+	// Early warning: compare my own profile with the last snapshot
+	if cfg.SelfAudit.Enabled || o.selfAudit {
+		changes, err := selfaudit.Ensure(s.page, cfg, lgr, store, o.selfAudit)
+		if err != nil {
+			lgr.Warn("Self-audit failed: %v", err)
+		}
+		for _, c := range changes {
+			if c.Alert {
+				rep.Alerts = append(rep.Alerts, "own profile "+c.String())
+			}
+		}
+	}
+
+	if o.track {
+		s.checkTracked(rep)
+	}
+
+	if o.matchEmails != "" && worker.Expired() {
+		lgr.Info("Maximum runtime reached; skipping email matching")
+	} else if o.matchEmails != "" {
+		s.matchEmails(rc, rep, o.matchEmails, o.max)
+	}
+
+	if o.connect && worker.Expired() {
+		lgr.Info("Maximum runtime reached; skipping connection requests")
+	} else if o.connect {
+		var profiles []search.Profile
+		var err error
+		if o.input != "" {
+			profiles, err = loadInput(store, lgr, o.input, o.max)
+		} else if o.matched {
+			profiles, err = loadMatched(store, lgr, o.max)
+		} else if o.leadList != "" {
+			if err = s.caps.Require(capability.FeatureSalesNavLists); err == nil {
+				syncer := salesnav.New(s.page, cfg, lgr, store)
+				s.pageUsers = append(s.pageUsers, syncer)
+				profiles, err = syncer.ImportList(o.leadList, o.max)
+			} else {
+				err = fmt.Errorf("lead lists need Sales Navigator: %w", err)
+			}
+		} else {
+			profiles, err = sourceProspects(s.page, rc, s.limiter, rep, o.query, o.location, o.company, o.importCSV, o.max)
+		}
+		if err != nil {
+			lgr.Error("%v", err)
+			rep.AddError("sourcing: %v", err)
+		}
+
+		lgr.Info("✓ Found %d profiles", len(profiles))
+
+		// Send connection requests
+		lgr.Info("Sending connection requests...")
+		connector := connect.New(s.page, rc)
+		connector.SetLimiter(s.limiter)
+		s.pageUsers = append(s.pageUsers, connector)
+
+		if _, err := connector.EnqueueProfiles(profiles, s.templates.note, o.campaign); err != nil {
+			lgr.Error("Failed to queue connections: %v", err)
+		}
+
+		worker.Handle(storage.QueueActionConnect, stealth.ActionConnectionReq, connector.ProcessQueueItem)
+		if cfg.Stealth.BiasToBestHours {
+			biasPacing(worker, cfg, store, lgr, storage.QueueActionConnect)
+		}
+		sent, err := worker.Run(storage.QueueActionConnect)
+		if err != nil {
+			lgr.Error("Failed to send connections: %v", err)
+			rep.AddError("connect: %v", err)
+		}
+		rep.ConnectionsSent = sent
+
+		lgr.Info("✓ Connection requests completed (%d sent)", sent)
+	}
+
+	if o.withdrawStale && worker.Expired() {
+		lgr.Info("Maximum runtime reached; skipping stale invitations")
+	} else if o.withdrawStale {
+		connector := connect.New(s.page, rc)
+		connector.SetLimiter(s.limiter)
+		s.pageUsers = append(s.pageUsers, connector)
+
+		withdrawn, err := connector.WithdrawStaleInvitations(rc, cfg.Limits.WithdrawAfterDays)
+		if err != nil {
+			lgr.Error("Failed to withdraw stale invitations: %v", err)
+			rep.AddError("withdraw: %v", err)
+		}
+		lgr.Info("✓ Stale invitations withdrawn (%d)", withdrawn)
+	}
+
+	if o.runCampaign != "" && worker.Expired() {
+		lgr.Info("Maximum runtime reached; skipping campaign %q", o.runCampaign)
+	} else if o.runCampaign != "" {
+		s.runCampaign(o.runCampaign, rep)
+	}
+
+	if o.checkAccepted && worker.Expired() {
+		lgr.Info("Maximum runtime reached; skipping the acceptance check")
+	} else if o.checkAccepted {
+		s.checkAccepted(rc, rep)
+	}
+
+	if o.message && worker.Expired() {
+		lgr.Info("Maximum runtime reached; skipping follow-up messages")
+	} else if o.message {
+		// Send follow-up messages
+		lgr.Info("Sending follow-up messages...")
+		messenger := message.New(s.page, rc)
+		messenger.SetLimiter(s.limiter)
+		s.pageUsers = append(s.pageUsers, messenger)
+
+		// Read receipts decide which earlier follow-ups are due a bump
+		if _, err := messenger.CheckReceipts(rc); err != nil {
+			lgr.Warn("Failed to check read receipts: %v", err)
+		}
+		messenger.SetSequence(s.templates.sequence)
+		if _, err := messenger.QueueFollowUps(rc, s.templates.message); err != nil {
+			lgr.Error("Failed to queue messages: %v", err)
+		}
+		if len(s.templates.sequence) > 0 {
+			runner := campaign.New(s.page, rc.WithCampaign(storage.SequenceCampaign))
+			if _, err := runner.AdvanceSequence(s.templates.sequence); err != nil {
+				lgr.Error("Failed to advance follow-up sequences: %v", err)
+			}
+		}
+		if _, err := messenger.QueueBumps(s.templates.bump); err != nil {
+			lgr.Error("Failed to queue bumps: %v", err)
+		}
+
+		worker.Handle(storage.QueueActionMessage, stealth.ActionMessage, messenger.ProcessQueueItem)
+		if cfg.Stealth.BiasToBestHours {
+			biasPacing(worker, cfg, store, lgr, storage.QueueActionMessage)
+		}
+		sent, err := worker.Run(storage.QueueActionMessage)
+		if err != nil {
+			lgr.Error("Failed to send messages: %v", err)
+			rep.AddError("message: %v", err)
+		}
+		rep.MessagesSent = sent
+
+		worker.Handle(storage.QueueActionBump, stealth.ActionMessage, messenger.ProcessBumpItem)
+		bumped, err := worker.Run(storage.QueueActionBump)
+		if err != nil {
+			lgr.Error("Failed to send bumps: %v", err)
+			rep.AddError("bump: %v", err)
+		}
+		rep.MessagesSent += bumped
+
+		lgr.Info("✓ Follow-up messages completed (%d sent, %d bumps)", sent, bumped)
+	}
+
+	if o.broadcast && worker.Expired() {
+		lgr.Info("Maximum runtime reached; skipping broadcast")
+	} else if o.broadcast {
+		messenger := message.New(s.page, rc)
+		messenger.SetLimiter(s.limiter)
+		s.pageUsers = append(s.pageUsers, messenger)
+
+		// New segments are only staged; they need approval before anything is sent
+		if o.collectSegment() {
+			segment, err := o.segment(s)
+			if err != nil {
+				lgr.Error("Failed to build broadcast segment: %v", err)
+				rep.AddError("broadcast: %v", err)
+			} else if n, err := messenger.QueueBroadcast(segment, s.templates.broadcast, o.campaign); err != nil {
+				lgr.Error("Failed to stage broadcast: %v", err)
+			} else if n > 0 {
+				lgr.Info("Review with: go run . broadcast review %s, then approve it", o.campaign)
+			}
+		}
+
+		worker.Handle(storage.QueueActionBroadcast, stealth.ActionMessage, messenger.ProcessBroadcastItem)
+		sent, err := worker.Run(storage.QueueActionBroadcast)
+		if err != nil {
+			lgr.Error("Failed to send broadcast: %v", err)
+			rep.AddError("broadcast: %v", err)
+		}
+		rep.MessagesSent += sent
+
+		lgr.Info("✓ Broadcast completed (%d sent)", sent)
+	}
+
+	if o.eventURL != "" && worker.Expired() {
+		lgr.Info("Maximum runtime reached; skipping event invites")
+	} else if o.eventURL != "" {
+		inviter := event.New(s.page, cfg, lgr, store)
+		s.pageUsers = append(s.pageUsers, inviter)
+
+		// Each event gets its own campaign unless one is named
+		eventCampaign := o.campaign
+		if eventCampaign == "default" {
+			eventCampaign = "event:" + strings.TrimSuffix(o.eventURL, "/")
+		}
+
+		if o.hasSegment() {
+			segment, err := o.segment(s)
+			if err != nil {
+				lgr.Error("Failed to build event invite segment: %v", err)
+				rep.AddError("event: %v", err)
+			} else if _, err := inviter.EnqueueInvites(o.eventURL, segment, eventCampaign); err != nil {
+				lgr.Error("Failed to queue event invites: %v", err)
+			}
+		}
+
+		worker.Handle(storage.QueueActionEventInvite, stealth.ActionEventInvite, inviter.ProcessQueueItem)
+		sent, err := worker.Run(storage.QueueActionEventInvite)
+		if err != nil {
+			lgr.Error("Failed to send event invites: %v", err)
+			rep.AddError("event: %v", err)
+		}
+		rep.EventInvitesSent = sent
+
+		lgr.Info("✓ Event invites completed (%d sent)", sent)
+	}
+
+	if o.saveLeads != "" && worker.Expired() {
+		lgr.Info("Maximum runtime reached; skipping lead list sync")
+	} else if o.saveLeads != "" {
+		if err := s.caps.Require(capability.FeatureSalesNavLists); err != nil {
+			lgr.Error("Lead lists need Sales Navigator: %v", err)
+			rep.AddError("save-leads: %v", err)
+		} else {
+			syncer := salesnav.New(s.page, cfg, lgr, store)
+			s.pageUsers = append(s.pageUsers, syncer)
+
+			if _, err := syncer.EnqueueSaves(o.saveLeads); err != nil {
+				lgr.Error("Failed to queue lead saves: %v", err)
+			}
+			worker.Handle(storage.QueueActionSaveLead, stealth.ActionPageView, syncer.ProcessQueueItem)
+			saved, err := worker.Run(storage.QueueActionSaveLead)
+			if err != nil {
+				lgr.Error("Failed to save leads: %v", err)
+				rep.AddError("save-leads: %v", err)
+			}
+			lgr.Info("✓ Lead list sync completed (%d saved to %q)", saved, o.saveLeads)
+		}
+	}
+
+	if (o.follow != "" || o.cleanupFollows) && worker.Expired() {
+		lgr.Info("Maximum runtime reached; skipping follows")
+	} else if o.follow != "" || o.cleanupFollows {
+		follower := follow.New(s.page, cfg, lgr, store)
+		s.pageUsers = append(s.pageUsers, follower)
+
+		if o.follow != "" {
+			var urls []string
+			for _, u := range strings.Split(o.follow, ",") {
+				if u = strings.TrimSpace(u); u != "" {
+					urls = append(urls, u)
+				}
+			}
+			if _, err := follower.EnqueueFollows(urls, o.campaign); err != nil {
+				lgr.Error("Failed to queue follows: %v", err)
+			}
+			worker.Handle(storage.QueueActionFollow, stealth.ActionFollow, follower.ProcessFollowItem)
+			followed, err := worker.Run(storage.QueueActionFollow)
+			if err != nil {
+				lgr.Error("Failed to follow: %v", err)
+				rep.AddError("follow: %v", err)
+			}
+			lgr.Info("✓ Follows completed (%d followed)", followed)
+		}
+
+		if o.cleanupFollows {
+			if _, err := follower.QueueCleanup(); err != nil {
+				lgr.Error("Failed to queue unfollows: %v", err)
+			}
+			worker.Handle(storage.QueueActionUnfollow, stealth.ActionFollow, follower.ProcessUnfollowItem)
+			unfollowed, err := worker.Run(storage.QueueActionUnfollow)
+			if err != nil {
+				lgr.Error("Failed to unfollow: %v", err)
+				rep.AddError("unfollow: %v", err)
+			}
+			lgr.Info("✓ Follow cleanup completed (%d unfollowed)", unfollowed)
+		}
+	}
+}
+
+// runCampaign tops the campaign up with new prospects, advances the ones it
+// has, and sends what is due
+func (s *session) runCampaign(name string, rep *report.Report) {
+	rc := s.rc.WithCampaign(name)
+	cfg, lgr, worker := s.cfg, rc.Logger, s.worker
+
+	def, err := campaign.Load(cfg.Campaigns.Dir, name)
+	if err != nil {
+		lgr.Error("%v", err)
+		rep.AddError("campaign: %v", err)
+		return
+	}
+	// Daemon jobs switch accounts, so the pin is checked on the session's own
+	if def.Account != "" && def.Account != cfg.Account {
+		lgr.Error("Campaign %q runs under account %q only; run it with -account %s", name, def.Account, def.Account)
+		rep.AddError("campaign: %q is pinned to account %q", name, def.Account)
+		return
+	}
+
+	runner := campaign.New(s.page, rc)
+	if err := runner.SetCompliance(s.page, def.Compliance); err != nil {
+		lgr.Error("%v", err)
+		rep.AddError("campaign: %v", err)
+		return
+	}
+	runner.SetLimiter(s.limiter)
+	s.pageUsers = append(s.pageUsers, runner)
+
+	// Search only while the campaign is short of prospects
+	if missing, err := runner.Missing(def); err != nil {
+		lgr.Error("Failed to count campaign prospects: %v", err)
+	} else if missing > 0 {
+		sp := def.Search
+		profiles, err := sourceProspects(s.page, rc, s.limiter, rep, sp.Query, sp.Location, sp.Company, sp.Import, sp.Max)
+		if err != nil {
+			lgr.Error("%v", err)
+			rep.AddError("campaign sourcing: %v", err)
+		}
+		if _, err := runner.Enqueue(def, profiles); err != nil {
+			lgr.Error("Failed to queue campaign connections: %v", err)
+		}
+	}
+
+	// Job change messages need fresh reads of the tracked profiles
+	if def.OnJobChange != nil {
+		s.checkTracked(rep)
+	}
+
+	if _, err := runner.Advance(def); err != nil {
+		lgr.Error("Failed to advance campaign %q: %v", name, err)
+		rep.AddError("campaign: %v", err)
+	}
+
+	worker.Handle(storage.QueueActionConnect, stealth.ActionConnectionReq, runner.ProcessConnectItem)
+	sent, err := worker.Run(storage.QueueActionConnect)
+	if err != nil {
+		lgr.Error("Failed to send connections: %v", err)
+		rep.AddError("connect: %v", err)
+	}
+	rep.ConnectionsSent += sent
+
+	worker.Handle(storage.QueueActionMessage, stealth.ActionMessage, runner.ProcessMessageItem)
+	messaged, err := worker.Run(storage.QueueActionMessage)
+	if err != nil {
+		lgr.Error("Failed to send messages: %v", err)
+		rep.AddError("message: %v", err)
+	}
+	rep.MessagesSent += messaged
+
+	if def.OnJobChange != nil {
+		worker.Handle(storage.QueueActionCongrats, stealth.ActionMessage, runner.ProcessCongratsItem)
+		congratulated, err := worker.Run(storage.QueueActionCongrats)
+		if err != nil {
+			lgr.Error("Failed to send job change messages: %v", err)
+			rep.AddError("congrats: %v", err)
+		}
+		rep.MessagesSent += congratulated
+		messaged += congratulated
+	}
+
+	lgr.Info("✓ Campaign %q completed (%d connection requests, %d follow-ups sent)", name, sent, messaged)
+}
+
+// checkTracked revisits the tracked contacts due a check for job changes
+func (s *session) checkTracked(rep *report.Report) {
+	tracker := tracking.New(s.page, s.cfg, s.lgr, s.store)
+	tracker.SetLimiter(s.limiter)
+	s.pageUsers = append(s.pageUsers, tracker)
+	changes, err := tracker.Check()
+	if err != nil {
+		s.lgr.Error("Failed to check tracked contacts: %v", err)
+		rep.AddError("tracking: %v", err)
+	}
+	rep.JobChanges += len(changes)
+}
+
+// finish summarizes a run and persists next-step recommendations
+func (s *session) finish(rep *report.Report) {
+	s.passSafeMode(rep)
+	rep.Finish(s.cfg, s.store)
+	if s.cfg.Features.Analytics {
+		if err := s.store.SnapshotPipeline(time.Now()); err != nil {
+			s.lgr.Warn("Failed to snapshot pipeline: %v", err)
+		}
+	}
+	if path, err := rep.Save(s.cfg.Storage.ReportDir); err != nil {
+		s.lgr.Warn("Failed to save run report: %v", err)
+	} else {
+		s.lgr.Info("Run report saved to %s", path)
+	}
+	rep.Print()
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"time"
+
+	"linkedin-automation/internal/report"
+	"linkedin-automation/internal/storage"
+)
+
+// buildVersion identifies the running binary: the VCS revision go build
+// stamps, the module version, or else a hash of the executable, which is
+// what changes for go run and builds of a modified tree
+func buildVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		revision, modified := "", false
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				revision = s.Value
+			case "vcs.modified":
+				modified = s.Value == "true"
+			}
+		}
+		if revision != "" && !modified {
+			return revision[:min(12, len(revision))]
+		}
+		if v := info.Main.Version; v != "" && v != "(devel)" && !modified {
+			return v
+		}
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	f, err := os.Open(exe)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return ""
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// checkBuild compares the running build with the last one that completed a
+// run and enters safe mode when it changed: limits are scaled down and every
+// action is followed by a screenshot for review
+func (s *session) checkBuild() {
+	cfg, lgr := s.cfg, s.lgr
+	if !cfg.SafeMode.Enabled {
+		return
+	}
+	if s.build = buildVersion(); s.build == "" {
+		lgr.Warn("Build version unknown; safe mode disabled")
+		return
+	}
+
+	last, err := s.store.GetState(storage.StateBuildVersion)
+	if err != nil {
+		lgr.Warn("%v", err)
+		return
+	}
+	// A fresh database has no earlier build to compare with
+	if last == "" {
+		if err := s.store.SetState(storage.StateBuildVersion, s.build); err != nil {
+			lgr.Warn("%v", err)
+		}
+		return
+	}
+	if last == s.build {
+		return
+	}
+
+	if err := os.MkdirAll(cfg.SafeMode.ScreenshotDir, 0755); err != nil {
+		lgr.Warn("Failed to create safe mode screenshot directory: %v", err)
+	}
+	s.fullLimits = cfg.Limits
+	cfg.Limits.Scale(cfg.SafeMode.VolumePercent)
+	s.worker.AfterItem(s.screenshot)
+
+	lgr.Warn("New build %s (last run with %s): safe mode at %d%% volume until a run completes without errors",
+		s.build, last, cfg.SafeMode.VolumePercent)
+	lgr.Info("Safe mode screenshots go to %s", cfg.SafeMode.ScreenshotDir)
+	for _, line := range cfg.PacingSummary() {
+		lgr.Info("%s", line)
+	}
+	s.safeMode = true
+}
+
+// screenshot saves the page after a queue item so its outcome can be checked
+func (s *session) screenshot(item storage.QueueItem, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = "failed"
+	}
+	name := fmt.Sprintf("%s-%s-%d-%s.png", time.Now().Format("20060102-150405"), item.Action, item.ID, outcome)
+	path := filepath.Join(s.cfg.SafeMode.ScreenshotDir, name)
+	if err := s.br.Screenshot(path); err != nil {
+		s.lgr.Warn("Failed to save safe mode screenshot: %v", err)
+		return
+	}
+	s.lgr.Debug("Safe mode screenshot: %s", path)
+}
+
+// passSafeMode ends safe mode after a run without errors: the build is
+// recorded as verified and later runs of the session get the full limits.
+// A dry run sends nothing, so it verifies nothing
+func (s *session) passSafeMode(rep *report.Report) {
+	if !s.safeMode {
+		return
+	}
+	rep.SafeMode = s.build
+	if s.cfg.DryRun || len(rep.Errors) > 0 {
+		s.lgr.Warn("Safe mode stays on for build %s until a run completes without errors", s.build)
+		return
+	}
+
+	if err := s.store.SetState(storage.StateBuildVersion, s.build); err != nil {
+		s.lgr.Warn("%v", err)
+		return
+	}
+	// Limits cut after throttling stay cut for the rest of the session
+	if s.slowdown == 0 {
+		s.cfg.Limits = s.fullLimits
+	}
+	s.worker.AfterItem(nil)
+	s.safeMode = false
+	s.lgr.Info("Build %s passed safe mode; full volume from now on", s.build)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"linkedin-automation/internal/cooldown"
+	"linkedin-automation/internal/notify"
+	"linkedin-automation/internal/safety"
+	"linkedin-automation/internal/stealth"
+	"linkedin-automation/internal/storage"
+)
+
+// maxSlowdown caps how far repeated throttling stretches the session's delays
+const maxSlowdown = 8
+
+// checkSafety inspects the page after the navigations since the last check.
+// A warning, restriction or checkpoint is recorded, emailed, starts a
+// cooldown and is returned, which stops the worker and with it the current
+// campaign.
+// Throttling is recorded and slows the session down instead
+func (s *session) checkSafety() error {
+	if s.safety == nil {
+		return nil
+	}
+	f := s.safety.Check()
+	if f == nil {
+		return nil
+	}
+
+	if f.Kind == safety.KindThrottled {
+		s.lgr.Warn("LinkedIn is throttling requests: %s", f)
+		if err := s.store.RecordIncident(f.Kind, f.URL+" "+f.Detail); err != nil {
+			s.lgr.Warn("%v", err)
+		}
+		s.slowDown()
+		return nil
+	}
+
+	s.lgr.Error("LinkedIn showed a %s", f)
+	if err := s.store.RecordIncident(f.Kind, f.URL+" "+f.Detail); err != nil {
+		s.lgr.Warn("%v", err)
+	}
+
+	now := time.Now()
+	hours := s.cfg.Safety.CooldownHours
+	switch f.Kind {
+	case safety.KindRestriction:
+		hours = s.cfg.Safety.RestrictionCooldownHours
+	case safety.KindInviteLimit:
+		// Seen outside a connection request, so nothing has recorded it yet
+		if until, err := s.store.GetStateTime(storage.StateInviteLimitUntil); err == nil && now.After(until) {
+			if err := s.store.SetStateTime(storage.StateInviteLimitUntil, now.Add(7*24*time.Hour)); err != nil {
+				s.lgr.Warn("%v", err)
+			}
+		}
+	}
+
+	until := now.Add(time.Duration(hours) * time.Hour)
+	if err := cooldown.Hold(s.store, until); err != nil {
+		s.lgr.Warn("Failed to persist cooldown: %v", err)
+	}
+	if until.After(s.cooldownUntil) {
+		s.cooldownUntil = until
+	}
+	err := fmt.Errorf("%s; nothing is sent until %s", f.Kind, until.Local().Format(time.RFC1123))
+	s.alert("LinkedIn showed a "+f.Kind+" on "+s.cfg.Creds.Email, f.String()+"\n\n"+err.Error()+"\n")
+	return err
+}
+
+// alert emails the operator about a critical failure when email.alerts is on
+func (s *session) alert(subject, body string) {
+	if !s.cfg.Email.Alerting() {
+		return
+	}
+	if err := notify.Email(s.cfg.Email, subject, body); err != nil {
+		s.lgr.Warn("Failed to email alert: %v", err)
+	}
+}
+
+// slowDown multiplies every delay for the rest of the session by
+// safety.slowdown_factor, compounding up to maxSlowdown, and cuts what is
+// left of each limit to safety.slowdown_keep of it
+func (s *session) slowDown() {
+	cfg, lgr := s.cfg, s.lgr
+	factor, keep := cfg.Safety.SlowdownFactor, cfg.Safety.SlowdownKeep
+	if s.slowdown == 0 {
+		s.slowdown = 1
+	}
+	if s.slowdown*factor > maxSlowdown {
+		factor = maxSlowdown / s.slowdown
+	}
+	s.slowdown *= factor
+
+	stealth.SetDelayFactor(s.slowdown)
+	think := cfg.Stealth.ThinkTimeFactor
+	if think <= 0 {
+		think = 1
+	}
+	s.scheduler.SetThinkTimeFactor(think * s.slowdown)
+	s.limiter.SlowDown(factor, keep)
+	s.cutLimits(keep)
+
+	lgr.Warn("Slowed down to x%.1f delays for the rest of the session", s.slowdown)
+	for _, line := range cfg.PacingSummary() {
+		lgr.Info("%s", line)
+	}
+}
+
+// cutLimits lowers each daily and weekly limit to what was used so far plus
+// keep of what remained
+func (s *session) cutLimits(keep float64) {
+	l, store := &s.cfg.Limits, s.store
+	count := func(n int, err error) int {
+		if err != nil {
+			s.lgr.Warn("%v", err)
+		}
+		return n
+	}
+	follows, unfollows, err := store.GetFollowCountsToday()
+	if err != nil {
+		s.lgr.Warn("%v", err)
+	}
+	weekAgo := time.Now().AddDate(0, 0, -7)
+	for _, c := range []struct {
+		limit *int
+		used  int
+	}{
+		{&l.MaxConnectionsPerDay, count(store.GetConnectionsCountToday())},
+		{&l.MaxConnectionsPerWeek, count(store.GetConnectionsCountSince(weekAgo))},
+		{&l.MaxMessagesPerDay, count(store.GetMessagesCountToday())},
+		{&l.MaxBroadcastsPerDay, count(store.GetMessagesCountTodayByKind(storage.MessageKindBroadcast))},
+		{&l.MaxEventInvitesPerDay, count(store.GetEventInvitesCountToday())},
+		{&l.MaxFollowsPerDay, follows},
+		{&l.MaxUnfollowsPerDay, unfollows},
+		{&l.MaxWithdrawalsPerDay, count(store.GetWithdrawalsCountToday())},
+	} {
+		if *c.limit > c.used {
+			*c.limit = c.used + int(float64(*c.limit-c.used)*keep)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"linkedin-automation/internal/warmup"
+)
+
+// checkWarmUp records the account's first run, starts the warm-up on the
+// first run with it enabled, and caps the limits to today's step, so no
+// campaign or action sends more than the warm-up allows. It runs before safe
+// mode, whose full limits keep the cap. Like the cooldown it fails closed:
+// an account whose age or progress cannot be read is not run at full limits
+func (s *session) checkWarmUp() error {
+	now := time.Now()
+	if _, err := warmup.FirstSeen(s.store, now); err != nil {
+		return fmt.Errorf("failed to record account age, not starting: %w", err)
+	}
+	if !s.cfg.WarmUp.Enabled {
+		return nil
+	}
+	if _, err := warmup.Start(s.store, now); err != nil {
+		return fmt.Errorf("failed to start warm-up, not starting: %w", err)
+	}
+
+	p, err := warmup.Load(s.cfg, s.store, now)
+	if err != nil {
+		return fmt.Errorf("failed to read warm-up progress, not starting: %w", err)
+	}
+	p.Apply(s.cfg)
+	s.lgr.Info("Account %s", p)
+	if p.Graduated() {
+		return nil
+	}
+	for _, line := range s.cfg.PacingSummary() {
+		s.lgr.Info("%s", line)
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-rod/rod/lib/launcher"
+
+	"linkedin-automation/config"
+)
+
+// setupInit asks for the few settings a new user has to decide on, writes a
+// config from the documented example with those filled in, checks it against
+// the schema and reports whether a browser is available
+func setupInit(fs *flag.FlagSet) func(env *cmdEnv) error {
+	envPath := fs.String("env", ".env", "Where to write credentials and templates")
+	force := fs.Bool("force", false, "Overwrite an existing config file")
+
+	return func(env *cmdEnv) error {
+		if _, err := os.Stat(env.configPath); err == nil && !*force {
+			return fmt.Errorf("%s already exists (use -force to overwrite, or -config for another path)", env.configPath)
+		}
+
+		in := bufio.NewReader(os.Stdin)
+		ask := func(question, def string) (string, error) {
+			if def != "" {
+				fmt.Printf("%s [%s]: ", question, def)
+			} else {
+				fmt.Printf("%s: ", question)
+			}
+			line, err := in.ReadString('\n')
+			if err != nil && (err != io.EOF || line == "") {
+				return "", fmt.Errorf("no answer: %w", err)
+			}
+			if line = strings.TrimSpace(line); line == "" {
+				return def, nil
+			}
+			return line, nil
+		}
+
+		fmt.Println("Creating", env.configPath, "- press Enter to keep the suggestion in brackets")
+		var a wizardAnswers
+		var err error
+		for {
+			if a.account, err = ask("Account name, for running several accounts (empty for one)", ""); err != nil {
+				return err
+			}
+			if a.account == "" || validAccountName(a.account) {
+				break
+			}
+			fmt.Println("  Use letters, digits, - and _ only")
+		}
+		if a.email, err = ask("LinkedIn email", ""); err != nil {
+			return err
+		}
+		for {
+			if a.pacing, err = ask("Pacing preset ("+strings.Join(config.PacingPresets(), ", ")+")", "conservative"); err != nil {
+				return err
+			}
+			if slices.Contains(config.PacingPresets(), a.pacing) {
+				break
+			}
+			fmt.Println("  Unknown preset")
+		}
+		if a.dataDir, err = ask("Data directory (database, session cookies, reports)", "./data"); err != nil {
+			return err
+		}
+		found, _ := launcher.LookPath()
+		if a.chrome, err = ask("Chrome or Chromium binary (empty lets Rod download one)", found); err != nil {
+			return err
+		}
+		if a.note, err = ask("Connection note ({name} is replaced)", loadTemplates().note); err != nil {
+			return err
+		}
+		if a.message, err = ask("Follow-up message", loadTemplates().message); err != nil {
+			return err
+		}
+
+		data, err := a.config()
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(env.configPath), 0755); err != nil {
+			return fmt.Errorf("failed to create config directory: %w", err)
+		}
+		if err := os.WriteFile(env.configPath, data, 0644); err != nil {
+			return fmt.Errorf("failed to write config: %w", err)
+		}
+		fmt.Println("✓ Wrote", env.configPath)
+
+		// Existing variables are not clobbered; the lines are shown instead
+		lines := a.envLines()
+		if _, err := os.Stat(*envPath); err == nil {
+			fmt.Printf("%s already exists; add these lines to it:\n\n%s\n", *envPath, strings.Join(lines, "\n"))
+		} else if err := os.WriteFile(*envPath, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", *envPath, err)
+		} else {
+			fmt.Printf("✓ Wrote %s; set the password in it (or in the encrypted overlay, see secrets)\n", *envPath)
+		}
+
+		issues, err := config.Check(env.configPath)
+		if err != nil {
+			return err
+		}
+		for _, is := range issues {
+			if is.Level == "error" {
+				fmt.Printf("error  %s: %s\n", is.Key, is.Message)
+				err = errors.New("the written config does not match the schema")
+			}
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Println("✓ Config matches the schema")
+
+		switch {
+		case a.chrome == "":
+			fmt.Println("! No Chrome found; Rod downloads Chromium on the first run, which needs network access")
+		default:
+			if _, err := os.Stat(a.chrome); err != nil {
+				fmt.Printf("! Chrome binary %s: %v\n", a.chrome, err)
+			} else {
+				fmt.Println("✓ Browser found at", a.chrome)
+			}
+		}
+
+		run := "go run ."
+		if a.account != "" {
+			run += " -account " + a.account
+		}
+		fmt.Printf("\nNext: %s -connect -query \"Software Engineer\" -max 5\n", run)
+		return nil
+	}
+}
+
+// wizardAnswers are the init wizard's answers
+type wizardAnswers struct {
+	account string
+	email   string
+	pacing  string
+	dataDir string
+	chrome  string
+	note    string
+	message string
+}
+
+// config returns the example config with the answers filled in; values are
+// replaced in the text so its comments and layout stay as they are
+func (a wizardAnswers) config() ([]byte, error) {
+	data := config.Example
+	set := []struct{ key, value string }{
+		{"pacing", a.pacing},
+		{"account", a.account},
+		{"bin", a.chrome},
+		{"db_path", a.dataPath("automation.db")},
+		{"session_cookie_path", a.dataPath("session.json")},
+		{"report_dir", a.dataPath("reports")},
+	}
+	for _, s := range set {
+		re := regexp.MustCompile(`(?m)^(\s*` + regexp.QuoteMeta(s.key) + `: )"[^"]*"`)
+		if n := len(re.FindAllIndex(data, -1)); n != 1 {
+			return nil, fmt.Errorf("the example config has %d %s keys, want 1", n, s.key)
+		}
+		data = re.ReplaceAllFunc(data, func(m []byte) []byte {
+			return append(re.ReplaceAll(m, []byte("$1")), strconv.Quote(s.value)...)
+		})
+	}
+
+	if a.account != "" {
+		re := regexp.MustCompile(`(?m)^accounts: \{\}`)
+		if !re.Match(data) {
+			return nil, errors.New("the example config has no empty accounts key")
+		}
+		data = re.ReplaceAllLiteral(data, []byte("accounts:\n  "+a.account+": {}"))
+	}
+	return data, nil
+}
+
+// dataPath names a file in the data directory, keeping a leading ./
+func (a wizardAnswers) dataPath(name string) string {
+	return strings.TrimRight(filepath.ToSlash(a.dataDir), "/") + "/" + name
+}
+
+// envLines are the .env entries for credentials and templates
+func (a wizardAnswers) envLines() []string {
+	suffix := ""
+	if a.account != "" {
+		suffix = "_" + strings.ToUpper(strings.ReplaceAll(a.account, "-", "_"))
+	}
+	return []string{
+		"LINKEDIN_EMAIL" + suffix + "=" + envQuote(a.email),
+		"LINKEDIN_PASSWORD" + suffix + "=",
+		"CONNECTION_NOTE=" + envQuote(a.note),
+		"FOLLOW_UP_MESSAGE=" + envQuote(a.message),
+	}
+}
+
+func validAccountName(name string) bool {
+	return strings.Trim(name, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-") == ""
+}
+
+// envQuote double-quotes s the way .env files read it back
+func envQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}